@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/importer"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+)
+
+// runCommand runs a maintenance command against the configured database and image storage.
+//
+//	website export [-o file.zip]   writes every post and its images to a zip archive
+//	website import file.zip        imports an archive written by export
+//	website import-wordpress [-dry-run] [-no-images] export.xml
+//	website import-ghost [-dry-run] [-no-images] export.json
+func (a *App) runCommand(name string, args []string) error {
+	switch name {
+	case "export":
+		return a.runExport(args)
+	case "import":
+		return a.runImport(args)
+	case "import-wordpress":
+		return a.runSourceImport(importer.SourceWordPress, args)
+	case "import-ghost":
+		return a.runSourceImport(importer.SourceGhost, args)
+	default:
+		return fmt.Errorf("unknown command %q, expected export, import, import-wordpress or import-ghost", name)
+	}
+}
+
+func (a *App) runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	output := flags.String("o", fmt.Sprintf("blog-export-%s.zip", time.Now().Format("20060102-150405")), "archive to write")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := a.connectDatabase(); err != nil {
+		return err
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	if err := a.newArchiveService(a.newImageService()).Export(file); err != nil {
+		file.Close()
+		os.Remove(*output)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	a.logger.Infof("Archive written to %s", *output)
+	return nil
+}
+
+func (a *App) runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: website import <archive.zip>")
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	if err := a.connectDatabase(); err != nil {
+		return err
+	}
+	result, err := a.newArchiveService(a.newImageService()).Import(file, info.Size())
+	if err != nil {
+		return err
+	}
+	for _, post := range result.Posts {
+		action := "updated"
+		if post.Created {
+			action = "created"
+		}
+		a.logger.Infof("%s: %s post %d (%s)", post.File, action, post.ID, post.Slug)
+	}
+	a.logger.Infof("Imported %d posts (%d created, %d updated) and %d images", len(result.Posts), result.Created, result.Updated, result.Images)
+	return nil
+}
+
+func (a *App) runSourceImport(source string, args []string) error {
+	flags := flag.NewFlagSet("import-"+source, flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only report what would be imported")
+	noImages := flags.Bool("no-images", false, "don't download featured images")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: website import-%s [-dry-run] [-no-images] <export file>", source)
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to open export: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to open export: %w", err)
+	}
+
+	if err := a.connectDatabase(); err != nil {
+		return err
+	}
+	summary, err := a.newImportService(a.newImageService()).Import(source, file, info.Size(), importer.Options{
+		DryRun:     *dryRun,
+		SkipImages: *noImages,
+	})
+	if err != nil {
+		return err
+	}
+	for _, warning := range summary.Warnings {
+		a.logger.Warn(warning)
+	}
+	if summary.DryRun {
+		a.logger.Info("Dry run, nothing was written")
+	}
+	for _, item := range []struct {
+		name  string
+		count dto.ImportCount
+	}{{"posts", summary.Posts}, {"comments", summary.Comments}, {"images", summary.Images}} {
+		a.logger.Infof("%s: %d created, %d skipped, %d failed", item.name, item.count.Created, item.count.Skipped, item.count.Failed)
+	}
+	a.logger.Infof("New categories: %d, new tags: %d", summary.NewCategories, summary.NewTags)
+	return nil
+}
+
+// connectDatabase opens the database for commands, which run without the HTTP server
+func (a *App) connectDatabase() error {
+	db, err := a.setupDatabase()
+	if err != nil {
+		return fmt.Errorf("database setup failed: %w", err)
+	}
+	a.db = db
+	return nil
+}
@@ -6,19 +6,28 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/archive"
 	"github.com/dervisgenc/dervisgenc-blog/backend/internal/auth"
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/blocklist"
 	"github.com/dervisgenc/dervisgenc-blog/backend/internal/comment"
 	"github.com/dervisgenc/dervisgenc-blog/backend/internal/image"
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/importer"
 	"github.com/dervisgenc/dervisgenc-blog/backend/internal/like"
 	"github.com/dervisgenc/dervisgenc-blog/backend/internal/post"
 	"github.com/dervisgenc/dervisgenc-blog/backend/internal/routes"
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/series"
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/sitemap"
 	"github.com/dervisgenc/dervisgenc-blog/backend/internal/stat"
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/taxonomy"
 	"github.com/dervisgenc/dervisgenc-blog/backend/migrations"
 	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/config"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/mailer"
 	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/middleware"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -33,11 +42,13 @@ import (
 // @description This is the API documentation for Personal Blog Web Site
 
 type App struct {
-	logger      *logrus.Logger
-	cfg         *config.Config
-	db          *gorm.DB
-	statsWorker *stat.StatsWorker
-	server      *http.Server
+	logger        *logrus.Logger
+	cfg           *config.Config
+	db            *gorm.DB
+	statsWorker   *stat.StatsWorker
+	postScheduler *post.Scheduler
+	mailQueue     *mailer.Queue
+	server        *http.Server
 }
 
 func main() {
@@ -49,6 +60,14 @@ func main() {
 		logger: logger,
 	}
 
+	// Maintenance commands such as "website export" run instead of the server
+	if len(os.Args) > 1 {
+		if err := app.runCommand(os.Args[1], os.Args[2:]); err != nil {
+			app.logger.Fatalf("%s failed: %v", os.Args[1], err)
+		}
+		return
+	}
+
 	if err := app.initialize(); err != nil {
 		app.logger.Fatalf("Failed to initialize app: %v", err)
 	}
@@ -87,6 +106,10 @@ func (a *App) initialize() error {
 	// Setup routes
 	routes.SetupRoutes(router, handlers, []byte(os.Getenv("JWT_SECRET")))
 
+	// Start publishing scheduled posts in the background
+	a.postScheduler.Start()
+	a.mailQueue.Start()
+
 	// Setup Swagger
 	// if os.Getenv("SWAGGER_ENABLED") == "true" {
 	// 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -124,6 +147,16 @@ func (a *App) shutdown(ctx context.Context) error {
 	}
 	a.logger.Info("Stats worker shutdown completed")
 
+	// Stop the post scheduler before the database goes away
+	if err := a.postScheduler.Shutdown(ctx); err != nil {
+		return fmt.Errorf("post scheduler shutdown failed: %w", err)
+	}
+
+	// Send the emails that are already queued
+	if err := a.mailQueue.Shutdown(ctx); err != nil {
+		return fmt.Errorf("mail queue shutdown failed: %w", err)
+	}
+
 	// Finally, close the database connection
 	if sqlDB, err := a.db.DB(); err == nil {
 		if err := sqlDB.Close(); err != nil {
@@ -163,6 +196,17 @@ func (a *App) setupDatabase() (*gorm.DB, error) {
 
 func (a *App) setupRouter() *gin.Engine {
 	r := gin.Default()
+	// Only X-Forwarded-For set by a trusted proxy is used as the client IP; trusting every proxy would let clients
+	// pick the IP that rate limits, flags and blocklists go by, trusting none would put every reader behind the
+	// frontend or the reverse proxy in one bucket
+	if err := r.SetTrustedProxies(a.cfg.RateLimit.TrustedProxies); err != nil {
+		a.logger.WithError(err).Fatal("Invalid TRUSTED_PROXIES")
+	}
+	if len(a.cfg.RateLimit.TrustedProxies) == 0 {
+		a.logger.Warn("TRUSTED_PROXIES is empty, X-Forwarded-For is ignored and requests through a proxy share its IP")
+	} else {
+		a.logger.WithField("trusted_proxies", a.cfg.RateLimit.TrustedProxies).Info("Trusting X-Forwarded-For from proxies")
+	}
 
 	// CORS configuration
 	r.Use(cors.New(cors.Config{
@@ -184,6 +228,8 @@ func (a *App) setupRouter() *gin.Engine {
 			"Accept",
 			"X-Requested-With",
 			"X-CSRF-Token",
+			post.AccessTokenHeader,
+			comment.EditTokenHeader,
 		},
 		ExposeHeaders: []string{
 			"Content-Length",
@@ -191,6 +237,10 @@ func (a *App) setupRouter() *gin.Engine {
 			"Authorization",
 			"X-Total-Count",
 			"X-Total-Pages",
+			"RateLimit-Limit",
+			"RateLimit-Remaining",
+			"RateLimit-Reset",
+			"Retry-After",
 		},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
@@ -222,51 +272,211 @@ func (a *App) initializeHandlers() *routes.HandlerContainer {
 		a.logger.Fatalf("Failed to create upload directory: %v", err)
 	}
 
-	// Initialize image storage with correct parameters
-	imgStorage := image.NewLocalStorage(
-		a.cfg.Image.StoragePath,
-		fmt.Sprintf("%s/api/uploads/images", a.cfg.AppURL), // Use AppURL from config
-	)
-
-	// Initialize image service with storage and config
-	imgService := image.NewService(
-		imgStorage,
-		image.ProcessConfig{
-			MaxWidth:     a.cfg.Image.MaxWidth,
-			MaxHeight:    a.cfg.Image.MaxHeight,
-			Quality:      a.cfg.Image.Quality,
-			AllowedTypes: a.cfg.Image.AllowedTypes,
-		},
-	)
+	imgService := a.newImageService()
 
 	// Initialize repositories
 	loginRepo := auth.NewUserRepository(a.db)
 	postRepo := post.NewPostRepository(a.db, a.logger)
+	revisionRepo := post.NewRevisionRepository(a.db, a.logger)
+	previewRepo := post.NewPreviewRepository(a.db, a.logger)
 	statRepo := stat.NewStatRepository(a.db, a.logger)
 	likeRepo := like.NewLikeRepository(a.db)
 	commentRepo := comment.NewCommentRepository(a.db, a.logger) // Initialize Comment Repository
+	taxonomyRepo := taxonomy.NewTaxonomyRepository(a.db, a.logger)
+	sitemapRepo := sitemap.NewSitemapRepository(a.db, a.logger)
+	seriesRepo := series.NewSeriesRepository(a.db, a.logger)
+	blocklistRepo := blocklist.NewBlocklistRepository(a.db, a.logger)
 
 	// Initialize services
 	loginService := auth.NewLoginService(loginRepo, a.cfg.JWTSecret)
-	postService := post.NewPostService(postRepo, imgService.GetImageURL(""))
+	postService := post.NewPostService(postRepo, seriesRepo, revisionRepo, post.RevisionPolicy{
+		KeepLast: a.cfg.Post.RevisionKeepLast,
+		KeepDays: a.cfg.Post.RevisionKeepDays,
+	}, post.FeedSettings{
+		SiteURL:     a.cfg.AppURL,
+		Title:       a.cfg.Feed.Title,
+		Description: a.cfg.Feed.Description,
+		Language:    a.cfg.Feed.Language,
+		Limit:       a.cfg.Feed.Limit,
+		FullContent: a.cfg.Feed.FullContent,
+	}, post.ReadingSettings{
+		DefaultLanguage: a.cfg.Post.DefaultLanguage,
+	}, post.AccessSettings{
+		Secret:   a.cfg.JWTSecret,
+		TokenTTL: a.cfg.Post.AccessTokenTTL,
+	}, post.CommentPolicy{
+		CloseAfterDays: a.cfg.Comment.CloseAfterDays,
+	}, imgService.GetImageURL(""), a.logger)
+	previewService := post.NewPreviewService(postService, previewRepo, post.PreviewSettings{
+		Secret:     a.cfg.JWTSecret,
+		DefaultTTL: a.cfg.Post.PreviewLinkTTL,
+		MaxTTL:     a.cfg.Post.PreviewLinkMaxTTL,
+		SiteURL:    a.cfg.AppURL,
+	}, a.logger)
 	statService := stat.NewStatService(statRepo)
 	likeService := like.NewLikeService(likeRepo)
-	commentService := comment.NewCommentService(commentRepo, a.logger) // Initialize Comment Service
+	spamFilter := comment.NewSpamFilter(comment.NewSpamRepository(a.db, a.logger), comment.SpamSettings{
+		Secret:        a.cfg.JWTSecret,
+		Threshold:     a.cfg.Comment.SpamThreshold,
+		MaxLinks:      a.cfg.Comment.MaxLinks,
+		BlockedWords:  a.cfg.Comment.BlockedWords,
+		MinSubmitTime: a.cfg.Comment.MinSubmitTime,
+		FormTokenTTL:  a.cfg.Comment.FormTokenTTL,
+	}, a.logger)
+	blocklistService := blocklist.NewBlocklistService(blocklistRepo, a.logger)
+	a.mailQueue = a.newMailQueue()
+	notifier := comment.NewNotifier(a.mailQueue, comment.NewNotificationRepository(a.db, a.logger), comment.NotificationSettings{
+		Secret:      a.cfg.JWTSecret,
+		SiteURL:     a.cfg.AppURL,
+		AdminEmails: a.cfg.Mail.AdminEmails,
+	}, a.logger)
+	commentService := comment.NewCommentService(commentRepo, spamFilter, blocklistService, loginRepo, notifier, comment.Settings{
+		MaxDepth:        a.cfg.Comment.MaxDepth,
+		Secret:          a.cfg.JWTSecret,
+		EditWindow:      a.cfg.Comment.EditWindow,
+		VerifyEmails:    a.cfg.Comment.VerifyEmails,
+		VerificationTTL: a.cfg.Comment.VerificationTTL,
+		CloseAfterDays:  a.cfg.Comment.CloseAfterDays,
+		Reactions:       a.cfg.Comment.Reactions,
+		FlagThreshold:   a.cfg.Comment.FlagThreshold,
+		FlagsPerDay:     a.cfg.Comment.FlagsPerDay,
+	}, a.logger) // Initialize Comment Service
+	taxonomyService := taxonomy.NewTaxonomyService(taxonomyRepo)
+	sitemapService := sitemap.NewSitemapService(sitemapRepo, sitemap.Settings{
+		SiteURL:        a.cfg.AppURL,
+		CacheTTL:       a.cfg.Sitemap.CacheTTL,
+		RobotsDisallow: a.cfg.Sitemap.RobotsDisallow,
+	}, a.logger)
+	seriesService := series.NewSeriesService(seriesRepo)
+	archiveService := a.newArchiveService(imgService)
+	importService := a.newImportService(imgService)
+
+	// Rebuild the sitemap and recount the listings whenever the set of published posts may have changed
+	postService.OnChange(func(uint) { sitemapService.Invalidate() })
+	archiveService.OnImport(sitemapService.Invalidate)
+	importService.OnImport(sitemapService.Invalidate)
+	archiveService.OnImport(postService.InvalidateCounts)
+	importService.OnImport(postService.InvalidateCounts)
+
+	// Initialize background workers
+	a.postScheduler = post.NewScheduler(postRepo, a.cfg.Post.SchedulerInterval, a.logger)
+	a.postScheduler.OnPublish(func(*models.Post) {
+		sitemapService.Invalidate()
+		postService.InvalidateCounts()
+	})
 
 	// Initialize handlers
 	loginHandler := auth.NewLoginHandler(loginService)
 	postHandler := post.NewPostHandler(postService, imgService)
+	previewHandler := post.NewPreviewHandler(previewService)
 	statHandler := stat.NewStatHandler(statService)
 	imageHandler := image.NewImageHandler(imgService)
 	likeHandler := like.NewLikeHandler(likeService)
 	commentHandler := comment.NewCommentHandler(commentService, a.logger) // Initialize Comment Handler
+	taxonomyHandler := taxonomy.NewTaxonomyHandler(taxonomyService)
+	sitemapHandler := sitemap.NewSitemapHandler(sitemapService)
+	seriesHandler := series.NewSeriesHandler(seriesService)
+	archiveHandler := archive.NewArchiveHandler(archiveService)
+	importHandler := importer.NewImportHandler(importService)
+	blocklistHandler := blocklist.NewBlocklistHandler(blocklistService)
 
 	return &routes.HandlerContainer{
-		Auth:    loginHandler,
-		Post:    postHandler,
-		Stats:   statHandler,
-		Like:    likeHandler,
-		Image:   imageHandler,
-		Comment: commentHandler, // Add comment handler
+		Auth:      loginHandler,
+		Post:      postHandler,
+		Stats:     statHandler,
+		Like:      likeHandler,
+		Image:     imageHandler,
+		Comment:   commentHandler, // Add comment handler
+		Taxonomy:  taxonomyHandler,
+		Sitemap:   sitemapHandler,
+		Series:    seriesHandler,
+		Preview:   previewHandler,
+		Archive:   archiveHandler,
+		Importer:  importHandler,
+		Blocklist: blocklistHandler,
+		Limits:    a.newRateLimiters(),
+	}
+}
+
+// newRateLimiters creates the rate limits of the public write endpoints, sharing one store
+func (a *App) newRateLimiters() routes.RateLimiters {
+	var store middleware.RateLimitStore = middleware.NewMemoryRateLimitStore()
+	if a.cfg.RateLimit.Backend == "postgres" {
+		store = middleware.NewPostgresRateLimitStore(a.db)
+	}
+	limiter := func(name string, rule config.RateLimitRule) gin.HandlerFunc {
+		return middleware.RateLimitMiddleware(store, name, middleware.RateLimit{
+			Requests: rule.Requests,
+			Period:   rule.Period,
+			PerRoute: slices.Contains(a.cfg.RateLimit.PerRoute, name),
+		}, a.logger)
 	}
+	return routes.RateLimiters{
+		Comments:  limiter("comments", a.cfg.RateLimit.Comments),
+		Likes:     limiter("likes", a.cfg.RateLimit.Likes),
+		Shares:    limiter("shares", a.cfg.RateLimit.Shares),
+		Unlock:    limiter("unlock", a.cfg.RateLimit.Unlock),
+		Reactions: limiter("reactions", a.cfg.RateLimit.Reactions),
+		Flags:     limiter("flags", a.cfg.RateLimit.Flags),
+	}
+}
+
+// newMailQueue creates the queue emails are sent through, using the configured mail driver
+func (a *App) newMailQueue() *mailer.Queue {
+	var m mailer.Mailer
+	switch a.cfg.Mail.Driver {
+	case "smtp":
+		m = mailer.NewSMTPMailer(mailer.SMTPSettings{
+			Host:     a.cfg.Mail.SMTPHost,
+			Port:     a.cfg.Mail.SMTPPort,
+			Username: a.cfg.Mail.SMTPUsername,
+			Password: a.cfg.Mail.SMTPPassword,
+			From:     a.cfg.Mail.From,
+		})
+	case "file":
+		m = mailer.NewDevMailer(a.cfg.Mail.Dir, a.cfg.Mail.From, a.logger)
+	case "log":
+		m = mailer.NewDevMailer("", a.cfg.Mail.From, a.logger)
+	default:
+		a.logger.Warn("Mail is disabled, no notification or verification email is sent (set MAIL_DRIVER)")
+		m = mailer.NewDisabledMailer(a.logger)
+	}
+	return mailer.NewQueue(m, mailer.QueueSettings{
+		MaxAttempts: a.cfg.Mail.MaxAttempts,
+		RetryDelay:  a.cfg.Mail.RetryDelay,
+	}, a.logger)
+}
+
+func (a *App) newImageService() *image.Service {
+	// Initialize image storage with correct parameters
+	imgStorage := image.NewLocalStorage(
+		a.cfg.Image.StoragePath,
+		fmt.Sprintf("%s/api/uploads/images", a.cfg.AppURL), // Use AppURL from config
+	)
+
+	// Initialize image service with storage and config
+	return image.NewService(
+		imgStorage,
+		image.ProcessConfig{
+			MaxWidth:     a.cfg.Image.MaxWidth,
+			MaxHeight:    a.cfg.Image.MaxHeight,
+			Quality:      a.cfg.Image.Quality,
+			AllowedTypes: a.cfg.Image.AllowedTypes,
+		},
+	)
+}
+
+func (a *App) newArchiveService(imgService *image.Service) *archive.ArchiveService {
+	return archive.NewArchiveService(archive.NewArchiveRepository(a.db, a.logger), imgService, archive.Settings{
+		MaxImportSize: int64(a.cfg.Archive.MaxImportSizeMB) << 20,
+	}, a.logger)
+}
+
+func (a *App) newImportService(imgService *image.Service) *importer.ImportService {
+	return importer.NewImportService(importer.NewImportRepository(a.db, a.logger), imgService, importer.Settings{
+		DefaultLanguage: a.cfg.Post.DefaultLanguage,
+		ImageTimeout:    a.cfg.Import.ImageTimeout,
+		MaxImageSize:    int64(a.cfg.Image.MaxSizeMB) << 20,
+		MaxFileSize:     int64(a.cfg.Import.MaxFileSizeMB) << 20,
+	}, a.logger)
 }
@@ -0,0 +1,198 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/render"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/slug"
+	"gopkg.in/yaml.v3"
+)
+
+// Layout of an archive: one Markdown file per post and the images they reference.
+// Posts link to images relative to their own file, so the archive can be browsed as is.
+const (
+	postsDir       = "posts/"
+	imagesDir      = "images/"
+	imageRefPrefix = "../" + imagesDir
+)
+
+const frontmatterDelimiter = "---\n"
+
+// uploadURL matches references to images served from LocalStorage, absolute or site relative
+var uploadURL = regexp.MustCompile(`(?:https?://[^\s"'()<>]*)?/api/uploads/images/([A-Za-z0-9][A-Za-z0-9._-]*)`)
+
+// imageRef matches the image references written into an archive by the exporter
+var imageRef = regexp.MustCompile(`\.\./images/([A-Za-z0-9][A-Za-z0-9._-]*)`)
+
+// Frontmatter holds the fields of a post besides its content. Everything derived from the content
+// (rendered HTML, table of contents, word count, estimated read time) is computed again on import.
+// Password hashes are left out of archives, see ArchiveRepository.ImportPosts for password-protected posts.
+type Frontmatter struct {
+	ID               uint       `yaml:"id"`
+	Title            string     `yaml:"title"`
+	Slug             string     `yaml:"slug"`
+	Summary          string     `yaml:"summary,omitempty"`
+	Status           string     `yaml:"status"`
+	IsActive         bool       `yaml:"is_active"`
+	PublishedAt      *time.Time `yaml:"published_at,omitempty"`
+	Visibility       string     `yaml:"visibility"`
+	CommentMode      string     `yaml:"comment_mode,omitempty"` // open when left out
+	Category         string     `yaml:"category,omitempty"`
+	Tags             []string   `yaml:"tags,omitempty"`
+	Language         string     `yaml:"language"`
+	ContentFormat    string     `yaml:"content_format"`
+	ReadTimeOverride int        `yaml:"read_time_override,omitempty"`
+	Image            string     `yaml:"image,omitempty"`
+	ImagePath        string     `yaml:"image_path,omitempty"`
+	CreatedAt        time.Time  `yaml:"created_at"`
+	UpdatedAt        time.Time  `yaml:"updated_at"`
+}
+
+func newFrontmatter(post *models.Post) Frontmatter {
+	return Frontmatter{
+		ID:               post.ID,
+		Title:            post.Title,
+		Slug:             post.Slug,
+		Summary:          post.Summary,
+		Status:           post.Status,
+		IsActive:         post.IsActive,
+		PublishedAt:      post.PublishedAt,
+		Visibility:       post.Visibility,
+		CommentMode:      post.CommentMode,
+		Category:         post.Category,
+		Tags:             dto.SplitTags(post.Tags),
+		Language:         post.Language,
+		ContentFormat:    post.ContentFormat,
+		ReadTimeOverride: post.ReadTimeOverride,
+		Image:            post.ImageURL,
+		ImagePath:        post.ImagePath,
+		CreatedAt:        post.CreatedAt,
+		UpdatedAt:        post.UpdatedAt,
+	}
+}
+
+// toPost builds the post described by the frontmatter and content.
+func (fm *Frontmatter) toPost(content string) *models.Post {
+	post := &models.Post{
+		ID:               fm.ID,
+		CreatedAt:        fm.CreatedAt,
+		UpdatedAt:        fm.UpdatedAt,
+		Title:            fm.Title,
+		Slug:             fm.Slug,
+		Content:          content,
+		Summary:          fm.Summary,
+		ImagePath:        fm.ImagePath,
+		ImageURL:         fm.Image,
+		IsActive:         fm.IsActive,
+		Category:         fm.Category,
+		Tags:             strings.Join(fm.Tags, ","),
+		Status:           fm.Status,
+		PublishedAt:      fm.PublishedAt,
+		ContentFormat:    fm.ContentFormat,
+		Language:         fm.Language,
+		ReadTimeOverride: fm.ReadTimeOverride,
+		Visibility:       fm.Visibility,
+		CommentMode:      fm.CommentMode,
+	}
+	render.Refresh(post)
+	return post
+}
+
+// validate checks the fields of a post file, filling in the defaults of the fields that may be left out
+func (fm *Frontmatter) validate() error {
+	if strings.TrimSpace(fm.Title) == "" {
+		return errors.New("title is required")
+	}
+	if fm.Slug == "" {
+		return errors.New("slug is required")
+	}
+	if !slug.IsValid(fm.Slug) {
+		return fmt.Errorf("invalid slug %q", fm.Slug)
+	}
+	switch fm.Status {
+	case models.PostStatusDraft, models.PostStatusScheduled, models.PostStatusPublished, models.PostStatusArchived:
+	default:
+		return fmt.Errorf("invalid status %q", fm.Status)
+	}
+	if fm.Visibility == "" {
+		fm.Visibility = models.PostVisibilityPublic
+	}
+	switch fm.Visibility {
+	case models.PostVisibilityPublic, models.PostVisibilityUnlisted, models.PostVisibilityPassword:
+	default:
+		return fmt.Errorf("invalid visibility %q", fm.Visibility)
+	}
+	if fm.CommentMode == "" {
+		fm.CommentMode = models.CommentModeOpen
+	}
+	switch fm.CommentMode {
+	case models.CommentModeOpen, models.CommentModeClosed, models.CommentModeModerated, models.CommentModeAutoApprove:
+	default:
+		return fmt.Errorf("invalid comment mode %q", fm.CommentMode)
+	}
+	if fm.ContentFormat == "" {
+		fm.ContentFormat = models.ContentFormatHTML
+	}
+	switch fm.ContentFormat {
+	case models.ContentFormatHTML, models.ContentFormatMarkdown:
+	default:
+		return fmt.Errorf("invalid content_format %q", fm.ContentFormat)
+	}
+	if fm.Language == "" {
+		fm.Language = "en"
+	}
+	return nil
+}
+
+// encodePost writes the frontmatter followed by the content, which is kept byte for byte.
+func encodePost(fm Frontmatter, content string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(frontmatterDelimiter)
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(fm); err != nil {
+		return nil, fmt.Errorf("failed to encode frontmatter: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode frontmatter: %w", err)
+	}
+	buf.WriteString(frontmatterDelimiter)
+	buf.WriteString(content)
+	return buf.Bytes(), nil
+}
+
+// decodePost splits a post file into its frontmatter and content
+func decodePost(data []byte) (*Frontmatter, string, error) {
+	text := string(data)
+	text = strings.TrimPrefix(text, "\ufeff") // Editors on Windows like to add a BOM
+	if !strings.HasPrefix(text, frontmatterDelimiter) {
+		return nil, "", errors.New("missing frontmatter")
+	}
+	text = text[len(frontmatterDelimiter):]
+
+	end := strings.Index(text, "\n"+frontmatterDelimiter)
+	if end < 0 {
+		return nil, "", errors.New("unterminated frontmatter")
+	}
+
+	var fm Frontmatter
+	if err := yaml.Unmarshal([]byte(text[:end+1]), &fm); err != nil {
+		return nil, "", fmt.Errorf("invalid frontmatter: %w", err)
+	}
+	return &fm, text[end+1+len(frontmatterDelimiter):], nil
+}
+
+// postFilename is the path of a post inside an archive
+func postFilename(post *models.Post) string {
+	if post.Slug == "" {
+		return fmt.Sprintf("%spost-%d.md", postsDir, post.ID)
+	}
+	return postsDir + post.Slug + ".md"
+}
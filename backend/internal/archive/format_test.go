@@ -0,0 +1,69 @@
+package archive
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+)
+
+func TestExportLeavesOutPasswordHash(t *testing.T) {
+	post := &models.Post{
+		ID:           3,
+		Title:        "Locked",
+		Slug:         "locked",
+		Status:       models.PostStatusPublished,
+		Visibility:   models.PostVisibilityPassword,
+		PasswordHash: "$2a$10$abcdefghijklmnopqrstuv",
+		CreatedAt:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	data, err := encodePost(newFrontmatter(post), "secret content")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), "password_hash") || strings.Contains(string(data), post.PasswordHash) {
+		t.Fatalf("archived post contains the password hash:\n%s", data)
+	}
+
+	fm, content, err := decodePost(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := fm.validate(); err != nil {
+		t.Fatalf("exported post doesn't validate: %v", err)
+	}
+	if imported := fm.toPost(content); imported.PasswordHash != "" || imported.Visibility != models.PostVisibilityPassword {
+		t.Errorf("imported post has visibility %q and password hash %q", imported.Visibility, imported.PasswordHash)
+	}
+}
+
+func TestValidateRequiresSlug(t *testing.T) {
+	tests := []struct {
+		slug, wantErr string
+	}{
+		{"", "slug is required"},
+		{"Not A Slug", "invalid slug"},
+		{"../etc", "invalid slug"},
+		{"hello-world", ""},
+	}
+	for _, tt := range tests {
+		fm := Frontmatter{Title: "Hello", Slug: tt.slug, Status: models.PostStatusDraft}
+		err := fm.validate()
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("slug %q: unexpected error: %v", tt.slug, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("slug %q: err = %v, want %q", tt.slug, err, tt.wantErr)
+		}
+	}
+}
+
+func TestImportKeepsDeletionAndPassword(t *testing.T) {
+	for _, column := range importColumns {
+		switch column {
+		case "deleted_at", "password_hash", "like_count":
+			t.Errorf("import overwrites %s", column)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package archive
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+type ArchiveHandler struct {
+	service *ArchiveService
+}
+
+func NewArchiveHandler(service *ArchiveService) *ArchiveHandler {
+	return &ArchiveHandler{service: service}
+}
+
+// ExportArchive godoc
+// @Summary Export all posts
+// @Description Downloads every post as a Markdown file with YAML frontmatter, together with the images they use, in a zip archive
+// @Tags Archive
+// @Produce application/zip
+// @Success 200 {file} file "Zip archive"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/archive/export [get]
+func (h *ArchiveHandler) ExportArchive(c *gin.Context) {
+	filename := fmt.Sprintf("blog-export-%s.zip", time.Now().Format("20060102-150405"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	// The archive is streamed, so errors after the first write can only be logged
+	if err := h.service.Export(c.Writer); err != nil {
+		if !c.Writer.Written() {
+			c.Header("Content-Disposition", "")
+			c.Error(err)
+			return
+		}
+		h.service.logger.WithError(err).Error("Handler: Archive export failed while streaming")
+	}
+}
+
+// ImportArchive godoc
+// @Summary Import posts from an archive
+// @Description Imports an archive written by the export endpoint. Posts are matched by ID, then slug, and overwritten; the others are created.
+// @Tags Archive
+// @Accept multipart/form-data
+// @Produce json
+// @Param archive formData file true "Zip archive"
+// @Success 200 {object} dto.ArchiveImportResult
+// @Failure 400 {object} models.ErrorResponse "Invalid archive"
+// @Failure 409 {object} models.ErrorResponse "A post matches two different existing posts"
+// @Failure 413 {object} models.ErrorResponse "Archive too large"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/archive/import [post]
+func (h *ArchiveHandler) ImportArchive(c *gin.Context) {
+	header, err := c.FormFile("archive")
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(fmt.Errorf("failed to get archive from form: %w", err), http.StatusBadRequest))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(fmt.Errorf("failed to open archive: %w", err), http.StatusBadRequest))
+		return
+	}
+	defer file.Close()
+
+	result, err := h.service.Import(file, header.Size)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,129 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/taxonomy"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ErrImportConflict is returned when a post of an archive matches two different existing posts
+var ErrImportConflict = errors.New("archive conflicts with existing posts")
+
+type ArchiveRepository interface {
+	FindAllPosts() ([]*models.Post, error)
+	// ImportPosts upserts the posts in a single transaction and reports for each whether it was created
+	ImportPosts(posts []*models.Post) ([]bool, error)
+}
+
+type archiveRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewArchiveRepository(db *gorm.DB, logger *logrus.Logger) ArchiveRepository {
+	return &archiveRepository{db: db, logger: logger}
+}
+
+// FindAllPosts returns every post that is not deleted, whatever its status and visibility, oldest first.
+func (r *archiveRepository) FindAllPosts() ([]*models.Post, error) {
+	var posts []*models.Post
+	if err := r.db.Where("deleted_at IS NULL").Order("created_at ASC, id ASC").Find(&posts).Error; err != nil {
+		r.logger.WithError(err).Error("Repository: Failed to fetch posts for export")
+		return nil, fmt.Errorf("failed to fetch posts: %w", err)
+	}
+	return posts, nil
+}
+
+// importColumns are the columns an import writes. The like counter, the deletion time and the password hash
+// are kept. updated_at is among them so imported posts keep their original timestamps.
+var importColumns = []string{
+	"created_at", "updated_at", "title", "slug", "content", "summary", "image_path", "image_url",
+	"read_time", "is_active", "category", "tags", "category_id", "status", "published_at", "content_format",
+	"rendered_content", "language", "word_count", "read_time_override", "toc", "visibility", "comment_mode",
+}
+
+// ImportPosts matches each post with an existing one by ID, then by slug, and overwrites it;
+// posts without a match are created with their original ID when it is free.
+// Deleted posts that match are updated but stay deleted. Password-protected posts keep the password of the
+// post they replace; created ones have none and can't be opened until the admin sets one.
+func (r *archiveRepository) ImportPosts(posts []*models.Post) ([]bool, error) {
+	created := make([]bool, len(posts))
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for i, post := range posts {
+			existing, err := r.findMatch(tx, post)
+			if err != nil {
+				return err
+			}
+
+			tags, err := taxonomy.ResolvePostTaxonomy(tx, post)
+			if err != nil {
+				return fmt.Errorf("post %q: %w", post.Slug, err)
+			}
+
+			if existing == nil {
+				if err := tx.Create(post).Error; err != nil {
+					return fmt.Errorf("post %q: failed to create post: %w", post.Slug, err)
+				}
+				created[i] = true
+			} else {
+				post.ID = existing.ID
+				// UpdateColumns leaves updated_at alone, it is imported like every other column
+				if err := tx.Model(&models.Post{}).Where("id = ?", post.ID).
+					Select(importColumns).UpdateColumns(post).Error; err != nil {
+					return fmt.Errorf("post %q: failed to update post: %w", post.Slug, err)
+				}
+			}
+
+			if err := taxonomy.LinkPostTags(tx, post.ID, tags); err != nil {
+				return fmt.Errorf("post %q: %w", post.Slug, err)
+			}
+		}
+
+		// Posts created with their original IDs don't advance the sequence
+		return tx.Exec(`SELECT setval(pg_get_serial_sequence('posts', 'id'), GREATEST((SELECT MAX(id) FROM posts), 1))`).Error
+	})
+	if err != nil {
+		r.logger.WithError(err).Error("Repository: Failed to import posts")
+		return nil, err
+	}
+	return created, nil
+}
+
+// findMatch returns the post an imported post replaces, nil if it is new.
+// An ID and slug pointing at two different posts is a conflict the import cannot resolve.
+func (r *archiveRepository) findMatch(tx *gorm.DB, post *models.Post) (*models.Post, error) {
+	var byID, bySlug *models.Post
+	if post.ID != 0 {
+		var found models.Post
+		err := tx.Unscoped().Select("id", "slug").First(&found, post.ID).Error
+		if err == nil {
+			byID = &found
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("post %q: failed to look up post: %w", post.Slug, err)
+		}
+	}
+	if post.Slug != "" {
+		var found models.Post
+		err := tx.Unscoped().Select("id", "slug").Where("slug = ?", post.Slug).First(&found).Error
+		if err == nil {
+			bySlug = &found
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("post %q: failed to look up post: %w", post.Slug, err)
+		}
+	}
+
+	switch {
+	case byID != nil && bySlug != nil && byID.ID != bySlug.ID:
+		return nil, fmt.Errorf("%w: post %q: ID %d belongs to post %q while the slug is used by post %d",
+			ErrImportConflict, post.Slug, post.ID, byID.Slug, bySlug.ID)
+	case byID != nil:
+		return byID, nil
+	case bySlug != nil:
+		return bySlug, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,272 @@
+package archive
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidArchive is returned for uploads that are not archives written by Export
+var ErrInvalidArchive = errors.New("invalid archive")
+
+// ImageStore is the part of image.Service used to export and import images
+type ImageStore interface {
+	ReadImage(filename string) ([]byte, error)
+	ImportImage(data []byte, filename string) (string, error)
+	GetImageURL(filename string) string
+}
+
+// Settings configures archive imports
+type Settings struct {
+	MaxImportSize int64 // Limit on the uncompressed size of an imported archive in bytes
+}
+
+// ArchiveService exports all posts as a zip of Markdown files with YAML frontmatter and imports such archives.
+type ArchiveService struct {
+	repo      ArchiveRepository
+	images    ImageStore
+	settings  Settings
+	logger    *logrus.Logger
+	listeners []func()
+}
+
+func NewArchiveService(repo ArchiveRepository, images ImageStore, settings Settings, logger *logrus.Logger) *ArchiveService {
+	return &ArchiveService{
+		repo:     repo,
+		images:   images,
+		settings: settings,
+		logger:   logger,
+	}
+}
+
+// OnImport registers a function called after posts were imported
+func (s *ArchiveService) OnImport(listener func()) {
+	s.listeners = append(s.listeners, listener)
+}
+
+// Export writes every post, drafts and protected posts included, and the images they use to w as a zip archive.
+// Links to uploaded images are rewritten to the copies inside the archive; images missing from the storage
+// are left out and keep their original URL.
+func (s *ArchiveService) Export(w io.Writer) error {
+	posts, err := s.repo.FindAllPosts()
+	if err != nil {
+		return myerr.WithHTTPStatus(err, http.StatusInternalServerError)
+	}
+
+	zw := zip.NewWriter(w)
+	exported := make(map[string]bool) // Image filename -> whether it is in the archive
+	rewrite := func(text string) string {
+		return uploadURL.ReplaceAllStringFunc(text, func(match string) string {
+			filename := uploadURL.FindStringSubmatch(match)[1]
+			if _, done := exported[filename]; !done {
+				exported[filename] = s.exportImage(zw, filename)
+			}
+			if !exported[filename] {
+				return match
+			}
+			return imageRefPrefix + filename
+		})
+	}
+
+	for _, post := range posts {
+		fm := newFrontmatter(post)
+		fm.Image = rewrite(fm.Image)
+		content := rewrite(post.Content)
+
+		data, err := encodePost(fm, content)
+		if err != nil {
+			return myerr.WithHTTPStatus(fmt.Errorf("post %d: %w", post.ID, err), http.StatusInternalServerError)
+		}
+		entry, err := zw.CreateHeader(&zip.FileHeader{Name: postFilename(post), Method: zip.Deflate, Modified: post.UpdatedAt})
+		if err != nil {
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+		if _, err := entry.Write(data); err != nil {
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	images := 0
+	for _, ok := range exported {
+		if ok {
+			images++
+		}
+	}
+	s.logger.WithFields(logrus.Fields{"posts": len(posts), "images": images}).Info("Service: Exported archive")
+	return nil
+}
+
+// exportImage copies an image from the storage into the archive and reports whether it succeeded
+func (s *ArchiveService) exportImage(zw *zip.Writer, filename string) bool {
+	data, err := s.images.ReadImage(filename)
+	if err != nil {
+		s.logger.WithError(err).WithField("filename", filename).Warn("Service: Image referenced by a post is missing, keeping its URL")
+		return false
+	}
+	// Images are compressed already
+	entry, err := zw.CreateHeader(&zip.FileHeader{Name: imagesDir + filename, Method: zip.Store})
+	if err == nil {
+		_, err = entry.Write(data)
+	}
+	if err != nil {
+		s.logger.WithError(err).WithField("filename", filename).Error("Service: Failed to add image to archive")
+		return false
+	}
+	return true
+}
+
+// archivedPost is a post file read from an archive
+type archivedPost struct {
+	file        string
+	frontmatter *Frontmatter
+	content     string
+}
+
+// Import reads an archive written by Export and upserts its posts by ID or slug, see ArchiveRepository.ImportPosts.
+// Images are stored through the image service and the links to them point to the storage again.
+// Nothing is written to the database unless every post of the archive is valid.
+func (s *ArchiveService) Import(r io.ReaderAt, size int64) (*dto.ArchiveImportResult, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, myerr.WithHTTPStatus(fmt.Errorf("%w: %v", ErrInvalidArchive, err), http.StatusBadRequest)
+	}
+
+	var total uint64
+	var postFiles []*zip.File
+	imageFiles := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		total += f.UncompressedSize64
+		switch {
+		case strings.HasPrefix(f.Name, postsDir) && path.Ext(f.Name) == ".md":
+			postFiles = append(postFiles, f)
+		case strings.HasPrefix(f.Name, imagesDir):
+			imageFiles[strings.TrimPrefix(f.Name, imagesDir)] = f
+		}
+	}
+	if s.settings.MaxImportSize > 0 && total > uint64(s.settings.MaxImportSize) {
+		return nil, myerr.WithHTTPStatus(fmt.Errorf("%w: archive is larger than %d bytes uncompressed", ErrInvalidArchive, s.settings.MaxImportSize), http.StatusRequestEntityTooLarge)
+	}
+	if len(postFiles) == 0 {
+		return nil, myerr.WithHTTPStatus(fmt.Errorf("%w: no posts found in %s", ErrInvalidArchive, postsDir), http.StatusBadRequest)
+	}
+
+	// Parse everything before touching the storage or the database
+	archived := make([]archivedPost, 0, len(postFiles))
+	referenced := make(map[string]bool)
+	for _, f := range postFiles {
+		data, err := readEntry(f)
+		if err != nil {
+			return nil, myerr.WithHTTPStatus(fmt.Errorf("%w: %s: %v", ErrInvalidArchive, f.Name, err), http.StatusBadRequest)
+		}
+		fm, content, err := decodePost(data)
+		if err == nil {
+			err = fm.validate()
+		}
+		if err != nil {
+			return nil, myerr.WithHTTPStatus(fmt.Errorf("%w: %s: %v", ErrInvalidArchive, f.Name, err), http.StatusBadRequest)
+		}
+		for _, text := range []string{fm.Image, content} {
+			for _, match := range imageRef.FindAllStringSubmatch(text, -1) {
+				referenced[match[1]] = true
+			}
+		}
+		archived = append(archived, archivedPost{file: f.Name, frontmatter: fm, content: content})
+	}
+
+	// Store the referenced images, a name may change if the storage has a different image by that name
+	stored := make(map[string]string)
+	for filename := range referenced {
+		f, ok := imageFiles[filename]
+		if !ok {
+			continue // Not part of the archive, the reference is kept as it is
+		}
+		data, err := readEntry(f)
+		if err != nil {
+			return nil, myerr.WithHTTPStatus(fmt.Errorf("%w: %s: %v", ErrInvalidArchive, f.Name, err), http.StatusBadRequest)
+		}
+		name, err := s.images.ImportImage(data, filename)
+		if err != nil {
+			return nil, myerr.WithHTTPStatus(fmt.Errorf("%w: %s: %v", ErrInvalidArchive, f.Name, err), http.StatusBadRequest)
+		}
+		stored[filename] = name
+	}
+	restore := func(text string) string {
+		return imageRef.ReplaceAllStringFunc(text, func(match string) string {
+			if name, ok := stored[imageRef.FindStringSubmatch(match)[1]]; ok {
+				return s.images.GetImageURL(name)
+			}
+			return match
+		})
+	}
+
+	posts := make([]*models.Post, len(archived))
+	for i, a := range archived {
+		a.frontmatter.Image = restore(a.frontmatter.Image)
+		if name, ok := stored[a.frontmatter.ImagePath]; ok {
+			a.frontmatter.ImagePath = name
+		}
+		posts[i] = a.frontmatter.toPost(restore(a.content))
+	}
+
+	created, err := s.repo.ImportPosts(posts)
+	if err != nil {
+		if errors.Is(err, ErrImportConflict) {
+			return nil, myerr.WithHTTPStatus(err, http.StatusConflict)
+		}
+		return nil, myerr.WithHTTPStatus(fmt.Errorf("failed to import posts: %w", err), http.StatusInternalServerError)
+	}
+
+	result := &dto.ArchiveImportResult{Images: len(stored), Posts: make([]dto.ArchiveImportedPost, len(posts))}
+	for i, post := range posts {
+		if created[i] {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+		result.Posts[i] = dto.ArchiveImportedPost{
+			ID:      post.ID,
+			Slug:    post.Slug,
+			Title:   post.Title,
+			File:    archived[i].file,
+			Created: created[i],
+		}
+	}
+	s.logger.WithFields(logrus.Fields{"created": result.Created, "updated": result.Updated, "images": result.Images}).Info("Service: Imported archive")
+
+	for _, listener := range s.listeners {
+		listener()
+	}
+	return result, nil
+}
+
+// readEntry reads a file of an archive, refusing entries larger than their header claims
+func readEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, int64(f.UncompressedSize64)+1))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) > f.UncompressedSize64 {
+		return nil, errors.New("entry is larger than declared")
+	}
+	return data, nil
+}
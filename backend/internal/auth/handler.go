@@ -1,8 +1,11 @@
 package auth
 
 import (
+	"fmt"
 	"net/http"
 
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
 	"github.com/gin-gonic/gin"
 )
 
@@ -11,6 +14,24 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// ProfileRequest sets how the admin is presented on their comments
+type ProfileRequest struct {
+	DisplayName string `json:"display_name" binding:"max=100"` // Empty signs them "Author"
+	AvatarURL   string `json:"avatar_url" binding:"max=255"`
+}
+
+// ProfileResponse is the account of the admin
+type ProfileResponse struct {
+	Username    string `json:"username"`
+	Email       string `json:"email"`
+	DisplayName string `json:"display_name"`
+	AvatarURL   string `json:"avatar_url"`
+}
+
+func toProfileResponse(user *models.User) ProfileResponse {
+	return ProfileResponse{Username: user.Username, Email: user.Email, DisplayName: user.DisplayName, AvatarURL: user.AvatarURL}
+}
+
 type LoginHandler struct {
 	service *LoginService
 }
@@ -48,3 +69,46 @@ func (h *LoginHandler) LoginHandler(c *gin.Context) {
 		"message": "Successfully logged in",
 	})
 }
+
+// GetProfile godoc
+// @Summary Get the admin profile
+// @Description Returns the account of the authenticated admin, with the name and avatar shown on their comments
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} auth.ProfileResponse
+// @Failure 404 {object} models.ErrorResponse "User not found"
+// @Router /admin/profile [get]
+func (h *LoginHandler) GetProfile(c *gin.Context) {
+	user, err := h.service.GetProfile(UsernameFromContext(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, toProfileResponse(user))
+}
+
+// UpdateProfile godoc
+// @Summary Update the admin profile
+// @Description Sets the name and avatar shown on the comments of the authenticated admin
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param profile body auth.ProfileRequest true "Profile"
+// @Success 200 {object} auth.ProfileResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid profile"
+// @Failure 404 {object} models.ErrorResponse "User not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/profile [put]
+func (h *LoginHandler) UpdateProfile(c *gin.Context) {
+	var req ProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(myerr.WithHTTPStatus(fmt.Errorf("invalid request body: %w", err), http.StatusBadRequest))
+		return
+	}
+	user, err := h.service.UpdateProfile(UsernameFromContext(c), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, toProfileResponse(user))
+}
@@ -7,6 +7,7 @@ import (
 
 type UserRepository interface {
 	FindByUsername(username string) (*models.User, error)
+	UpdateProfile(user *models.User) error
 }
 
 type userRepository struct {
@@ -25,3 +26,8 @@ func (r *userRepository) FindByUsername(username string) (*models.User, error) {
 	}
 	return &user, nil
 }
+
+// UpdateProfile saves the display name and avatar of a user
+func (r *userRepository) UpdateProfile(user *models.User) error {
+	return r.db.Model(user).Select("display_name", "avatar_url", "updated_at").Updates(user).Error
+}
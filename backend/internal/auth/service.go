@@ -2,12 +2,22 @@ package auth
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// ClaimsKey is the context key of the claims of the admin authenticated by middleware.AuthMiddleware
+const ClaimsKey = "user"
+
 type LoginService struct {
 	userRepo  UserRepository
 	jwtSecret []byte
@@ -38,6 +48,45 @@ func (s *LoginService) AuthenticateAdmin(username, password string) (string, err
 	return token, nil
 }
 
+// GetProfile returns the account of an admin
+func (s *LoginService) GetProfile(username string) (*models.User, error) {
+	user, err := s.userRepo.FindByUsername(username)
+	if err != nil {
+		return nil, myerr.WithHTTPStatus(errors.New("user not found"), http.StatusNotFound)
+	}
+	return user, nil
+}
+
+// UpdateProfile changes how an admin is presented on their comments
+func (s *LoginService) UpdateProfile(username string, req ProfileRequest) (*models.User, error) {
+	user, err := s.GetProfile(username)
+	if err != nil {
+		return nil, err
+	}
+	user.DisplayName = strings.TrimSpace(req.DisplayName)
+	user.AvatarURL = strings.TrimSpace(req.AvatarURL)
+	if user.AvatarURL != "" {
+		if u, err := url.Parse(user.AvatarURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, myerr.WithHTTPStatus(errors.New("avatar URL must be an http or https URL"), http.StatusBadRequest)
+		}
+	}
+	if err := s.userRepo.UpdateProfile(user); err != nil {
+		return nil, myerr.WithHTTPStatus(fmt.Errorf("failed to update profile: %w", err), http.StatusInternalServerError)
+	}
+	return user, nil
+}
+
+// UsernameFromContext returns the username of the admin authenticated by middleware.AuthMiddleware, empty outside
+// admin routes
+func UsernameFromContext(c *gin.Context) string {
+	if claims, ok := c.Get(ClaimsKey); ok {
+		if claims, ok := claims.(*Claims); ok {
+			return claims.Username
+		}
+	}
+	return ""
+}
+
 // Generates a JWT token
 func (s *LoginService) generateJWT(username string) (string, error) {
 	expirataionTime := time.Now().Add(6 * time.Hour)
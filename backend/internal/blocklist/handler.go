@@ -0,0 +1,162 @@
+package blocklist
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+type BlocklistHandler struct {
+	service *BlocklistService
+}
+
+func NewBlocklistHandler(service *BlocklistService) *BlocklistHandler {
+	return &BlocklistHandler{service: service}
+}
+
+// ListRules godoc
+// @Summary List block rules
+// @Description Lists the comment block rules with how many comments each one stopped
+// @Tags Blocklist
+// @Produce json
+// @Success 200 {array} models.BlockRule
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/blocklist [get]
+func (h *BlocklistHandler) ListRules(c *gin.Context) {
+	rules, err := h.service.ListRules()
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateRule godoc
+// @Summary Create a block rule
+// @Description Blocks comments by submitter IP, CIDR range, email, email domain or a regular expression matched against
+// @Description author name and content. Matching comments are rejected, held for moderation or silently discarded
+// @Description into the spam bucket.
+// @Tags Blocklist
+// @Accept json
+// @Produce json
+// @Param rule body dto.BlockRuleRequest true "Rule"
+// @Success 201 {object} models.BlockRule
+// @Failure 400 {object} models.ErrorResponse "Invalid rule"
+// @Failure 409 {object} models.ErrorResponse "Rule already exists"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/blocklist [post]
+func (h *BlocklistHandler) CreateRule(c *gin.Context) {
+	var req dto.BlockRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(myerr.WithHTTPStatus(fmt.Errorf("invalid request body: %w", err), http.StatusBadRequest))
+		return
+	}
+	rule, err := h.service.CreateRule(&req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// CreateRuleFromComment godoc
+// @Summary Block the submitter of a comment
+// @Description Creates a block rule from the IP, email or email domain of an existing comment, deleted ones included
+// @Tags Blocklist
+// @Accept json
+// @Produce json
+// @Param comment_id path int true "Comment ID"
+// @Param rule body dto.BlockRuleFromCommentRequest true "Rule"
+// @Success 201 {object} models.BlockRule
+// @Failure 400 {object} models.ErrorResponse "Invalid rule, or the comment has no recorded IP"
+// @Failure 404 {object} models.ErrorResponse "Comment not found"
+// @Failure 409 {object} models.ErrorResponse "Rule already exists"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/blocklist/from-comment/{comment_id} [post]
+func (h *BlocklistHandler) CreateRuleFromComment(c *gin.Context) {
+	commentID, err := strconv.ParseUint(c.Param("comment_id"), 10, 32)
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid comment ID"), http.StatusBadRequest))
+		return
+	}
+	var req dto.BlockRuleFromCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(myerr.WithHTTPStatus(fmt.Errorf("invalid request body: %w", err), http.StatusBadRequest))
+		return
+	}
+	rule, err := h.service.CreateRuleFromComment(uint(commentID), &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateRule godoc
+// @Summary Update a block rule
+// @Description Changes a block rule; its hit counter is kept
+// @Tags Blocklist
+// @Accept json
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Param rule body dto.BlockRuleRequest true "Rule"
+// @Success 200 {object} models.BlockRule
+// @Failure 400 {object} models.ErrorResponse "Invalid rule"
+// @Failure 404 {object} models.ErrorResponse "Rule not found"
+// @Failure 409 {object} models.ErrorResponse "Rule already exists"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/blocklist/{id} [put]
+func (h *BlocklistHandler) UpdateRule(c *gin.Context) {
+	id, ok := parseRuleID(c)
+	if !ok {
+		return
+	}
+	var req dto.BlockRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(myerr.WithHTTPStatus(fmt.Errorf("invalid request body: %w", err), http.StatusBadRequest))
+		return
+	}
+	rule, err := h.service.UpdateRule(id, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule godoc
+// @Summary Delete a block rule
+// @Tags Blocklist
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Success 200 {object} models.SuccessResponse "Block rule deleted"
+// @Failure 400 {object} models.ErrorResponse "Invalid rule ID"
+// @Failure 404 {object} models.ErrorResponse "Rule not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/blocklist/{id} [delete]
+func (h *BlocklistHandler) DeleteRule(c *gin.Context) {
+	id, ok := parseRuleID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteRule(id); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{Message: "Block rule deleted"})
+}
+
+func parseRuleID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid rule ID"), http.StatusBadRequest))
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,128 @@
+package blocklist
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Sentinel errors of the blocklist repository
+var (
+	ErrRuleNotFound    = errors.New("block rule not found")
+	ErrCommentNotFound = errors.New("comment not found")
+)
+
+type BlocklistRepository interface {
+	FindAll() ([]models.BlockRule, error)
+	FindByID(id uint) (*models.BlockRule, error)
+	// Exists reports whether another rule than excludeID has the same kind and pattern
+	Exists(kind, pattern string, excludeID uint) (bool, error)
+	Create(rule *models.BlockRule) error
+	Update(rule *models.BlockRule) error
+	Delete(id uint) error
+	RecordHit(id uint) error
+	// FindComment returns a comment to build a rule from, deleted ones included
+	FindComment(id uint) (*models.Comment, error)
+}
+
+type blocklistRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewBlocklistRepository(db *gorm.DB, logger *logrus.Logger) BlocklistRepository {
+	return &blocklistRepository{db: db, logger: logger}
+}
+
+func (r *blocklistRepository) FindAll() ([]models.BlockRule, error) {
+	var rules []models.BlockRule
+	if err := r.db.Order("kind ASC, pattern ASC").Find(&rules).Error; err != nil {
+		r.logger.WithError(err).Error("Repository: Failed to fetch block rules")
+		return nil, fmt.Errorf("failed to fetch block rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (r *blocklistRepository) FindByID(id uint) (*models.BlockRule, error) {
+	var rule models.BlockRule
+	if err := r.db.First(&rule, id).Error; err != nil {
+		return nil, notFound(err, ErrRuleNotFound)
+	}
+	return &rule, nil
+}
+
+func (r *blocklistRepository) Exists(kind, pattern string, excludeID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.BlockRule{}).Where("kind = ? AND pattern = ? AND id <> ?", kind, pattern, excludeID).Count(&count).Error
+	if err != nil {
+		r.logger.WithError(err).Error("Repository: Failed to check block rule")
+		return false, fmt.Errorf("failed to check block rule: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *blocklistRepository) Create(rule *models.BlockRule) error {
+	if err := r.db.Create(rule).Error; err != nil {
+		r.logger.WithError(err).Error("Repository: Failed to create block rule")
+		return fmt.Errorf("failed to create block rule: %w", err)
+	}
+	r.logger.WithFields(logrus.Fields{"rule_id": rule.ID, "kind": rule.Kind, "action": rule.Action}).Info("Repository: Block rule created")
+	return nil
+}
+
+// Update saves the definition of a rule, leaving its hit counter alone
+func (r *blocklistRepository) Update(rule *models.BlockRule) error {
+	result := r.db.Model(rule).Select("kind", "pattern", "action", "note", "updated_at").Updates(rule)
+	if result.Error != nil {
+		r.logger.WithError(result.Error).Error("Repository: Failed to update block rule")
+		return fmt.Errorf("failed to update block rule: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return myerr.WithHTTPStatus(ErrRuleNotFound, http.StatusNotFound)
+	}
+	return nil
+}
+
+func (r *blocklistRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.BlockRule{}, id)
+	if result.Error != nil {
+		r.logger.WithError(result.Error).Error("Repository: Failed to delete block rule")
+		return fmt.Errorf("failed to delete block rule: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return myerr.WithHTTPStatus(ErrRuleNotFound, http.StatusNotFound)
+	}
+	return nil
+}
+
+func (r *blocklistRepository) RecordHit(id uint) error {
+	err := r.db.Model(&models.BlockRule{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"hits":        gorm.Expr("hits + 1"),
+		"last_hit_at": time.Now(),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record block rule hit: %w", err)
+	}
+	return nil
+}
+
+func (r *blocklistRepository) FindComment(id uint) (*models.Comment, error) {
+	var comment models.Comment
+	if err := r.db.Unscoped().First(&comment, id).Error; err != nil {
+		return nil, notFound(err, ErrCommentNotFound)
+	}
+	return &comment, nil
+}
+
+func notFound(err, sentinel error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return myerr.WithHTTPStatus(sentinel, http.StatusNotFound)
+	}
+	return myerr.WithHTTPStatus(fmt.Errorf("failed to fetch: %w", err), http.StatusInternalServerError)
+}
@@ -0,0 +1,266 @@
+package blocklist
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrRuleExists is returned when an admin adds a rule that is already on the blocklist
+var ErrRuleExists = errors.New("an identical block rule already exists")
+
+// cacheTTL bounds how long rules changed by another instance take to apply
+const cacheTTL = time.Minute
+
+// actionRank orders the actions by precedence when several rules match. A discarded troll should not learn
+// about it from another rule rejecting their comment.
+var actionRank = map[string]int{
+	models.BlockActionDiscard: 0,
+	models.BlockActionReject:  1,
+	models.BlockActionHold:    2,
+}
+
+type compiledRule struct {
+	rule   models.BlockRule
+	ip     net.IP
+	ipNet  *net.IPNet
+	phrase *regexp.Regexp
+}
+
+// BlocklistService manages the block rules and matches comments against them. Rules are cached in memory and
+// reloaded when they change or the cache expires.
+type BlocklistService struct {
+	repo     BlocklistRepository
+	logger   *logrus.Logger
+	mu       sync.Mutex
+	rules    []compiledRule
+	loadedAt time.Time
+}
+
+func NewBlocklistService(repo BlocklistRepository, logger *logrus.Logger) *BlocklistService {
+	return &BlocklistService{repo: repo, logger: logger}
+}
+
+// Match returns the rule that stops a comment, or nil. The hit is counted on the rule. Matching fails open:
+// if the rules can't be loaded, nothing is blocked.
+func (s *BlocklistService) Match(c *models.Comment) *models.BlockRule {
+	rules, err := s.compiledRules()
+	if err != nil {
+		s.logger.WithError(err).Warn("Service: Blocklist unavailable, comment not checked")
+		return nil
+	}
+	ip := net.ParseIP(c.IPAddress)
+	email := strings.ToLower(c.AuthorEmail)
+	_, domain, _ := strings.Cut(email, "@")
+
+	for _, r := range rules {
+		if !r.matches(ip, email, domain, c.AuthorName, c.Content) {
+			continue
+		}
+		if err := s.repo.RecordHit(r.rule.ID); err != nil {
+			s.logger.WithError(err).WithField("rule_id", r.rule.ID).Warn("Service: Failed to count block rule hit")
+		}
+		rule := r.rule
+		return &rule
+	}
+	return nil
+}
+
+func (r *compiledRule) matches(ip net.IP, email, domain, name, content string) bool {
+	switch r.rule.Kind {
+	case models.BlockKindIP:
+		return ip != nil && r.ip.Equal(ip)
+	case models.BlockKindCIDR:
+		return ip != nil && r.ipNet.Contains(ip)
+	case models.BlockKindEmail:
+		return email == r.rule.Pattern
+	case models.BlockKindDomain:
+		return domain == r.rule.Pattern || strings.HasSuffix(domain, "."+r.rule.Pattern)
+	case models.BlockKindPhrase:
+		return r.phrase.MatchString(name) || r.phrase.MatchString(content)
+	}
+	return false
+}
+
+// compiledRules returns the cached rules, sorted by precedence, loading them if needed
+func (s *BlocklistService) compiledRules() ([]compiledRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rules != nil && time.Since(s.loadedAt) < cacheTTL {
+		return s.rules, nil
+	}
+
+	rules, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		c, err := compile(rule)
+		if err != nil {
+			s.logger.WithError(err).WithField("rule_id", rule.ID).Warn("Service: Skipping invalid block rule")
+			continue
+		}
+		compiled = append(compiled, c)
+	}
+	sort.SliceStable(compiled, func(i, j int) bool {
+		return actionRank[compiled[i].rule.Action] < actionRank[compiled[j].rule.Action]
+	})
+	s.rules, s.loadedAt = compiled, time.Now()
+	return compiled, nil
+}
+
+// invalidate drops the cached rules after a change
+func (s *BlocklistService) invalidate() {
+	s.mu.Lock()
+	s.rules = nil
+	s.mu.Unlock()
+}
+
+func compile(rule models.BlockRule) (compiledRule, error) {
+	c := compiledRule{rule: rule}
+	var err error
+	switch rule.Kind {
+	case models.BlockKindIP:
+		if c.ip = net.ParseIP(rule.Pattern); c.ip == nil {
+			err = fmt.Errorf("invalid IP address %q", rule.Pattern)
+		}
+	case models.BlockKindCIDR:
+		_, c.ipNet, err = net.ParseCIDR(rule.Pattern)
+	case models.BlockKindPhrase:
+		c.phrase, err = regexp.Compile("(?i)" + rule.Pattern)
+	}
+	return c, err
+}
+
+// normalize validates the pattern of a rule and brings it into the form it is stored and matched in
+func normalize(kind, pattern string) (string, error) {
+	pattern = strings.TrimSpace(pattern)
+	switch kind {
+	case models.BlockKindIP:
+		ip := net.ParseIP(pattern)
+		if ip == nil {
+			return "", errors.New("pattern is not an IP address")
+		}
+		return ip.String(), nil
+	case models.BlockKindCIDR:
+		_, ipNet, err := net.ParseCIDR(pattern)
+		if err != nil {
+			return "", errors.New("pattern is not a CIDR range, e.g. 203.0.113.0/24")
+		}
+		return ipNet.String(), nil
+	case models.BlockKindEmail:
+		pattern = strings.ToLower(pattern)
+		if addr, err := mail.ParseAddress(pattern); err != nil || addr.Address != pattern {
+			return "", errors.New("pattern is not an email address")
+		}
+		return pattern, nil
+	case models.BlockKindDomain:
+		pattern = strings.ToLower(strings.TrimPrefix(pattern, "@"))
+		if !strings.Contains(pattern, ".") || strings.ContainsAny(pattern, "@ /") {
+			return "", errors.New("pattern is not a domain, e.g. example.com")
+		}
+		return pattern, nil
+	case models.BlockKindPhrase:
+		if _, err := regexp.Compile("(?i)" + pattern); err != nil {
+			return "", fmt.Errorf("pattern is not a valid regular expression: %w", err)
+		}
+		return pattern, nil
+	}
+	return "", errors.New("invalid rule kind")
+}
+
+func (s *BlocklistService) ListRules() ([]models.BlockRule, error) {
+	return s.repo.FindAll()
+}
+
+func (s *BlocklistService) CreateRule(req *dto.BlockRuleRequest) (*models.BlockRule, error) {
+	rule := &models.BlockRule{Kind: req.Kind, Action: req.Action, Note: strings.TrimSpace(req.Note)}
+	if err := s.setPattern(rule, req.Pattern); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(rule); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return rule, nil
+}
+
+func (s *BlocklistService) UpdateRule(id uint, req *dto.BlockRuleRequest) (*models.BlockRule, error) {
+	rule, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	rule.Kind, rule.Action, rule.Note = req.Kind, req.Action, strings.TrimSpace(req.Note)
+	if err := s.setPattern(rule, req.Pattern); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(rule); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return rule, nil
+}
+
+func (s *BlocklistService) DeleteRule(id uint) error {
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// CreateRuleFromComment blocks the IP, email or email domain a comment was submitted with
+func (s *BlocklistService) CreateRuleFromComment(commentID uint, req *dto.BlockRuleFromCommentRequest) (*models.BlockRule, error) {
+	comment, err := s.repo.FindComment(commentID)
+	if err != nil {
+		return nil, err
+	}
+	var pattern string
+	switch req.Kind {
+	case models.BlockKindIP:
+		if comment.IPAddress == "" {
+			return nil, myerr.WithHTTPStatus(errors.New("the IP address of the comment was not recorded"), http.StatusBadRequest)
+		}
+		pattern = comment.IPAddress
+	case models.BlockKindEmail:
+		pattern = comment.AuthorEmail
+	case models.BlockKindDomain:
+		_, pattern, _ = strings.Cut(comment.AuthorEmail, "@")
+	}
+
+	note := req.Note
+	if note == "" {
+		note = fmt.Sprintf("From comment #%d by %s", comment.ID, comment.AuthorName)
+	}
+	return s.CreateRule(&dto.BlockRuleRequest{Kind: req.Kind, Pattern: pattern, Action: req.Action, Note: note})
+}
+
+// setPattern validates and sets the pattern of a rule, refusing duplicates
+func (s *BlocklistService) setPattern(rule *models.BlockRule, pattern string) error {
+	normalized, err := normalize(rule.Kind, pattern)
+	if err != nil {
+		return myerr.WithHTTPStatus(err, http.StatusBadRequest)
+	}
+	exists, err := s.repo.Exists(rule.Kind, normalized, rule.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return myerr.WithHTTPStatus(ErrRuleExists, http.StatusConflict)
+	}
+	rule.Pattern = normalized
+	return nil
+}
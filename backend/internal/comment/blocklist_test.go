@@ -0,0 +1,54 @@
+package comment
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+)
+
+func TestDiscardedCommentLooksPending(t *testing.T) {
+	repo := &fakeRepository{posts: map[uint]*models.Post{1: openPost(1)}}
+	s := newTestService(repo, Settings{EditWindow: time.Hour})
+	req := dto.CommentCreateRequest{
+		AuthorName:  "Troll",
+		AuthorEmail: "troll@example.com",
+		Content:     "Something rude",
+		FormToken:   s.IssueFormToken(1).Token,
+	}
+
+	pending, err := s.CreateComment(1, req, "198.51.100.9", "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pending.Status != StatusPending {
+		t.Fatalf("comment without a rule: status %q, want %q", pending.Status, StatusPending)
+	}
+
+	s.blocklist = fakeBlocklist{rule: &models.BlockRule{ID: 4, Kind: models.BlockKindEmail, Action: models.BlockActionDiscard}}
+	discarded, err := s.CreateComment(1, req, "198.51.100.9", "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Both responses differ in their id, edit token and edit window end only, and both ids belong to stored comments
+	if discarded.ID == 0 || discarded.EditToken == "" || discarded.EditableUntil == nil {
+		t.Fatalf("discarded comment response has no id or edit token: %+v", discarded)
+	}
+	normalize := func(r dto.CommentCreateResponse) dto.CommentCreateResponse {
+		r.ID, r.EditToken, r.EditableUntil = 0, "", nil
+		return r
+	}
+	if !reflect.DeepEqual(normalize(*pending), normalize(*discarded)) {
+		t.Errorf("responses differ:\n%+v\n%+v", *pending, *discarded)
+	}
+	stored := repo.comments[discarded.ID]
+	if stored == nil || !stored.IsSpam || stored.IsApproved {
+		t.Fatalf("discarded comment is not stored as spam: %+v", stored)
+	}
+	if stored.SpamReasons != "blocklist: email rule #4" {
+		t.Errorf("spam reasons = %q", stored.SpamReasons)
+	}
+}
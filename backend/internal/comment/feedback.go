@@ -0,0 +1,164 @@
+package comment
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// FlagActor is the moderation log actor of comments put back into moderation by reader flags
+const FlagActor = "flags"
+
+// ErrAlreadyFlagged is returned when a reader flags a comment a second time
+var ErrAlreadyFlagged = errors.New("you already flagged this comment")
+
+// ErrTooManyFlags is returned when a client flagged FlagsPerDay comments in the last day
+var ErrTooManyFlags = errors.New("you flagged too many comments today, try again later")
+
+var errCommentNotFound = myerr.WithHTTPStatus(errors.New("comment not found"), http.StatusNotFound)
+
+// ToggleReaction gives the reaction of a reader to an approved comment, or takes it back if the reader gave it before
+func (s *CommentService) ToggleReaction(id uint, emoji, ipAddress string) (*dto.CommentReactionResponse, error) {
+	log := s.logger.WithFields(logrus.Fields{"comment_id": id, "emoji": emoji})
+	if !slices.Contains(s.settings.Reactions, emoji) {
+		return nil, myerr.WithHTTPStatus(fmt.Errorf("invalid reaction, use one of %s", strings.Join(s.settings.Reactions, " ")), http.StatusBadRequest)
+	}
+	comment, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errCommentNotFound
+		}
+		return nil, err
+	}
+	if !comment.IsApproved {
+		return nil, errCommentNotFound
+	}
+
+	reacted, err := s.repo.ToggleReaction(id, emoji, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.reactionCounts([]models.Comment{*comment})
+	if err != nil {
+		return nil, err
+	}
+	log.WithField("reacted", reacted).Info("Service: Comment reaction toggled")
+
+	response := &dto.CommentReactionResponse{Emoji: emoji, Reacted: reacted, Reactions: counts[id]}
+	if response.Reactions == nil {
+		response.Reactions = map[string]int{}
+	}
+	return response, nil
+}
+
+// reactionCounts counts the reactions to the approved comments among comments, leaving out emojis that are no
+// longer offered
+func (s *CommentService) reactionCounts(comments []models.Comment) (map[uint]map[string]int, error) {
+	ids := make([]uint, 0, len(comments))
+	for _, c := range comments {
+		if c.IsApproved && !c.DeletedAt.Valid {
+			ids = append(ids, c.ID)
+		}
+	}
+	counts, err := s.repo.CountReactions(ids)
+	if err != nil {
+		return nil, err
+	}
+	for id, byEmoji := range counts {
+		for emoji := range byEmoji {
+			if !slices.Contains(s.settings.Reactions, emoji) {
+				delete(byEmoji, emoji)
+			}
+		}
+		if len(byEmoji) == 0 {
+			delete(counts, id)
+		}
+	}
+	return counts, nil
+}
+
+// FlagComment records the report of a reader about an approved comment. The comment goes back into moderation, and
+// the admins are notified, once readers from FlagThreshold distinct networks flagged it since it was last approved.
+// Counting networks rather than addresses keeps a single reader switching addresses from taking comments down.
+func (s *CommentService) FlagComment(id uint, req dto.CommentFlagRequest, ipAddress string) error {
+	log := s.logger.WithFields(logrus.Fields{"comment_id": id, "reason": req.Reason})
+	log.Info("Service: Flagging comment")
+	switch req.Reason {
+	case models.FlagReasonSpam, models.FlagReasonAbuse, models.FlagReasonOffTopic, models.FlagReasonOther:
+	default:
+		return myerr.WithHTTPStatus(fmt.Errorf("invalid reason %q, expected %s, %s, %s or %s", req.Reason,
+			models.FlagReasonSpam, models.FlagReasonAbuse, models.FlagReasonOffTopic, models.FlagReasonOther), http.StatusBadRequest)
+	}
+
+	if s.settings.FlagsPerDay > 0 {
+		flagged, err := s.repo.CountFlagsSince(ipAddress, time.Now().Add(-24*time.Hour))
+		if err != nil {
+			return err
+		}
+		if flagged >= int64(s.settings.FlagsPerDay) {
+			log.Warn("Service: Client reached the daily flag limit")
+			return myerr.WithHTTPStatus(ErrTooManyFlags, http.StatusTooManyRequests)
+		}
+	}
+
+	comment, counted, err := s.repo.Flag(&models.CommentFlag{
+		CommentID: id,
+		Reason:    req.Reason,
+		Details:   truncate(strings.TrimSpace(req.Details), 500),
+		IPAddress: ipAddress,
+		Network:   flagNetwork(ipAddress),
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return errCommentNotFound
+	case errors.Is(err, ErrAlreadyFlagged):
+		return myerr.WithHTTPStatus(err, http.StatusConflict)
+	case err != nil:
+		return err
+	}
+
+	// Flags are serialized per comment, so exactly one counted flag reaches the threshold
+	if !counted || s.settings.FlagThreshold <= 0 || comment.FlagCount != s.settings.FlagThreshold {
+		return nil
+	}
+	log.WithField("flags", comment.FlagCount).Info("Service: Comment reached the flag threshold, returning it to moderation")
+	if _, err := s.moderate(ActionUnapprove, Selection{IDs: []uint{id}}, FlagActor, ""); err != nil {
+		return err
+	}
+	s.notifier.CommentAwaitingModeration(comment)
+	return nil
+}
+
+// flagNetwork returns the network flags from ipAddress count for: its /24 for IPv4 and its /64 for IPv6. Addresses
+// that don't parse are their own network.
+func flagNetwork(ipAddress string) string {
+	addr, err := netip.ParseAddr(ipAddress)
+	if err != nil {
+		return ipAddress
+	}
+	addr = addr.Unmap()
+	bits := 64
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ipAddress
+	}
+	return prefix.String()
+}
+
+// GetFlags returns the flags of a comment, newest first
+func (s *CommentService) GetFlags(id uint) ([]models.CommentFlag, error) {
+	return s.repo.FindFlags(id)
+}
@@ -0,0 +1,76 @@
+package comment
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+)
+
+func TestFlagNetwork(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"203.0.113.7", "203.0.113.0/24"},
+		{"203.0.113.250", "203.0.113.0/24"},
+		{"::ffff:203.0.113.7", "203.0.113.0/24"},
+		{"2001:db8:1:2:3:4:5:6", "2001:db8:1:2::/64"},
+		{"not an ip", "not an ip"},
+	}
+	for _, tt := range tests {
+		if got := flagNetwork(tt.ip); got != tt.want {
+			t.Errorf("flagNetwork(%q) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestFlagCommentThreshold(t *testing.T) {
+	tests := []struct {
+		name          string
+		flagCount     int
+		counted       bool
+		wantModerated bool
+	}{
+		{name: "below the threshold", flagCount: 2, counted: true},
+		{name: "counted flag reaching the threshold", flagCount: 3, counted: true, wantModerated: true},
+		{name: "flag from a network that flagged before", flagCount: 3, counted: false},
+		{name: "past the threshold", flagCount: 4, counted: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stored *models.CommentFlag
+			repo := &fakeRepository{flag: func(flag *models.CommentFlag) (*models.Comment, bool, error) {
+				stored = flag
+				return &models.Comment{ID: flag.CommentID, FlagCount: tt.flagCount}, tt.counted, nil
+			}}
+			s := newTestService(repo, Settings{FlagThreshold: 3})
+
+			if err := s.FlagComment(7, dto.CommentFlagRequest{Reason: models.FlagReasonSpam}, "198.51.100.9"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if stored.Network != "198.51.100.0/24" {
+				t.Errorf("network = %q", stored.Network)
+			}
+			if moderated := len(repo.moderated) > 0; moderated != tt.wantModerated {
+				t.Errorf("moderated = %v, want %v", moderated, tt.wantModerated)
+			}
+		})
+	}
+}
+
+func TestFlagCommentDailyLimit(t *testing.T) {
+	repo := &fakeRepository{flagsSince: 10, flag: func(*models.CommentFlag) (*models.Comment, bool, error) {
+		t.Fatal("flag recorded past the daily limit")
+		return nil, false, nil
+	}}
+	s := newTestService(repo, Settings{FlagThreshold: 3, FlagsPerDay: 10})
+
+	err := s.FlagComment(7, dto.CommentFlagRequest{Reason: models.FlagReasonAbuse}, "198.51.100.9")
+	if !errors.Is(err, ErrTooManyFlags) || myerr.HTTPStatus(err) != http.StatusTooManyRequests {
+		t.Errorf("err = %v, want ErrTooManyFlags with status 429", err)
+	}
+}
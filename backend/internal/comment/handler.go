@@ -4,9 +4,11 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
 	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/middleware"
 	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -24,14 +26,19 @@ func NewCommentHandler(service *CommentService, logger *logrus.Logger) *CommentH
 
 // HandleCreateComment godoc
 // @Summary Create a new comment on a post
-// @Description Adds a new comment to a specific post. Comments require moderation.
+// @Description Adds a new comment to a specific post. Comments require moderation, or with email verification enabled,
+// @Description the author's confirmation of their email; comments from verified emails are published right away.
+// @Description The comment mode of the post can close it to comments, or moderate or publish every comment.
+// @Description Comments also close the configured number of days after the post was published.
+// @Description The response holds the token that edits and withdraws the comment during the edit window.
 // @Tags Comments
 // @Accept json
 // @Produce json
 // @Param id path int true "Post ID"
 // @Param comment body dto.CommentCreateRequest true "Comment Data"
-// @Success 201 {object} models.SuccessResponse "Comment submitted successfully"
-// @Failure 400 {object} models.ErrorResponse "Invalid input"
+// @Success 201 {object} dto.CommentCreateResponse "Comment submitted successfully"
+// @Failure 400 {object} models.ErrorResponse "Invalid input, or the parent comment is not part of the post"
+// @Failure 403 {object} models.ErrorResponse "Comments are closed on the post, or the comment was refused by a block rule"
 // @Failure 404 {object} models.ErrorResponse "Post not found"
 // @Failure 500 {object} models.ErrorResponse "Internal Server Error"
 // @Router /posts/{id}/comments [post]
@@ -55,7 +62,7 @@ func (h *CommentHandler) HandleCreateComment(c *gin.Context) {
 	log := h.logger.WithFields(logrus.Fields{"post_id": postID, "author": req.AuthorName})
 	log.Info("Handler: Received request to create comment")
 
-	_, err = h.service.CreateComment(uint(postID), req)
+	response, err := h.service.CreateComment(uint(postID), req, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
 		log.WithError(err).Error("Handler: Failed to create comment")
 		// Handle specific errors if needed, e.g., post not found if service checks it
@@ -63,19 +70,169 @@ func (h *CommentHandler) HandleCreateComment(c *gin.Context) {
 		return
 	}
 
-	log.Info("Handler: Comment created successfully (pending approval)")
-	c.JSON(http.StatusCreated, models.SuccessResponse{Message: "Comment submitted successfully and is awaiting moderation."})
+	log.WithField("status", response.Status).Info("Handler: Comment created successfully")
+	c.Header("Cache-Control", "no-store") // The edit token must not end up in a cache
+	c.JSON(http.StatusCreated, response)
+}
+
+// EditTokenHeader carries the edit token of a comment, see dto.CommentCreateResponse
+const EditTokenHeader = "X-Comment-Edit-Token"
+
+// HandleEditComment godoc
+// @Summary Edit own comment
+// @Description Changes the content of a comment, authorized by the edit token returned when it was created. Only possible
+// @Description during the edit window and while the post takes comments. The new content goes through the blocklist
+// @Description and the spam filter again, and a published comment returns to moderation unless a new one would be
+// @Description published right away.
+// @Tags Comments
+// @Accept json
+// @Produce json
+// @Param comment_id path int true "Comment ID"
+// @Param X-Comment-Edit-Token header string true "Edit token of the comment"
+// @Param comment body dto.CommentUpdateRequest true "New content"
+// @Success 200 {object} models.SuccessResponse "Comment updated"
+// @Failure 400 {object} models.ErrorResponse "Invalid comment ID or content"
+// @Failure 403 {object} models.ErrorResponse "Invalid edit token, edit window passed, comments closed or content blocked"
+// @Failure 404 {object} models.ErrorResponse "Comment not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /comments/{comment_id} [put]
+func (h *CommentHandler) HandleEditComment(c *gin.Context) {
+	commentID, err := strconv.ParseUint(c.Param("comment_id"), 10, 32)
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid comment ID"), http.StatusBadRequest))
+		return
+	}
+	var req dto.CommentUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(myerr.WithHTTPStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	if err := h.service.EditComment(uint(commentID), c.GetHeader(EditTokenHeader), req); err != nil {
+		h.handleAuthorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{Message: "Comment updated"})
+}
+
+// HandleWithdrawComment godoc
+// @Summary Withdraw own comment
+// @Description Deletes a comment, authorized by the edit token returned when it was created. Only possible during the
+// @Description edit window.
+// @Tags Comments
+// @Produce json
+// @Param comment_id path int true "Comment ID"
+// @Param X-Comment-Edit-Token header string true "Edit token of the comment"
+// @Success 200 {object} models.SuccessResponse "Comment withdrawn"
+// @Failure 400 {object} models.ErrorResponse "Invalid comment ID"
+// @Failure 403 {object} models.ErrorResponse "Invalid edit token, or the edit window has passed"
+// @Failure 404 {object} models.ErrorResponse "Comment not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /comments/{comment_id} [delete]
+func (h *CommentHandler) HandleWithdrawComment(c *gin.Context) {
+	commentID, err := strconv.ParseUint(c.Param("comment_id"), 10, 32)
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid comment ID"), http.StatusBadRequest))
+		return
+	}
+
+	if err := h.service.WithdrawComment(uint(commentID), c.GetHeader(EditTokenHeader)); err != nil {
+		h.handleAuthorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{Message: "Comment withdrawn"})
+}
+
+// HandleToggleReaction godoc
+// @Summary React to a comment
+// @Description Gives a reaction to an approved comment, or takes it back if the reader gave it before. Readers are
+// @Description told apart by IP address; the reactions on offer are listed with the comment threads.
+// @Tags Comments
+// @Accept json
+// @Produce json
+// @Param comment_id path int true "Comment ID"
+// @Param reaction body dto.CommentReactionRequest true "Reaction"
+// @Success 200 {object} dto.CommentReactionResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid comment ID or reaction"
+// @Failure 404 {object} models.ErrorResponse "Comment not found"
+// @Failure 429 {object} models.ErrorResponse "Too many requests"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /comments/{comment_id}/reactions [post]
+func (h *CommentHandler) HandleToggleReaction(c *gin.Context) {
+	commentID, err := strconv.ParseUint(c.Param("comment_id"), 10, 32)
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid comment ID"), http.StatusBadRequest))
+		return
+	}
+	var req dto.CommentReactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(myerr.WithHTTPStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	response, err := h.service.ToggleReaction(uint(commentID), req.Emoji, c.ClientIP())
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// HandleFlagComment godoc
+// @Summary Flag a comment
+// @Description Reports an approved comment to the moderators. Once enough readers flagged it, the comment is taken
+// @Description offline until an admin approves it again.
+// @Tags Comments
+// @Accept json
+// @Produce json
+// @Param comment_id path int true "Comment ID"
+// @Param flag body dto.CommentFlagRequest true "Reason"
+// @Success 201 {object} models.SuccessResponse "Comment flagged"
+// @Failure 400 {object} models.ErrorResponse "Invalid comment ID or reason"
+// @Failure 404 {object} models.ErrorResponse "Comment not found"
+// @Failure 409 {object} models.ErrorResponse "Comment already flagged by this reader"
+// @Failure 429 {object} models.ErrorResponse "Too many requests"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /comments/{comment_id}/flags [post]
+func (h *CommentHandler) HandleFlagComment(c *gin.Context) {
+	commentID, err := strconv.ParseUint(c.Param("comment_id"), 10, 32)
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid comment ID"), http.StatusBadRequest))
+		return
+	}
+	var req dto.CommentFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(myerr.WithHTTPStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	if err := h.service.FlagComment(uint(commentID), req, c.ClientIP()); err != nil {
+		c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+	c.JSON(http.StatusCreated, models.SuccessResponse{Message: "Comment flagged, thank you for the report"})
+}
+
+func (h *CommentHandler) handleAuthorError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.Error(myerr.WithHTTPStatus(errors.New("comment not found"), http.StatusNotFound))
+		return
+	}
+	c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
 }
 
 // HandleGetCommentsByPost godoc
 // @Summary Get comments for a specific post
-// @Description Retrieves all approved comments for a given post ID.
+// @Description Retrieves the approved comments of a post as threads of nested replies, paginated by top-level thread.
+// @Description Replies to unapproved comments are hidden; deleted comments with replies are returned as tombstones.
 // @Tags Comments
 // @Accept json
 // @Produce json
 // @Param id path int true "Post ID"
-// @Success 200 {array} dto.CommentResponse
-// @Failure 400 {object} models.ErrorResponse "Invalid post ID"
+// @Param page query int false "Page number (default: 1)"
+// @Param size query int false "Threads per page (default: 20, max: 100)"
+// @Success 200 {object} dto.CommentThreadsResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid post ID or pagination parameters"
 // @Failure 500 {object} models.ErrorResponse "Internal Server Error"
 // @Router /posts/{id}/comments [get]
 func (h *CommentHandler) HandleGetCommentsByPost(c *gin.Context) {
@@ -87,20 +244,52 @@ func (h *CommentHandler) HandleGetCommentsByPost(c *gin.Context) {
 		return
 	}
 
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid page number"), http.StatusBadRequest))
+		return
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if err != nil || pageSize < 1 {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid page size"), http.StatusBadRequest))
+		return
+	}
+
 	log := h.logger.WithField("post_id", postID)
 	log.Info("Handler: Received request to get comments by post")
 
-	comments, err := h.service.GetCommentsByPostID(uint(postID))
+	comments, err := h.service.GetCommentsByPostID(uint(postID), page, pageSize)
 	if err != nil {
 		log.WithError(err).Error("Handler: Failed to get comments")
 		c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
 		return
 	}
 
-	log.WithField("count", len(comments)).Info("Handler: Successfully fetched comments")
+	log.WithField("threads", len(comments.Threads)).Info("Handler: Successfully fetched comments")
 	c.JSON(http.StatusOK, comments)
 }
 
+// HandleGetFormToken godoc
+// @Summary Get a comment form token
+// @Description Returns a signed token to send as form_token when submitting a comment on the post. The spam filter uses it
+// @Description to tell how long the form was open; comments without it are more likely to be treated as spam.
+// @Tags Comments
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} dto.CommentFormTokenResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid post ID"
+// @Router /posts/{id}/comments/form-token [get]
+func (h *CommentHandler) HandleGetFormToken(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid post ID"), http.StatusBadRequest))
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, h.service.IssueFormToken(uint(postID)))
+}
+
 // HandleGetAllCommentsAdmin godoc
 // @Summary Get all comments (Admin)
 // @Description Retrieves all comments with pagination and filtering for moderation.
@@ -109,7 +298,7 @@ func (h *CommentHandler) HandleGetCommentsByPost(c *gin.Context) {
 // @Produce json
 // @Param page query int false "Page number (default: 1)"
 // @Param size query int false "Page size (default: 10)"
-// @Param filter query string false "Filter comments (all, pending, approved - default: pending)" Enums(all, pending, approved)
+// @Param filter query string false "Filter comments (all, pending, approved, spam, flagged - default: pending)" Enums(all, pending, approved, spam, flagged)
 // @Success 200 {object} map[string]interface{} "comments: []dto.AdminCommentResponse, total_count: int64"
 // @Failure 400 {object} models.ErrorResponse "Invalid parameters"
 // @Failure 500 {object} models.ErrorResponse "Internal Server Error"
@@ -172,7 +361,7 @@ func (h *CommentHandler) HandleApproveComment(c *gin.Context) {
 	log := h.logger.WithField("comment_id", commentID)
 	log.Info("Handler: Received request to approve comment")
 
-	err = h.service.ApproveComment(uint(commentID))
+	err = h.service.ApproveComment(uint(commentID), middleware.AdminUsername(c))
 	if err != nil {
 		log.WithError(err).Error("Handler: Failed to approve comment")
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -210,7 +399,7 @@ func (h *CommentHandler) HandleDeleteComment(c *gin.Context) {
 	log := h.logger.WithField("comment_id", commentID)
 	log.Info("Handler: Received request to delete comment")
 
-	err = h.service.DeleteComment(uint(commentID))
+	err = h.service.DeleteComment(uint(commentID), middleware.AdminUsername(c))
 	if err != nil {
 		log.WithError(err).Error("Handler: Failed to delete comment")
 		// Don't expose gorm.ErrRecordNotFound as 404, DELETE should be idempotent
@@ -221,3 +410,288 @@ func (h *CommentHandler) HandleDeleteComment(c *gin.Context) {
 	log.Info("Handler: Comment deleted successfully")
 	c.JSON(http.StatusOK, models.SuccessResponse{Message: "Comment deleted successfully"})
 }
+
+// HandleMarkSpam godoc
+// @Summary Mark a comment as spam (Admin)
+// @Description Moves a comment into the spam bucket, unapproving it, and teaches the spam filter that it is spam.
+// @Tags Admin Comments
+// @Produce json
+// @Param comment_id path int true "Comment ID"
+// @Success 200 {object} models.SuccessResponse "Comment marked as spam"
+// @Failure 400 {object} models.ErrorResponse "Invalid comment ID"
+// @Failure 404 {object} models.ErrorResponse "Comment not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/comments/{comment_id}/spam [patch]
+func (h *CommentHandler) HandleMarkSpam(c *gin.Context) {
+	h.handleSetSpam(c, true)
+}
+
+// HandleMarkNotSpam godoc
+// @Summary Mark a comment as not spam (Admin)
+// @Description Returns a false positive from the spam bucket to the pending queue and teaches the spam filter that it is not spam.
+// @Tags Admin Comments
+// @Produce json
+// @Param comment_id path int true "Comment ID"
+// @Success 200 {object} models.SuccessResponse "Comment marked as not spam"
+// @Failure 400 {object} models.ErrorResponse "Invalid comment ID"
+// @Failure 404 {object} models.ErrorResponse "Comment not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/comments/{comment_id}/not-spam [patch]
+func (h *CommentHandler) HandleMarkNotSpam(c *gin.Context) {
+	h.handleSetSpam(c, false)
+}
+
+func (h *CommentHandler) handleSetSpam(c *gin.Context, isSpam bool) {
+	commentID, err := strconv.ParseUint(c.Param("comment_id"), 10, 32)
+	if err != nil {
+		h.logger.WithError(err).Warn("Handler: Invalid comment ID format for spam update")
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid comment ID"), http.StatusBadRequest))
+		return
+	}
+
+	if isSpam {
+		err = h.service.MarkSpam(uint(commentID), middleware.AdminUsername(c))
+	} else {
+		err = h.service.MarkNotSpam(uint(commentID), middleware.AdminUsername(c))
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.Error(myerr.WithHTTPStatus(errors.New("comment not found"), http.StatusNotFound))
+		} else {
+			c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
+		}
+		return
+	}
+
+	message := "Comment marked as not spam"
+	if isSpam {
+		message = "Comment marked as spam"
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{Message: message})
+}
+
+// bulkActions maps the action of a bulk moderation path to the action it logs
+var bulkActions = map[string]string{
+	"approve":   ActionApprove,
+	"unapprove": ActionUnapprove,
+	"spam":      ActionSpam,
+	"not-spam":  ActionNotSpam,
+	"delete":    ActionDelete,
+}
+
+// HandleBulkModerate godoc
+// @Summary Moderate many comments at once (Admin)
+// @Description Approves, unapproves, marks as spam or not spam, or deletes the listed comments, or the oldest 1000 comments
+// @Description matching the filter, in a single transaction. A filter needs a post, author email or time range besides
+// @Description the status. Every affected comment is recorded in the moderation log under one batch ID. Unlike actions
+// @Description on single comments, bulk actions don't train the spam filter.
+// @Tags Admin Comments
+// @Accept json
+// @Produce json
+// @Param action path string true "Moderation action" Enums(approve, unapprove, spam, not-spam, delete)
+// @Param selection body dto.CommentBulkRequest true "Comment IDs or filter"
+// @Success 200 {object} dto.CommentBulkResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid action or selection"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/comments/bulk/{action} [post]
+func (h *CommentHandler) HandleBulkModerate(c *gin.Context) {
+	action, ok := bulkActions[c.Param("action")]
+	if !ok {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid moderation action"), http.StatusBadRequest))
+		return
+	}
+	var req dto.CommentBulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Handler: Invalid bulk moderation request body")
+		c.Error(myerr.WithHTTPStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	log := h.logger.WithField("action", action)
+	log.Info("Handler: Received request to moderate comments in bulk")
+
+	response, err := h.service.BulkModerate(action, middleware.AdminUsername(c), req)
+	if err != nil {
+		log.WithError(err).Error("Handler: Failed to moderate comments in bulk")
+		c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	log.WithField("affected", response.Affected).Info("Handler: Comments moderated in bulk successfully")
+	c.JSON(http.StatusOK, response)
+}
+
+// HandleGetModerationLog godoc
+// @Summary Get the moderation log (Admin)
+// @Description Lists the moderation actions on comments, newest first, with who took them and when.
+// @Tags Admin Comments
+// @Produce json
+// @Param page query int false "Page number (default: 1)"
+// @Param size query int false "Page size (default: 50, max: 100)"
+// @Param actor query string false "Username of the admin, or flags for comments flagged by readers"
+// @Param action query string false "Moderation action" Enums(approve, unapprove, spam, not_spam, delete)
+// @Param comment_id query int false "Comment ID"
+// @Param post_id query int false "Post ID"
+// @Param batch_id query string false "Batch ID of a bulk action"
+// @Param from query string false "Taken at or after (RFC 3339)"
+// @Param to query string false "Taken before (RFC 3339)"
+// @Success 200 {object} map[string]interface{} "entries: []models.ModerationLogEntry, total_count: int64"
+// @Failure 400 {object} models.ErrorResponse "Invalid parameters"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/comments/moderation-log [get]
+func (h *CommentHandler) HandleGetModerationLog(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid page number"), http.StatusBadRequest))
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("size", "50"))
+	if err != nil || pageSize < 1 {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid page size"), http.StatusBadRequest))
+		return
+	}
+
+	filter := ModerationLogFilter{Actor: c.Query("actor"), Action: c.Query("action"), BatchID: c.Query("batch_id")}
+	for param, id := range map[string]*uint{"comment_id": &filter.CommentID, "post_id": &filter.PostID} {
+		if value := c.Query(param); value != "" {
+			parsed, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				c.Error(myerr.WithHTTPStatus(errors.New("invalid "+param), http.StatusBadRequest))
+				return
+			}
+			*id = uint(parsed)
+		}
+	}
+	for param, t := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := c.Query(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.Error(myerr.WithHTTPStatus(errors.New("invalid "+param+", use RFC 3339"), http.StatusBadRequest))
+				return
+			}
+			*t = &parsed
+		}
+	}
+
+	entries, totalCount, err := h.service.GetModerationLog(page, pageSize, filter)
+	if err != nil {
+		h.logger.WithError(err).Error("Handler: Failed to get moderation log")
+		c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"entries":     entries,
+		"total_count": totalCount,
+		"page":        page,
+		"page_size":   pageSize,
+	})
+}
+
+// unsubscribePage is shown to readers following the unsubscribe link of a reply notification
+const unsubscribePage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Unsubscribed</title></head>
+<body><p>You will no longer receive emails about replies to your comments.</p></body></html>
+`
+
+// HandleUnsubscribe godoc
+// @Summary Unsubscribe from reply notifications
+// @Description Stops the reply notification emails of the address the signed token was sent to. Serves both the link in
+// @Description the email (GET) and one-click unsubscribe from mail clients (POST).
+// @Tags Comments
+// @Produce html
+// @Param token query string true "Unsubscribe token from the notification email"
+// @Success 200 {string} string "Confirmation page"
+// @Failure 400 {object} models.ErrorResponse "Invalid unsubscribe link"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /comments/unsubscribe [get]
+// @Router /comments/unsubscribe [post]
+func (h *CommentHandler) HandleUnsubscribe(c *gin.Context) {
+	if err := h.service.Unsubscribe(c.Query("token")); err != nil {
+		c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(unsubscribePage))
+}
+
+// verifiedPage is shown to commenters following the link of a verification email
+const verifiedPage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Email confirmed</title></head>
+<body><p>Your email address is confirmed and your comment is published. Thanks for joining the conversation!</p></body></html>
+`
+
+// HandleVerifyEmail godoc
+// @Summary Verify a commenter's email
+// @Description Confirms the email of a comment's author through the link of the verification email. Comments from
+// @Description the email that were held for verification are published, later ones are published right away.
+// @Tags Comments
+// @Produce html
+// @Param token query string true "Verification token from the email"
+// @Success 200 {string} string "Confirmation page"
+// @Failure 400 {object} models.ErrorResponse "Invalid or expired verification link"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /comments/verify [get]
+func (h *CommentHandler) HandleVerifyEmail(c *gin.Context) {
+	if err := h.service.VerifyEmail(c.Query("token")); err != nil {
+		c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(verifiedPage))
+}
+
+// HandleGetFlags godoc
+// @Summary Get the flags of a comment (Admin)
+// @Description Lists the reports of readers about a comment, newest first, including those from before it was last approved.
+// @Tags Admin Comments
+// @Produce json
+// @Param comment_id path int true "Comment ID"
+// @Success 200 {array} models.CommentFlag
+// @Failure 400 {object} models.ErrorResponse "Invalid comment ID"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/comments/{comment_id}/flags [get]
+func (h *CommentHandler) HandleGetFlags(c *gin.Context) {
+	commentID, err := strconv.ParseUint(c.Param("comment_id"), 10, 32)
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid comment ID"), http.StatusBadRequest))
+		return
+	}
+	flags, err := h.service.GetFlags(uint(commentID))
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+	c.JSON(http.StatusOK, flags)
+}
+
+// HandleAdminReply godoc
+// @Summary Reply to a comment (Admin)
+// @Description Publishes a reply of the authenticated admin, shown to readers as written by the blog author. A pending
+// @Description comment is approved along with the reply; spam has to be marked as not spam first.
+// @Tags Admin Comments
+// @Accept json
+// @Produce json
+// @Param comment_id path int true "Comment ID"
+// @Param reply body dto.CommentReplyRequest true "Reply"
+// @Success 201 {object} dto.CommentResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid comment ID or content, or the comment is spam"
+// @Failure 404 {object} models.ErrorResponse "Comment not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/comments/{comment_id}/reply [post]
+func (h *CommentHandler) HandleAdminReply(c *gin.Context) {
+	commentID, err := strconv.ParseUint(c.Param("comment_id"), 10, 32)
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid comment ID"), http.StatusBadRequest))
+		return
+	}
+	var req dto.CommentReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(myerr.WithHTTPStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	reply, err := h.service.ReplyAsAdmin(uint(commentID), middleware.AdminUsername(c), req)
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+	c.JSON(http.StatusCreated, reply)
+}
@@ -0,0 +1,61 @@
+package comment
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+)
+
+var (
+	// ErrInvalidEditToken is returned when the edit token doesn't belong to the comment
+	ErrInvalidEditToken = errors.New("invalid edit token")
+	// ErrEditWindowPassed is returned for edits after the edit window of the comment closed
+	ErrEditWindowPassed = errors.New("comment can no longer be changed")
+	// ErrInvalidVerificationToken is returned for verification links that are forged or expired
+	ErrInvalidVerificationToken = errors.New("verification link is invalid or expired")
+)
+
+// issueEditToken creates the token the author of a new comment edits and withdraws it with. Only its hash is
+// stored; the signature lets forged tokens be rejected without looking at the comment.
+func (s *CommentService) issueEditToken() (token, hash string, err error) {
+	nonce := make([]byte, 24)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", "", err
+	}
+	token = s.editTokens.Seal(nonce)
+	return token, hashEditToken(token), nil
+}
+
+// checkEditToken reports whether token is the edit token of c
+func (s *CommentService) checkEditToken(c *models.Comment, token string) bool {
+	if c.EditTokenHash == "" {
+		return false
+	}
+	if _, ok := s.editTokens.Open(token); !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashEditToken(token)), []byte(c.EditTokenHash)) == 1
+}
+
+func hashEditToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueVerificationToken creates the token of the link that verifies the email of a comment's author
+func (s *CommentService) issueVerificationToken(commentID uint, now time.Time) string {
+	return s.verificationTokens.EncodePair(uint64(commentID), uint64(now.Add(s.settings.VerificationTTL).Unix()))
+}
+
+func (s *CommentService) parseVerificationToken(token string, now time.Time) (commentID uint, ok bool) {
+	id, expiresAt, ok := s.verificationTokens.DecodePair(token)
+	if !ok || now.Unix() > int64(expiresAt) {
+		return 0, false
+	}
+	return uint(id), true
+}
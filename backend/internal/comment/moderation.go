@@ -0,0 +1,287 @@
+package comment
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Moderation actions, as recorded in the moderation log
+const (
+	ActionApprove   = "approve"
+	ActionUnapprove = "unapprove"
+	ActionSpam      = "spam"
+	ActionNotSpam   = "not_spam"
+	ActionDelete    = "delete"
+)
+
+const (
+	maxBulkIDs           = 1000 // Comments a bulk action can list, and the most a filter selects at once
+	maxLogEntriesPerPage = 100
+)
+
+// Selection picks the comments a moderation action applies to: the listed IDs, or else every comment matching
+// the other fields
+type Selection struct {
+	IDs         []uint
+	PostID      uint
+	AuthorEmail string
+	From        *time.Time // Created at or after
+	To          *time.Time // Created before
+	Status      string     // pending, approved, spam or all
+	Limit       int        // Selects at most this many comments matching the filter, oldest first; 0 doesn't limit
+}
+
+// ModerationLogFilter narrows down the moderation log; zero fields match everything
+type ModerationLogFilter struct {
+	Actor     string
+	Action    string
+	CommentID uint
+	PostID    uint
+	BatchID   string
+	From      *time.Time
+	To        *time.Time
+}
+
+// ApproveComment approves a comment, taking it out of the spam bucket if it was there, and teaches the spam filter it is ham.
+func (s *CommentService) ApproveComment(id uint, actor string) error {
+	return s.moderateOne(ActionApprove, id, actor)
+}
+
+// DeleteComment deletes a comment and teaches the spam filter it is spam. Deleting a missing comment is not an error.
+func (s *CommentService) DeleteComment(id uint, actor string) error {
+	_, err := s.moderate(ActionDelete, Selection{IDs: []uint{id}}, actor, "")
+	return err
+}
+
+// MarkSpam moves a comment into the spam bucket, unapproving it, and teaches the spam filter it is spam
+func (s *CommentService) MarkSpam(id uint, actor string) error {
+	return s.moderateOne(ActionSpam, id, actor)
+}
+
+// MarkNotSpam returns a false positive from the spam bucket to the pending queue and teaches the spam filter it is ham
+func (s *CommentService) MarkNotSpam(id uint, actor string) error {
+	return s.moderateOne(ActionNotSpam, id, actor)
+}
+
+// moderateOne applies an action to a single comment, returning gorm.ErrRecordNotFound if it doesn't exist
+func (s *CommentService) moderateOne(action string, id uint, actor string) error {
+	comments, err := s.moderate(action, Selection{IDs: []uint{id}}, actor, "")
+	if err != nil {
+		return err
+	}
+	if len(comments) == 0 {
+		s.logger.WithFields(logrus.Fields{"comment_id": id, "action": action}).Warn("Service: Comment not found for moderation")
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// BulkModerate applies an action to the listed comments, or to every comment matching the filter, at once
+func (s *CommentService) BulkModerate(action, actor string, req dto.CommentBulkRequest) (*dto.CommentBulkResponse, error) {
+	sel, err := bulkSelection(req)
+	if err != nil {
+		return nil, myerr.WithHTTPStatus(err, http.StatusBadRequest)
+	}
+	batchID := uuid.New().String()
+	comments, err := s.moderate(action, sel, actor, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.CommentBulkResponse{
+		Action:   action,
+		Affected: len(comments),
+		IDs:      make([]uint, len(comments)),
+		Capped:   sel.Limit > 0 && len(comments) >= sel.Limit,
+	}
+	for i, c := range comments {
+		response.IDs[i] = c.ID
+	}
+	if len(comments) > 0 {
+		response.BatchID = batchID
+	}
+	return response, nil
+}
+
+func bulkSelection(req dto.CommentBulkRequest) (Selection, error) {
+	switch {
+	case len(req.IDs) > 0 && req.Filter != nil:
+		return Selection{}, errors.New("give either ids or a filter, not both")
+	case len(req.IDs) > maxBulkIDs:
+		return Selection{}, errors.New("too many ids, use a filter instead")
+	case len(req.IDs) > 0:
+		return Selection{IDs: req.IDs}, nil
+	case req.Filter == nil:
+		return Selection{}, errors.New("ids or a filter are required")
+	}
+
+	// The status narrows down a filter but doesn't make one: "all" would select every comment
+	f := req.Filter
+	if f.PostID == 0 && f.AuthorEmail == "" && f.From == nil && f.To == nil {
+		return Selection{}, errors.New("filter needs a post, author email or time range")
+	}
+	sel := Selection{PostID: f.PostID, AuthorEmail: f.AuthorEmail, From: f.From, To: f.To, Status: f.Status, Limit: maxBulkIDs}
+	switch sel.Status {
+	case "":
+		sel.Status = "all"
+	case "pending", "approved", "spam", "all":
+	default:
+		return Selection{}, errors.New("invalid status, use pending, approved, spam or all")
+	}
+	return sel, nil
+}
+
+// moderate applies an action through the repository, then announces approved replies. Decisions on single comments
+// teach the spam filter; bulk actions, which have a batchID, don't: deleting a whole thread or the comments of a
+// post says nothing about whether each of them is spam, and a large batch would outweigh everything learned before.
+func (s *CommentService) moderate(action string, sel Selection, actor, batchID string) ([]models.Comment, error) {
+	log := s.logger.WithFields(logrus.Fields{"action": action, "actor": actor})
+	if len(sel.IDs) == 1 {
+		log = log.WithField("comment_id", sel.IDs[0])
+	}
+	log.Info("Service: Moderating comments")
+	comments, err := s.repo.Moderate(action, sel, actor, batchID)
+	if err != nil {
+		log.WithError(err).Error("Service: Failed to moderate comments in repository")
+		return nil, err
+	}
+
+	if batchID == "" {
+		for i := range comments {
+			switch action {
+			case ActionApprove, ActionNotSpam:
+				s.spam.Learn(&comments[i], models.SpamLabelHam)
+			case ActionSpam, ActionDelete:
+				s.spam.Learn(&comments[i], models.SpamLabelSpam)
+			}
+		}
+	}
+	if action == ActionApprove {
+		s.announceReplies(comments, log)
+	}
+	log.WithField("count", len(comments)).Info("Service: Comments moderated successfully")
+	return comments, nil
+}
+
+// announceReplies notifies the authors of the parents of newly approved replies. Replies are announced once, when
+// they first become visible; the parents are loaded in one query.
+func (s *CommentService) announceReplies(approved []models.Comment, log *logrus.Entry) {
+	var replies []*models.Comment
+	var parentIDs []uint
+	for i := range approved {
+		if c := &approved[i]; !c.IsApproved && c.ParentCommentID != nil {
+			replies = append(replies, c)
+			parentIDs = append(parentIDs, *c.ParentCommentID)
+		}
+	}
+	if len(replies) == 0 {
+		return
+	}
+	parents, err := s.repo.FindByIDs(parentIDs)
+	if err != nil {
+		log.WithError(err).Warn("Service: Parent comments not loaded, reply notifications not sent")
+		return
+	}
+	byID := make(map[uint]*models.Comment, len(parents))
+	for i := range parents {
+		byID[parents[i].ID] = &parents[i]
+	}
+	for _, reply := range replies {
+		parent, ok := byID[*reply.ParentCommentID]
+		if !ok {
+			log.WithField("comment_id", reply.ID).Warn("Service: Parent comment not found, reply notification not sent")
+			continue
+		}
+		s.notifier.ReplyApproved(reply, parent)
+	}
+}
+
+// ReplyAsAdmin publishes the reply of an admin to a comment on a published post, approving the comment with the
+// reply if it is pending. The reply skips the spam filter and the blocklist, and is marked as written by the blog
+// author.
+func (s *CommentService) ReplyAsAdmin(parentID uint, username string, req dto.CommentReplyRequest) (*dto.CommentResponse, error) {
+	log := s.logger.WithFields(logrus.Fields{"parent_id": parentID, "actor": username})
+	log.Info("Service: Replying to comment as admin")
+	if strings.TrimSpace(req.Content) == "" {
+		return nil, myerr.WithHTTPStatus(errors.New("content cannot be empty"), http.StatusBadRequest)
+	}
+	user, err := s.users.FindByUsername(username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, myerr.WithHTTPStatus(errors.New("admin account not found"), http.StatusForbidden)
+		}
+		return nil, err
+	}
+	parent, err := s.repo.FindByID(parentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, myerr.WithHTTPStatus(errors.New("comment not found"), http.StatusNotFound)
+		}
+		return nil, err
+	}
+	if parent.IsSpam {
+		return nil, myerr.WithHTTPStatus(errors.New("comment is spam, mark it as not spam before replying"), http.StatusBadRequest)
+	}
+	if _, err := s.repo.FindPost(parent.PostID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, myerr.WithHTTPStatus(errors.New("post is not published"), http.StatusBadRequest)
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	author := toCommentAuthor(user)
+	reply := &models.Comment{
+		PostID:          parent.PostID,
+		AuthorName:      truncate(author.DisplayName, 100),
+		AuthorEmail:     user.Email,
+		Content:         req.Content,
+		IsApproved:      true,
+		ApprovedAt:      &now,
+		ParentCommentID: &parent.ID,
+		ContentHash:     contentHash(req.Content),
+		UserID:          &user.ID,
+	}
+	approvedParent, err := s.repo.CreateReply(reply, username, !parent.IsApproved)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, myerr.WithHTTPStatus(errors.New("comment not found"), http.StatusNotFound)
+		}
+		log.WithError(err).Error("Service: Failed to create reply in repository")
+		return nil, err
+	}
+	log.WithField("comment_id", reply.ID).Info("Service: Admin reply published")
+	if approvedParent != nil {
+		s.spam.Learn(approvedParent, models.SpamLabelHam)
+		s.announceReplies([]models.Comment{*approvedParent}, log)
+		parent.IsApproved, parent.ApprovedAt = true, &now
+	}
+	s.notifier.ReplyApproved(reply, parent)
+
+	return &dto.CommentResponse{
+		ID:              reply.ID,
+		CreatedAt:       reply.CreatedAt,
+		AuthorName:      reply.AuthorName,
+		Content:         reply.Content,
+		ParentCommentID: reply.ParentCommentID,
+		IsAuthor:        true,
+		Author:          author,
+	}, nil
+}
+
+// GetModerationLog returns a page of the moderation log, newest first
+func (s *CommentService) GetModerationLog(page, pageSize int, filter ModerationLogFilter) ([]models.ModerationLogEntry, int64, error) {
+	if pageSize > maxLogEntriesPerPage {
+		pageSize = maxLogEntriesPerPage
+	}
+	return s.repo.FindModerationLog((page-1)*pageSize, pageSize, filter)
+}
@@ -0,0 +1,136 @@
+package comment
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+)
+
+func TestBulkSelection(t *testing.T) {
+	from := time.Now().Add(-time.Hour)
+	tooMany := make([]uint, maxBulkIDs+1)
+	tests := []struct {
+		name    string
+		req     dto.CommentBulkRequest
+		want    Selection
+		wantErr bool
+	}{
+		{name: "ids", req: dto.CommentBulkRequest{IDs: []uint{1, 2}}, want: Selection{IDs: []uint{1, 2}}},
+		{name: "too many ids", req: dto.CommentBulkRequest{IDs: tooMany}, wantErr: true},
+		{name: "nothing", req: dto.CommentBulkRequest{}, wantErr: true},
+		{name: "ids and filter", req: dto.CommentBulkRequest{IDs: []uint{1}, Filter: &dto.CommentBulkFilter{PostID: 1}}, wantErr: true},
+		{name: "empty filter", req: dto.CommentBulkRequest{Filter: &dto.CommentBulkFilter{}}, wantErr: true},
+		{name: "status alone", req: dto.CommentBulkRequest{Filter: &dto.CommentBulkFilter{Status: "all"}}, wantErr: true},
+		{name: "pending status alone", req: dto.CommentBulkRequest{Filter: &dto.CommentBulkFilter{Status: "pending"}}, wantErr: true},
+		{name: "invalid status", req: dto.CommentBulkRequest{Filter: &dto.CommentBulkFilter{PostID: 1, Status: "deleted"}}, wantErr: true},
+		{
+			name: "post filter",
+			req:  dto.CommentBulkRequest{Filter: &dto.CommentBulkFilter{PostID: 3}},
+			want: Selection{PostID: 3, Status: "all", Limit: maxBulkIDs},
+		},
+		{
+			name: "time range and status",
+			req:  dto.CommentBulkRequest{Filter: &dto.CommentBulkFilter{From: &from, Status: "spam"}},
+			want: Selection{From: &from, Status: "spam", Limit: maxBulkIDs},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bulkSelection(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && !equalSelections(got, tt.want) {
+				t.Errorf("selection = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func equalSelections(a, b Selection) bool {
+	return len(a.IDs) == len(b.IDs) && a.PostID == b.PostID && a.AuthorEmail == b.AuthorEmail && a.From == b.From &&
+		a.To == b.To && a.Status == b.Status && a.Limit == b.Limit
+}
+
+func TestBulkModerationDoesNotTrainSpamFilter(t *testing.T) {
+	repo := &fakeRepository{comments: map[uint]*models.Comment{
+		1: {ID: 1, PostID: 1, Content: "first"},
+		2: {ID: 2, PostID: 1, Content: "second"},
+	}}
+	spamRepo := &fakeSpamRepository{}
+	s := newTestService(repo, Settings{})
+	s.spam = newTestSpamFilter(spamRepo)
+
+	response, err := s.BulkModerate(ActionDelete, "admin", dto.CommentBulkRequest{Filter: &dto.CommentBulkFilter{PostID: 1}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.Affected != 2 || response.Capped {
+		t.Errorf("response = %+v", response)
+	}
+	if repo.selections[0].Limit != maxBulkIDs {
+		t.Errorf("filter selection limit = %d, want %d", repo.selections[0].Limit, maxBulkIDs)
+	}
+	if spamRepo.learned != 0 {
+		t.Errorf("bulk delete taught the spam filter %d times", spamRepo.learned)
+	}
+
+	// Single decisions still teach it
+	if err := s.DeleteComment(1, "admin"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spamRepo.learned == 0 {
+		t.Error("deleting a single comment didn't teach the spam filter")
+	}
+}
+
+func TestReplyAsAdmin(t *testing.T) {
+	repo := &fakeRepository{
+		posts: map[uint]*models.Post{1: openPost(1)},
+		comments: map[uint]*models.Comment{
+			1: {ID: 1, PostID: 1, Content: "pending question"},
+			2: {ID: 2, PostID: 2, Content: "on a draft", IsApproved: true},
+		},
+		nextID: 2,
+	}
+	s := newTestService(repo, Settings{})
+
+	response, err := s.ReplyAsAdmin(1, "admin", dto.CommentReplyRequest{Content: "answer"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.AuthorName != defaultAuthorName || response.Author.DisplayName != defaultAuthorName {
+		t.Errorf("reply signed %q / %q, want %q", response.AuthorName, response.Author.DisplayName, defaultAuthorName)
+	}
+	if !slices.Equal(repo.replies, []bool{true}) || len(repo.moderated) != 0 {
+		t.Errorf("parent approved with replies %v and moderation %v, want a single CreateReply", repo.replies, repo.moderated)
+	}
+	if !repo.comments[1].IsApproved {
+		t.Error("pending parent not approved")
+	}
+
+	// The parent is on a post that isn't published
+	_, err = s.ReplyAsAdmin(2, "admin", dto.CommentReplyRequest{Content: "answer"})
+	if myerr.HTTPStatus(err) != http.StatusBadRequest {
+		t.Errorf("reply on unpublished post: err = %v, want status 400", err)
+	}
+	if len(repo.replies) != 1 {
+		t.Error("reply on unpublished post was stored")
+	}
+}
+
+func TestToCommentAuthorHidesUsername(t *testing.T) {
+	author := toCommentAuthor(&models.User{Username: "login-name"})
+	if author.DisplayName != defaultAuthorName {
+		t.Errorf("display name = %q, want %q", author.DisplayName, defaultAuthorName)
+	}
+	author = toCommentAuthor(&models.User{Username: "login-name", DisplayName: "Jane"})
+	if author.DisplayName != "Jane" {
+		t.Errorf("display name = %q, want Jane", author.DisplayName)
+	}
+}
@@ -0,0 +1,56 @@
+package comment
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository interface {
+	FindPostTitle(postID uint) (string, error)
+	IsUnsubscribed(email string) (bool, error)
+	Unsubscribe(email string) error
+}
+
+type notificationRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewNotificationRepository(db *gorm.DB, logger *logrus.Logger) NotificationRepository {
+	return &notificationRepository{db: db, logger: logger}
+}
+
+func (r *notificationRepository) FindPostTitle(postID uint) (string, error) {
+	var post models.Post
+	if err := r.db.Select("id", "title").Where("id = ? AND deleted_at IS NULL", postID).First(&post).Error; err != nil {
+		r.logger.WithError(err).WithField("post_id", postID).Error("Repository: Failed to fetch post title")
+		return "", fmt.Errorf("failed to fetch post: %w", err)
+	}
+	return post.Title, nil
+}
+
+func (r *notificationRepository) IsUnsubscribed(email string) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.EmailUnsubscribe{}).Where("email = ?", strings.ToLower(email)).Count(&count).Error; err != nil {
+		r.logger.WithError(err).Error("Repository: Failed to check unsubscribe")
+		return false, fmt.Errorf("failed to check unsubscribe: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Unsubscribe records an unsubscribe; unsubscribing twice is not an error
+func (r *notificationRepository) Unsubscribe(email string) error {
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.EmailUnsubscribe{Email: strings.ToLower(email)}).Error
+	if err != nil {
+		r.logger.WithError(err).Error("Repository: Failed to save unsubscribe")
+		return fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+	r.logger.Info("Repository: Email unsubscribed from reply notifications")
+	return nil
+}
@@ -0,0 +1,170 @@
+package comment
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/mailer"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/signedtoken"
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var (
+	moderationTemplate   = mailer.MustParseTemplate(templateFS, "templates/moderation.tmpl")
+	replyTemplate        = mailer.MustParseTemplate(templateFS, "templates/reply.tmpl")
+	verificationTemplate = mailer.MustParseTemplate(templateFS, "templates/verification.tmpl")
+)
+
+// ErrInvalidUnsubscribeToken is returned for unsubscribe links that were not issued by us
+var ErrInvalidUnsubscribeToken = errors.New("unsubscribe link is invalid")
+
+// MailQueue sends messages in the background, see mailer.Queue
+type MailQueue interface {
+	Enqueue(msg *mailer.Message) bool
+}
+
+// NotificationSettings configures comment notifications
+type NotificationSettings struct {
+	Secret      []byte   // Application secret, signs unsubscribe links
+	SiteURL     string   // Public URL of the blog, used in links
+	AdminEmails []string // Notified of comments awaiting moderation
+}
+
+// Notifier emails admins about comments to moderate and commenters about replies. Emails are only queued,
+// failures are logged and never reach the request that triggered them.
+type Notifier struct {
+	queue             MailQueue
+	repo              NotificationRepository
+	settings          NotificationSettings
+	unsubscribeTokens signedtoken.Signer
+	logger            *logrus.Logger
+}
+
+func NewNotifier(queue MailQueue, repo NotificationRepository, settings NotificationSettings, logger *logrus.Logger) *Notifier {
+	return &Notifier{
+		queue:             queue,
+		repo:              repo,
+		settings:          settings,
+		unsubscribeTokens: signedtoken.New(settings.Secret, "comment-unsubscribe"),
+		logger:            logger,
+	}
+}
+
+// CommentAwaitingModeration tells the admins about a new comment in the pending queue
+func (n *Notifier) CommentAwaitingModeration(c *models.Comment) {
+	if len(n.settings.AdminEmails) == 0 {
+		return
+	}
+	log := n.logger.WithField("comment_id", c.ID)
+	title, err := n.repo.FindPostTitle(c.PostID)
+	if err != nil {
+		log.WithError(err).Warn("Service: Moderation notification not sent")
+		return
+	}
+	msg, err := moderationTemplate.Render(n.settings.AdminEmails, map[string]interface{}{
+		"Comment":       c,
+		"PostTitle":     title,
+		"PostURL":       n.postURL(c.PostID),
+		"ModerationURL": n.settings.SiteURL + "/admin",
+	})
+	if err != nil {
+		log.WithError(err).Error("Service: Failed to render moderation notification")
+		return
+	}
+	n.queue.Enqueue(msg)
+}
+
+// ReplyApproved tells the author of the parent of an approved reply about it, unless they unsubscribed
+// or replied to themselves
+func (n *Notifier) ReplyApproved(reply, parent *models.Comment) {
+	log := n.logger.WithFields(logrus.Fields{"comment_id": reply.ID, "parent_id": parent.ID})
+	if !parent.IsApproved || parent.AuthorEmail == "" || strings.EqualFold(parent.AuthorEmail, reply.AuthorEmail) {
+		return
+	}
+	unsubscribed, err := n.repo.IsUnsubscribed(parent.AuthorEmail)
+	if err != nil || unsubscribed {
+		if err != nil {
+			log.WithError(err).Warn("Service: Reply notification not sent")
+		}
+		return
+	}
+	title, err := n.repo.FindPostTitle(reply.PostID)
+	if err != nil {
+		log.WithError(err).Warn("Service: Reply notification not sent")
+		return
+	}
+
+	unsubscribeURL := n.settings.SiteURL + "/api/comments/unsubscribe?token=" + url.QueryEscape(n.unsubscribeToken(parent.AuthorEmail))
+	msg, err := replyTemplate.Render([]string{parent.AuthorEmail}, map[string]interface{}{
+		"Reply":          reply,
+		"Parent":         parent,
+		"PostTitle":      title,
+		"PostURL":        n.postURL(reply.PostID),
+		"ReplyURL":       fmt.Sprintf("%s#comment-%d", n.postURL(reply.PostID), reply.ID),
+		"UnsubscribeURL": unsubscribeURL,
+	})
+	if err != nil {
+		log.WithError(err).Error("Service: Failed to render reply notification")
+		return
+	}
+	// One-click unsubscribe from the mail client (RFC 8058)
+	msg.Headers = map[string]string{
+		"List-Unsubscribe":      "<" + unsubscribeURL + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+	n.queue.Enqueue(msg)
+}
+
+// VerificationRequested sends the author of a comment held for email verification the link that publishes it.
+// It is sent to unsubscribed addresses too, since the author asked for it by commenting.
+func (n *Notifier) VerificationRequested(c *models.Comment, token string, expiresAt time.Time) {
+	log := n.logger.WithField("comment_id", c.ID)
+	title, err := n.repo.FindPostTitle(c.PostID)
+	if err != nil {
+		log.WithError(err).Warn("Service: Verification email not sent")
+		return
+	}
+	msg, err := verificationTemplate.Render([]string{c.AuthorEmail}, map[string]interface{}{
+		"Comment":   c,
+		"PostTitle": title,
+		"PostURL":   n.postURL(c.PostID),
+		"VerifyURL": n.settings.SiteURL + "/api/comments/verify?token=" + url.QueryEscape(token),
+		"ExpiresAt": expiresAt,
+	})
+	if err != nil {
+		log.WithError(err).Error("Service: Failed to render verification email")
+		return
+	}
+	n.queue.Enqueue(msg)
+}
+
+// Unsubscribe stops the reply notifications of the address an unsubscribe token was issued for
+func (n *Notifier) Unsubscribe(token string) error {
+	email, ok := n.parseUnsubscribeToken(token)
+	if !ok {
+		return ErrInvalidUnsubscribeToken
+	}
+	return n.repo.Unsubscribe(email)
+}
+
+func (n *Notifier) postURL(postID uint) string {
+	return fmt.Sprintf("%s/post/%d", n.settings.SiteURL, postID)
+}
+
+// unsubscribeToken signs an email address; the token never expires, so old emails keep working
+func (n *Notifier) unsubscribeToken(email string) string {
+	return n.unsubscribeTokens.Seal([]byte(strings.ToLower(email)))
+}
+
+func (n *Notifier) parseUnsubscribeToken(token string) (string, bool) {
+	email, ok := n.unsubscribeTokens.Open(token)
+	return string(email), ok
+}
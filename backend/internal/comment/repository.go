@@ -1,23 +1,58 @@
 package comment
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CommentRepository interface {
 	Create(comment *models.Comment) error
-	FindByPostID(postID uint) ([]models.Comment, error)
+	// FindThreadsByPostID returns the visible comments of limit threads of a post, skipping the first offset threads.
+	// Threads are ordered by the creation of their first comment.
+	FindThreadsByPostID(postID uint, offset, limit int) ([]models.Comment, error)
+	// CountThreads counts the threads readers see on a post and the visible comments in them
+	CountThreads(postID uint) (threads, comments int64, err error)
 	FindAll(offset, limit int, filter string) ([]models.AdminComment, int64, error)
 	FindByID(id uint) (*models.Comment, error)
+	// FindByIDs returns the comments with the given IDs that exist, in no particular order
+	FindByIDs(ids []uint) ([]models.Comment, error)
+	// FindPost returns the published post with the given ID, with the fields that decide whether it takes comments
+	FindPost(id uint) (*models.Post, error)
 	Update(comment *models.Comment) error
+	// UpdateContent saves the content of an edited comment with its spam and approval state
+	UpdateContent(comment *models.Comment) error
 	Delete(id uint) error
-	Approve(id uint) error
+	// Moderate applies a moderation action to the selected comments and logs it for actor, in one transaction.
+	// It returns the affected comments as they were before.
+	Moderate(action string, sel Selection, actor, batchID string) ([]models.Comment, error)
+	// CreateReply creates the reply of an admin, approving its parent first if approveParent is set, in one
+	// transaction. It returns the approved parent as it was before, or gorm.ErrRecordNotFound if it no longer exists.
+	CreateReply(reply *models.Comment, actor string, approveParent bool) (*models.Comment, error)
+	FindModerationLog(offset, limit int, filter ModerationLogFilter) ([]models.ModerationLogEntry, int64, error)
+	IsEmailVerified(email string) (bool, error)
+	// VerifyEmail records a verified email and approves the comments from it that were awaiting verification,
+	// except spam. It returns the approved comments.
+	VerifyEmail(email string) ([]models.Comment, error)
+	// ToggleReaction gives the reaction of a reader to a comment, or takes it back if it was given. It reports whether
+	// the reaction is given now.
+	ToggleReaction(commentID uint, emoji, ipAddress string) (bool, error)
+	// CountReactions counts the reactions to the given comments by emoji
+	CountReactions(commentIDs []uint) (map[uint]map[string]int, error)
+	// Flag records the flag of a reader on the approved comment it is for, and counts it unless the comment was flagged
+	// from the same network before. It returns the comment with its flag count and whether the flag was counted,
+	// gorm.ErrRecordNotFound if readers can't see the comment and ErrAlreadyFlagged if the reader flagged it before.
+	Flag(flag *models.CommentFlag) (*models.Comment, bool, error)
+	FindFlags(commentID uint) ([]models.CommentFlag, error)
+	// CountFlagsSince counts the flags given from an IP address since the given time
+	CountFlagsSince(ipAddress string, since time.Time) (int64, error)
 }
 
 type commentRepository struct {
@@ -40,18 +75,52 @@ func (r *commentRepository) Create(comment *models.Comment) error {
 	return nil
 }
 
-// FindByPostID retrieves only approved comments for a specific post, ordered by creation date.
-func (r *commentRepository) FindByPostID(postID uint) ([]models.Comment, error) {
-	var comments []models.Comment
-	log := r.logger.WithField("post_id", postID)
-	log.Info("Repository: Fetching approved comments by post ID")
+// visibleComments walks the comments of a post that readers can see, from the first comments of the threads down
+// through approved replies. Unapproved comments hide their replies. Deleted comments are included with their
+// deleted_at; a thread is shown as long as one of its comments is not deleted, the others become tombstones. Replies
+// whose parent no longer exists start threads of their own. Every row carries the root of its thread.
+const visibleComments = `WITH RECURSIVE visible AS (
+	SELECT c.id, c.id AS root_id, c.created_at AS root_created_at, c.deleted_at
+	FROM comments c
+	WHERE c.post_id = @post AND c.is_approved
+		AND (c.parent_comment_id IS NULL OR NOT EXISTS (SELECT 1 FROM comments p WHERE p.id = c.parent_comment_id AND p.post_id = c.post_id))
+	UNION
+	SELECT c.id, v.root_id, v.root_created_at, c.deleted_at
+	FROM comments c
+	JOIN visible v ON c.parent_comment_id = v.id
+	WHERE c.is_approved
+)
+`
 
-	// Fetch only approved comments, ordered by creation date
-	// Add Preload("Replies") if you want to fetch nested replies automatically
-	err := r.db.Where("post_id = ? AND is_approved = ?", postID, true).
-		Order("created_at ASC").
-		Find(&comments).Error
+// FindThreadsByPostID picks a page of threads in the database and loads their visible comments, deleted ones
+// included because they may be needed as tombstones.
+func (r *commentRepository) FindThreadsByPostID(postID uint, offset, limit int) ([]models.Comment, error) {
+	log := r.logger.WithFields(logrus.Fields{"post_id": postID, "offset": offset, "limit": limit})
+	log.Info("Repository: Fetching comment threads by post ID")
 
+	var ids []uint
+	err := r.db.Raw(visibleComments+`, page AS (
+	SELECT root_id FROM visible
+	WHERE deleted_at IS NULL
+	GROUP BY root_id, root_created_at
+	ORDER BY root_created_at ASC, root_id ASC
+	LIMIT @limit OFFSET @offset
+)
+SELECT visible.id FROM visible JOIN page ON page.root_id = visible.root_id`,
+		sql.Named("post", postID), sql.Named("limit", limit), sql.Named("offset", offset)).
+		Scan(&ids).Error
+	if err != nil {
+		log.WithError(err).Error("Repository: Failed to select comment threads")
+		return nil, fmt.Errorf("failed to fetch comments: %w", err)
+	}
+	if len(ids) == 0 {
+		return []models.Comment{}, nil
+	}
+
+	var comments []models.Comment
+	err = r.db.Unscoped().Preload("User").Where("id IN ?", ids).
+		Order("created_at ASC, id ASC").
+		Find(&comments).Error
 	if err != nil {
 		log.WithError(err).Error("Repository: Failed to fetch comments by post ID")
 		return nil, fmt.Errorf("failed to fetch comments: %w", err)
@@ -60,6 +129,22 @@ func (r *commentRepository) FindByPostID(postID uint) ([]models.Comment, error)
 	return comments, nil
 }
 
+func (r *commentRepository) CountThreads(postID uint) (threads, comments int64, err error) {
+	var counts struct {
+		Threads  int64
+		Comments int64
+	}
+	err = r.db.Raw(visibleComments+`SELECT
+	COUNT(DISTINCT root_id) FILTER (WHERE deleted_at IS NULL) AS threads,
+	COUNT(*) FILTER (WHERE deleted_at IS NULL) AS comments
+FROM visible`, sql.Named("post", postID)).Scan(&counts).Error
+	if err != nil {
+		r.logger.WithError(err).WithField("post_id", postID).Error("Repository: Failed to count comment threads")
+		return 0, 0, fmt.Errorf("failed to count comments: %w", err)
+	}
+	return counts.Threads, counts.Comments, nil
+}
+
 // FindAll retrieves all comments (including unapproved) for the admin panel with pagination and filtering.
 func (r *commentRepository) FindAll(offset, limit int, filter string) ([]models.AdminComment, int64, error) {
 	var comments []models.AdminComment
@@ -71,17 +156,7 @@ func (r *commentRepository) FindAll(offset, limit int, filter string) ([]models.
 		Select("comments.*, posts.title as post_title").        // Select fields from both tables
 		Joins("LEFT JOIN posts ON posts.id = comments.post_id") // Join with posts table
 
-	// Apply filter
-	switch filter {
-	case "approved":
-		query = query.Where("comments.is_approved = ?", true)
-	case "pending":
-		query = query.Where("comments.is_approved = ?", false)
-	case "all":
-		// No additional filter needed
-	default:
-		query = query.Where("comments.is_approved = ?", false) // Default to pending
-	}
+	query = filterByStatus(query, filter)
 
 	// Count total matching records
 	if err := query.Count(&totalCount).Error; err != nil {
@@ -104,6 +179,23 @@ func (r *commentRepository) FindAll(offset, limit int, filter string) ([]models.
 	return comments, totalCount, nil
 }
 
+func (r *commentRepository) FindPost(id uint) (*models.Post, error) {
+	var post models.Post
+	log := r.logger.WithField("post_id", id)
+	err := r.db.Select("id", "status", "published_at", "comment_mode").
+		Scopes(models.PublishedPosts).
+		First(&post, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Warn("Repository: Post not found")
+			return nil, gorm.ErrRecordNotFound
+		}
+		log.WithError(err).Error("Repository: Failed to fetch post")
+		return nil, fmt.Errorf("failed to fetch post: %w", err)
+	}
+	return &post, nil
+}
+
 func (r *commentRepository) FindByID(id uint) (*models.Comment, error) {
 	var comment models.Comment
 	log := r.logger.WithField("comment_id", id)
@@ -120,6 +212,15 @@ func (r *commentRepository) FindByID(id uint) (*models.Comment, error) {
 	return &comment, nil
 }
 
+func (r *commentRepository) FindByIDs(ids []uint) ([]models.Comment, error) {
+	var comments []models.Comment
+	if err := r.db.Where("id IN ?", ids).Find(&comments).Error; err != nil {
+		r.logger.WithError(err).Error("Repository: Failed to fetch comments by ID")
+		return nil, fmt.Errorf("failed to fetch comments: %w", err)
+	}
+	return comments, nil
+}
+
 func (r *commentRepository) Update(comment *models.Comment) error {
 	log := r.logger.WithField("comment_id", comment.ID)
 	log.Info("Repository: Updating comment")
@@ -132,6 +233,20 @@ func (r *commentRepository) Update(comment *models.Comment) error {
 	return nil
 }
 
+func (r *commentRepository) UpdateContent(comment *models.Comment) error {
+	log := r.logger.WithField("comment_id", comment.ID)
+	log.Info("Repository: Updating comment content")
+	err := r.db.Model(comment).
+		Select("content", "content_hash", "edited_at", "is_spam", "spam_score", "spam_reasons", "is_approved", "approved_at", "awaiting_verification").
+		Updates(comment).Error
+	if err != nil {
+		log.WithError(err).Error("Repository: Failed to update comment content")
+		return fmt.Errorf("failed to update comment: %w", err)
+	}
+	log.Info("Repository: Comment content updated successfully")
+	return nil
+}
+
 func (r *commentRepository) Delete(id uint) error {
 	log := r.logger.WithField("comment_id", id)
 	log.Info("Repository: Deleting comment")
@@ -143,22 +258,326 @@ func (r *commentRepository) Delete(id uint) error {
 	return nil
 }
 
-func (r *commentRepository) Approve(id uint) error {
-	log := r.logger.WithField("comment_id", id)
-	log.Info("Repository: Approving comment")
-	now := time.Now()
-	result := r.db.Model(&models.Comment{}).Where("id = ?", id).Updates(map[string]interface{}{
-		"is_approved": true,
-		"approved_at": &now,
+func (r *commentRepository) IsEmailVerified(email string) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.VerifiedEmail{}).Where("email = ?", strings.ToLower(email)).Count(&count).Error; err != nil {
+		r.logger.WithError(err).Error("Repository: Failed to check verified email")
+		return false, fmt.Errorf("failed to check verified email: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *commentRepository) VerifyEmail(email string) ([]models.Comment, error) {
+	email = strings.ToLower(email)
+	r.logger.Info("Repository: Verifying commenter email")
+	var approved []models.Comment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.VerifiedEmail{Email: email, VerifiedAt: time.Now()}).Error
+		if err != nil {
+			return err
+		}
+		held := tx.Model(&models.Comment{}).Where("LOWER(author_email) = ? AND awaiting_verification = ?", email, true)
+		if err := held.Session(&gorm.Session{}).Where("is_spam = ?", false).Find(&approved).Error; err != nil {
+			return err
+		}
+		if len(approved) > 0 {
+			now := time.Now()
+			ids := make([]uint, len(approved))
+			for i := range approved {
+				ids[i] = approved[i].ID
+				approved[i].IsApproved, approved[i].ApprovedAt, approved[i].AwaitingVerification = true, &now, false
+			}
+			err := tx.Model(&models.Comment{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+				"is_approved": true,
+				"approved_at": &now,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return held.Session(&gorm.Session{}).Update("awaiting_verification", false).Error
+	})
+	if err != nil {
+		r.logger.WithError(err).Error("Repository: Failed to verify commenter email")
+		return nil, fmt.Errorf("failed to verify email: %w", err)
+	}
+	r.logger.WithField("approved", len(approved)).Info("Repository: Commenter email verified")
+	return approved, nil
+}
+
+// filterByStatus narrows a query of comments down to one moderation queue: pending (the default), approved, spam or all
+func filterByStatus(query *gorm.DB, status string) *gorm.DB {
+	switch status {
+	case "approved":
+		return query.Where("comments.is_approved = ?", true)
+	case "spam":
+		return query.Where("comments.is_spam = ?", true)
+	case "flagged":
+		return query.Where("comments.flag_count > ?", 0)
+	case "all":
+		return query
+	default:
+		return query.Where("comments.is_approved = ? AND comments.is_spam = ?", false, false)
+	}
+}
+
+// moderationUpdates are the column changes of the moderation actions other than delete
+var moderationUpdates = map[string]func(now time.Time) map[string]interface{}{
+	ActionApprove: func(now time.Time) map[string]interface{} {
+		return map[string]interface{}{"is_approved": true, "approved_at": &now, "is_spam": false, "awaiting_verification": false, "flag_count": 0}
+	},
+	ActionUnapprove: func(time.Time) map[string]interface{} {
+		return map[string]interface{}{"is_approved": false, "approved_at": nil}
+	},
+	ActionSpam: func(time.Time) map[string]interface{} {
+		return map[string]interface{}{"is_spam": true, "is_approved": false, "approved_at": nil}
+	},
+	ActionNotSpam: func(time.Time) map[string]interface{} {
+		return map[string]interface{}{"is_spam": false}
+	},
+}
+
+func (r *commentRepository) Moderate(action string, sel Selection, actor, batchID string) ([]models.Comment, error) {
+	log := r.logger.WithFields(logrus.Fields{"action": action, "actor": actor, "batch_id": batchID})
+	log.Info("Repository: Moderating comments")
+	var comments []models.Comment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		comments, err = moderate(tx, action, sel, actor, batchID)
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Error("Repository: Failed to moderate comments")
+		return nil, fmt.Errorf("failed to moderate comments: %w", err)
+	}
+	log.WithField("count", len(comments)).Info("Repository: Comments moderated successfully")
+	return comments, nil
+}
+
+// moderate applies a moderation action to the selected comments and logs it within the transaction tx
+func moderate(tx *gorm.DB, action string, sel Selection, actor, batchID string) ([]models.Comment, error) {
+	query := tx.Model(&models.Comment{}).Clauses(clause.Locking{Strength: "UPDATE"})
+	if len(sel.IDs) > 0 {
+		query = query.Where("comments.id IN ?", sel.IDs)
+	} else {
+		query = filterByStatus(query, sel.Status)
+		if sel.PostID != 0 {
+			query = query.Where("comments.post_id = ?", sel.PostID)
+		}
+		if sel.AuthorEmail != "" {
+			query = query.Where("LOWER(comments.author_email) = ?", strings.ToLower(sel.AuthorEmail))
+		}
+		if sel.From != nil {
+			query = query.Where("comments.created_at >= ?", *sel.From)
+		}
+		if sel.To != nil {
+			query = query.Where("comments.created_at < ?", *sel.To)
+		}
+	}
+	if sel.Limit > 0 {
+		query = query.Limit(sel.Limit)
+	}
+	var comments []models.Comment
+	if err := query.Order("comments.id").Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return comments, nil
+	}
+
+	ids := make([]uint, len(comments))
+	entries := make([]models.ModerationLogEntry, len(comments))
+	for i, c := range comments {
+		ids[i] = c.ID
+		entries[i] = models.ModerationLogEntry{Actor: actor, Action: action, CommentID: c.ID, PostID: c.PostID, BatchID: batchID}
+	}
+	var err error
+	if action == ActionDelete {
+		err = tx.Delete(&models.Comment{}, ids).Error
+	} else {
+		err = tx.Model(&models.Comment{}).Where("id IN ?", ids).Updates(moderationUpdates[action](time.Now())).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return comments, tx.CreateInBatches(entries, 500).Error
+}
+
+func (r *commentRepository) CreateReply(reply *models.Comment, actor string, approveParent bool) (*models.Comment, error) {
+	log := r.logger.WithFields(logrus.Fields{"parent_id": *reply.ParentCommentID, "actor": actor})
+	log.Info("Repository: Creating admin reply")
+	var approvedParent *models.Comment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if approveParent {
+			approved, err := moderate(tx, ActionApprove, Selection{IDs: []uint{*reply.ParentCommentID}}, actor, "")
+			if err != nil {
+				return err
+			}
+			if len(approved) == 0 {
+				return gorm.ErrRecordNotFound
+			}
+			approvedParent = &approved[0]
+		}
+		return tx.Create(reply).Error
 	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		log.WithError(err).Error("Repository: Failed to create admin reply")
+		return nil, fmt.Errorf("failed to create reply: %w", err)
+	}
+	log.WithField("comment_id", reply.ID).Info("Repository: Admin reply created successfully")
+	return approvedParent, nil
+}
+
+func (r *commentRepository) FindModerationLog(offset, limit int, filter ModerationLogFilter) ([]models.ModerationLogEntry, int64, error) {
+	var entries []models.ModerationLogEntry
+	var totalCount int64
+	log := r.logger.WithFields(logrus.Fields{"offset": offset, "limit": limit})
+	log.Info("Repository: Fetching moderation log")
+
+	query := r.db.Model(&models.ModerationLogEntry{})
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.CommentID != 0 {
+		query = query.Where("comment_id = ?", filter.CommentID)
+	}
+	if filter.PostID != 0 {
+		query = query.Where("post_id = ?", filter.PostID)
+	}
+	if filter.BatchID != "" {
+		query = query.Where("batch_id = ?", filter.BatchID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	if err := query.Count(&totalCount).Error; err != nil {
+		log.WithError(err).Error("Repository: Failed to count moderation log entries")
+		return nil, 0, fmt.Errorf("failed to count moderation log: %w", err)
+	}
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&entries).Error; err != nil {
+		log.WithError(err).Error("Repository: Failed to fetch moderation log")
+		return nil, 0, fmt.Errorf("failed to fetch moderation log: %w", err)
+	}
+	return entries, totalCount, nil
+}
+
+func (r *commentRepository) ToggleReaction(commentID uint, emoji, ipAddress string) (bool, error) {
+	log := r.logger.WithFields(logrus.Fields{"comment_id": commentID, "emoji": emoji})
+	result := r.db.Where("comment_id = ? AND emoji = ? AND ip_address = ?", commentID, emoji, ipAddress).Delete(&models.CommentReaction{})
 	if result.Error != nil {
-		log.WithError(result.Error).Error("Repository: Failed to approve comment")
-		return fmt.Errorf("failed to approve comment: %w", result.Error)
+		log.WithError(result.Error).Error("Repository: Failed to remove comment reaction")
+		return false, fmt.Errorf("failed to remove reaction: %w", result.Error)
 	}
-	if result.RowsAffected == 0 {
-		log.Warn("Repository: Comment not found for approval or already approved")
-		return gorm.ErrRecordNotFound // Or a more specific error
+	if result.RowsAffected > 0 {
+		return false, nil
 	}
-	log.Info("Repository: Comment approved successfully")
-	return nil
+
+	// A concurrent request of the same reader may have added it meanwhile, either way it is given now
+	reaction := models.CommentReaction{CommentID: commentID, Emoji: emoji, IPAddress: ipAddress}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction).Error; err != nil {
+		log.WithError(err).Error("Repository: Failed to add comment reaction")
+		return false, fmt.Errorf("failed to add reaction: %w", err)
+	}
+	return true, nil
+}
+
+func (r *commentRepository) CountReactions(commentIDs []uint) (map[uint]map[string]int, error) {
+	counts := make(map[uint]map[string]int)
+	if len(commentIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		CommentID uint
+		Emoji     string
+		Count     int
+	}
+	err := r.db.Model(&models.CommentReaction{}).
+		Select("comment_id, emoji, COUNT(*) AS count").
+		Where("comment_id IN ?", commentIDs).
+		Group("comment_id, emoji").
+		Scan(&rows).Error
+	if err != nil {
+		r.logger.WithError(err).Error("Repository: Failed to count comment reactions")
+		return nil, fmt.Errorf("failed to count reactions: %w", err)
+	}
+	for _, row := range rows {
+		if counts[row.CommentID] == nil {
+			counts[row.CommentID] = make(map[string]int)
+		}
+		counts[row.CommentID][row.Emoji] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *commentRepository) Flag(flag *models.CommentFlag) (*models.Comment, bool, error) {
+	log := r.logger.WithFields(logrus.Fields{"comment_id": flag.CommentID, "reason": flag.Reason})
+	log.Info("Repository: Flagging comment")
+	var comment models.Comment
+	counted := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Locking the comment serializes the flags on it, so every flag count is seen once
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("is_approved = ?", true).First(&comment, flag.CommentID).Error
+		if err != nil {
+			return err
+		}
+		var count int64
+		err = tx.Model(&models.CommentFlag{}).Where("comment_id = ? AND ip_address = ?", flag.CommentID, flag.IPAddress).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrAlreadyFlagged
+		}
+		err = tx.Model(&models.CommentFlag{}).Where("comment_id = ? AND network = ?", flag.CommentID, flag.Network).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(flag).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		counted = true
+		comment.FlagCount++
+		return tx.Model(&comment).UpdateColumn("flag_count", gorm.Expr("flag_count + 1")).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, ErrAlreadyFlagged) {
+			return nil, false, err
+		}
+		log.WithError(err).Error("Repository: Failed to flag comment")
+		return nil, false, fmt.Errorf("failed to flag comment: %w", err)
+	}
+	return &comment, counted, nil
+}
+
+func (r *commentRepository) CountFlagsSince(ipAddress string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.CommentFlag{}).Where("ip_address = ? AND created_at >= ?", ipAddress, since).Count(&count).Error
+	if err != nil {
+		r.logger.WithError(err).Error("Repository: Failed to count flags of client")
+		return 0, fmt.Errorf("failed to count flags: %w", err)
+	}
+	return count, nil
+}
+
+func (r *commentRepository) FindFlags(commentID uint) ([]models.CommentFlag, error) {
+	var flags []models.CommentFlag
+	if err := r.db.Where("comment_id = ?", commentID).Order("created_at DESC").Find(&flags).Error; err != nil {
+		r.logger.WithError(err).WithField("comment_id", commentID).Error("Repository: Failed to fetch comment flags")
+		return nil, fmt.Errorf("failed to fetch comment flags: %w", err)
+	}
+	return flags, nil
 }
@@ -0,0 +1,42 @@
+package comment
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/dbtest"
+)
+
+func TestFindThreadsByPostIDPaginatesInTheDatabase(t *testing.T) {
+	db, recorder := dbtest.DryRun(t)
+	repo := NewCommentRepository(db, newTestLogger())
+
+	if _, err := repo.FindThreadsByPostID(7, 40, 20); err != nil && !dbtest.Unsupported(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	queries := recorder.Queries()
+	if len(queries) != 1 {
+		t.Fatalf("expected one query selecting the threads, got %q", queries)
+	}
+	for _, want := range []string{"c.post_id = 7", "c.parent_comment_id IS NULL", "LIMIT 20 OFFSET 40", "WHERE c.is_approved"} {
+		if !strings.Contains(queries[0], want) {
+			t.Errorf("thread query lacks %q: %s", want, queries[0])
+		}
+	}
+}
+
+func TestFindPostOnlyFindsPublishedPosts(t *testing.T) {
+	db, recorder := dbtest.DryRun(t)
+	repo := NewCommentRepository(db, newTestLogger())
+
+	repo.FindPost(7) // A dry run finds no post, only the query matters
+	queries := recorder.Queries()
+	if len(queries) != 1 {
+		t.Fatalf("expected one query, got %q", queries)
+	}
+	for _, want := range []string{"posts.is_active = true", "posts.status = 'published'", "posts.published_at <= "} {
+		if !strings.Contains(queries[0], want) {
+			t.Errorf("post lookup lacks %q: %s", want, queries[0])
+		}
+	}
+}
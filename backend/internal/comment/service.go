@@ -3,24 +3,96 @@ package comment
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"net/mail" // For basic email validation
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
 	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
 	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/signedtoken"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
+// maxThreadsPerPage limits the page size of the public comment threads
+const maxThreadsPerPage = 100
+
+// Statuses of a new comment, see dto.CommentCreateResponse
+const (
+	StatusPending              = "pending"
+	StatusApproved             = "approved"
+	StatusAwaitingVerification = "awaiting_verification"
+)
+
+// Settings configures how comments are shown and what their authors may do
+type Settings struct {
+	MaxDepth        int           // Levels of nested replies; deeper replies are listed under their ancestor at the last level
+	Secret          []byte        // Application secret, signs edit tokens and verification links
+	EditWindow      time.Duration // How long authors can edit and withdraw their comments; 0 disables it
+	VerifyEmails    bool          // Hold the first comment of an email until its author follows a link sent to it
+	VerificationTTL time.Duration // Lifetime of verification links
+	CloseAfterDays  int           // Comments on a post close this many days after its publication; 0 keeps them open
+	Reactions       []string      // Emojis readers can react to comments with
+	FlagThreshold   int           // Flags from distinct networks that put an approved comment back into moderation; 0 only records them
+	FlagsPerDay     int           // Comments a client IP may flag per day; 0 doesn't limit them
+}
+
+var (
+	// ErrCommentBlocked is returned for comments refused by a block rule
+	ErrCommentBlocked = errors.New("comment was not accepted")
+	// ErrCommentsClosed is returned for comments on a post that no longer takes them
+	ErrCommentsClosed = errors.New("comments are closed on this post")
+)
+
+// Blocklist finds the admin-managed block rule that stops a comment, if any, see blocklist.BlocklistService
+type Blocklist interface {
+	Match(c *models.Comment) *models.BlockRule
+}
+
+// Users finds admin accounts, see auth.UserRepository
+type Users interface {
+	FindByUsername(username string) (*models.User, error)
+}
+
 type CommentService struct {
-	repo   CommentRepository
-	logger *logrus.Logger
+	repo               CommentRepository
+	spam               *SpamFilter
+	blocklist          Blocklist
+	users              Users
+	notifier           *Notifier
+	settings           Settings
+	editTokens         signedtoken.Signer
+	verificationTokens signedtoken.Signer
+	logger             *logrus.Logger
+}
+
+func NewCommentService(repo CommentRepository, spam *SpamFilter, blocklist Blocklist, users Users, notifier *Notifier, settings Settings, logger *logrus.Logger) *CommentService {
+	return &CommentService{
+		repo:               repo,
+		spam:               spam,
+		blocklist:          blocklist,
+		users:              users,
+		notifier:           notifier,
+		settings:           settings,
+		logger:             logger,
+		editTokens:         signedtoken.New(settings.Secret, "comment-edit"),
+		verificationTokens: signedtoken.New(settings.Secret, "comment-verify"),
+	}
 }
 
-func NewCommentService(repo CommentRepository, logger *logrus.Logger) *CommentService {
-	return &CommentService{repo: repo, logger: logger}
+// IssueFormToken returns the token the comment form of a post sends with a comment
+func (s *CommentService) IssueFormToken(postID uint) dto.CommentFormTokenResponse {
+	return dto.CommentFormTokenResponse{Token: s.spam.IssueFormToken(postID, time.Now())}
 }
 
-func (s *CommentService) CreateComment(postID uint, req dto.CommentCreateRequest) (*models.Comment, error) {
+// CreateComment stores a new comment on a post that takes comments. Comments stopped by a block rule are refused,
+// discarded or held for moderation. The others go to the spam bucket or, depending on the comment mode of the post,
+// the moderation queue or straight to publication. With email verification enabled, comments on open posts are held
+// until their author verifies the email, or approved if it already is.
+func (s *CommentService) CreateComment(postID uint, req dto.CommentCreateRequest, ipAddress, userAgent string) (*dto.CommentCreateResponse, error) {
 	log := s.logger.WithFields(logrus.Fields{"post_id": postID, "author": req.AuthorName})
 	log.Info("Service: Creating comment")
 
@@ -34,6 +106,35 @@ func (s *CommentService) CreateComment(postID uint, req dto.CommentCreateRequest
 		return nil, fmt.Errorf("name and content cannot be empty")
 	}
 
+	post, err := s.repo.FindPost(postID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, myerr.WithHTTPStatus(errors.New("post not found"), http.StatusNotFound)
+		}
+		return nil, err
+	}
+	if !post.CommentsOpen(s.settings.CloseAfterDays, time.Now()) {
+		log.Info("Service: Comments are closed on post")
+		return nil, myerr.WithHTTPStatus(ErrCommentsClosed, http.StatusForbidden)
+	}
+
+	// Replies must stay in the thread they were written in
+	var parent *models.Comment
+	if req.ParentCommentID != nil {
+		parent, err = s.repo.FindByID(*req.ParentCommentID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				log.WithField("parent_id", *req.ParentCommentID).Warn("Service: Parent comment not found")
+				return nil, myerr.WithHTTPStatus(errors.New("parent comment not found"), http.StatusBadRequest)
+			}
+			return nil, err
+		}
+		if parent.PostID != postID {
+			log.WithField("parent_id", parent.ID).Warn("Service: Parent comment belongs to another post")
+			return nil, myerr.WithHTTPStatus(errors.New("parent comment belongs to another post"), http.StatusBadRequest)
+		}
+	}
+
 	comment := &models.Comment{
 		PostID:          postID,
 		AuthorName:      req.AuthorName,
@@ -41,6 +142,39 @@ func (s *CommentService) CreateComment(postID uint, req dto.CommentCreateRequest
 		Content:         req.Content,
 		IsApproved:      false, // Comments start as unapproved
 		ParentCommentID: req.ParentCommentID,
+		ContentHash:     contentHash(req.Content),
+		IPAddress:       ipAddress,
+		UserAgent:       truncate(userAgent, 255),
+	}
+
+	var editToken string
+	if s.settings.EditWindow > 0 {
+		var editTokenHash string
+		editToken, editTokenHash, err = s.issueEditToken()
+		if err != nil {
+			log.WithError(err).Error("Service: Failed to create edit token")
+			return nil, fmt.Errorf("failed to create edit token: %w", err)
+		}
+		comment.EditTokenHash = editTokenHash
+	}
+
+	// Spam is stored like any other comment, so that admins can rescue false positives
+	held, err := s.screen(comment, &Submission{
+		Comment:    comment,
+		Honeypot:   req.Website,
+		FormToken:  req.FormToken,
+		ReceivedAt: time.Now(),
+	}, log)
+	if err != nil {
+		return nil, err
+	}
+	comment.IsApproved, comment.AwaitingVerification, err = s.admission(comment, post, held)
+	if err != nil {
+		return nil, err
+	}
+	if comment.IsApproved {
+		now := time.Now()
+		comment.ApprovedAt = &now
 	}
 
 	if err := s.repo.Create(comment); err != nil {
@@ -48,36 +182,272 @@ func (s *CommentService) CreateComment(postID uint, req dto.CommentCreateRequest
 		return nil, err // Return the original error
 	}
 
-	log.WithField("comment_id", comment.ID).Info("Service: Comment created successfully (pending approval)")
-	// TODO: Consider sending notification for moderation
+	// Spam looks like any comment awaiting moderation to its author
+	response := s.pendingResponse(comment.ID, comment.CreatedAt, editToken)
+
+	log = log.WithField("comment_id", comment.ID)
+	switch {
+	case comment.IsSpam:
+		log.WithFields(logrus.Fields{"score": comment.SpamScore, "reasons": comment.SpamReasons}).Info("Service: Comment created as spam")
+	case comment.IsApproved:
+		log.Info("Service: Comment created and approved")
+		response.Status, response.Message = StatusApproved, "Comment published."
+		if parent != nil {
+			s.notifier.ReplyApproved(comment, parent)
+		}
+	case comment.AwaitingVerification:
+		log.Info("Service: Comment created, awaiting email verification")
+		response.Status, response.Message = StatusAwaitingVerification, "Check your email and confirm your address to publish the comment."
+		now := time.Now()
+		s.notifier.VerificationRequested(comment, s.issueVerificationToken(comment.ID, now), now.Add(s.settings.VerificationTTL))
+	default:
+		log.Info("Service: Comment created successfully (pending approval)")
+		s.notifier.CommentAwaitingModeration(comment)
+	}
+	return response, nil
+}
+
+// screen runs a new or edited comment through the blocklist and the spam filter, setting its spam state. Comments
+// refused by a block rule return ErrCommentBlocked; discarded ones become spam. It reports whether a block rule
+// holds the comment for moderation.
+func (s *CommentService) screen(comment *models.Comment, sub *Submission, log *logrus.Entry) (held bool, err error) {
+	var blockReason string
+	discard := false
+	if rule := s.blocklist.Match(comment); rule != nil {
+		log := log.WithFields(logrus.Fields{"rule_id": rule.ID, "kind": rule.Kind, "action": rule.Action})
+		switch rule.Action {
+		case models.BlockActionReject:
+			log.Info("Service: Comment rejected by block rule")
+			return false, myerr.WithHTTPStatus(ErrCommentBlocked, http.StatusForbidden)
+		case models.BlockActionDiscard:
+			// Discarded comments are stored as spam, so that their author gets the response of a real comment
+			// awaiting moderation, with an id and an edit token that work
+			log.Info("Service: Comment discarded into the spam bucket by block rule")
+			discard = true
+		default:
+			log.Info("Service: Comment held for moderation by block rule")
+			held = true
+		}
+		blockReason = fmt.Sprintf("blocklist: %s rule #%d", rule.Kind, rule.ID)
+	}
+
+	verdict := s.spam.Score(sub)
+	comment.SpamScore = verdict.Score
+	// Edits don't take a comment out of the spam bucket, only admins do
+	comment.IsSpam = comment.IsSpam || verdict.IsSpam || discard
+	if blockReason != "" {
+		verdict.Reasons = append([]string{blockReason}, verdict.Reasons...)
+	}
+	comment.SpamReasons = truncateReasons(verdict.Reasons, 255)
+	return held, nil
+}
+
+// admission decides whether a screened comment is published right away or waits for its author to verify the email.
+// Spam, comments held by a block rule and comments on moderated posts wait for moderation; on auto-approve posts the
+// others are published. With email verification enabled, comments on open posts from verified emails are published
+// and the others wait for verification. Comments not published and not waiting for verification await moderation.
+func (s *CommentService) admission(comment *models.Comment, post *models.Post, held bool) (approved, awaitingVerification bool, err error) {
+	switch {
+	case comment.IsSpam, held, post.CommentMode == models.CommentModeModerated:
+		return false, false, nil
+	case post.CommentMode == models.CommentModeAutoApprove:
+		return true, false, nil
+	case s.settings.VerifyEmails:
+		verified, err := s.repo.IsEmailVerified(comment.AuthorEmail)
+		if err != nil {
+			return false, false, err
+		}
+		return verified, !verified, nil
+	}
+	return false, false, nil
+}
+
+func (s *CommentService) pendingResponse(id uint, createdAt time.Time, editToken string) *dto.CommentCreateResponse {
+	response := &dto.CommentCreateResponse{
+		ID:        id,
+		Status:    StatusPending,
+		Message:   "Comment submitted successfully and is awaiting moderation.",
+		EditToken: editToken,
+	}
+	if s.settings.EditWindow > 0 {
+		editableUntil := createdAt.Add(s.settings.EditWindow)
+		response.EditableUntil = &editableUntil
+	}
+	return response
+}
+
+// EditComment changes the content of a comment for the holder of its edit token, within the edit window, while its
+// post still takes comments. New content goes through the blocklist and the spam filter again, and the comment keeps
+// its approval only if CreateComment would have published it right away; otherwise it goes back to moderation.
+// Edits never publish a comment that wasn't.
+func (s *CommentService) EditComment(id uint, token string, req dto.CommentUpdateRequest) error {
+	log := s.logger.WithField("comment_id", id)
+	log.Info("Service: Editing comment")
+	comment, err := s.authorizeAuthor(id, token)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		return myerr.WithHTTPStatus(errors.New("content cannot be empty"), http.StatusBadRequest)
+	}
+	post, err := s.repo.FindPost(comment.PostID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if post == nil || !post.CommentsOpen(s.settings.CloseAfterDays, time.Now()) {
+		log.Info("Service: Comments are closed on post, edit refused")
+		return myerr.WithHTTPStatus(ErrCommentsClosed, http.StatusForbidden)
+	}
+
+	now := time.Now()
+	hash := contentHash(req.Content)
+	comment.Content, comment.EditedAt = req.Content, &now
+	if hash == comment.ContentHash {
+		return s.saveEdit(comment, log)
+	}
+	comment.ContentHash = hash
+
+	wasApproved := comment.IsApproved
+	held, err := s.screen(comment, &Submission{Comment: comment, ReceivedAt: now, Edited: true}, log)
+	if err != nil {
+		return err
+	}
+	approved, awaitingVerification, err := s.admission(comment, post, held)
+	if err != nil {
+		return err
+	}
+	comment.IsApproved = wasApproved && approved
+	comment.AwaitingVerification = comment.AwaitingVerification && awaitingVerification
+	if !comment.IsApproved {
+		comment.ApprovedAt = nil
+	}
+	if err := s.saveEdit(comment, log); err != nil {
+		return err
+	}
+
+	switch {
+	case comment.IsSpam:
+		log.WithFields(logrus.Fields{"score": comment.SpamScore, "reasons": comment.SpamReasons}).Info("Service: Edited comment is spam")
+	case wasApproved && !comment.IsApproved:
+		log.Info("Service: Edited comment returned to moderation")
+		s.notifier.CommentAwaitingModeration(comment)
+	}
+	return nil
+}
+
+func (s *CommentService) saveEdit(comment *models.Comment, log *logrus.Entry) error {
+	if err := s.repo.UpdateContent(comment); err != nil {
+		log.WithError(err).Error("Service: Failed to update comment in repository")
+		return err
+	}
+	log.Info("Service: Comment edited successfully")
+	return nil
+}
+
+// WithdrawComment deletes a comment for the holder of its edit token, within the edit window
+func (s *CommentService) WithdrawComment(id uint, token string) error {
+	log := s.logger.WithField("comment_id", id)
+	log.Info("Service: Withdrawing comment")
+	if _, err := s.authorizeAuthor(id, token); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		log.WithError(err).Error("Service: Failed to delete comment in repository")
+		return err
+	}
+	log.Info("Service: Comment withdrawn successfully")
+	return nil
+}
+
+// authorizeAuthor returns the comment if token is its edit token and the edit window is still open
+func (s *CommentService) authorizeAuthor(id uint, token string) (*models.Comment, error) {
+	comment, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !s.checkEditToken(comment, token) {
+		s.logger.WithField("comment_id", id).Warn("Service: Invalid edit token")
+		return nil, myerr.WithHTTPStatus(ErrInvalidEditToken, http.StatusForbidden)
+	}
+	if s.settings.EditWindow <= 0 || time.Since(comment.CreatedAt) > s.settings.EditWindow {
+		return nil, myerr.WithHTTPStatus(ErrEditWindowPassed, http.StatusForbidden)
+	}
 	return comment, nil
 }
 
-// GetCommentsByPostID retrieves approved comments and maps them to DTOs.
-func (s *CommentService) GetCommentsByPostID(postID uint) ([]dto.CommentResponse, error) {
-	log := s.logger.WithField("post_id", postID)
-	log.Info("Service: Fetching comments by post ID")
+// VerifyEmail marks the email of the comment a verification link was sent for as verified, approving the comments
+// from it that were held for verification
+func (s *CommentService) VerifyEmail(token string) error {
+	commentID, ok := s.parseVerificationToken(token, time.Now())
+	if !ok {
+		s.logger.Warn("Service: Invalid verification token")
+		return myerr.WithHTTPStatus(ErrInvalidVerificationToken, http.StatusBadRequest)
+	}
+	log := s.logger.WithField("comment_id", commentID)
+	comment, err := s.repo.FindByID(commentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Warn("Service: Comment of verification link not found")
+			return myerr.WithHTTPStatus(errors.New("the comment no longer exists"), http.StatusBadRequest)
+		}
+		return err
+	}
 
-	comments, err := s.repo.FindByPostID(postID)
+	approved, err := s.repo.VerifyEmail(comment.AuthorEmail)
+	if err != nil {
+		return err
+	}
+	log.WithField("approved", len(approved)).Info("Service: Commenter email verified")
+	for i := range approved {
+		if approved[i].ParentCommentID == nil {
+			continue
+		}
+		parent, err := s.repo.FindByID(*approved[i].ParentCommentID)
+		if err != nil {
+			log.WithError(err).Warn("Service: Parent comment not found, reply notification not sent")
+			continue
+		}
+		s.notifier.ReplyApproved(&approved[i], parent)
+	}
+	return nil
+}
+
+// GetCommentsByPostID returns a page of the comment threads of a post, see buildThreads for what readers see.
+func (s *CommentService) GetCommentsByPostID(postID uint, page, pageSize int) (*dto.CommentThreadsResponse, error) {
+	log := s.logger.WithFields(logrus.Fields{"post_id": postID, "page": page, "pageSize": pageSize})
+	log.Info("Service: Fetching comment threads by post ID")
+
+	if pageSize > maxThreadsPerPage {
+		pageSize = maxThreadsPerPage
+	}
+
+	totalThreads, totalComments, err := s.repo.CountThreads(postID)
+	if err != nil {
+		log.WithError(err).Error("Service: Failed to count comment threads")
+		return nil, err
+	}
+	comments, err := s.repo.FindThreadsByPostID(postID, (page-1)*pageSize, pageSize)
 	if err != nil {
 		log.WithError(err).Error("Service: Failed to fetch comments from repository")
 		return nil, err
 	}
+	reactions, err := s.reactionCounts(comments)
+	if err != nil {
+		log.WithError(err).Error("Service: Failed to count reactions")
+		return nil, err
+	}
 
-	// Map models.Comment to dto.CommentResponse
-	// This simple mapping works for flat lists. For nested, you'd need recursion.
-	var response []dto.CommentResponse
-	for _, c := range comments {
-		response = append(response, dto.CommentResponse{
-			ID:              c.ID,
-			CreatedAt:       c.CreatedAt,
-			AuthorName:      c.AuthorName,
-			Content:         c.Content,
-			ParentCommentID: c.ParentCommentID,
-		})
+	response := &dto.CommentThreadsResponse{
+		Threads:       buildThreads(comments, reactions, s.settings.MaxDepth),
+		TotalThreads:  int(totalThreads),
+		TotalComments: int(totalComments),
+		Page:          page,
+		PageSize:      pageSize,
+		TotalPages:    (int(totalThreads) + pageSize - 1) / pageSize,
+		Reactions:     s.settings.Reactions,
 	}
 
-	log.WithField("count", len(response)).Info("Service: Fetched comments successfully")
+	log.WithFields(logrus.Fields{"threads": len(response.Threads), "total": response.TotalThreads}).Info("Service: Fetched comment threads successfully")
 	return response, nil
 }
 
@@ -97,17 +467,26 @@ func (s *CommentService) GetAllCommentsAdmin(page, pageSize int, filter string)
 	var response []dto.AdminCommentResponse
 	for _, c := range comments {
 		response = append(response, dto.AdminCommentResponse{
-			ID:              c.ID,
-			CreatedAt:       c.CreatedAt,
-			UpdatedAt:       c.UpdatedAt,
-			PostID:          c.PostID,
-			AuthorName:      c.AuthorName,
-			AuthorEmail:     c.AuthorEmail,
-			Content:         c.Content,
-			IsApproved:      c.IsApproved,
-			ApprovedAt:      c.ApprovedAt,
-			ParentCommentID: c.ParentCommentID,
-			PostTitle:       c.Post.Title, // Include post title
+			ID:                   c.ID,
+			CreatedAt:            c.CreatedAt,
+			UpdatedAt:            c.UpdatedAt,
+			PostID:               c.PostID,
+			AuthorName:           c.AuthorName,
+			AuthorEmail:          c.AuthorEmail,
+			Content:              c.Content,
+			IsApproved:           c.IsApproved,
+			ApprovedAt:           c.ApprovedAt,
+			ParentCommentID:      c.ParentCommentID,
+			PostTitle:            c.Post.Title, // Include post title
+			IsSpam:               c.IsSpam,
+			SpamScore:            c.SpamScore,
+			SpamReasons:          c.SpamReasons,
+			EditedAt:             c.EditedAt,
+			AwaitingVerification: c.AwaitingVerification,
+			IPAddress:            c.IPAddress,
+			UserAgent:            c.UserAgent,
+			FlagCount:            c.FlagCount,
+			IsAuthor:             c.UserID != nil,
 		})
 	}
 
@@ -115,31 +494,40 @@ func (s *CommentService) GetAllCommentsAdmin(page, pageSize int, filter string)
 	return response, totalCount, nil
 }
 
-func (s *CommentService) ApproveComment(id uint) error {
-	log := s.logger.WithField("comment_id", id)
-	log.Info("Service: Approving comment")
-	err := s.repo.Approve(id)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			log.Warn("Service: Comment not found for approval")
-		} else {
-			log.WithError(err).Error("Service: Failed to approve comment in repository")
-		}
-		return err
+// Unsubscribe stops the reply notifications of the address an unsubscribe link was sent to
+func (s *CommentService) Unsubscribe(token string) error {
+	err := s.notifier.Unsubscribe(token)
+	if errors.Is(err, ErrInvalidUnsubscribeToken) {
+		s.logger.Warn("Service: Invalid unsubscribe token")
+		return myerr.WithHTTPStatus(err, http.StatusBadRequest)
 	}
-	log.Info("Service: Comment approved successfully")
-	// TODO: Consider sending notification to the author
-	return nil
+	return err
 }
 
-func (s *CommentService) DeleteComment(id uint) error {
-	log := s.logger.WithField("comment_id", id)
-	log.Info("Service: Deleting comment")
-	err := s.repo.Delete(id)
-	if err != nil {
-		log.WithError(err).Error("Service: Failed to delete comment in repository")
-		return err
+// truncate cuts s to at most n bytes without splitting a character
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
 	}
-	log.Info("Service: Comment deleted successfully")
-	return nil
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// truncateReasons joins the reasons of a spam verdict into at most n bytes. Reasons that don't fit are dropped,
+// except the first one, which is cut to n bytes so a verdict never loses all of its reasons.
+func truncateReasons(reasons []string, n int) string {
+	const separator = "; "
+	if len(reasons) == 0 {
+		return ""
+	}
+	joined := truncate(reasons[0], n)
+	for _, reason := range reasons[1:] {
+		if len(joined)+len(separator)+len(reason) > n {
+			break
+		}
+		joined += separator + reason
+	}
+	return joined
 }
@@ -0,0 +1,363 @@
+package comment
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// fakeRepository implements the repository methods the tests need. Calling any other method panics on the nil
+// embedded interface, which shows that a code path reaches the database unexpectedly.
+type fakeRepository struct {
+	CommentRepository
+
+	posts      map[uint]*models.Post
+	comments   map[uint]*models.Comment
+	nextID     uint
+	flag       func(flag *models.CommentFlag) (*models.Comment, bool, error)
+	flagsSince int64
+	moderated  []string    // Actions passed to Moderate
+	selections []Selection // Selections passed to Moderate
+	pages      [][2]int    // Offsets and limits passed to FindThreadsByPostID
+	replies    []bool      // Whether CreateReply was asked to approve the parent
+}
+
+func (r *fakeRepository) FindPost(id uint) (*models.Post, error) {
+	if post, ok := r.posts[id]; ok {
+		return post, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// FindThreadsByPostID pages through the stored comments without parents, returning them with their replies
+func (r *fakeRepository) FindThreadsByPostID(postID uint, offset, limit int) ([]models.Comment, error) {
+	r.pages = append(r.pages, [2]int{offset, limit})
+	var roots []uint
+	for id, c := range r.comments {
+		if c.PostID == postID && c.ParentCommentID == nil {
+			roots = append(roots, id)
+		}
+	}
+	slices.Sort(roots)
+	roots = roots[min(offset, len(roots)):min(offset+limit, len(roots))]
+	var comments []models.Comment
+	for _, c := range r.comments {
+		if slices.Contains(roots, c.ID) || (c.ParentCommentID != nil && slices.Contains(roots, *c.ParentCommentID)) {
+			comments = append(comments, *c)
+		}
+	}
+	slices.SortFunc(comments, func(a, b models.Comment) int { return int(a.ID) - int(b.ID) })
+	return comments, nil
+}
+
+func (r *fakeRepository) CountThreads(postID uint) (threads, comments int64, err error) {
+	for _, c := range r.comments {
+		if c.PostID == postID {
+			comments++
+			if c.ParentCommentID == nil {
+				threads++
+			}
+		}
+	}
+	return threads, comments, nil
+}
+
+func (r *fakeRepository) CountReactions([]uint) (map[uint]map[string]int, error) {
+	return map[uint]map[string]int{}, nil
+}
+
+func (r *fakeRepository) FindByID(id uint) (*models.Comment, error) {
+	if c, ok := r.comments[id]; ok {
+		copied := *c
+		return &copied, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRepository) Create(c *models.Comment) error {
+	if r.comments == nil {
+		r.comments = make(map[uint]*models.Comment)
+	}
+	r.nextID++
+	c.ID, c.CreatedAt = r.nextID, time.Now()
+	stored := *c
+	r.comments[c.ID] = &stored
+	return nil
+}
+
+func (r *fakeRepository) UpdateContent(c *models.Comment) error {
+	stored := *c
+	r.comments[c.ID] = &stored
+	return nil
+}
+
+func (r *fakeRepository) IsEmailVerified(string) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeRepository) Flag(flag *models.CommentFlag) (*models.Comment, bool, error) {
+	return r.flag(flag)
+}
+
+func (r *fakeRepository) CountFlagsSince(string, time.Time) (int64, error) {
+	return r.flagsSince, nil
+}
+
+// Moderate returns the stored comments with the selected IDs, or every stored comment for filters
+func (r *fakeRepository) Moderate(action string, sel Selection, _, _ string) ([]models.Comment, error) {
+	r.moderated = append(r.moderated, action)
+	r.selections = append(r.selections, sel)
+	var comments []models.Comment
+	for id, c := range r.comments {
+		if len(sel.IDs) == 0 || slices.Contains(sel.IDs, id) {
+			comments = append(comments, *c)
+		}
+	}
+	if len(comments) == 0 {
+		for _, id := range sel.IDs {
+			comments = append(comments, models.Comment{ID: id})
+		}
+	}
+	return comments, nil
+}
+
+// CreateReply stores the reply and approves the parent in the same call, as the transaction does
+func (r *fakeRepository) CreateReply(reply *models.Comment, _ string, approveParent bool) (*models.Comment, error) {
+	r.replies = append(r.replies, approveParent)
+	var approved *models.Comment
+	if approveParent {
+		parent, ok := r.comments[*reply.ParentCommentID]
+		if !ok {
+			return nil, gorm.ErrRecordNotFound
+		}
+		before := *parent
+		approved = &before
+		parent.IsApproved = true
+	}
+	return approved, r.Create(reply)
+}
+
+// fakeSpamRepository is a spam filter store that has seen no comments. It counts what it is taught.
+type fakeSpamRepository struct{ learned int }
+
+func (*fakeSpamRepository) CountContentHash(string) (int64, int64, error) { return 0, 0, nil }
+func (*fakeSpamRepository) FindTokens([]string) (map[string]models.SpamToken, error) {
+	return map[string]models.SpamToken{}, nil
+}
+func (*fakeSpamRepository) CountDocuments() (int, int, error) { return 0, 0, nil }
+func (r *fakeSpamRepository) Learn(uint, []string, string, string) error {
+	r.learned++
+	return nil
+}
+
+// fakeBlocklist matches every comment with its rule, or none if the rule is nil
+type fakeBlocklist struct{ rule *models.BlockRule }
+
+func (b fakeBlocklist) Match(*models.Comment) *models.BlockRule { return b.rule }
+
+// fakeUsers knows a single admin, "admin", who hasn't set a display name
+type fakeUsers struct{}
+
+func (fakeUsers) FindByUsername(username string) (*models.User, error) {
+	if username != "admin" {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &models.User{ID: 7, Username: "admin", Email: "admin@example.com"}, nil
+}
+
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// newTestService creates a comment service on a fake repository, with an empty blocklist and without admin
+// notifications. The spam filter only flags honeypots, links and bad form tokens.
+func newTestService(repo CommentRepository, settings Settings) *CommentService {
+	logger := newTestLogger()
+	spam := newTestSpamFilter(&fakeSpamRepository{})
+	notifier := NewNotifier(nil, nil, NotificationSettings{}, logger)
+	if settings.Secret == nil {
+		settings.Secret = []byte("test")
+	}
+	return NewCommentService(repo, spam, fakeBlocklist{}, fakeUsers{}, notifier, settings, logger)
+}
+
+func newTestSpamFilter(repo SpamRepository) *SpamFilter {
+	return NewSpamFilter(repo, SpamSettings{Secret: []byte("test"), Threshold: 0.9, MaxLinks: 2}, newTestLogger())
+}
+
+// openPost returns a published post taking comments
+func openPost(id uint) *models.Post {
+	published := time.Now().Add(-time.Hour)
+	return &models.Post{ID: id, Status: models.PostStatusPublished, PublishedAt: &published, CommentMode: models.CommentModeOpen}
+}
+
+// createApproved posts a comment and approves it as an admin would, returning its id and edit token
+func createApproved(t *testing.T, s *CommentService, repo *fakeRepository, postID uint) (uint, string) {
+	t.Helper()
+	response, err := s.CreateComment(postID, dto.CommentCreateRequest{
+		AuthorName:  "Reader",
+		AuthorEmail: "reader@example.com",
+		Content:     "Nice post",
+		FormToken:   s.IssueFormToken(postID).Token,
+	}, "198.51.100.9", "test")
+	if err != nil {
+		t.Fatalf("creating comment: %v", err)
+	}
+	now := time.Now()
+	stored := repo.comments[response.ID]
+	stored.IsApproved, stored.ApprovedAt = true, &now
+	return response.ID, response.EditToken
+}
+
+func TestEditComment(t *testing.T) {
+	tests := []struct {
+		name         string
+		commentMode  string
+		closed       bool
+		rule         *models.BlockRule
+		wantErr      error
+		wantStatus   int
+		wantApproved bool
+		wantSpam     bool
+	}{
+		{name: "open post returns the edit to moderation", commentMode: models.CommentModeOpen},
+		{name: "auto-approve post keeps the edit published", commentMode: models.CommentModeAutoApprove, wantApproved: true},
+		{name: "block rule holding the edit", commentMode: models.CommentModeAutoApprove,
+			rule: &models.BlockRule{ID: 1, Kind: models.BlockKindPhrase, Action: models.BlockActionHold}},
+		{name: "block rule rejecting the edit", commentMode: models.CommentModeAutoApprove, wantApproved: true,
+			rule:    &models.BlockRule{ID: 2, Kind: models.BlockKindPhrase, Action: models.BlockActionReject},
+			wantErr: ErrCommentBlocked, wantStatus: http.StatusForbidden},
+		{name: "block rule discarding the edit", commentMode: models.CommentModeAutoApprove, wantSpam: true,
+			rule: &models.BlockRule{ID: 3, Kind: models.BlockKindPhrase, Action: models.BlockActionDiscard}},
+		{name: "closed post", commentMode: models.CommentModeOpen, closed: true, wantApproved: true,
+			wantErr: ErrCommentsClosed, wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := openPost(1)
+			post.CommentMode = tt.commentMode
+			repo := &fakeRepository{posts: map[uint]*models.Post{1: post}}
+			s := newTestService(repo, Settings{EditWindow: time.Hour})
+			id, token := createApproved(t, s, repo, 1)
+
+			if tt.closed {
+				post.CommentMode = models.CommentModeClosed
+			}
+			if tt.rule != nil {
+				s.blocklist = fakeBlocklist{rule: tt.rule}
+			}
+			err := s.EditComment(id, token, dto.CommentUpdateRequest{Content: "Edited into something else"})
+			if !errors.Is(err, tt.wantErr) || (err != nil && myerr.HTTPStatus(err) != tt.wantStatus) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			stored := repo.comments[id]
+			if stored.IsApproved != tt.wantApproved || stored.IsSpam != tt.wantSpam {
+				t.Errorf("approved = %v, spam = %v, want %v and %v", stored.IsApproved, stored.IsSpam, tt.wantApproved, tt.wantSpam)
+			}
+			if !stored.IsApproved && stored.ApprovedAt != nil {
+				t.Error("unapproved comment kept its approval time")
+			}
+		})
+	}
+}
+
+func TestEditCommentNeverPublishes(t *testing.T) {
+	post := openPost(1)
+	repo := &fakeRepository{posts: map[uint]*models.Post{1: post}}
+	s := newTestService(repo, Settings{EditWindow: time.Hour})
+	id, token := createApproved(t, s, repo, 1)
+	// Unapproved by an admin, or by reader flags
+	repo.comments[id].IsApproved, repo.comments[id].ApprovedAt = false, nil
+
+	post.CommentMode = models.CommentModeAutoApprove
+	if err := s.EditComment(id, token, dto.CommentUpdateRequest{Content: "Edited into something else"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.comments[id].IsApproved {
+		t.Error("edit published a comment awaiting moderation")
+	}
+}
+
+func TestNoEditTokenWithoutEditWindow(t *testing.T) {
+	repo := &fakeRepository{posts: map[uint]*models.Post{1: openPost(1)}}
+	s := newTestService(repo, Settings{})
+	response, err := s.CreateComment(1, dto.CommentCreateRequest{
+		AuthorName:  "Reader",
+		AuthorEmail: "reader@example.com",
+		Content:     "Nice post",
+		FormToken:   s.IssueFormToken(1).Token,
+	}, "198.51.100.9", "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.EditToken != "" || response.EditableUntil != nil || repo.comments[response.ID].EditTokenHash != "" {
+		t.Errorf("edit token issued with edits disabled: %+v", response)
+	}
+}
+
+func TestGetCommentsByPostIDPagesThreads(t *testing.T) {
+	repo := &fakeRepository{comments: map[uint]*models.Comment{}}
+	parent := func(id uint) *uint { return &id }
+	for id := uint(1); id <= 5; id++ {
+		repo.comments[id] = &models.Comment{ID: id, PostID: 1, IsApproved: true, Content: "thread"}
+	}
+	repo.comments[6] = &models.Comment{ID: 6, PostID: 1, IsApproved: true, Content: "reply", ParentCommentID: parent(3)}
+	s := newTestService(repo, Settings{MaxDepth: 4})
+
+	response, err := s.GetCommentsByPostID(1, 2, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.pages) != 1 || repo.pages[0] != [2]int{2, 2} {
+		t.Fatalf("threads requested with offset and limit %v, want [2 2]", repo.pages)
+	}
+	if response.TotalThreads != 5 || response.TotalComments != 6 || response.TotalPages != 3 {
+		t.Errorf("totals = %d threads, %d comments, %d pages", response.TotalThreads, response.TotalComments, response.TotalPages)
+	}
+	if len(response.Threads) != 2 || response.Threads[0].ID != 3 || response.Threads[1].ID != 4 {
+		t.Fatalf("threads = %+v", response.Threads)
+	}
+	if len(response.Threads[0].Replies) != 1 || response.Threads[0].ReplyCount != 1 {
+		t.Errorf("thread 3 replies = %+v", response.Threads[0].Replies)
+	}
+}
+
+func TestTruncateReasons(t *testing.T) {
+	long := strings.Repeat("é", 200) // 400 bytes
+	tests := []struct {
+		name    string
+		reasons []string
+		want    string
+	}{
+		{"none", nil, ""},
+		{"all fit", []string{"link count", "blocked word"}, "link count; blocked word"},
+		{"first is cut", []string{long, "link count"}, strings.Repeat("é", 127)},
+		{"later ones are dropped", []string{strings.Repeat("a", 250), "link count"}, strings.Repeat("a", 250)},
+		{"exact fit", []string{strings.Repeat("a", 243), "1234567890"}, strings.Repeat("a", 243) + "; 1234567890"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateReasons(tt.reasons, 255)
+			if got != tt.want {
+				t.Errorf("truncateReasons = %q, want %q", got, tt.want)
+			}
+			if len(got) > 255 || !utf8.ValidString(got) {
+				t.Errorf("truncateReasons returned %d bytes of invalid UTF-8", len(got))
+			}
+		})
+	}
+}
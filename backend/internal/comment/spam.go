@@ -0,0 +1,345 @@
+package comment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/signedtoken"
+	"github.com/sirupsen/logrus"
+)
+
+// SpamSettings configures the spam filter
+type SpamSettings struct {
+	Secret        []byte        // Application secret, signs the form tokens
+	Threshold     float64       // Comments scoring at least this much go to the spam bucket
+	MaxLinks      int           // Links a comment may contain before it becomes suspicious
+	BlockedWords  []string      // Words and phrases that mark a comment as spam, matched case-insensitively
+	MinSubmitTime time.Duration // Comments submitted sooner after the form was loaded are suspicious
+	FormTokenTTL  time.Duration // Form tokens older than this are suspicious
+}
+
+// Submission is a comment as submitted, with the fields of the form that only the spam filter looks at
+type Submission struct {
+	Comment    *models.Comment
+	Honeypot   string // Hidden form field that people leave empty
+	FormToken  string // Issued when the form was loaded, see SpamFilter.IssueFormToken
+	ReceivedAt time.Time
+	Edited     bool // Re-check of a comment its author edited; there is no form to check
+}
+
+// SpamCheck is one step of the spam filter.
+type SpamCheck interface {
+	Name() string
+	// Check returns how likely the submission is spam, from 0 to 1, and a short reason when it is above 0
+	Check(sub *Submission) (float64, string, error)
+}
+
+// SpamLearner is implemented by checks that learn from moderation
+type SpamLearner interface {
+	Learn(c *models.Comment, label string) error
+}
+
+// SpamVerdict is the outcome of the spam filter for a submission
+type SpamVerdict struct {
+	Score   float64
+	Reasons []string
+	IsSpam  bool
+}
+
+// SpamFilter scores submitted comments by running them through its checks. The scores of the checks are combined
+// as independent probabilities: a comment is as likely spam as it is unlikely that every check is wrong.
+type SpamFilter struct {
+	checks     []SpamCheck
+	settings   SpamSettings
+	formTokens signedtoken.Signer
+	logger     *logrus.Logger
+}
+
+// NewSpamFilter creates a spam filter with the default checks; AddCheck plugs in more.
+func NewSpamFilter(repo SpamRepository, settings SpamSettings, logger *logrus.Logger) *SpamFilter {
+	f := &SpamFilter{settings: settings, formTokens: signedtoken.New(settings.Secret, "comment-form"), logger: logger}
+	f.checks = []SpamCheck{
+		honeypotCheck{},
+		formTokenCheck{filter: f},
+		linkCheck{maxLinks: settings.MaxLinks},
+		blockedWordsCheck{words: settings.BlockedWords},
+		duplicateCheck{repo: repo},
+		&BayesClassifier{repo: repo},
+	}
+	return f
+}
+
+// AddCheck adds a check to the filter
+func (f *SpamFilter) AddCheck(check SpamCheck) {
+	f.checks = append(f.checks, check)
+}
+
+// Score runs a submission through every check. Checks that fail are logged and ignored, so that an outage
+// doesn't hold back comments.
+func (f *SpamFilter) Score(sub *Submission) SpamVerdict {
+	var verdict SpamVerdict
+	notSpam := 1.0
+	for _, check := range f.checks {
+		score, reason, err := check.Check(sub)
+		if err != nil {
+			f.logger.WithError(err).WithField("check", check.Name()).Warn("Service: Spam check failed")
+			continue
+		}
+		score = math.Min(math.Max(score, 0), 1)
+		if score == 0 {
+			continue
+		}
+		notSpam *= 1 - score
+		verdict.Reasons = append(verdict.Reasons, fmt.Sprintf("%s: %s", check.Name(), reason))
+	}
+	verdict.Score = 1 - notSpam
+	verdict.IsSpam = f.settings.Threshold > 0 && verdict.Score >= f.settings.Threshold
+	return verdict
+}
+
+// Learn teaches every learning check that a comment is spam or ham after a moderation decision
+func (f *SpamFilter) Learn(c *models.Comment, label string) {
+	for _, check := range f.checks {
+		learner, ok := check.(SpamLearner)
+		if !ok {
+			continue
+		}
+		if err := learner.Learn(c, label); err != nil {
+			f.logger.WithError(err).WithFields(logrus.Fields{"check": check.Name(), "comment_id": c.ID}).Warn("Service: Spam check failed to learn")
+		}
+	}
+}
+
+// IssueFormToken returns a token for the comment form of a post, which tells the filter when the form was loaded
+func (f *SpamFilter) IssueFormToken(postID uint, now time.Time) string {
+	return f.formTokens.EncodePair(uint64(postID), uint64(now.UnixMilli()))
+}
+
+// parseFormToken checks the signature of a form token and returns the post and issue time it carries
+func (f *SpamFilter) parseFormToken(token string) (postID uint, issuedAt time.Time, ok bool) {
+	id, issued, ok := f.formTokens.DecodePair(token)
+	if !ok {
+		return 0, time.Time{}, false
+	}
+	return uint(id), time.UnixMilli(int64(issued)), true
+}
+
+// honeypotCheck catches bots that fill in every field of a form, including one hidden from people
+type honeypotCheck struct{}
+
+func (honeypotCheck) Name() string { return "honeypot" }
+
+func (honeypotCheck) Check(sub *Submission) (float64, string, error) {
+	if sub.Edited {
+		return 0, "", nil
+	}
+	if strings.TrimSpace(sub.Honeypot) != "" {
+		return 1, "hidden field filled in", nil
+	}
+	return 0, "", nil
+}
+
+// formTokenCheck catches comments posted without loading the form, or faster than anyone can type
+type formTokenCheck struct {
+	filter *SpamFilter
+}
+
+func (formTokenCheck) Name() string { return "form_token" }
+
+func (c formTokenCheck) Check(sub *Submission) (float64, string, error) {
+	if sub.Edited {
+		return 0, "", nil
+	}
+	if sub.FormToken == "" {
+		return 0.3, "no form token", nil
+	}
+	postID, issuedAt, ok := c.filter.parseFormToken(sub.FormToken)
+	if !ok || postID != sub.Comment.PostID {
+		return 0.9, "invalid form token", nil
+	}
+	elapsed := sub.ReceivedAt.Sub(issuedAt)
+	settings := c.filter.settings
+	switch {
+	case elapsed < settings.MinSubmitTime:
+		return 0.8, fmt.Sprintf("submitted %.1fs after loading the form", elapsed.Seconds()), nil
+	case settings.FormTokenTTL > 0 && elapsed > settings.FormTokenTTL:
+		return 0.3, "form token expired", nil
+	}
+	return 0, "", nil
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]+`)
+
+// linkCheck scores comments by the number of links beyond the allowed ones
+type linkCheck struct {
+	maxLinks int
+}
+
+func (linkCheck) Name() string { return "links" }
+
+func (c linkCheck) Check(sub *Submission) (float64, string, error) {
+	links := len(linkPattern.FindAllString(sub.Comment.Content, -1))
+	extra := links - c.maxLinks
+	if extra <= 0 {
+		return 0, "", nil
+	}
+	return math.Min(0.3+0.2*float64(extra), 0.95), fmt.Sprintf("%d links", links), nil
+}
+
+// blockedWordsCheck catches comments containing configured words or phrases
+type blockedWordsCheck struct {
+	words []string
+}
+
+func (blockedWordsCheck) Name() string { return "blocked_words" }
+
+func (c blockedWordsCheck) Check(sub *Submission) (float64, string, error) {
+	text := strings.ToLower(sub.Comment.AuthorName + "\n" + sub.Comment.Content)
+	for _, word := range c.words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" && strings.Contains(text, word) {
+			return 0.95, fmt.Sprintf("contains %q", word), nil
+		}
+	}
+	return 0, "", nil
+}
+
+// minDuplicateLength is the length of normalized content below which repeats are normal ("Thanks!")
+const minDuplicateLength = 20
+
+// duplicateCheck catches the same text posted again, which is how most spam runs look
+type duplicateCheck struct {
+	repo SpamRepository
+}
+
+func (duplicateCheck) Name() string { return "duplicate" }
+
+func (c duplicateCheck) Check(sub *Submission) (float64, string, error) {
+	if len(normalizeContent(sub.Comment.Content)) < minDuplicateLength {
+		return 0, "", nil
+	}
+	total, spam, err := c.repo.CountContentHash(sub.Comment.ContentHash)
+	if err != nil {
+		return 0, "", err
+	}
+	switch {
+	case spam > 0:
+		return 0.95, fmt.Sprintf("same text as %d spam comments", spam), nil
+	case total > 0:
+		return 0.6, fmt.Sprintf("same text as %d comments", total), nil
+	}
+	return 0, "", nil
+}
+
+// normalizeContent lowercases content and collapses its whitespace, so trivial variations hash the same
+func normalizeContent(content string) string {
+	return strings.Join(strings.Fields(strings.ToLower(content)), " ")
+}
+
+// contentHash is the hex SHA-256 of the normalized content of a comment
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(normalizeContent(content)))
+	return hex.EncodeToString(sum[:])
+}
+
+// minTrainingDocuments is the number of comments of each label the classifier needs to learn before it scores
+const minTrainingDocuments = 5
+
+// maxSpamTokens limits the tokens taken from a single comment
+const maxSpamTokens = 300
+
+// BayesClassifier is a naive Bayes classifier over the words, link hosts and email domain of comments.
+// It learns from moderation: approved comments are ham, deleted ones and those marked as spam are spam.
+type BayesClassifier struct {
+	repo SpamRepository
+}
+
+func (*BayesClassifier) Name() string { return "classifier" }
+
+// Check returns the probability that the comment is spam, assuming both labels are equally likely up front.
+// Tokens the classifier never saw are ignored.
+func (b *BayesClassifier) Check(sub *Submission) (float64, string, error) {
+	spamDocs, hamDocs, err := b.repo.CountDocuments()
+	if err != nil {
+		return 0, "", err
+	}
+	if spamDocs < minTrainingDocuments || hamDocs < minTrainingDocuments {
+		return 0, "", nil
+	}
+	tokens := spamTokens(sub.Comment)
+	counts, err := b.repo.FindTokens(tokens)
+	if err != nil {
+		return 0, "", err
+	}
+
+	logOdds := 0.0
+	for _, token := range tokens {
+		count, ok := counts[token]
+		if !ok || count.Spam+count.Ham == 0 {
+			continue
+		}
+		// Laplace-smoothed probabilities of a comment of each label containing the token
+		pSpam := (float64(count.Spam) + 1) / (float64(spamDocs) + 2)
+		pHam := (float64(count.Ham) + 1) / (float64(hamDocs) + 2)
+		logOdds += math.Log(pSpam) - math.Log(pHam)
+	}
+	probability := 1 / (1 + math.Exp(-logOdds))
+	if probability <= 0.5 {
+		return 0, "", nil
+	}
+	return probability, fmt.Sprintf("%.0f%% spam", probability*100), nil
+}
+
+func (b *BayesClassifier) Learn(c *models.Comment, label string) error {
+	if c.SpamTrainedAs == label {
+		return nil
+	}
+	if err := b.repo.Learn(c.ID, spamTokens(c), label, c.SpamTrainedAs); err != nil {
+		return err
+	}
+	c.SpamTrainedAs = label
+	return nil
+}
+
+// spamTokens returns the distinct tokens of a comment: its words, the hosts it links to and the domain of its author's email
+func spamTokens(c *models.Comment) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	add := func(token string) {
+		if len(tokens) < maxSpamTokens && len(token) <= 64 && !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	for _, link := range linkPattern.FindAllString(c.Content, -1) {
+		host := strings.ToLower(link)
+		host = strings.TrimPrefix(strings.TrimPrefix(host, "http://"), "https://")
+		host = strings.TrimPrefix(host, "www.")
+		if i := strings.IndexAny(host, "/?#:"); i >= 0 {
+			host = host[:i]
+		}
+		if host != "" {
+			add("host:" + host)
+		}
+	}
+	if at := strings.LastIndex(c.AuthorEmail, "@"); at >= 0 {
+		add("email:" + strings.ToLower(c.AuthorEmail[at+1:]))
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(c.Content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if n := len([]rune(word)); n >= 2 && n <= 30 {
+			add(word)
+		}
+	}
+	return tokens
+}
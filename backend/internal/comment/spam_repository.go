@@ -0,0 +1,134 @@
+package comment
+
+import (
+	"fmt"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SpamRepository interface {
+	// CountContentHash counts the comments with the given content hash, deleted ones included, and how many of them are spam
+	CountContentHash(hash string) (total, spam int64, err error)
+	FindTokens(tokens []string) (map[string]models.SpamToken, error)
+	// CountDocuments returns how many comments the classifier learned as spam and as ham
+	CountDocuments() (spam, ham int, err error)
+	// Learn records the tokens of a comment under label, forgetting them under previous first if it is set,
+	// and stores label as the comment's SpamTrainedAs.
+	Learn(commentID uint, tokens []string, label, previous string) error
+}
+
+type spamRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewSpamRepository(db *gorm.DB, logger *logrus.Logger) SpamRepository {
+	return &spamRepository{db: db, logger: logger}
+}
+
+func (r *spamRepository) CountContentHash(hash string) (total, spam int64, err error) {
+	var counts struct {
+		Total int64
+		Spam  int64
+	}
+	err = r.db.Model(&models.Comment{}).Unscoped().
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE is_spam OR spam_trained_as = ?) AS spam", models.SpamLabelSpam).
+		Where("content_hash = ?", hash).
+		Scan(&counts).Error
+	if err != nil {
+		r.logger.WithError(err).Error("Repository: Failed to count comments by content hash")
+		return 0, 0, fmt.Errorf("failed to count comments by content hash: %w", err)
+	}
+	return counts.Total, counts.Spam, nil
+}
+
+func (r *spamRepository) FindTokens(tokens []string) (map[string]models.SpamToken, error) {
+	result := make(map[string]models.SpamToken, len(tokens))
+	if len(tokens) == 0 {
+		return result, nil
+	}
+	var rows []models.SpamToken
+	if err := r.db.Where("token IN ?", tokens).Find(&rows).Error; err != nil {
+		r.logger.WithError(err).Error("Repository: Failed to fetch spam tokens")
+		return nil, fmt.Errorf("failed to fetch spam tokens: %w", err)
+	}
+	for _, row := range rows {
+		result[row.Token] = row
+	}
+	return result, nil
+}
+
+func (r *spamRepository) CountDocuments() (spam, ham int, err error) {
+	var classes []models.SpamClass
+	if err := r.db.Find(&classes).Error; err != nil {
+		r.logger.WithError(err).Error("Repository: Failed to fetch spam classes")
+		return 0, 0, fmt.Errorf("failed to fetch spam classes: %w", err)
+	}
+	for _, class := range classes {
+		switch class.Label {
+		case models.SpamLabelSpam:
+			spam = class.Documents
+		case models.SpamLabelHam:
+			ham = class.Documents
+		}
+	}
+	return spam, ham, nil
+}
+
+func (r *spamRepository) Learn(commentID uint, tokens []string, label, previous string) error {
+	log := r.logger.WithFields(logrus.Fields{"comment_id": commentID, "label": label, "previous": previous})
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if previous != "" {
+			if err := addDocument(tx, previous, tokens, -1); err != nil {
+				return err
+			}
+		}
+		if err := addDocument(tx, label, tokens, 1); err != nil {
+			return err
+		}
+		return tx.Model(&models.Comment{}).Unscoped().Where("id = ?", commentID).Update("spam_trained_as", label).Error
+	})
+	if err != nil {
+		log.WithError(err).Error("Repository: Failed to train spam classifier")
+		return fmt.Errorf("failed to train spam classifier: %w", err)
+	}
+	log.Info("Repository: Spam classifier trained")
+	return nil
+}
+
+// addDocument adds delta to the document count of label and to the count of each token under label
+func addDocument(tx *gorm.DB, label string, tokens []string, delta int) error {
+	column := "ham"
+	if label == models.SpamLabelSpam {
+		column = "spam"
+	}
+
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "label"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"documents": gorm.Expr("GREATEST(spam_classes.documents + ?, 0)", delta)}),
+	}).Create(&models.SpamClass{Label: label, Documents: max(delta, 0)}).Error
+	if err != nil || len(tokens) == 0 {
+		return err
+	}
+
+	if delta < 0 {
+		return tx.Model(&models.SpamToken{}).Where("token IN ?", tokens).
+			Update(column, gorm.Expr(fmt.Sprintf("GREATEST(%s + ?, 0)", column), delta)).Error
+	}
+	rows := make([]models.SpamToken, len(tokens))
+	for i, token := range tokens {
+		rows[i] = models.SpamToken{Token: token}
+		if column == "spam" {
+			rows[i].Spam = delta
+		} else {
+			rows[i].Ham = delta
+		}
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{column: gorm.Expr(fmt.Sprintf("spam_tokens.%s + ?", column), delta)}),
+	}).CreateInBatches(rows, 200).Error
+}
@@ -0,0 +1,124 @@
+package comment
+
+import (
+	"sort"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+)
+
+type commentNode struct {
+	comment *models.Comment
+	replies []*commentNode
+}
+
+// buildThreads arranges the comments of a post, in creation order, into the threads shown to readers.
+//   - Unapproved comments are hidden together with all their replies.
+//   - Deleted comments with visible replies are kept as tombstones without author and content, other deleted comments are hidden.
+//   - Replies are nested at most maxDepth levels; deeper replies are listed in creation order under their ancestor at the last level.
+//   - Replies whose parent no longer exists at all become threads of their own.
+//
+// reactions holds the reaction counts by comment ID.
+func buildThreads(comments []models.Comment, reactions map[uint]map[string]int, maxDepth int) []dto.CommentResponse {
+	if maxDepth < 1 {
+		maxDepth = 1
+	}
+
+	nodes := make(map[uint]*commentNode, len(comments))
+	for i := range comments {
+		nodes[comments[i].ID] = &commentNode{comment: &comments[i]}
+	}
+	var roots []*commentNode
+	for i := range comments {
+		node := nodes[comments[i].ID]
+		if parentID := comments[i].ParentCommentID; parentID != nil {
+			if parent, ok := nodes[*parentID]; ok && *parentID != comments[i].ID {
+				parent.replies = append(parent.replies, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	threads := make([]dto.CommentResponse, 0, len(roots))
+	for _, root := range roots {
+		if thread, ok := buildThread(root, reactions, 0, maxDepth); ok {
+			threads = append(threads, thread)
+		}
+	}
+	return threads
+}
+
+// buildThread returns the visible part of the thread below node, which is at the given depth
+func buildThread(node *commentNode, reactions map[uint]map[string]int, depth, maxDepth int) (dto.CommentResponse, bool) {
+	c := node.comment
+	if !c.IsApproved {
+		return dto.CommentResponse{}, false
+	}
+
+	response := dto.CommentResponse{
+		ID:              c.ID,
+		CreatedAt:       c.CreatedAt,
+		AuthorName:      c.AuthorName,
+		Content:         c.Content,
+		ParentCommentID: c.ParentCommentID,
+		EditedAt:        c.EditedAt,
+		Reactions:       reactions[c.ID],
+		IsAuthor:        c.UserID != nil,
+	}
+	if c.User != nil {
+		response.Author = toCommentAuthor(c.User)
+	}
+	for _, reply := range node.replies {
+		child, ok := buildThread(reply, reactions, depth+1, maxDepth)
+		if !ok {
+			continue
+		}
+		response.ReplyCount += child.ReplyCount
+		if !child.Deleted {
+			response.ReplyCount++
+		}
+		response.Replies = append(response.Replies, child)
+	}
+
+	if c.DeletedAt.Valid {
+		if len(response.Replies) == 0 {
+			return dto.CommentResponse{}, false
+		}
+		response.Deleted = true
+		response.AuthorName, response.Content, response.EditedAt, response.Reactions = "", "", nil, nil
+		response.IsAuthor, response.Author = false, nil
+	}
+
+	// The replies of the last nested level hold the rest of the thread as a flat list
+	if depth == maxDepth-1 && len(response.Replies) > 0 {
+		var flat []dto.CommentResponse
+		flatten(response.Replies, &flat)
+		sort.SliceStable(flat, func(i, j int) bool { return flat[i].CreatedAt.Before(flat[j].CreatedAt) })
+		response.Replies = flat
+	}
+	return response, true
+}
+
+// defaultAuthorName signs the comments of admins without a display name; the username is a login name and stays private
+const defaultAuthorName = "Author"
+
+// toCommentAuthor returns the public profile of an admin, named defaultAuthorName unless a display name is set
+func toCommentAuthor(user *models.User) *dto.CommentAuthor {
+	author := &dto.CommentAuthor{DisplayName: user.DisplayName, AvatarURL: user.AvatarURL}
+	if author.DisplayName == "" {
+		author.DisplayName = defaultAuthorName
+	}
+	return author
+}
+
+// flatten appends the comments of a tree to flat, depth first, without their replies
+func flatten(comments []dto.CommentResponse, flat *[]dto.CommentResponse) {
+	for _, c := range comments {
+		replies := c.Replies
+		c.Replies = nil
+		c.ReplyCount = 0
+		*flat = append(*flat, c)
+		flatten(replies, flat)
+	}
+}
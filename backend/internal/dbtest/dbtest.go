@@ -0,0 +1,101 @@
+// Package dbtest helps testing repositories without a database. A dry run session builds the SQL of every
+// statement without running it, and the recorder keeps the statements with their parameters inlined.
+package dbtest
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Recorder keeps the SQL built by a dry run session
+type Recorder struct {
+	mu      sync.Mutex
+	queries []string
+}
+
+// Queries returns the recorded statements in the order they were built
+func (r *Recorder) Queries() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.queries...)
+}
+
+// Reset forgets the recorded statements
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries = nil
+}
+
+func (r *Recorder) record(db *gorm.DB) {
+	if db.Statement.SQL.Len() == 0 {
+		return
+	}
+	sql := db.Dialector.Explain(db.Statement.SQL.String(), db.Statement.Vars...)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries = append(r.queries, sql)
+}
+
+// DryRun opens a Postgres session that never connects and records every statement it builds
+func DryRun(t testing.TB) (*gorm.DB, *Recorder) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("dbtest: opening dry run session: %v", err)
+	}
+
+	recorder := &Recorder{}
+	callbacks := db.Callback()
+	register := func(err error) {
+		if err != nil {
+			t.Fatalf("dbtest: registering recorder: %v", err)
+		}
+	}
+	register(callbacks.Query().After("gorm:query").Register("dbtest:record", recorder.record))
+	register(callbacks.Create().After("gorm:create").Register("dbtest:record", recorder.record))
+	register(callbacks.Update().After("gorm:update").Register("dbtest:record", recorder.record))
+	register(callbacks.Delete().After("gorm:delete").Register("dbtest:record", recorder.record))
+	register(callbacks.Row().After("gorm:row").Register("dbtest:record", recorder.record))
+	register(callbacks.Raw().After("gorm:raw").Register("dbtest:record", recorder.record))
+	return db, recorder
+}
+
+// readsPosts matches statements reading the posts table
+var readsPosts = regexp.MustCompile(`(FROM|JOIN) "?posts"?\b`)
+
+// ExpectListedPostsOnly fails the test unless the recorded statements list posts and every one of them leaves unlisted
+// posts out. Lookups of a single post by its id are not listings and are skipped.
+func ExpectListedPostsOnly(t testing.TB, r *Recorder) {
+	t.Helper()
+	listings := 0
+	for _, sql := range r.Queries() {
+		if !readsPosts.MatchString(sql) || strings.Contains(sql, `"posts"."id" =`) {
+			continue
+		}
+		listings++
+		if !strings.Contains(sql, "posts.visibility <> 'unlisted'") && !strings.Contains(sql, "posts.visibility = 'public'") {
+			t.Errorf("listing includes unlisted posts: %s", sql)
+		}
+	}
+	if listings == 0 {
+		t.Errorf("no listing of posts recorded: %q", r.Queries())
+	}
+}
+
+// Unsupported reports whether err only means that the statement can't run in a dry run session. Scans fail that way
+// after their statement is recorded.
+func Unsupported(err error) bool {
+	return errors.Is(err, gorm.ErrDryRunModeUnsupported)
+}
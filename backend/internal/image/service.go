@@ -2,18 +2,25 @@ package image
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"image"
 	"image/jpeg"
 	"image/png"
 	"io"
+	"io/fs"
 	"mime/multipart"
+	"net/http"
+	"regexp"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/nfnt/resize"
 )
 
+// validFilename matches the names of stored images; anything else could escape the storage directory
+var validFilename = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
 type ProcessConfig struct {
 	MaxWidth     int
 	MaxHeight    int
@@ -59,6 +66,68 @@ func (s *Service) SaveImage(file *multipart.FileHeader) (string, error) {
 	return filename, nil
 }
 
+// SaveImageData processes and stores an image that was not uploaded through a form, e.g. one downloaded
+// by an import. The type is detected from the data itself.
+func (s *Service) SaveImageData(data []byte) (string, error) {
+	if !s.isAllowedType(http.DetectContentType(data)) {
+		return "", fmt.Errorf("unsupported file type")
+	}
+
+	processedImage, format, err := s.processImage(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("process image: %w", err)
+	}
+
+	filename := s.generateFilename(format)
+	if err := s.storage.Save(processedImage, filename); err != nil {
+		return "", fmt.Errorf("save image: %w", err)
+	}
+	return filename, nil
+}
+
+// ReadImage returns the stored bytes of an image saved by this service.
+func (s *Service) ReadImage(filename string) ([]byte, error) {
+	if !validFilename.MatchString(filename) {
+		return nil, fmt.Errorf("invalid image filename %q", filename)
+	}
+	return s.storage.Read(filename)
+}
+
+// ImportImage stores an image exported from the storage, keeping its bytes and, when possible, its filename.
+// Unlike SaveImage the image is not processed again, so importing an export reproduces it exactly.
+// A new filename is generated when the name is taken by a different image; the name actually used is returned.
+func (s *Service) ImportImage(data []byte, filename string) (string, error) {
+	if !s.isAllowedType(http.DetectContentType(data)) {
+		return "", fmt.Errorf("unsupported file type")
+	}
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("decode image: %w", err)
+	}
+
+	if validFilename.MatchString(filename) {
+		existing, err := s.storage.Read(filename)
+		if err == nil && bytes.Equal(existing, data) {
+			return filename, nil // Already there, e.g. when importing into the same site
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			if err := s.storage.Save(data, filename); err != nil {
+				return "", fmt.Errorf("save image: %w", err)
+			}
+			return filename, nil
+		}
+	}
+
+	if format == "jpeg" {
+		format = "jpg"
+	}
+	filename = s.generateFilename(format)
+	if err := s.storage.Save(data, filename); err != nil {
+		return "", fmt.Errorf("save image: %w", err)
+	}
+	return filename, nil
+}
+
 func (s *Service) DeleteImage(filename string) error {
 	return s.storage.Delete(filename)
 }
@@ -9,6 +9,7 @@ import (
 // Storage defines the interface for image storage operations
 type Storage interface {
 	Save(data []byte, filename string) error
+	Read(filename string) ([]byte, error)
 	Delete(filename string) error
 	GetURL(filename string) string
 }
@@ -35,6 +36,10 @@ func (s *LocalStorage) Save(data []byte, filename string) error {
 	return os.WriteFile(path, data, 0644)
 }
 
+func (s *LocalStorage) Read(filename string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.basePath, filename))
+}
+
 func (s *LocalStorage) Delete(filename string) error {
 	if filename == "" {
 		return nil
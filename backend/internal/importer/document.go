@@ -0,0 +1,98 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Sources an import can read
+const (
+	SourceWordPress = "wordpress"
+	SourceGhost     = "ghost"
+)
+
+// Document is the content of another blog's export, independent of its format.
+type Document struct {
+	Source   string
+	Site     string // Identifies the exporting blog, see models.ImportMapping
+	Posts    []SourcePost
+	Warnings []string // Things the parser skipped or could not map
+}
+
+// SourcePost is a post of another blog, already mapped to our statuses
+type SourcePost struct {
+	ExternalID   string
+	Title        string
+	Slug         string // May be empty, a slug is generated from the title then
+	Content      string // HTML
+	Excerpt      string
+	Status       string // One of the models.PostStatus values
+	PublishedAt  *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Category     string
+	Tags         []string
+	Password     string // Plain password of a password-protected post
+	FeatureImage string // URL of the featured image
+	Comments     []SourceComment
+}
+
+// SourceComment is a comment of another blog. ParentExternalID refers to another comment of the same post.
+type SourceComment struct {
+	ExternalID       string
+	ParentExternalID string
+	AuthorName       string
+	AuthorEmail      string
+	Content          string // Plain text
+	Approved         bool
+	CreatedAt        time.Time
+}
+
+func (d *Document) warnf(format string, args ...interface{}) {
+	d.Warnings = append(d.Warnings, fmt.Sprintf(format, args...))
+}
+
+// htmlToText converts the HTML of a comment to plain text, keeping paragraphs and line breaks.
+func htmlToText(s string) string {
+	var b strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(s))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return strings.TrimSpace(collapseBlankLines(b.String()))
+		case html.TextToken:
+			b.Write(tokenizer.Text())
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "br":
+				b.WriteString("\n")
+			case "p", "div", "blockquote", "li", "pre":
+				b.WriteString("\n\n")
+			}
+		}
+	}
+}
+
+// collapseBlankLines reduces runs of blank lines to a single one
+func collapseBlankLines(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	result := make([]string, 0, len(lines))
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			if blank {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		result = append(result, line)
+	}
+	return strings.Join(result, "\n")
+}
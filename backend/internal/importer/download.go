@@ -0,0 +1,83 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned for images on addresses of the server's own network
+var ErrForbiddenAddress = errors.New("address is not public")
+
+// reservedPrefixes are not covered by the checks of netip.Addr but aren't reachable on the internet either
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"), // Benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64, maps onto IPv4 addresses
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+}
+
+// publicIP reports whether ip is a public unicast address
+func publicIP(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkDownloadURL accepts the http and https URLs of images
+func checkDownloadURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("cannot download %q, only http and https are supported", u.Redacted())
+	}
+	if u.Host == "" {
+		return fmt.Errorf("cannot download %q, the host is missing", u.Redacted())
+	}
+	return nil
+}
+
+// newDownloadClient returns the client featured images are downloaded with. Exports are uploaded by admins but come
+// from elsewhere, so the client only connects to public addresses. The address is checked once the host is resolved,
+// right before connecting, which also covers redirects and hosts that resolve to a different address on every lookup.
+func newDownloadClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !publicIP(addrPort.Addr()) {
+				return fmt.Errorf("connection to %s refused: %w", addrPort.Addr(), ErrForbiddenAddress)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // A proxy would connect to the image on our behalf, past the check
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			return checkDownloadURL(req.URL)
+		},
+	}
+}
@@ -0,0 +1,234 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+)
+
+// ghostURLPlaceholder stands for the site URL in links and images of Ghost exports
+const ghostURLPlaceholder = "__GHOST_URL__"
+
+type ghostExport struct {
+	DB []ghostDatabase `json:"db"`
+}
+
+type ghostDatabase struct {
+	Data struct {
+		Posts     []ghostPost    `json:"posts"`
+		Tags      []ghostTag     `json:"tags"`
+		PostsTags []ghostPostTag `json:"posts_tags"`
+		Members   []ghostMember  `json:"members"`
+		Comments  []ghostComment `json:"comments"`
+	} `json:"data"`
+}
+
+type ghostPost struct {
+	ID            string    `json:"id"`
+	UUID          string    `json:"uuid"`
+	Title         string    `json:"title"`
+	Slug          string    `json:"slug"`
+	HTML          string    `json:"html"`
+	Plaintext     string    `json:"plaintext"`
+	FeatureImage  string    `json:"feature_image"`
+	Type          string    `json:"type"` // post or page
+	Status        string    `json:"status"`
+	Visibility    string    `json:"visibility"` // public, members, paid or tiers
+	CustomExcerpt string    `json:"custom_excerpt"`
+	CreatedAt     ghostTime `json:"created_at"`
+	UpdatedAt     ghostTime `json:"updated_at"`
+	PublishedAt   ghostTime `json:"published_at"`
+}
+
+type ghostTag struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Visibility string `json:"visibility"` // internal tags start with # and are not shown
+}
+
+type ghostPostTag struct {
+	PostID    string `json:"post_id"`
+	TagID     string `json:"tag_id"`
+	SortOrder int    `json:"sort_order"`
+}
+
+type ghostMember struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type ghostComment struct {
+	ID        string    `json:"id"`
+	PostID    string    `json:"post_id"`
+	MemberID  string    `json:"member_id"`
+	ParentID  string    `json:"parent_id"`
+	Status    string    `json:"status"` // published, hidden or deleted
+	HTML      string    `json:"html"`
+	CreatedAt ghostTime `json:"created_at"`
+}
+
+// ghostTime accepts both date formats found in Ghost exports: ISO 8601 strings and, in old versions, Unix milliseconds.
+type ghostTime struct {
+	time.Time
+}
+
+func (t *ghostTime) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if ms, err := strconv.ParseInt(string(data), 10, 64); err == nil {
+		t.Time = time.UnixMilli(ms).UTC()
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05"} {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			t.Time = parsed.UTC()
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid Ghost date %q", s)
+}
+
+// ParseGhost reads a Ghost JSON export. Ghost has no categories, the primary (first) tag of a post
+// becomes its category and the other public tags its tags. Members-only posts are imported as drafts,
+// pages are skipped.
+func ParseGhost(r io.Reader) (*Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Ghost export: %w", err)
+	}
+	var export ghostExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid Ghost export: %w", err)
+	}
+	if len(export.DB) == 0 {
+		// Exports of some versions have the database at the top level
+		var db ghostDatabase
+		if err := json.Unmarshal(data, &db); err != nil {
+			return nil, fmt.Errorf("invalid Ghost export: %w", err)
+		}
+		export.DB = []ghostDatabase{db}
+	}
+	ghost := export.DB[0].Data
+
+	// Post UUIDs are unique across Ghost sites, so the site doesn't need to be identified
+	doc := &Document{Source: SourceGhost}
+
+	tags := make(map[string]ghostTag, len(ghost.Tags))
+	for _, tag := range ghost.Tags {
+		tags[tag.ID] = tag
+	}
+	postTags := make(map[string][]ghostPostTag)
+	for _, link := range ghost.PostsTags {
+		postTags[link.PostID] = append(postTags[link.PostID], link)
+	}
+	members := make(map[string]ghostMember, len(ghost.Members))
+	for _, member := range ghost.Members {
+		members[member.ID] = member
+	}
+	comments := make(map[string][]ghostComment)
+	for _, comment := range ghost.Comments {
+		comments[comment.PostID] = append(comments[comment.PostID], comment)
+	}
+
+	pages, placeholders := 0, 0
+	for _, p := range ghost.Posts {
+		if p.Type == "page" {
+			pages++
+			continue
+		}
+		post := SourcePost{
+			ExternalID:   p.UUID,
+			Title:        strings.TrimSpace(p.Title),
+			Slug:         p.Slug,
+			Content:      p.HTML,
+			Excerpt:      p.CustomExcerpt,
+			CreatedAt:    p.CreatedAt.Time,
+			UpdatedAt:    p.UpdatedAt.Time,
+			FeatureImage: p.FeatureImage,
+		}
+		if post.ExternalID == "" {
+			post.ExternalID = p.ID
+		}
+		if post.Content == "" && p.Plaintext != "" {
+			post.Content = autop(p.Plaintext)
+			doc.warnf("post %q has no HTML in the export, imported from its plain text", post.Title)
+		}
+		if strings.Contains(post.Content, ghostURLPlaceholder) || strings.HasPrefix(post.FeatureImage, ghostURLPlaceholder) {
+			placeholders++
+		}
+
+		switch p.Status {
+		case "published":
+			post.Status = models.PostStatusPublished
+		case "scheduled":
+			post.Status = models.PostStatusScheduled
+		default:
+			post.Status = models.PostStatusDraft
+		}
+		if !p.PublishedAt.IsZero() && post.Status != models.PostStatusDraft {
+			publishedAt := p.PublishedAt.Time
+			post.PublishedAt = &publishedAt
+		}
+		if p.Visibility != "" && p.Visibility != "public" {
+			post.Status, post.PublishedAt = models.PostStatusDraft, nil
+			doc.warnf("post %q is only visible to %s in Ghost, imported as a draft", post.Title, p.Visibility)
+		}
+
+		links := postTags[p.ID]
+		sort.SliceStable(links, func(i, j int) bool { return links[i].SortOrder < links[j].SortOrder })
+		for _, link := range links {
+			tag, ok := tags[link.TagID]
+			if !ok || tag.Visibility == "internal" || strings.HasPrefix(tag.Name, "#") {
+				continue
+			}
+			if post.Category == "" {
+				post.Category = tag.Name
+			} else {
+				post.Tags = append(post.Tags, tag.Name)
+			}
+		}
+
+		for _, c := range comments[p.ID] {
+			if c.Status == "deleted" {
+				continue
+			}
+			member := members[c.MemberID]
+			name := member.Name
+			if name == "" {
+				name = "Anonymous"
+			}
+			post.Comments = append(post.Comments, SourceComment{
+				ExternalID:       c.ID,
+				ParentExternalID: c.ParentID,
+				AuthorName:       name,
+				AuthorEmail:      member.Email,
+				Content:          htmlToText(c.HTML),
+				Approved:         c.Status == "published",
+				CreatedAt:        c.CreatedAt.Time,
+			})
+		}
+
+		doc.Posts = append(doc.Posts, post)
+	}
+
+	if pages > 0 {
+		doc.warnf("skipped %d pages", pages)
+	}
+	if placeholders > 0 {
+		doc.warnf("%d posts link to %s, replace it with the old site URL to keep those links working", placeholders, ghostURLPlaceholder)
+	}
+	return doc, nil
+}
@@ -0,0 +1,58 @@
+package importer
+
+import (
+	"fmt"
+	"net/http"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+type ImportHandler struct {
+	service *ImportService
+}
+
+func NewImportHandler(service *ImportService) *ImportHandler {
+	return &ImportHandler{service: service}
+}
+
+// ImportExport godoc
+// @Summary Import posts from WordPress or Ghost
+// @Description Imports the posts, categories, tags, comments and featured images of a WordPress export (WXR) or a Ghost JSON export.
+// @Description Items imported before are skipped, so the same export can be imported again. With dry_run nothing is written.
+// @Tags Import
+// @Accept multipart/form-data
+// @Produce json
+// @Param source path string true "Export format" Enums(wordpress, ghost)
+// @Param file formData file true "Export file"
+// @Param dry_run query bool false "Only report what would be imported"
+// @Param images query bool false "Download featured images (default true)"
+// @Success 200 {object} dto.ImportSummary
+// @Failure 400 {object} models.ErrorResponse "Invalid export or unknown source"
+// @Failure 413 {object} models.ErrorResponse "Export too large"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/import/{source} [post]
+func (h *ImportHandler) ImportExport(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(fmt.Errorf("failed to get export from form: %w", err), http.StatusBadRequest))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(fmt.Errorf("failed to open export: %w", err), http.StatusBadRequest))
+		return
+	}
+	defer file.Close()
+
+	opts := Options{
+		DryRun:     c.Query("dry_run") == "true",
+		SkipImages: c.Query("images") == "false",
+	}
+	summary, err := h.service.Import(c.Param("source"), file, header.Size, opts)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
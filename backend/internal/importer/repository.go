@@ -0,0 +1,297 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/taxonomy"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errDryRun rolls back the transaction of a dry run
+var errDryRun = errors.New("dry run")
+
+// importPlan is a parsed export ready to be written, with its featured images already stored
+type importPlan struct {
+	Source string
+	Site   string
+	Posts  []plannedPost
+	Images map[string]string // Source URL -> stored filename of the images stored by this import
+}
+
+type plannedPost struct {
+	ExternalID string
+	Post       *models.Post
+	Comments   []SourceComment
+}
+
+type ImportRepository interface {
+	FindMappings(source, site, kind string) (map[string]models.ImportMapping, error)
+	// Apply writes a plan in a single transaction and counts what it did into summary.
+	// A dry run counts the same way but rolls everything back.
+	Apply(plan *importPlan, summary *dto.ImportSummary, dryRun bool) error
+}
+
+type importRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewImportRepository(db *gorm.DB, logger *logrus.Logger) ImportRepository {
+	return &importRepository{db: db, logger: logger}
+}
+
+// FindMappings returns the items of a kind imported from a site before, by external ID
+func (r *importRepository) FindMappings(source, site, kind string) (map[string]models.ImportMapping, error) {
+	var mappings []models.ImportMapping
+	if err := r.db.Where("source = ? AND site = ? AND kind = ?", source, site, kind).Find(&mappings).Error; err != nil {
+		r.logger.WithError(err).Error("Repository: Failed to fetch import mappings")
+		return nil, fmt.Errorf("failed to fetch import mappings: %w", err)
+	}
+	result := make(map[string]models.ImportMapping, len(mappings))
+	for _, mapping := range mappings {
+		result[mapping.ExternalID] = mapping
+	}
+	return result, nil
+}
+
+func (r *importRepository) Apply(plan *importPlan, summary *dto.ImportSummary, dryRun bool) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		categoriesBefore, tagsBefore, err := countTaxonomy(tx)
+		if err != nil {
+			return err
+		}
+
+		for url, filename := range plan.Images {
+			if err := saveMapping(tx, plan, models.ImportKindImage, url, 0, filename); err != nil {
+				return err
+			}
+		}
+
+		for _, planned := range plan.Posts {
+			postID, created, err := r.importPost(tx, plan, &planned)
+			if err != nil {
+				return err
+			}
+			if created {
+				summary.Posts.Created++
+			} else {
+				summary.Posts.Skipped++
+			}
+			if err := r.importComments(tx, plan, postID, planned.Comments, summary); err != nil {
+				return err
+			}
+		}
+
+		categoriesAfter, tagsAfter, err := countTaxonomy(tx)
+		if err != nil {
+			return err
+		}
+		summary.NewCategories = int(categoriesAfter - categoriesBefore)
+		summary.NewTags = int(tagsAfter - tagsBefore)
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		return nil
+	}
+	if err != nil {
+		r.logger.WithError(err).WithField("source", plan.Source).Error("Repository: Import failed")
+	}
+	return err
+}
+
+// importPost creates a post unless it was imported before. A post deleted after its import is not
+// brought back while it is in the trash, but is created again once it was deleted permanently.
+func (r *importRepository) importPost(tx *gorm.DB, plan *importPlan, planned *plannedPost) (uint, bool, error) {
+	var mapping models.ImportMapping
+	err := tx.Where("source = ? AND site = ? AND kind = ? AND external_id = ?",
+		plan.Source, plan.Site, models.ImportKindPost, planned.ExternalID).First(&mapping).Error
+	if err == nil {
+		var count int64
+		if err := tx.Model(&models.Post{}).Where("id = ?", mapping.LocalID).Count(&count).Error; err != nil {
+			return 0, false, fmt.Errorf("failed to look up imported post: %w", err)
+		}
+		if count > 0 {
+			return mapping.LocalID, false, nil
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, fmt.Errorf("failed to look up import mapping: %w", err)
+	}
+
+	post := planned.Post
+	postSlug, err := uniqueSlug(tx, post.Slug)
+	if err != nil {
+		return 0, false, err
+	}
+	post.Slug = postSlug
+
+	tags, err := taxonomy.ResolvePostTaxonomy(tx, post)
+	if err != nil {
+		return 0, false, fmt.Errorf("post %q: %w", post.Title, err)
+	}
+	if err := tx.Create(post).Error; err != nil {
+		return 0, false, fmt.Errorf("post %q: failed to create post: %w", post.Title, err)
+	}
+	if err := taxonomy.LinkPostTags(tx, post.ID, tags); err != nil {
+		return 0, false, fmt.Errorf("post %q: %w", post.Title, err)
+	}
+	if err := saveMapping(tx, plan, models.ImportKindPost, planned.ExternalID, post.ID, ""); err != nil {
+		return 0, false, err
+	}
+	return post.ID, true, nil
+}
+
+// importComments creates the comments of a post that were not imported before, parents before their replies.
+// Replies whose parent is not part of the export (e.g. because it was spam) become top-level comments.
+func (r *importRepository) importComments(tx *gorm.DB, plan *importPlan, postID uint, comments []SourceComment, summary *dto.ImportSummary) error {
+	local := make(map[string]uint, len(comments)) // External ID -> local comment ID
+	pending := comments
+	for len(pending) > 0 {
+		var waiting []SourceComment
+		for _, c := range pending {
+			var parentID *uint
+			if c.ParentExternalID != "" {
+				id, ok := local[c.ParentExternalID]
+				if !ok {
+					waiting = append(waiting, c)
+					continue
+				}
+				parentID = &id
+			}
+
+			id, created, err := r.importComment(tx, plan, postID, &c, parentID)
+			if err != nil {
+				return err
+			}
+			switch {
+			case id == 0:
+				summary.Comments.Failed++
+				summary.Warnings = append(summary.Warnings, fmt.Sprintf("comment %s of post %d is empty and was skipped", c.ExternalID, postID))
+				continue
+			case created:
+				summary.Comments.Created++
+			default:
+				summary.Comments.Skipped++
+			}
+			local[c.ExternalID] = id
+		}
+
+		if len(waiting) == len(pending) {
+			// None of the remaining parents can be found
+			for i := range waiting {
+				summary.Warnings = append(summary.Warnings, fmt.Sprintf("parent of comment %s of post %d is missing, imported as a top-level comment", waiting[i].ExternalID, postID))
+				waiting[i].ParentExternalID = ""
+			}
+		}
+		pending = waiting
+	}
+	return nil
+}
+
+// importComment creates a comment unless it was imported before and returns its ID, 0 for comments without content.
+func (r *importRepository) importComment(tx *gorm.DB, plan *importPlan, postID uint, c *SourceComment, parentID *uint) (uint, bool, error) {
+	var mapping models.ImportMapping
+	err := tx.Where("source = ? AND site = ? AND kind = ? AND external_id = ?",
+		plan.Source, plan.Site, models.ImportKindComment, c.ExternalID).First(&mapping).Error
+	if err == nil {
+		var count int64
+		if err := tx.Unscoped().Model(&models.Comment{}).Where("id = ?", mapping.LocalID).Count(&count).Error; err != nil {
+			return 0, false, fmt.Errorf("failed to look up imported comment: %w", err)
+		}
+		if count > 0 {
+			return mapping.LocalID, false, nil
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, fmt.Errorf("failed to look up import mapping: %w", err)
+	}
+
+	if c.Content == "" {
+		return 0, false, nil
+	}
+	comment := &models.Comment{
+		CreatedAt:       c.CreatedAt,
+		UpdatedAt:       c.CreatedAt,
+		PostID:          postID,
+		AuthorName:      truncate(c.AuthorName, 100),
+		AuthorEmail:     truncate(c.AuthorEmail, 100),
+		Content:         c.Content,
+		IsApproved:      c.Approved,
+		ParentCommentID: parentID,
+	}
+	if comment.AuthorName == "" {
+		comment.AuthorName = "Anonymous"
+	}
+	if c.Approved && !c.CreatedAt.IsZero() {
+		approvedAt := c.CreatedAt
+		comment.ApprovedAt = &approvedAt
+	}
+	if err := tx.Create(comment).Error; err != nil {
+		return 0, false, fmt.Errorf("comment %s: failed to create comment: %w", c.ExternalID, err)
+	}
+	if err := saveMapping(tx, plan, models.ImportKindComment, c.ExternalID, comment.ID, ""); err != nil {
+		return 0, false, err
+	}
+	return comment.ID, true, nil
+}
+
+// saveMapping records an imported item, replacing the mapping of an item that is imported again
+func saveMapping(tx *gorm.DB, plan *importPlan, kind, externalID string, localID uint, localFile string) error {
+	mapping := models.ImportMapping{
+		Source:     plan.Source,
+		Site:       plan.Site,
+		Kind:       kind,
+		ExternalID: externalID,
+		LocalID:    localID,
+		LocalFile:  localFile,
+	}
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "source"}, {Name: "site"}, {Name: "kind"}, {Name: "external_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"local_id", "local_file"}),
+	}).Create(&mapping).Error
+	if err != nil {
+		return fmt.Errorf("failed to save import mapping: %w", err)
+	}
+	return nil
+}
+
+// uniqueSlug returns base, or base with the lowest numeric suffix that no post uses
+func uniqueSlug(tx *gorm.DB, base string) (string, error) {
+	candidate := base
+	for i := 2; ; i++ {
+		var count int64
+		if err := tx.Model(&models.Post{}).Where("slug = ?", candidate).Count(&count).Error; err != nil {
+			return "", fmt.Errorf("failed to check slug: %w", err)
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+func countTaxonomy(tx *gorm.DB) (categories, tags int64, err error) {
+	if err := tx.Model(&models.Category{}).Count(&categories).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to count categories: %w", err)
+	}
+	if err := tx.Model(&models.Tag{}).Count(&tags).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to count tags: %w", err)
+	}
+	return categories, tags, nil
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
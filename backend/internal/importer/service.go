@@ -0,0 +1,287 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/auth"
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/render"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/slug"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrUnknownSource is returned for sources other than wordpress and ghost
+var ErrUnknownSource = errors.New("unknown import source, expected wordpress or ghost")
+
+// ImageStore is the part of image.Service used to store featured images
+type ImageStore interface {
+	SaveImageData(data []byte) (string, error)
+	DeleteImage(filename string) error
+	GetImageURL(filename string) string
+}
+
+// Settings configures imports
+type Settings struct {
+	DefaultLanguage string        // Language of imported posts
+	ImageTimeout    time.Duration // Timeout of a featured image download
+	MaxImageSize    int64         // Larger featured images are not downloaded
+	MaxFileSize     int64         // Limit on the size of an export in bytes
+}
+
+// Options choose how a single import runs
+type Options struct {
+	DryRun     bool // Report what would be imported without writing anything
+	SkipImages bool // Don't download featured images
+}
+
+// ImportService imports the posts and comments of WordPress and Ghost blogs.
+// Imported items are remembered, so importing the same export again only adds what is new.
+type ImportService struct {
+	repo      ImportRepository
+	images    ImageStore
+	client    *http.Client
+	settings  Settings
+	logger    *logrus.Logger
+	listeners []func()
+}
+
+func NewImportService(repo ImportRepository, images ImageStore, settings Settings, logger *logrus.Logger) *ImportService {
+	return &ImportService{
+		repo:     repo,
+		images:   images,
+		client:   newDownloadClient(settings.ImageTimeout),
+		settings: settings,
+		logger:   logger,
+	}
+}
+
+// OnImport registers a function called after an import that was not a dry run
+func (s *ImportService) OnImport(listener func()) {
+	s.listeners = append(s.listeners, listener)
+}
+
+// Import parses an export of the given source and imports it. size is the size of the export in bytes.
+func (s *ImportService) Import(source string, r io.Reader, size int64, opts Options) (*dto.ImportSummary, error) {
+	if s.settings.MaxFileSize > 0 && size > s.settings.MaxFileSize {
+		return nil, myerr.WithHTTPStatus(fmt.Errorf("export is larger than %d bytes", s.settings.MaxFileSize), http.StatusRequestEntityTooLarge)
+	}
+	var doc *Document
+	var err error
+	switch source {
+	case SourceWordPress:
+		doc, err = ParseWordPress(r)
+	case SourceGhost:
+		doc, err = ParseGhost(r)
+	default:
+		return nil, myerr.WithHTTPStatus(ErrUnknownSource, http.StatusBadRequest)
+	}
+	if err != nil {
+		return nil, myerr.WithHTTPStatus(err, http.StatusBadRequest)
+	}
+
+	summary := &dto.ImportSummary{Source: source, DryRun: opts.DryRun, Warnings: append([]string{}, doc.Warnings...)}
+	plan, err := s.plan(doc, opts, summary)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Apply(plan, summary, opts.DryRun); err != nil {
+		s.deleteImages(plan.Images)
+		return nil, myerr.WithHTTPStatus(fmt.Errorf("import failed: %w", err), http.StatusInternalServerError)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"source":   source,
+		"dry_run":  opts.DryRun,
+		"posts":    summary.Posts.Created,
+		"comments": summary.Comments.Created,
+		"images":   summary.Images.Created,
+	}).Info("Service: Imported export")
+
+	if !opts.DryRun {
+		for _, listener := range s.listeners {
+			listener()
+		}
+	}
+	return summary, nil
+}
+
+// plan maps the posts of an export to our model and stores the featured images of posts that are new.
+func (s *ImportService) plan(doc *Document, opts Options, summary *dto.ImportSummary) (*importPlan, error) {
+	postMappings, err := s.repo.FindMappings(doc.Source, doc.Site, models.ImportKindPost)
+	if err != nil {
+		return nil, myerr.WithHTTPStatus(err, http.StatusInternalServerError)
+	}
+	imageMappings, err := s.repo.FindMappings(doc.Source, doc.Site, models.ImportKindImage)
+	if err != nil {
+		return nil, myerr.WithHTTPStatus(err, http.StatusInternalServerError)
+	}
+
+	plan := &importPlan{Source: doc.Source, Site: doc.Site, Images: make(map[string]string)}
+	seenImages := make(map[string]bool)
+	for i := range doc.Posts {
+		source := &doc.Posts[i]
+		_, imported := postMappings[source.ExternalID]
+
+		imageURL := ""
+		if source.FeatureImage != "" && !seenImages[source.FeatureImage] {
+			seenImages[source.FeatureImage] = true
+			switch mapping, ok := imageMappings[source.FeatureImage]; {
+			case ok:
+				summary.Images.Skipped++
+				imageURL = s.images.GetImageURL(mapping.LocalFile)
+			case imported || opts.SkipImages:
+				// The post is not created again, or images are not wanted
+			case opts.DryRun:
+				summary.Images.Created++
+			default:
+				filename, err := s.storeImage(source.FeatureImage)
+				if err != nil {
+					summary.Images.Failed++
+					summary.Warnings = append(summary.Warnings, fmt.Sprintf("featured image of post %q: %v", source.Title, err))
+					break
+				}
+				summary.Images.Created++
+				plan.Images[source.FeatureImage] = filename
+				imageURL = s.images.GetImageURL(filename)
+			}
+		} else if filename, ok := plan.Images[source.FeatureImage]; ok {
+			imageURL = s.images.GetImageURL(filename)
+		} else if mapping, ok := imageMappings[source.FeatureImage]; ok {
+			imageURL = s.images.GetImageURL(mapping.LocalFile)
+		}
+
+		// Imported posts are still mapped, they are created again if they were deleted permanently since
+		post, warnings := s.newPost(source, imageURL)
+		if !imported {
+			summary.Warnings = append(summary.Warnings, warnings...)
+		}
+		plan.Posts = append(plan.Posts, plannedPost{ExternalID: source.ExternalID, Post: post, Comments: source.Comments})
+	}
+	return plan, nil
+}
+
+// newPost maps a post of the export to our model
+func (s *ImportService) newPost(source *SourcePost, imageURL string) (*models.Post, []string) {
+	var warnings []string
+	post := &models.Post{
+		CreatedAt:     source.CreatedAt,
+		UpdatedAt:     source.UpdatedAt,
+		Title:         truncate(source.Title, 200),
+		Slug:          truncate(slug.Make(source.Slug), 200),
+		Content:       source.Content,
+		Summary:       source.Excerpt,
+		ImageURL:      imageURL,
+		Category:      truncate(source.Category, 100),
+		Status:        source.Status,
+		PublishedAt:   source.PublishedAt,
+		ContentFormat: models.ContentFormatHTML,
+		Language:      s.settings.DefaultLanguage,
+		Visibility:    models.PostVisibilityPublic,
+		CommentMode:   models.CommentModeOpen,
+	}
+	if post.Title == "" {
+		post.Title = "Untitled"
+	}
+	if post.Slug == "" {
+		post.Slug = truncate(slug.Make(post.Title), 200)
+	}
+	if post.Slug == "" {
+		post.Slug = "post"
+	}
+	if post.Language == "" {
+		post.Language = "en"
+	}
+
+	// The tags column holds the comma-separated names, drop the tags that don't fit
+	var tags []string
+	length := 0
+	for _, tag := range source.Tags {
+		tag = strings.TrimSpace(strings.ReplaceAll(tag, ",", " "))
+		if tag == "" {
+			continue
+		}
+		if length+len(tag)+1 > 255 {
+			warnings = append(warnings, fmt.Sprintf("post %q: tag %q dropped, the post has too many tags", post.Title, tag))
+			continue
+		}
+		length += len(tag) + 1
+		tags = append(tags, tag)
+	}
+	post.Tags = strings.Join(tags, ",")
+
+	// Same adjustments as for posts saved through the API
+	now := time.Now()
+	switch post.Status {
+	case models.PostStatusPublished:
+		if post.PublishedAt == nil {
+			publishedAt := post.CreatedAt
+			post.PublishedAt = &publishedAt
+		}
+	case models.PostStatusScheduled:
+		if post.PublishedAt == nil {
+			post.Status = models.PostStatusDraft
+		} else if !post.PublishedAt.After(now) {
+			post.Status = models.PostStatusPublished
+		}
+	}
+	post.IsActive = post.Status == models.PostStatusPublished
+
+	if source.Password != "" {
+		hash, err := auth.HashPassword(source.Password)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("post %q: failed to hash its password, imported as a draft", post.Title))
+			post.Status, post.IsActive = models.PostStatusDraft, false
+		} else {
+			post.Visibility = models.PostVisibilityPassword
+			post.PasswordHash = hash
+		}
+	}
+
+	render.Refresh(post)
+	return post, warnings
+}
+
+// storeImage downloads a featured image and stores it through the image service
+func (s *ImportService) storeImage(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("cannot download %q: %w", rawURL, err)
+	}
+	if err := checkDownloadURL(u); err != nil {
+		return "", err
+	}
+	resp, err := s.client.Get(u.String())
+	if err != nil {
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download failed: %s returned %s", u.Redacted(), resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, s.settings.MaxImageSize+1))
+	if err != nil {
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+	if int64(len(data)) > s.settings.MaxImageSize {
+		return "", fmt.Errorf("image is larger than %d bytes", s.settings.MaxImageSize)
+	}
+	return s.images.SaveImageData(data)
+}
+
+// deleteImages removes the images stored for an import that failed, nothing refers to them
+func (s *ImportService) deleteImages(images map[string]string) {
+	for source, filename := range images {
+		if err := s.images.DeleteImage(filename); err != nil {
+			s.logger.WithError(err).WithField("source", source).Warn("Service: Failed to delete image of failed import")
+		}
+	}
+}
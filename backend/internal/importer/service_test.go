@@ -0,0 +1,130 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeRepository has imported nothing before and fails to apply plans with err
+type fakeRepository struct{ err error }
+
+func (fakeRepository) FindMappings(string, string, string) (map[string]models.ImportMapping, error) {
+	return map[string]models.ImportMapping{}, nil
+}
+
+func (r fakeRepository) Apply(*importPlan, *dto.ImportSummary, bool) error { return r.err }
+
+// fakeImageStore keeps stored images in memory
+type fakeImageStore struct {
+	saved   []string
+	deleted []string
+}
+
+func (s *fakeImageStore) SaveImageData([]byte) (string, error) {
+	filename := fmt.Sprintf("image-%d.webp", len(s.saved)+1)
+	s.saved = append(s.saved, filename)
+	return filename, nil
+}
+
+func (s *fakeImageStore) DeleteImage(filename string) error {
+	s.deleted = append(s.deleted, filename)
+	return nil
+}
+
+func (s *fakeImageStore) GetImageURL(filename string) string { return "/images/" + filename }
+
+func newTestService(repo ImportRepository, images ImageStore) *ImportService {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewImportService(repo, images, Settings{ImageTimeout: 5 * time.Second, MaxImageSize: 1 << 20}, logger)
+}
+
+func TestPublicIP(t *testing.T) {
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"169.254.169.254", false}, // Cloud metadata
+		{"fe80::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"64:ff9b::a9fe:a9fe", false},
+	}
+	for _, tt := range tests {
+		if got := publicIP(netip.MustParseAddr(tt.ip)); got != tt.public {
+			t.Errorf("publicIP(%s) = %v, want %v", tt.ip, got, tt.public)
+		}
+	}
+}
+
+func TestStoreImageRefusesPrivateAddresses(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+		w.Write([]byte("image"))
+	}))
+	defer server.Close()
+	s := newTestService(fakeRepository{}, &fakeImageStore{})
+
+	if _, err := s.storeImage(server.URL + "/image.png"); !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("storeImage of a loopback URL: err = %v, want ErrForbiddenAddress", err)
+	}
+	if requested {
+		t.Error("private address was requested")
+	}
+}
+
+func TestStoreImageSchemes(t *testing.T) {
+	s := newTestService(fakeRepository{}, &fakeImageStore{})
+	for _, url := range []string{"file:///etc/passwd", "ftp://example.com/image.png", "gopher://example.com/", "//example.com/image.png", "http:///image.png"} {
+		_, err := s.storeImage(url)
+		if err == nil || errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("storeImage(%q): err = %v, want an unsupported URL", url, err)
+		}
+	}
+}
+
+func TestImportDeletesImagesWhenApplyFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("image"))
+	}))
+	defer server.Close()
+	images := &fakeImageStore{}
+	s := newTestService(fakeRepository{err: errors.New("connection lost")}, images)
+	s.client = server.Client() // The test server is on loopback
+
+	export := fmt.Sprintf(`{"db":[{"data":{"posts":[
+		{"id":"1","uuid":"a","title":"One","status":"published","type":"post","feature_image":%q},
+		{"id":"2","uuid":"b","title":"Two","status":"published","type":"post","feature_image":%q}
+	]}}]}`, server.URL+"/one.png", server.URL+"/two.png")
+	if _, err := s.Import(SourceGhost, strings.NewReader(export), int64(len(export)), Options{}); err == nil {
+		t.Fatal("import succeeded, want the error of the repository")
+	}
+	if len(images.saved) != 2 || len(images.deleted) != 2 {
+		t.Errorf("saved %v and deleted %v, want both images deleted", images.saved, images.deleted)
+	}
+}
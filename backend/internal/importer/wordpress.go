@@ -0,0 +1,249 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+)
+
+// contentNamespace is the namespace of content:encoded; excerpt:encoded has the same local name
+const contentNamespace = "http://purl.org/rss/1.0/modules/content/"
+
+// WXR elements are matched by their local name only, the wp namespace changes with the WXR version
+type wxrDocument struct {
+	Channel struct {
+		Title       string    `xml:"title"`
+		Link        string    `xml:"link"`
+		BaseSiteURL string    `xml:"base_site_url"`
+		Items       []wxrItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type wxrItem struct {
+	Title         string        `xml:"title"`
+	Encoded       []wxrEncoded  `xml:"encoded"` // content:encoded and excerpt:encoded
+	PostID        string        `xml:"post_id"`
+	PostDate      string        `xml:"post_date"`
+	PostDateGMT   string        `xml:"post_date_gmt"`
+	ModifiedGMT   string        `xml:"post_modified_gmt"`
+	PostName      string        `xml:"post_name"`
+	Status        string        `xml:"status"`
+	PostType      string        `xml:"post_type"`
+	Password      string        `xml:"post_password"`
+	AttachmentURL string        `xml:"attachment_url"`
+	Categories    []wxrCategory `xml:"category"`
+	Meta          []wxrMeta     `xml:"postmeta"`
+	Comments      []wxrComment  `xml:"comment"`
+}
+
+type wxrEncoded struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+type wxrCategory struct {
+	Domain   string `xml:"domain,attr"`
+	Nicename string `xml:"nicename,attr"`
+	Name     string `xml:",chardata"`
+}
+
+type wxrMeta struct {
+	Key   string `xml:"meta_key"`
+	Value string `xml:"meta_value"`
+}
+
+type wxrComment struct {
+	ID          string `xml:"comment_id"`
+	Author      string `xml:"comment_author"`
+	AuthorEmail string `xml:"comment_author_email"`
+	Date        string `xml:"comment_date"`
+	DateGMT     string `xml:"comment_date_gmt"`
+	Content     string `xml:"comment_content"`
+	Approved    string `xml:"comment_approved"` // 1, 0, spam or trash
+	Type        string `xml:"comment_type"`     // Empty or "comment" for comments, pingback and trackback otherwise
+	Parent      string `xml:"comment_parent"`   // 0 for top-level comments
+}
+
+// ParseWordPress reads a WordPress export (WXR). Only posts are imported; pages, attachments, trashed posts,
+// spam and pingbacks are skipped. The first category becomes the category of the post, further ones are added as tags.
+func ParseWordPress(r io.Reader) (*Document, error) {
+	var wxr wxrDocument
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false // WordPress doesn't always escape everything
+	if err := decoder.Decode(&wxr); err != nil {
+		return nil, fmt.Errorf("invalid WordPress export: %w", err)
+	}
+
+	doc := &Document{Source: SourceWordPress, Site: strings.TrimSuffix(wxr.Channel.BaseSiteURL, "/")}
+	if doc.Site == "" {
+		doc.Site = strings.TrimSuffix(wxr.Channel.Link, "/")
+	}
+	if doc.Site == "" {
+		return nil, fmt.Errorf("invalid WordPress export: the site URL is missing")
+	}
+
+	// Featured images are attachments referenced by the _thumbnail_id meta of a post
+	attachments := make(map[string]string)
+	for _, item := range wxr.Channel.Items {
+		if item.PostType == "attachment" && item.AttachmentURL != "" {
+			attachments[item.PostID] = item.AttachmentURL
+		}
+	}
+
+	skipped := make(map[string]int)
+	for _, item := range wxr.Channel.Items {
+		if item.PostType != "post" {
+			if item.PostType != "attachment" {
+				skipped[item.PostType]++
+			}
+			continue
+		}
+		post, ok := parseWordPressItem(doc, &item, attachments)
+		if ok {
+			doc.Posts = append(doc.Posts, post)
+		}
+	}
+	postTypes := make([]string, 0, len(skipped))
+	for postType := range skipped {
+		postTypes = append(postTypes, postType)
+	}
+	sort.Strings(postTypes)
+	for _, postType := range postTypes {
+		doc.warnf("skipped %d items of type %q", skipped[postType], postType)
+	}
+	return doc, nil
+}
+
+func parseWordPressItem(doc *Document, item *wxrItem, attachments map[string]string) (SourcePost, bool) {
+	post := SourcePost{
+		ExternalID: item.PostID,
+		Title:      strings.TrimSpace(item.Title),
+		Slug:       item.PostName,
+		Password:   item.Password,
+	}
+	for _, encoded := range item.Encoded {
+		if encoded.XMLName.Space == contentNamespace {
+			post.Content = autop(encoded.Value)
+		} else if strings.Contains(encoded.XMLName.Space, "excerpt") {
+			post.Excerpt = strings.TrimSpace(htmlToText(encoded.Value))
+		}
+	}
+
+	date := wordPressTime(item.PostDateGMT, item.PostDate)
+	post.CreatedAt = date
+	post.UpdatedAt = wordPressTime(item.ModifiedGMT, "")
+	if post.UpdatedAt.IsZero() {
+		post.UpdatedAt = date
+	}
+
+	switch item.Status {
+	case "publish":
+		post.Status = models.PostStatusPublished
+		post.PublishedAt = &date
+	case "future":
+		post.Status = models.PostStatusScheduled
+		post.PublishedAt = &date
+	case "draft", "pending", "auto-draft":
+		post.Status = models.PostStatusDraft
+	case "private":
+		post.Status = models.PostStatusDraft
+		doc.warnf("post %s (%q) is private in WordPress, imported as a draft", item.PostID, post.Title)
+	case "trash":
+		return post, false
+	default:
+		post.Status = models.PostStatusDraft
+		doc.warnf("post %s (%q) has unknown status %q, imported as a draft", item.PostID, post.Title, item.Status)
+	}
+
+	for _, category := range item.Categories {
+		name := strings.TrimSpace(category.Name)
+		switch category.Domain {
+		case "category":
+			if category.Nicename == "uncategorized" {
+				continue // WordPress assigns it to every post without a category
+			}
+			if post.Category == "" {
+				post.Category = name
+			} else {
+				post.Tags = append(post.Tags, name)
+			}
+		case "post_tag":
+			post.Tags = append(post.Tags, name)
+		}
+	}
+
+	for _, meta := range item.Meta {
+		if meta.Key == "_thumbnail_id" {
+			post.FeatureImage = attachments[meta.Value]
+		}
+	}
+
+	for _, c := range item.Comments {
+		if c.Type != "" && c.Type != "comment" {
+			continue // Pingbacks and trackbacks
+		}
+		if c.Approved == "spam" || c.Approved == "trash" {
+			continue
+		}
+		parent := c.Parent
+		if parent == "0" {
+			parent = ""
+		}
+		post.Comments = append(post.Comments, SourceComment{
+			ExternalID:       c.ID,
+			ParentExternalID: parent,
+			AuthorName:       strings.TrimSpace(c.Author),
+			AuthorEmail:      strings.TrimSpace(c.AuthorEmail),
+			Content:          htmlToText(c.Content),
+			Approved:         c.Approved == "1",
+			CreatedAt:        wordPressTime(c.DateGMT, c.Date),
+		})
+	}
+	return post, true
+}
+
+// wordPressTime parses a WordPress date, falling back to the local date when the GMT one is unset
+// (drafts have "0000-00-00 00:00:00"). Local dates are taken as UTC, the export doesn't include the offset.
+func wordPressTime(gmt, local string) time.Time {
+	for _, value := range []string{gmt, local} {
+		if t, err := time.Parse("2006-01-02 15:04:05", strings.TrimSpace(value)); err == nil && t.Year() > 1 {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+var (
+	blankLine  = regexp.MustCompile(`\n\s*\n`)
+	blockStart = regexp.MustCompile(`(?i)^<(p|h[1-6]|ul|ol|li|pre|blockquote|table|div|figure|hr|img|iframe|!--)[\s>/]`)
+)
+
+// autop adds the paragraphs WordPress adds when displaying classic editor content, which is stored without them.
+// Block editor content already has its paragraphs and is returned unchanged.
+func autop(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if strings.Contains(content, "<!-- wp:") || strings.Contains(strings.ToLower(content), "<p") {
+		return content
+	}
+	blocks := blankLine.Split(strings.TrimSpace(content), -1)
+	var b strings.Builder
+	for _, block := range blocks {
+		block = strings.TrimSpace(block)
+		if block == "" {
+			continue
+		}
+		if blockStart.MatchString(block) {
+			b.WriteString(block)
+		} else {
+			b.WriteString("<p>" + strings.ReplaceAll(block, "\n", "<br />\n") + "</p>")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
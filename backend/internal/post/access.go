@@ -0,0 +1,122 @@
+package post
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/auth"
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/signedtoken"
+	"github.com/golang-jwt/jwt"
+)
+
+// AccessTokenHeader carries the token that unlocks a password-protected post
+const AccessTokenHeader = "X-Post-Access-Token"
+
+// Errors returned when unlocking a post
+var (
+	ErrPostNotProtected    = errors.New("post is not password protected")
+	ErrInvalidPostPassword = errors.New("invalid password")
+)
+
+// AccessSettings configures the access tokens of password-protected posts
+type AccessSettings struct {
+	Secret   []byte        // Application secret, signs the access tokens
+	TokenTTL time.Duration // How long an unlocked post stays readable
+}
+
+// accessClaims grant access to a single password-protected post
+type accessClaims struct {
+	PostID          uint   `json:"post_id"`
+	PasswordVersion string `json:"pwv"` // Changes with the password, so a new password revokes issued tokens
+	jwt.StandardClaims
+}
+
+// UnlockPost checks the password of a protected post and returns a short-lived token granting access to its content.
+func (s *PostService) UnlockPost(id uint, password string) (*dto.PostAccessResponse, error) {
+	post, err := s.postRepo.FindByID(id)
+	if err != nil {
+		return nil, myerr.WithHTTPStatus(ErrPostNotFound, http.StatusNotFound)
+	}
+	if post.Visibility != models.PostVisibilityPassword {
+		return nil, myerr.WithHTTPStatus(ErrPostNotProtected, http.StatusBadRequest)
+	}
+	if !auth.CheckPasswordHash(password, post.PasswordHash) {
+		return nil, myerr.WithHTTPStatus(ErrInvalidPostPassword, http.StatusUnauthorized)
+	}
+
+	expiresAt := time.Now().Add(s.access.TokenTTL)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &accessClaims{
+		PostID:          post.ID,
+		PasswordVersion: passwordVersion(post.PasswordHash),
+		StandardClaims:  jwt.StandardClaims{ExpiresAt: expiresAt.Unix()},
+	})
+	signed, err := token.SignedString(s.accessKey())
+	if err != nil {
+		return nil, myerr.WithHTTPStatus(fmt.Errorf("failed to sign access token: %w", err), http.StatusInternalServerError)
+	}
+	return &dto.PostAccessResponse{Token: signed, ExpiresAt: expiresAt}, nil
+}
+
+// canRead reports whether the content of a post may be served to a reader holding the given access token.
+func (s *PostService) canRead(post *models.Post, accessToken string) bool {
+	if post.Visibility != models.PostVisibilityPassword {
+		return true
+	}
+	if accessToken == "" {
+		return false
+	}
+	claims := &accessClaims{}
+	token, err := jwt.ParseWithClaims(accessToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return s.accessKey(), nil
+	})
+	return err == nil && token.Valid && claims.PostID == post.ID && claims.PasswordVersion == passwordVersion(post.PasswordHash)
+}
+
+// accessKey derives the signing key of access tokens from the application secret.
+// A separate key keeps access tokens from ever validating as admin tokens.
+func (s *PostService) accessKey() []byte {
+	return signedtoken.Key(s.access.Secret, "post-access")
+}
+
+func passwordVersion(hash string) string {
+	sum := sha256.Sum256([]byte(hash))
+	return hex.EncodeToString(sum[:8])
+}
+
+// applyVisibility validates the visibility of a post and hashes a newly chosen password.
+// A protected post keeps its current password unless a new one is given; other modes drop it.
+func applyVisibility(post *models.Post) error {
+	if post.Visibility == "" {
+		post.Visibility = models.PostVisibilityPublic
+	}
+	switch post.Visibility {
+	case models.PostVisibilityPublic, models.PostVisibilityUnlisted:
+		post.PasswordHash = ""
+	case models.PostVisibilityPassword:
+		if post.Password != "" {
+			hash, err := auth.HashPassword(post.Password)
+			if err != nil {
+				return myerr.WithHTTPStatus(fmt.Errorf("failed to hash post password: %w", err), http.StatusInternalServerError)
+			}
+			post.PasswordHash = hash
+			post.Password = ""
+		}
+		if post.PasswordHash == "" {
+			return myerr.WithHTTPStatus(errors.New("password is required for password-protected posts"), http.StatusBadRequest)
+		}
+	default:
+		return myerr.WithHTTPStatus(fmt.Errorf("invalid visibility %q, expected %s, %s or %s", post.Visibility,
+			models.PostVisibilityPublic, models.PostVisibilityUnlisted, models.PostVisibilityPassword), http.StatusBadRequest)
+	}
+	return nil
+}
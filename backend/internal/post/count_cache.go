@@ -0,0 +1,58 @@
+package post
+
+import (
+	"sync"
+	"time"
+)
+
+// Totals are counted again after countCacheTTL even if no post changed, and at most maxCachedCounts
+// totals (one per search query) are kept
+const (
+	countCacheTTL   = time.Minute
+	maxCachedCounts = 1000
+)
+
+// countCache keeps the totals reported by page number mode, so paging through a listing doesn't count
+// the matching posts on every request. It is cleared whenever posts change.
+type countCache struct {
+	mu     sync.Mutex
+	totals map[string]cachedCount
+}
+
+type cachedCount struct {
+	total     int64
+	countedAt time.Time
+}
+
+func newCountCache() *countCache {
+	return &countCache{totals: make(map[string]cachedCount)}
+}
+
+// get returns the total cached under key, calling count when it is missing or expired
+func (c *countCache) get(key string, count func() (int64, error)) (int64, error) {
+	c.mu.Lock()
+	cached, ok := c.totals[key]
+	c.mu.Unlock()
+	if ok && time.Since(cached.countedAt) < countCacheTTL {
+		return cached.total, nil
+	}
+
+	total, err := count()
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.totals) >= maxCachedCounts {
+		c.totals = make(map[string]cachedCount)
+	}
+	c.totals[key] = cachedCount{total: total, countedAt: time.Now()}
+	return total, nil
+}
+
+// clear drops every cached total
+func (c *countCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.totals = make(map[string]cachedCount)
+}
@@ -0,0 +1,98 @@
+package post
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCursor is returned for cursors that were not issued by this API
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is a position in a listing sorted newest first by (published_at, id).
+// Search results are ranked, so their cursors carry the score as the most significant key.
+// Clients receive cursors as opaque strings, see EncodeCursor.
+type Cursor struct {
+	Score       float64   `json:"s,omitempty"`
+	PublishedAt time.Time `json:"p"`
+	ID          uint      `json:"id"`
+	Before      bool      `json:"b,omitempty"` // Page backwards: the items before this position instead of after it
+}
+
+// EncodeCursor returns the opaque representation of c
+func EncodeCursor(c Cursor) string {
+	b, _ := json.Marshal(c) // Cannot fail for this struct
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses a cursor returned by EncodeCursor. An empty string is the start of the listing.
+func DecodeCursor(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, myerr.WithHTTPStatus(ErrInvalidCursor, http.StatusBadRequest)
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == 0 || c.PublishedAt.IsZero() {
+		return nil, myerr.WithHTTPStatus(ErrInvalidCursor, http.StatusBadRequest)
+	}
+	return &c, nil
+}
+
+// keyset is the sort key of a cursor paginated query, most significant SQL expression first.
+// Rows are sorted descending on every key.
+type keyset []string
+
+// page restricts db to the rows after (or, for a backwards cursor, before) the cursor position.
+// One row more than limit is requested so the caller can tell whether another page follows.
+func (k keyset) page(db *gorm.DB, cursor *Cursor, values []interface{}, limit int) *gorm.DB {
+	op, dir := "<", "DESC"
+	if cursor != nil {
+		if cursor.Before {
+			op, dir = ">", "ASC"
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(k)), ", ")
+		db = db.Where(fmt.Sprintf("(%s) %s (%s)", strings.Join(k, ", "), op, placeholders), values...)
+	}
+	for _, key := range k {
+		db = db.Order(key + " " + dir)
+	}
+	return db.Limit(limit + 1)
+}
+
+// trimPage drops the extra row fetched by keyset.page and restores newest first order for backwards pages.
+// hasMore reports whether further rows exist in the direction of the cursor.
+func trimPage[T any](rows []T, cursor *Cursor, limit int) (page []T, hasMore bool) {
+	if len(rows) > limit {
+		rows, hasMore = rows[:limit], true
+	}
+	if cursor != nil && cursor.Before {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	return rows, hasMore
+}
+
+// pageCursors returns the cursors of the pages around a non-empty page whose first and last items are given.
+// A page fetched with a cursor always has a neighbour in the opposite direction.
+func pageCursors(cursor *Cursor, first, last Cursor, hasMore bool) (next, prev string) {
+	backwards := cursor != nil && cursor.Before
+	if backwards || hasMore {
+		next = EncodeCursor(last)
+	}
+	if (backwards && hasMore) || (!backwards && cursor != nil) {
+		first.Before = true
+		prev = EncodeCursor(first)
+	}
+	return next, prev
+}
@@ -0,0 +1,94 @@
+package post
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/dbtest"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+)
+
+// listingRepository serves a fixed page of posts and counts how often the total is requested
+type listingRepository struct {
+	PostRepository
+	posts  []*models.Post
+	counts int
+}
+
+func (r *listingRepository) FindPaginated(page, pageSize int) ([]*models.Post, error) {
+	return r.posts, nil
+}
+
+func (r *listingRepository) FindByCursor(cursor *Cursor, limit int) ([]*models.Post, bool, error) {
+	return r.posts, true, nil
+}
+
+func (r *listingRepository) CountPublished() (int64, error) {
+	r.counts++
+	return int64(len(r.posts)), nil
+}
+
+func TestCursorFollowsPublicationTime(t *testing.T) {
+	db, recorder := dbtest.DryRun(t)
+	repo := NewPostRepository(db, newTestLogger())
+	cursor := &Cursor{PublishedAt: time.Now(), ID: 5}
+	if _, _, err := repo.FindByCursor(cursor, 10); err != nil && !dbtest.Unsupported(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	queries := recorder.Queries()
+	if len(queries) != 1 || !strings.Contains(queries[0], "(posts.published_at, posts.id) <") ||
+		!strings.Contains(queries[0], "ORDER BY posts.published_at DESC,posts.id DESC") {
+		t.Fatalf("listing is not keyed on (published_at, id): %q", queries)
+	}
+
+	// A post scheduled long after it was written sorts by its publication
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	published := created.AddDate(0, 3, 0)
+	s := NewPostService(&listingRepository{posts: []*models.Post{{ID: 7, CreatedAt: created, PublishedAt: &published}}}, nil,
+		nil, RevisionPolicy{}, FeedSettings{}, ReadingSettings{}, AccessSettings{}, CommentPolicy{}, "", newTestLogger())
+	page, err := s.GetPostsByCursor("", 10, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	next, err := DecodeCursor(page.NextCursor)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !next.PublishedAt.Equal(published) || next.ID != 7 {
+		t.Errorf("next cursor = %+v, want the publication time of post 7", next)
+	}
+
+	// Cursors from before the change carried the creation time and are refused
+	old := EncodeCursor(Cursor{ID: 7})
+	if _, err := DecodeCursor(old); err == nil {
+		t.Error("cursor without a publication time was accepted")
+	}
+}
+
+func TestPageModeReusesTotals(t *testing.T) {
+	published := time.Now().Add(-time.Hour)
+	repo := &listingRepository{posts: []*models.Post{{ID: 1, PublishedAt: &published}}}
+	s := NewPostService(repo, nil, nil, RevisionPolicy{}, FeedSettings{}, ReadingSettings{}, AccessSettings{}, CommentPolicy{}, "", newTestLogger())
+
+	for page := 1; page <= 3; page++ {
+		result, err := s.GetPaginatedPosts(page, 10)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.TotalPosts != 1 || result.TotalPages != 1 {
+			t.Fatalf("page %d: total %d in %d pages, want 1 in 1", page, result.TotalPosts, result.TotalPages)
+		}
+	}
+	if repo.counts != 1 {
+		t.Errorf("posts counted %d times for three pages, want once", repo.counts)
+	}
+
+	s.InvalidateCounts()
+	if _, err := s.GetPaginatedPosts(1, 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.counts != 2 {
+		t.Errorf("posts not counted again after the totals were invalidated")
+	}
+}
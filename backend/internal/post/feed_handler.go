@@ -0,0 +1,142 @@
+package post
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/feed"
+	"github.com/gin-gonic/gin"
+)
+
+// feedFormat pairs a feed renderer with its content type
+type feedFormat struct {
+	name        string
+	contentType string
+	render      func(*feed.Feed) ([]byte, error)
+}
+
+var (
+	rssFormat  = feedFormat{"rss", feed.RSSContentType, feed.RSS}
+	atomFormat = feedFormat{"atom", feed.AtomContentType, feed.Atom}
+	jsonFormat = feedFormat{"json", feed.JSONContentType, feed.JSON}
+)
+
+// GetRSSFeed godoc
+// @Summary RSS 2.0 feed
+// @Description RSS feed of the newest published posts. Served at /feed.xml, /categories/{category}/feed.xml and /tags/{tag}/feed.xml
+// @Tags Feeds
+// @Produce xml
+// @Param mode query string false "full or summary (default from FEED_FULL_CONTENT)"
+// @Success 200 {string} string "RSS document"
+// @Success 304 "Not modified"
+// @Failure 404 {object} models.ErrorResponse "Unknown category or tag"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /feed.xml [get]
+func (h *PostHandler) GetRSSFeed(c *gin.Context) {
+	h.serveFeed(c, rssFormat)
+}
+
+// GetAtomFeed godoc
+// @Summary Atom feed
+// @Description Atom feed of the newest published posts. Served at /atom.xml, /categories/{category}/atom.xml and /tags/{tag}/atom.xml
+// @Tags Feeds
+// @Produce xml
+// @Param mode query string false "full or summary (default from FEED_FULL_CONTENT)"
+// @Success 200 {string} string "Atom document"
+// @Success 304 "Not modified"
+// @Failure 404 {object} models.ErrorResponse "Unknown category or tag"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /atom.xml [get]
+func (h *PostHandler) GetAtomFeed(c *gin.Context) {
+	h.serveFeed(c, atomFormat)
+}
+
+// GetJSONFeed godoc
+// @Summary JSON Feed
+// @Description JSON Feed 1.1 of the newest published posts. Served at /feed.json, /categories/{category}/feed.json and /tags/{tag}/feed.json
+// @Tags Feeds
+// @Produce json
+// @Param mode query string false "full or summary (default from FEED_FULL_CONTENT)"
+// @Success 200 {string} string "JSON Feed document"
+// @Success 304 "Not modified"
+// @Failure 404 {object} models.ErrorResponse "Unknown category or tag"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /feed.json [get]
+func (h *PostHandler) GetJSONFeed(c *gin.Context) {
+	h.serveFeed(c, jsonFormat)
+}
+
+func (h *PostHandler) serveFeed(c *gin.Context, format feedFormat) {
+	fullContent := h.service.DefaultFeedFullContent()
+	switch c.Query("mode") {
+	case "":
+	case "full":
+		fullContent = true
+	case "summary":
+		fullContent = false
+	default:
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid feed mode, expected full or summary"), http.StatusBadRequest))
+		return
+	}
+
+	filter := FeedFilter{CategorySlug: c.Param("category"), TagSlug: c.Param("tag")}
+	result, err := h.service.GetFeed(filter, c.Request.URL.Path, fullContent)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	lastModified := result.Updated.UTC().Truncate(time.Second)
+	etag := feedETag(result, format.name, fullContent)
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+	c.Header("Cache-Control", "public, max-age=300")
+
+	if notModified(c.Request, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	body, err := format.render(result)
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(fmt.Errorf("failed to render feed: %w", err), http.StatusInternalServerError))
+		return
+	}
+	c.Data(http.StatusOK, format.contentType, body)
+}
+
+// feedETag identifies a feed version. Besides the newest update time it covers the listed posts,
+// so a feed changes when a post is unpublished even though no remaining post was updated.
+func feedETag(f *feed.Feed, format string, fullContent bool) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s|%t|%s|%d", format, fullContent, f.FeedURL, f.Updated.UnixNano())
+	for _, item := range f.Items {
+		fmt.Fprintf(h, "|%s", item.ID)
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)) + `"`
+}
+
+// notModified evaluates the conditional request headers. If-None-Match takes precedence over If-Modified-Since.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		if t, err := http.ParseTime(ims); err == nil && !lastModified.After(t) {
+			return true
+		}
+	}
+	return false
+}
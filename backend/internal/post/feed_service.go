@@ -0,0 +1,134 @@
+package post
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/feed"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/render"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/slug"
+)
+
+// ErrFeedNotFound is returned for category or tag feeds without any published post
+var ErrFeedNotFound = errors.New("feed not found")
+
+// FeedSettings configures the feeds built by PostService.
+type FeedSettings struct {
+	SiteURL     string // Public URL of the blog, used to build absolute links
+	Title       string
+	Description string
+	Language    string
+	Limit       int
+	FullContent bool // Default content mode when the request doesn't choose one
+}
+
+// FeedFilter selects the posts of a feed. At most one of CategorySlug and TagSlug is set.
+type FeedFilter struct {
+	CategorySlug string
+	TagSlug      string
+	Limit        int
+}
+
+// relativeSrc matches src/href attributes pointing to a path on this site
+var relativeSrc = regexp.MustCompile(`(?i)\b(src|href)\s*=\s*(["'])/`)
+
+// GetFeed builds the feed of the newest published posts. feedPath is the path the feed is served
+// from (e.g. "/feed.xml") and fullContent chooses between the whole post and the summary only.
+func (s *PostService) GetFeed(filter FeedFilter, feedPath string, fullContent bool) (*feed.Feed, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = s.feedSettings.Limit
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+
+	posts, err := s.postRepo.FindForFeed(filter)
+	if err != nil {
+		return nil, myerr.WithHTTPStatus(err, http.StatusInternalServerError)
+	}
+
+	siteURL := strings.TrimSuffix(s.feedSettings.SiteURL, "/")
+	result := &feed.Feed{
+		Title:       s.feedSettings.Title,
+		Description: s.feedSettings.Description,
+		Link:        siteURL + "/",
+		FeedURL:     siteURL + feedPath,
+		Language:    s.feedSettings.Language,
+		Items:       make([]feed.Item, 0, len(posts)),
+	}
+
+	if filter.CategorySlug != "" || filter.TagSlug != "" {
+		if len(posts) == 0 {
+			return nil, myerr.WithHTTPStatus(ErrFeedNotFound, http.StatusNotFound)
+		}
+		if filter.CategorySlug != "" {
+			result.Title = fmt.Sprintf("%s - %s", s.feedSettings.Title, posts[0].Category)
+		} else {
+			result.Title = fmt.Sprintf("%s - #%s", s.feedSettings.Title, tagName(posts[0].Tags, filter.TagSlug))
+		}
+	}
+
+	for _, post := range posts {
+		link := fmt.Sprintf("%s/post/%d", siteURL, post.ID)
+		item := feed.Item{
+			ID:        link,
+			Title:     post.Title,
+			Link:      link,
+			Summary:   post.Summary,
+			ImageURL:  absoluteURL(siteURL, post.ImageURL),
+			Published: post.CreatedAt,
+			Updated:   post.UpdatedAt,
+		}
+		if post.PublishedAt != nil {
+			item.Published = *post.PublishedAt
+		}
+		// The content of protected posts is never syndicated
+		if fullContent && post.Visibility != models.PostVisibilityPassword {
+			item.Content = relativeSrc.ReplaceAllString(render.HTML(post), "$1=$2"+siteURL+"/")
+		}
+		if post.Category != "" {
+			item.Categories = append(item.Categories, post.Category)
+		}
+		item.Categories = append(item.Categories, dto.SplitTags(post.Tags)...)
+
+		if post.UpdatedAt.After(result.Updated) {
+			result.Updated = post.UpdatedAt
+		}
+		result.Items = append(result.Items, item)
+	}
+	if result.Updated.IsZero() {
+		result.Updated = time.Unix(0, 0)
+	}
+
+	return result, nil
+}
+
+// DefaultFeedFullContent reports whether feeds include the full post content unless asked otherwise.
+func (s *PostService) DefaultFeedFullContent() bool {
+	return s.feedSettings.FullContent
+}
+
+// absoluteURL prefixes site relative URLs ("/api/uploads/...") with siteURL.
+func absoluteURL(siteURL, u string) string {
+	if u == "" || strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") || strings.HasPrefix(u, "//") {
+		return u
+	}
+	return siteURL + "/" + strings.TrimPrefix(u, "/")
+}
+
+// tagName returns the display name of the tag with the given slug from a post's tag list.
+func tagName(tags, tagSlug string) string {
+	for _, tag := range dto.SplitTags(tags) {
+		if slug.Make(tag) == tagSlug {
+			return tag
+		}
+	}
+	return tagSlug
+}
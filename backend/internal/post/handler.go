@@ -9,6 +9,7 @@ import (
 	"github.com/dervisgenc/dervisgenc-blog/backend/internal/image"
 	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
 	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/middleware"
 	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -51,7 +52,8 @@ func (h *PostHandler) GetAllPosts(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Post ID"
-// @Success 200 {object} dto.PostDetailResponse
+// @Param X-Post-Access-Token header string false "Token from /posts/{id}/unlock for password-protected posts"
+// @Success 200 {object} dto.PostDetailResponse "Locked password-protected posts come without content"
 // @Failure 404 {object} dto.ErrorResponse "Post not found"
 // @Failure 500 {object} dto.ErrorResponse "Internal Server Error"
 // @Router /posts/{id} [get]
@@ -62,7 +64,7 @@ func (h *PostHandler) GetPostByID(c *gin.Context) {
 		c.Error(myerr.WithHTTPStatus(err, http.StatusBadRequest))
 		return
 	}
-	post, err := h.service.GetPostByID(uint(id))
+	post, err := h.service.GetPostByID(uint(id), c.GetHeader(AccessTokenHeader))
 	if err != nil {
 		c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
 		return
@@ -70,6 +72,74 @@ func (h *PostHandler) GetPostByID(c *gin.Context) {
 	c.JSON(http.StatusOK, post)
 }
 
+// GetPostBySlug godoc
+// @Summary Get a post by slug
+// @Description Fetch a single post by its slug. Old slugs of renamed posts answer with a 301 pointing to the current slug.
+// @Tags Posts
+// @Accept json
+// @Produce json
+// @Param slug path string true "Post slug"
+// @Param X-Post-Access-Token header string false "Token from /posts/{id}/unlock for password-protected posts"
+// @Success 200 {object} dto.PostDetailResponse "Locked password-protected posts come without content"
+// @Success 301 {object} dto.SlugRedirectResponse "Post was renamed"
+// @Failure 404 {object} dto.ErrorResponse "Post not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal Server Error"
+// @Router /posts/by-slug/{slug} [get]
+func (h *PostHandler) GetPostBySlug(c *gin.Context) {
+	post, redirectSlug, err := h.service.GetPostBySlug(c.Param("slug"), c.GetHeader(AccessTokenHeader))
+	if err != nil {
+		c.Error(err) // Already wrapped with a status by the repository
+		return
+	}
+
+	if redirectSlug != "" {
+		location := "/api/posts/by-slug/" + redirectSlug
+		c.Header("Location", location)
+		c.JSON(http.StatusMovedPermanently, dto.SlugRedirectResponse{
+			Slug:     redirectSlug,
+			Location: location,
+		})
+		return
+	}
+
+	// Let PostViewMiddleware know which post was viewed, the path only contains the slug
+	c.Set(middleware.PostViewIDKey, post.ID)
+	c.JSON(http.StatusOK, post)
+}
+
+// UnlockPost godoc
+// @Summary Unlock a password-protected post
+// @Description Checks the password of a post and returns a short-lived token. Send it in the X-Post-Access-Token header to receive the content.
+// @Tags Posts
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param request body dto.PostUnlockRequest true "Password"
+// @Success 200 {object} dto.PostAccessResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request or post is not protected"
+// @Failure 401 {object} models.ErrorResponse "Invalid password"
+// @Failure 404 {object} models.ErrorResponse "Post not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /posts/{id}/unlock [post]
+func (h *PostHandler) UnlockPost(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid post ID"), http.StatusBadRequest))
+		return
+	}
+	var req dto.PostUnlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(myerr.WithHTTPStatus(fmt.Errorf("invalid request body: %w", err), http.StatusBadRequest))
+		return
+	}
+	access, err := h.service.UnlockPost(uint(id), req.Password)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, access)
+}
+
 // CreatePost godoc
 // @Summary Create a new post
 // @Description Create a new post with the provided data including an image URL, category, and tags
@@ -104,14 +174,25 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 
 	// Map DTO to model
 	post := models.Post{
-		Title:    req.Title,
-		Summary:  req.Description, // Map Description from DTO to Summary in model
-		Content:  req.Content,
-		ReadTime: req.ReadTime,
-		IsActive: req.IsActive,
-		ImageURL: req.ImageURL,
-		Category: req.Category, // Map Category
-		Tags:     req.Tags,     // Map Tags
+		Title:         req.Title,
+		Slug:          req.Slug,
+		Summary:       req.Description, // Map Description from DTO to Summary in model
+		Content:       req.Content,
+		Language:      req.Language,
+		IsActive:      req.IsActive,
+		ImageURL:      req.ImageURL,
+		Category:      req.Category,      // Map Category
+		Tags:          req.Tags.String(), // Map Tags
+		Status:        req.Status,
+		PublishedAt:   req.PublishedAt,
+		ContentFormat: req.ContentFormat,
+		Visibility:    req.Visibility,
+		Password:      req.Password,
+		CommentMode:   req.CommentMode,
+	}
+
+	if req.OverrideReadTime {
+		post.ReadTimeOverride = req.ReadTime
 	}
 
 	// Create post using the service
@@ -164,18 +245,29 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 	// Map DTO to the model for update
 	// We only need to pass the fields that are being updated + ID
 	updatedPost := &models.Post{
-		ID:       uint(id),
-		Title:    req.Title,
-		Summary:  req.Description, // Map Description from DTO to Summary in model
-		Content:  req.Content,
-		ReadTime: req.ReadTime,
-		IsActive: req.IsActive,
-		ImageURL: req.ImageURL,
-		Category: req.Category, // Map Category
-		Tags:     req.Tags,     // Map Tags
+		ID:            uint(id),
+		Title:         req.Title,
+		Slug:          req.Slug,
+		Summary:       req.Description, // Map Description from DTO to Summary in model
+		Content:       req.Content,
+		Language:      req.Language,
+		IsActive:      req.IsActive,
+		ImageURL:      req.ImageURL,
+		Category:      req.Category,      // Map Category
+		Tags:          req.Tags.String(), // Map Tags
+		Status:        req.Status,
+		PublishedAt:   req.PublishedAt,
+		ContentFormat: req.ContentFormat,
+		Visibility:    req.Visibility,
+		Password:      req.Password,
+		CommentMode:   req.CommentMode,
 		// CreatedAt and LikeCount are handled by the service/repository layer
 	}
 
+	if req.OverrideReadTime {
+		updatedPost.ReadTimeOverride = req.ReadTime
+	}
+
 	// Update the post using the service
 	err = h.service.UpdatePost(updatedPost)
 	if err != nil {
@@ -307,48 +399,71 @@ func (h *PostHandler) GetPostByIDAdmin(c *gin.Context) {
 
 // GetPaginatedPosts godoc
 // @Summary Get paginated posts
-// @Description Fetch posts with pagination
+// @Description Fetch posts with pagination. Passing the cursor parameter (empty for the first page) switches from page numbers
+// @Description to cursor mode, which returns next_cursor and prev_cursor and is stable while new posts are published.
 // @Tags Posts
 // @Accept json
 // @Produce json
-// @Param page query int false "Page number (default: 1)"
+// @Param page query int false "Page number (default: 1), ignored in cursor mode"
 // @Param size query int false "Page size (default: 9)"
-// @Success 200 {object} models.PaginatedPosts
+// @Param cursor query string false "Opaque cursor from next_cursor or prev_cursor"
+// @Param count query bool false "Set X-Total-Count and X-Total-Pages headers"
+// @Success 200 {object} dto.PaginatedPostResponse "Page number mode; dto.CursorPostResponse in cursor mode"
 // @Failure 400 {object} models.ErrorResponse "Invalid parameters"
 // @Failure 500 {object} models.ErrorResponse "Internal Server Error"
 // @Router /posts/paginated [get]
 func (h *PostHandler) GetPaginatedPosts(c *gin.Context) {
-	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if err != nil || page < 1 {
-		c.Error(myerr.WithHTTPStatus(errors.New("invalid page number"), http.StatusBadRequest))
-		return
-	}
-
 	pageSize, err := strconv.Atoi(c.DefaultQuery("size", "9"))
 	if err != nil || pageSize < 1 {
 		c.Error(myerr.WithHTTPStatus(errors.New("invalid page size"), http.StatusBadRequest))
 		return
 	}
+	withTotal := c.Query("count") == "true"
+
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		result, err := h.service.GetPostsByCursor(cursor, pageSize, withTotal)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if result.TotalPosts != nil {
+			setTotalHeaders(c, *result.TotalPosts, pageSize)
+		}
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid page number"), http.StatusBadRequest))
+		return
+	}
 
 	result, err := h.service.GetPaginatedPosts(page, pageSize)
 	if err != nil {
 		c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
 		return
 	}
+	if withTotal {
+		setTotalHeaders(c, result.TotalPosts, pageSize)
+	}
 
 	c.JSON(http.StatusOK, result)
 }
 
 // SearchPosts godoc
 // @Summary Search posts with pagination
-// @Description Search posts by query string with pagination
+// @Description Search posts by query string with pagination. Like /posts/paginated, passing the cursor parameter
+// @Description switches to cursor mode; results stay ordered by rank.
 // @Tags Posts
 // @Accept json
 // @Produce json
 // @Param q query string true "Search query"
-// @Param page query int false "Page number (default: 1)"
+// @Param page query int false "Page number (default: 1), ignored in cursor mode"
 // @Param size query int false "Page size (default: 9)"
-// @Success 200 {object} models.PaginatedPosts
+// @Param cursor query string false "Opaque cursor from next_cursor or prev_cursor"
+// @Param count query bool false "Set X-Total-Count and X-Total-Pages headers"
+// @Success 200 {object} dto.PostSearchResponse "Page number mode; dto.CursorSearchResponse in cursor mode"
 // @Failure 400 {object} models.ErrorResponse "Invalid parameters"
 // @Failure 500 {object} models.ErrorResponse "Internal Server Error"
 // @Router /posts/search [get]
@@ -358,6 +473,25 @@ func (h *PostHandler) SearchPosts(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "search query is required"})
 		return
 	}
+	withTotal := c.Query("count") == "true"
+
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		pageSize, err := strconv.Atoi(c.DefaultQuery("size", "9"))
+		if err != nil || pageSize < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page size"})
+			return
+		}
+		result, err := h.service.SearchPostsByCursor(query, cursor, pageSize, withTotal)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if result.TotalPosts != nil {
+			setTotalHeaders(c, *result.TotalPosts, pageSize)
+		}
+		c.JSON(http.StatusOK, result)
+		return
+	}
 
 	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
 	if err != nil || page < 1 {
@@ -376,10 +510,19 @@ func (h *PostHandler) SearchPosts(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if withTotal {
+		setTotalHeaders(c, result.TotalPosts, pageSize)
+	}
 
 	c.JSON(http.StatusOK, result)
 }
 
+// setTotalHeaders exposes the size of a listing without changing the response body
+func setTotalHeaders(c *gin.Context, total int64, pageSize int) {
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.Header("X-Total-Pages", strconv.FormatInt((total+int64(pageSize)-1)/int64(pageSize), 10))
+}
+
 // GetRelatedPosts godoc
 // @Summary Get related posts
 // @Description Fetch posts related to a given post ID based on category and tags
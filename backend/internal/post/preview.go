@@ -0,0 +1,147 @@
+package post
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/signedtoken"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidPreviewToken is returned for preview tokens that are malformed, forged, expired or revoked
+var ErrInvalidPreviewToken = errors.New("preview link is invalid or has expired")
+
+// PreviewSettings configures draft preview links
+type PreviewSettings struct {
+	Secret     []byte        // Application secret, signs the link tokens
+	DefaultTTL time.Duration // Lifetime of a link when the request doesn't choose one
+	MaxTTL     time.Duration // Longest lifetime a link may be given
+	SiteURL    string        // Public URL of the blog frontend, links point at its /preview/<token> page
+}
+
+// PreviewService issues and resolves preview links, which share a post with readers regardless of its status.
+type PreviewService struct {
+	posts       *PostService
+	previewRepo PreviewRepository
+	settings    PreviewSettings
+	tokens      signedtoken.Signer
+	logger      *logrus.Logger
+}
+
+func NewPreviewService(posts *PostService, previewRepo PreviewRepository, settings PreviewSettings, logger *logrus.Logger) *PreviewService {
+	return &PreviewService{
+		posts:       posts,
+		previewRepo: previewRepo,
+		settings:    settings,
+		tokens:      signedtoken.New(settings.Secret, "post-preview"),
+		logger:      logger,
+	}
+}
+
+// CreatePreviewLink issues a preview link for a post, valid for ttl (the default lifetime when 0, capped at MaxTTL).
+func (s *PreviewService) CreatePreviewLink(postID uint, ttl time.Duration) (*dto.PreviewLinkResponse, error) {
+	if _, err := s.posts.GetPostByIDAdmin(postID); err != nil {
+		return nil, myerr.WithHTTPStatus(ErrPostNotFound, http.StatusNotFound)
+	}
+	if ttl <= 0 {
+		ttl = s.settings.DefaultTTL
+	}
+	if s.settings.MaxTTL > 0 && ttl > s.settings.MaxTTL {
+		ttl = s.settings.MaxTTL
+	}
+
+	// Tokens carry the expiry in seconds, so the stored expiry must match it exactly
+	link := &models.PreviewLink{
+		PostID:    postID,
+		ExpiresAt: time.Now().Add(ttl).Truncate(time.Second),
+	}
+	if err := s.previewRepo.Create(link); err != nil {
+		return nil, err
+	}
+	response := s.toResponse(link)
+	return &response, nil
+}
+
+// ListPreviewLinks returns the preview links of a post, newest first
+func (s *PreviewService) ListPreviewLinks(postID uint) ([]dto.PreviewLinkResponse, error) {
+	links, err := s.previewRepo.FindByPostID(postID)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]dto.PreviewLinkResponse, len(links))
+	for i := range links {
+		responses[i] = s.toResponse(&links[i])
+	}
+	return responses, nil
+}
+
+// RevokePreviewLink stops a preview link from working before it expires
+func (s *PreviewService) RevokePreviewLink(postID, linkID uint) (*dto.PreviewLinkResponse, error) {
+	link, err := s.previewRepo.Revoke(postID, linkID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	response := s.toResponse(link)
+	return &response, nil
+}
+
+// GetPreview returns the post a preview token was issued for, whatever its status and visibility.
+func (s *PreviewService) GetPreview(token string) (*dto.PostDetailResponse, error) {
+	linkID, expiresAt, ok := s.parseToken(token)
+	if !ok || time.Now().After(expiresAt) {
+		return nil, myerr.WithHTTPStatus(ErrInvalidPreviewToken, http.StatusNotFound)
+	}
+
+	link, err := s.previewRepo.FindByID(linkID)
+	if err != nil {
+		if errors.Is(err, ErrPreviewLinkNotFound) {
+			return nil, myerr.WithHTTPStatus(ErrInvalidPreviewToken, http.StatusNotFound)
+		}
+		return nil, err
+	}
+	if link.RevokedAt != nil || !link.ExpiresAt.Equal(expiresAt) {
+		return nil, myerr.WithHTTPStatus(ErrInvalidPreviewToken, http.StatusNotFound)
+	}
+
+	post, err := s.posts.GetPostByIDAdmin(link.PostID)
+	if err != nil {
+		return nil, myerr.WithHTTPStatus(ErrInvalidPreviewToken, http.StatusNotFound)
+	}
+	detail := dto.ToPostDetailResponse(post)
+	s.posts.attachSeries(&detail)
+	return &detail, nil
+}
+
+func (s *PreviewService) toResponse(link *models.PreviewLink) dto.PreviewLinkResponse {
+	token := s.signToken(link.ID, link.ExpiresAt)
+	return dto.PreviewLinkResponse{
+		ID:        link.ID,
+		PostID:    link.PostID,
+		Token:     token,
+		URL:       strings.TrimSuffix(s.settings.SiteURL, "/") + "/preview/" + token,
+		CreatedAt: link.CreatedAt,
+		ExpiresAt: link.ExpiresAt,
+		RevokedAt: link.RevokedAt,
+		Active:    link.RevokedAt == nil && time.Now().Before(link.ExpiresAt),
+	}
+}
+
+// signToken encodes a link ID and expiry together with their signature.
+// Tokens are derived from the link, so the same link always yields the same token.
+func (s *PreviewService) signToken(linkID uint, expiresAt time.Time) string {
+	return s.tokens.EncodePair(uint64(linkID), uint64(expiresAt.Unix()))
+}
+
+// parseToken checks the signature of a token and returns the link ID and expiry it carries.
+func (s *PreviewService) parseToken(token string) (linkID uint, expiresAt time.Time, ok bool) {
+	id, expires, ok := s.tokens.DecodePair(token)
+	if !ok {
+		return 0, time.Time{}, false
+	}
+	return uint(id), time.Unix(int64(expires), 0), true
+}
@@ -0,0 +1,132 @@
+package post
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/gin-gonic/gin"
+)
+
+type PreviewHandler struct {
+	service *PreviewService
+}
+
+func NewPreviewHandler(service *PreviewService) *PreviewHandler {
+	return &PreviewHandler{service: service}
+}
+
+// CreatePreviewLink godoc
+// @Summary Create a preview link
+// @Description Issues an expiring link that lets anyone holding it read the post, even as a draft
+// @Tags Post Previews
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param request body dto.PreviewLinkCreateRequest false "Lifetime of the link"
+// @Success 201 {object} dto.PreviewLinkResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 404 {object} models.ErrorResponse "Post not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/posts/{id}/preview-links [post]
+func (h *PreviewHandler) CreatePreviewLink(c *gin.Context) {
+	postID, ok := parseIDParam(c, "id", "invalid post ID")
+	if !ok {
+		return
+	}
+	var req dto.PreviewLinkCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) { // The body is optional
+		c.Error(myerr.WithHTTPStatus(fmt.Errorf("invalid request body: %w", err), http.StatusBadRequest))
+		return
+	}
+	if req.ExpiresInHours < 0 {
+		c.Error(myerr.WithHTTPStatus(errors.New("expiresInHours must not be negative"), http.StatusBadRequest))
+		return
+	}
+
+	link, err := h.service.CreatePreviewLink(postID, time.Duration(req.ExpiresInHours)*time.Hour)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, link)
+}
+
+// ListPreviewLinks godoc
+// @Summary List preview links of a post
+// @Description Lists the preview links of a post, newest first, including expired and revoked ones
+// @Tags Post Previews
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {array} dto.PreviewLinkResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid post ID"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/posts/{id}/preview-links [get]
+func (h *PreviewHandler) ListPreviewLinks(c *gin.Context) {
+	postID, ok := parseIDParam(c, "id", "invalid post ID")
+	if !ok {
+		return
+	}
+	links, err := h.service.ListPreviewLinks(postID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, links)
+}
+
+// RevokePreviewLink godoc
+// @Summary Revoke a preview link
+// @Description Stops a preview link from working before it expires
+// @Tags Post Previews
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param link_id path int true "Preview link ID"
+// @Success 200 {object} dto.PreviewLinkResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 404 {object} models.ErrorResponse "Preview link not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/posts/{id}/preview-links/{link_id} [delete]
+func (h *PreviewHandler) RevokePreviewLink(c *gin.Context) {
+	postID, ok := parseIDParam(c, "id", "invalid post ID")
+	if !ok {
+		return
+	}
+	linkID, ok := parseIDParam(c, "link_id", "invalid preview link ID")
+	if !ok {
+		return
+	}
+	link, err := h.service.RevokePreviewLink(postID, linkID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, link)
+}
+
+// GetPreview godoc
+// @Summary Preview a post
+// @Description Returns the post a preview link was issued for, whatever its status. Views are not recorded and the response is marked noindex.
+// @Tags Post Previews
+// @Produce json
+// @Param token path string true "Preview token"
+// @Success 200 {object} dto.PostDetailResponse
+// @Failure 404 {object} models.ErrorResponse "Invalid, expired or revoked preview link"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /preview/{token} [get]
+func (h *PreviewHandler) GetPreview(c *gin.Context) {
+	// Previews must never end up in search results or shared caches, not even the error responses
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	c.Header("Cache-Control", "private, no-store")
+
+	post, err := h.service.GetPreview(c.Param("token"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, post)
+}
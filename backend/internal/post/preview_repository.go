@@ -0,0 +1,83 @@
+package post
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ErrPreviewLinkNotFound is returned when a preview link does not exist or belongs to another post
+var ErrPreviewLinkNotFound = errors.New("preview link not found")
+
+type PreviewRepository interface {
+	Create(link *models.PreviewLink) error
+	FindByID(id uint) (*models.PreviewLink, error)
+	FindByPostID(postID uint) ([]models.PreviewLink, error)
+	Revoke(postID, id uint, now time.Time) (*models.PreviewLink, error)
+}
+
+type previewRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewPreviewRepository(db *gorm.DB, logger *logrus.Logger) PreviewRepository {
+	return &previewRepository{db: db, logger: logger}
+}
+
+func (r *previewRepository) Create(link *models.PreviewLink) error {
+	if err := r.db.Create(link).Error; err != nil {
+		r.logger.WithError(err).WithField("post_id", link.PostID).Error("Repository: Failed to create preview link")
+		return myerr.WithHTTPStatus(fmt.Errorf("failed to create preview link: %w", err), http.StatusInternalServerError)
+	}
+	return nil
+}
+
+func (r *previewRepository) FindByID(id uint) (*models.PreviewLink, error) {
+	var link models.PreviewLink
+	if err := r.db.First(&link, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, myerr.WithHTTPStatus(ErrPreviewLinkNotFound, http.StatusNotFound)
+		}
+		r.logger.WithError(err).WithField("preview_link_id", id).Error("Repository: Failed to fetch preview link")
+		return nil, myerr.WithHTTPStatus(fmt.Errorf("failed to fetch preview link: %w", err), http.StatusInternalServerError)
+	}
+	return &link, nil
+}
+
+// FindByPostID returns the preview links of a post, newest first, including expired and revoked ones.
+func (r *previewRepository) FindByPostID(postID uint) ([]models.PreviewLink, error) {
+	var links []models.PreviewLink
+	if err := r.db.Where("post_id = ?", postID).Order("created_at DESC, id DESC").Find(&links).Error; err != nil {
+		r.logger.WithError(err).WithField("post_id", postID).Error("Repository: Failed to fetch preview links")
+		return nil, myerr.WithHTTPStatus(fmt.Errorf("failed to fetch preview links: %w", err), http.StatusInternalServerError)
+	}
+	return links, nil
+}
+
+// Revoke marks a preview link of a post as revoked. Revoking a link twice keeps the first revocation time.
+func (r *previewRepository) Revoke(postID, id uint, now time.Time) (*models.PreviewLink, error) {
+	var link models.PreviewLink
+	if err := r.db.Where("post_id = ?", postID).First(&link, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, myerr.WithHTTPStatus(ErrPreviewLinkNotFound, http.StatusNotFound)
+		}
+		r.logger.WithError(err).WithField("preview_link_id", id).Error("Repository: Failed to fetch preview link")
+		return nil, myerr.WithHTTPStatus(fmt.Errorf("failed to fetch preview link: %w", err), http.StatusInternalServerError)
+	}
+	if link.RevokedAt != nil {
+		return &link, nil
+	}
+	link.RevokedAt = &now
+	if err := r.db.Model(&link).Update("revoked_at", now).Error; err != nil {
+		r.logger.WithError(err).WithField("preview_link_id", id).Error("Repository: Failed to revoke preview link")
+		return nil, myerr.WithHTTPStatus(fmt.Errorf("failed to revoke preview link: %w", err), http.StatusInternalServerError)
+	}
+	return &link, nil
+}
@@ -0,0 +1,21 @@
+package post
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+)
+
+func TestPreviewLinkPointsAtFrontendPage(t *testing.T) {
+	s := NewPreviewService(nil, nil, PreviewSettings{Secret: []byte("secret"), SiteURL: "https://blog.example.com/"}, newTestLogger())
+	link := &models.PreviewLink{ID: 4, PostID: 9, ExpiresAt: time.Now().Add(time.Hour)}
+
+	resp := s.toResponse(link)
+	if want := "https://blog.example.com/preview/" + resp.Token; resp.URL != want {
+		t.Errorf("URL = %q, want %q", resp.URL, want)
+	}
+	if id, _, ok := s.parseToken(resp.Token); !ok || id != link.ID {
+		t.Errorf("token of the link doesn't resolve back to it: id %d, ok %v", id, ok)
+	}
+}
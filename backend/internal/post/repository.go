@@ -3,30 +3,48 @@ package post
 import (
 	"errors"
 	"fmt"
+	"html"
 	"net/http"
-	"strings" // Import strings package
+	"strings"
+	"time"
 
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/taxonomy"
 	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
 	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Define a sentinel error for post not found
 var ErrPostNotFound = errors.New("post not found")
 
+// pgUniqueViolation is the Postgres error code of a unique constraint violation
+const pgUniqueViolation = "23505"
+
 type PostRepository interface {
 	FindAll() ([]*models.Post, error)
 	FindByID(id uint) (*models.Post, error)
 	Create(post *models.Post) error
-	Update(post *models.Post) error
+	// Update saves the post together with the revision of its previous version, if any, in one transaction
+	Update(post *models.Post, revision *models.PostRevision) error
 	Delete(post_id uint) error
 	FindAllAdmin() ([]*models.Post, error)
 	FindByIDAdmin(id uint) (*models.Post, error)
-	FindPaginated(page, pageSize int) ([]*models.Post, int64, error)
-	SearchPosts(query string, page, pageSize int) ([]*models.Post, int64, error)
+	FindPaginated(page, pageSize int) ([]*models.Post, error)
+	SearchPosts(query string, page, pageSize int) ([]*models.PostSearchResult, error)
+	FindByCursor(cursor *Cursor, limit int) ([]*models.Post, bool, error)
+	SearchByCursor(query string, cursor *Cursor, limit int) ([]*models.PostSearchResult, bool, error)
+	CountPublished() (int64, error)
+	CountSearchResults(query string) (int64, error)
 	DeletePostPermanently(id uint) error                        // Add this line
 	FindRelated(postID uint, limit int) ([]*models.Post, error) // Add this line for related posts
+	FindBySlug(slug string) (*models.Post, error)
+	FindBySlugHistory(slug string) (*models.Post, error)
+	SlugExists(slug string, excludePostID uint) (bool, error)
+	PublishDue(now time.Time) ([]*models.Post, error)
+	FindForFeed(filter FeedFilter) ([]*models.Post, error)
 }
 
 type postRepository struct {
@@ -40,34 +58,101 @@ func NewPostRepository(db *gorm.DB, logger *logrus.Logger) PostRepository {
 }
 
 func (r *postRepository) Create(post *models.Post) error {
-	result := r.db.Create(post)
-	if result.Error != nil {
-		return fmt.Errorf("failed to create post: %w", result.Error)
+	return slugConflict(r.db.Transaction(func(tx *gorm.DB) error {
+		tags, err := taxonomy.ResolvePostTaxonomy(tx, post)
+		if err != nil {
+			r.logger.WithError(err).Error("Error resolving category and tags for new post")
+			return fmt.Errorf("failed to create post: %w", err)
+		}
+		if err := tx.Create(post).Error; err != nil {
+			return fmt.Errorf("failed to create post: %w", err)
+		}
+		if err := taxonomy.LinkPostTags(tx, post.ID, tags); err != nil {
+			return fmt.Errorf("failed to create post: %w", err)
+		}
+		return nil
+	}))
+}
+
+func (r *postRepository) Update(post *models.Post, revision *models.PostRevision) error {
+	return slugConflict(r.db.Transaction(func(tx *gorm.DB) error {
+		// Keep the previous slug in the history table so old URLs can be redirected
+		var current models.Post
+		if err := tx.Select("id", "slug").First(&current, post.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				r.logger.Warnf("Attempted to update non-existent post with ID %d", post.ID)
+				return myerr.WithHTTPStatus(ErrPostNotFound, http.StatusNotFound) // Use the sentinel error
+			}
+			return fmt.Errorf("error while loading post before update: %w", err)
+		}
+		if post.Slug == "" {
+			post.Slug = current.Slug // Never clear an existing slug
+		}
+		if current.Slug != "" && current.Slug != post.Slug {
+			if err := r.recordSlugChange(tx, post.ID, current.Slug, post.Slug); err != nil {
+				return err
+			}
+		}
+
+		if revision != nil {
+			if err := tx.Create(revision).Error; err != nil {
+				r.logger.WithError(err).Errorf("Error saving revision of post with ID %d", post.ID)
+				return fmt.Errorf("error while saving post revision: %w", err)
+			}
+		}
+
+		tags, err := taxonomy.ResolvePostTaxonomy(tx, post)
+		if err != nil {
+			r.logger.WithError(err).Errorf("Error resolving category and tags for post with ID %d", post.ID)
+			return fmt.Errorf("error while updating post: %w", err)
+		}
+
+		// Use Select to specify which fields to update, including new ones
+		result := tx.Model(post).Select(
+			"title", "slug", "content", "summary", "image_path", "image_url",
+			"read_time", "is_active", "updated_at", "category", "tags", // Added category and tags
+			"status", "published_at", "category_id", "content_format", "rendered_content",
+			"language", "word_count", "read_time_override", "toc", "visibility", "password_hash", "comment_mode",
+		).Updates(post)
+
+		if result.Error != nil {
+			r.logger.WithError(result.Error).Errorf("Error updating post with ID %d", post.ID)
+			return fmt.Errorf("error while updating post: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			r.logger.Infof("No changes detected for post with ID %d during update", post.ID)
+		}
+		return taxonomy.LinkPostTags(tx, post.ID, tags)
+	}))
+}
+
+// slugConflict reports a unique violation on a slug as ErrSlugTaken. The service checks that a slug is free before
+// saving, but a concurrent save can take it in between; the unique index then has the last word.
+func slugConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation && strings.Contains(pgErr.ConstraintName, "slug") {
+		return myerr.WithHTTPStatus(ErrSlugTaken, http.StatusConflict)
 	}
-	return nil
+	return err
 }
 
-func (r *postRepository) Update(post *models.Post) error {
-	// Use Select to specify which fields to update, including new ones
-	result := r.db.Model(post).Select(
-		"title", "content", "summary", "image_path", "image_url",
-		"read_time", "is_active", "updated_at", "category", "tags", // Added category and tags
-	).Updates(post)
+// recordSlugChange stores oldSlug in the history of the post. If the post is switched back
+// to a slug it used before, that entry is removed from the history since it is live again.
+func (r *postRepository) recordSlugChange(tx *gorm.DB, postID uint, oldSlug, newSlug string) error {
+	log := r.logger.WithFields(logrus.Fields{"post_id": postID, "old_slug": oldSlug, "new_slug": newSlug})
 
-	if result.Error != nil {
-		r.logger.WithError(result.Error).Errorf("Error updating post with ID %d", post.ID)
-		return fmt.Errorf("error while updating post: %w", result.Error)
+	if err := tx.Where("post_id = ? AND slug = ?", postID, newSlug).Delete(&models.PostSlugHistory{}).Error; err != nil {
+		log.WithError(err).Error("Error removing reused slug from history")
+		return fmt.Errorf("error updating slug history: %w", err)
 	}
-	if result.RowsAffected == 0 {
-		// Optionally check if the record exists to differentiate between not found and no changes
-		var exists int64
-		r.db.Model(&models.Post{}).Where("id = ?", post.ID).Count(&exists)
-		if exists == 0 {
-			r.logger.Warnf("Attempted to update non-existent post with ID %d", post.ID)
-			return myerr.WithHTTPStatus(ErrPostNotFound, http.StatusNotFound) // Use the sentinel error
-		}
-		r.logger.Infof("No changes detected for post with ID %d during update", post.ID)
+
+	history := models.PostSlugHistory{PostID: postID, Slug: oldSlug}
+	if err := tx.Where(models.PostSlugHistory{Slug: oldSlug}).FirstOrCreate(&history).Error; err != nil {
+		log.WithError(err).Error("Error saving old slug to history")
+		return fmt.Errorf("error updating slug history: %w", err)
 	}
+
+	log.Info("Post slug changed, old slug kept for redirects")
 	return nil
 }
 
@@ -92,7 +177,7 @@ func (r *postRepository) Delete(post_id uint) error {
 func (r *postRepository) FindByID(id uint) (*models.Post, error) {
 	var post models.Post
 
-	if err := r.db.First(&post, id).Error; err != nil || !post.IsActive {
+	if err := r.db.Scopes(models.PublishedPosts).First(&post, id).Error; err != nil {
 		return nil, errors.New("error while fetching post")
 	}
 
@@ -106,14 +191,98 @@ func (r *postRepository) FindByIDAdmin(id uint) (*models.Post, error) {
 	return &post, nil
 }
 
+// FindBySlug returns the active post currently using the given slug.
+func (r *postRepository) FindBySlug(slug string) (*models.Post, error) {
+	var post models.Post
+	if err := r.db.Scopes(models.PublishedPosts).Where("slug = ?", slug).First(&post).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, myerr.WithHTTPStatus(ErrPostNotFound, http.StatusNotFound)
+		}
+		r.logger.WithError(err).Errorf("FindBySlug: Error fetching post with slug %q", slug)
+		return nil, myerr.WithHTTPStatus(fmt.Errorf("error while fetching post: %w", err), http.StatusInternalServerError)
+	}
+	return &post, nil
+}
+
+// FindBySlugHistory returns the active post that used the given slug in the past.
+func (r *postRepository) FindBySlugHistory(slug string) (*models.Post, error) {
+	var post models.Post
+	err := r.db.Scopes(models.PublishedPosts).
+		Joins("JOIN post_slug_histories h ON h.post_id = posts.id").
+		Where("h.slug = ?", slug).
+		First(&post).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, myerr.WithHTTPStatus(ErrPostNotFound, http.StatusNotFound)
+		}
+		r.logger.WithError(err).Errorf("FindBySlugHistory: Error fetching post for old slug %q", slug)
+		return nil, myerr.WithHTTPStatus(fmt.Errorf("error while fetching post: %w", err), http.StatusInternalServerError)
+	}
+	return &post, nil
+}
+
+// SlugExists reports whether slug is used by another post, either as its current slug or in its history.
+func (r *postRepository) SlugExists(slug string, excludePostID uint) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.Post{}).Where("slug = ? AND id <> ?", slug, excludePostID).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("error checking slug: %w", err)
+	}
+	if count > 0 {
+		return true, nil
+	}
+	if err := r.db.Model(&models.PostSlugHistory{}).Where("slug = ? AND post_id <> ?", slug, excludePostID).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("error checking slug history: %w", err)
+	}
+	return count > 0, nil
+}
+
+// PublishDue flips scheduled posts whose publish time has passed to published and returns them.
+func (r *postRepository) PublishDue(now time.Time) ([]*models.Post, error) {
+	var posts []*models.Post
+	result := r.db.Model(&posts).
+		Clauses(clause.Returning{}).
+		Where("status = ? AND published_at <= ?", models.PostStatusScheduled, now).
+		Updates(map[string]interface{}{
+			"status":     models.PostStatusPublished,
+			"is_active":  true,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		r.logger.WithError(result.Error).Error("PublishDue: Error publishing scheduled posts")
+		return nil, fmt.Errorf("error publishing scheduled posts: %w", result.Error)
+	}
+	return posts, nil
+}
+
 func (r *postRepository) FindAll() ([]*models.Post, error) {
 	var posts []*models.Post
-	if err := r.db.Where("is_active = ?", "true").Order("created_at desc").Find(&posts).Error; err != nil {
+	if err := r.db.Scopes(models.ListedPosts).Order("created_at desc").Find(&posts).Error; err != nil {
 		return nil, errors.New("error while fetching posts")
 	}
 	return posts, nil
 }
 
+// FindForFeed returns the newest published posts, optionally limited to a category or tag slug.
+func (r *postRepository) FindForFeed(filter FeedFilter) ([]*models.Post, error) {
+	query := r.db.Scopes(models.ListedPosts)
+	if filter.CategorySlug != "" {
+		query = query.Joins("JOIN categories ON categories.id = posts.category_id").
+			Where("categories.slug = ?", filter.CategorySlug)
+	}
+	if filter.TagSlug != "" {
+		query = query.Joins("JOIN post_tags ON post_tags.post_id = posts.id").
+			Joins("JOIN tags ON tags.id = post_tags.tag_id").
+			Where("tags.slug = ?", filter.TagSlug)
+	}
+
+	var posts []*models.Post
+	if err := query.Order("posts.published_at DESC, posts.id DESC").Limit(filter.Limit).Find(&posts).Error; err != nil {
+		r.logger.WithError(err).Error("FindForFeed: Error fetching posts")
+		return nil, fmt.Errorf("error fetching feed posts: %w", err)
+	}
+	return posts, nil
+}
+
 func (r *postRepository) FindAllAdmin() ([]*models.Post, error) {
 	var posts []*models.Post
 	if err := r.db.Order("created_at desc").Find(&posts).Error; err != nil {
@@ -122,65 +291,173 @@ func (r *postRepository) FindAllAdmin() ([]*models.Post, error) {
 	return posts, nil
 }
 
-func (r *postRepository) FindPaginated(page, pageSize int) ([]*models.Post, int64, error) {
+// FindPaginated returns a page of published posts, newest first like FindByCursor. The total is counted
+// separately with CountPublished.
+func (r *postRepository) FindPaginated(page, pageSize int) ([]*models.Post, error) {
 	var posts []*models.Post
-	var totalPosts int64
-
-	// Count total active posts
-	if err := r.db.Model(&models.Post{}).Where("is_active = ?", true).Count(&totalPosts).Error; err != nil {
-		return nil, 0, fmt.Errorf("error counting posts: %w", err)
-	}
 
 	// Calculate offset
 	offset := (page - 1) * pageSize
 
 	// Get paginated posts
-	if err := r.db.Where("is_active = ?", true).
-		Order("created_at desc").
+	if err := r.db.Scopes(models.ListedPosts).
+		Order("posts.published_at desc, posts.id desc").
 		Limit(pageSize).
 		Offset(offset).
 		Find(&posts).Error; err != nil {
-		return nil, 0, fmt.Errorf("error fetching paginated posts: %w", err)
+		return nil, fmt.Errorf("error fetching paginated posts: %w", err)
 	}
 
-	return posts, totalPosts, nil
+	return posts, nil
+}
+
+// CountPublished returns the number of posts visible to readers
+func (r *postRepository) CountPublished() (int64, error) {
+	var total int64
+	if err := r.db.Model(&models.Post{}).Scopes(models.ListedPosts).Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("error counting posts: %w", err)
+	}
+	return total, nil
 }
 
-func (r *postRepository) SearchPosts(query string, page, pageSize int) ([]*models.Post, int64, error) {
+// postKeyset orders public listings newest first by publication; the id breaks ties between posts published at the
+// same time. Scheduled posts are published long after they are created, so creation time would misplace them.
+var postKeyset = keyset{"posts.published_at", "posts.id"}
+
+// FindByCursor returns up to limit published posts after the cursor position, or before it for backwards cursors.
+// Unlike FindPaginated, posts published in the meantime don't shift the following pages.
+func (r *postRepository) FindByCursor(cursor *Cursor, limit int) ([]*models.Post, bool, error) {
+	var values []interface{}
+	if cursor != nil {
+		values = []interface{}{cursor.PublishedAt, cursor.ID}
+	}
+
 	var posts []*models.Post
-	var totalPosts int64
+	if err := postKeyset.page(r.db.Scopes(models.ListedPosts), cursor, values, limit).Find(&posts).Error; err != nil {
+		return nil, false, fmt.Errorf("error fetching posts by cursor: %w", err)
+	}
+	posts, hasMore := trimPage(posts, cursor, limit)
+	return posts, hasMore, nil
+}
+
+// ts_headline marks matched words with characters of the private use area, which are removed from the text first.
+// highlightSnippet turns them into <mark> tags once the text is escaped.
+const (
+	snippetStart = "\uE000"
+	snippetStop  = "\uE001"
+)
+
+// searchHeadlineOptions configures the ts_headline snippets returned with search results
+const searchHeadlineOptions = "StartSel=\"" + snippetStart + "\", StopSel=\"" + snippetStop + "\", MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" … \""
+
+// snippetMarkers removes stray markers from text
+var snippetMarkers = strings.NewReplacer(snippetStart, "", snippetStop, "")
 
-	// Base query for active posts with search conditions
-	searchQuery := r.db.Model(&models.Post{}).
-		Where("is_active = ?", true).
-		Where("title ILIKE ? OR content ILIKE ? OR summary ILIKE ?",
-			"%"+query+"%", "%"+query+"%", "%"+query+"%")
+// highlightSnippet turns a headline into HTML: the text is escaped and the matched words are wrapped in <mark> tags.
+// The content the headline is cut from is HTML with its tags stripped, so its entities are decoded first.
+func highlightSnippet(headline string) string {
+	text := func(s string) string {
+		return html.EscapeString(snippetMarkers.Replace(html.UnescapeString(s)))
+	}
+	parts := strings.Split(headline, snippetStart)
+	var b strings.Builder
+	b.WriteString(text(parts[0]))
+	for _, part := range parts[1:] {
+		match, rest, found := strings.Cut(part, snippetStop)
+		if !found {
+			b.WriteString(text(part))
+			continue
+		}
+		b.WriteString("<mark>" + text(match) + "</mark>" + text(rest))
+	}
+	return b.String()
+}
 
-	// Count total matching posts
-	if err := searchQuery.Count(&totalPosts).Error; err != nil {
-		return nil, 0, fmt.Errorf("error counting search results: %w", err)
+// highlightSnippets runs highlightSnippet on the snippets of search results
+func highlightSnippets(results []*models.PostSearchResult) {
+	for _, result := range results {
+		result.Snippet = highlightSnippet(result.Snippet)
 	}
+}
+
+// SearchPosts runs a full-text search over published posts using the generated search_vector column.
+// The query accepts web search syntax ("quoted phrases", -negation, or) and results are ordered by rank.
+// The total is counted separately with CountSearchResults.
+func (r *postRepository) SearchPosts(query string, page, pageSize int) ([]*models.PostSearchResult, error) {
+	var results []*models.PostSearchResult
 
 	// Calculate offset for pagination
 	offset := (page - 1) * pageSize
 
-	// Get paginated results
-	if err := searchQuery.
-		Order("created_at desc").
+	// Get ranked results with highlighted snippets
+	err := r.searchResults(query).
+		Order("score DESC, posts.published_at DESC, posts.id DESC").
 		Limit(pageSize).
 		Offset(offset).
-		Find(&posts).Error; err != nil {
-		return nil, 0, fmt.Errorf("error fetching search results: %w", err)
+		Scan(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("error fetching search results: %w", err)
 	}
+	highlightSnippets(results)
 
-	return posts, totalPosts, nil
+	return results, nil
+}
+
+// searchQuery is the base query for published posts matching the parsed tsquery, which is available as q.
+// Only public posts are searched: the index covers the content of protected posts, so matches and snippets would leak it.
+func (r *postRepository) searchQuery(query string) *gorm.DB {
+	return r.db.Table("posts, websearch_to_tsquery(?, ?) AS q", models.PostSearchConfig, query).
+		Scopes(models.PublishedPosts).
+		Where("posts.visibility = ?", models.PostVisibilityPublic).
+		Where("posts.search_vector @@ q")
+}
+
+// searchResults selects the matching posts with their rank and headline, see highlightSnippet.
+// HTML tags and the snippet markers are stripped before building the headline.
+func (r *postRepository) searchResults(query string) *gorm.DB {
+	return r.searchQuery(query).
+		Select(
+			"posts.*, ts_rank(posts.search_vector, q) AS score, "+
+				"ts_headline(?, translate(regexp_replace(coalesce(posts.summary, '') || ' ' || posts.content, '<[^>]+>', ' ', 'g'), ?, ''), q, ?) AS snippet",
+			models.PostSearchConfig, snippetStart+snippetStop, searchHeadlineOptions,
+		)
+}
+
+// CountSearchResults returns the number of published posts matching the search query
+func (r *postRepository) CountSearchResults(query string) (int64, error) {
+	var total int64
+	if err := r.searchQuery(query).Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("error counting search results: %w", err)
+	}
+	return total, nil
+}
+
+// searchKeyset orders search results by rank, then newest first like postKeyset
+var searchKeyset = keyset{"ts_rank(posts.search_vector, q)", "posts.published_at", "posts.id"}
+
+// SearchByCursor returns up to limit search results after the cursor position, or before it for backwards cursors
+func (r *postRepository) SearchByCursor(query string, cursor *Cursor, limit int) ([]*models.PostSearchResult, bool, error) {
+	var values []interface{}
+	if cursor != nil {
+		values = []interface{}{cursor.Score, cursor.PublishedAt, cursor.ID}
+	}
+
+	var results []*models.PostSearchResult
+	if err := searchKeyset.page(r.searchResults(query), cursor, values, limit).Scan(&results).Error; err != nil {
+		return nil, false, fmt.Errorf("error fetching search results by cursor: %w", err)
+	}
+	highlightSnippets(results)
+	results, hasMore := trimPage(results, cursor, limit)
+	return results, hasMore, nil
 }
 
 // FindRelated finds posts related to the given postID based on category and tags.
+// Posts sharing the category and at least one tag come first, then posts in the same category,
+// then posts sharing tags (more shared tags first); ties are broken by creation date.
 func (r *postRepository) FindRelated(postID uint, limit int) ([]*models.Post, error) {
 	var currentPost models.Post
-	// First, get the category and tags of the current post
-	if err := r.db.Select("category", "tags").First(&currentPost, postID).Error; err != nil {
+	// First, get the category of the current post
+	if err := r.db.Select("id", "category_id").First(&currentPost, postID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			r.logger.Warnf("FindRelated: Current post with ID %d not found", postID)
 			return nil, myerr.WithHTTPStatus(fmt.Errorf("current post not found: %w", err), http.StatusNotFound)
@@ -189,80 +466,30 @@ func (r *postRepository) FindRelated(postID uint, limit int) ([]*models.Post, er
 		return nil, myerr.WithHTTPStatus(fmt.Errorf("error fetching current post: %w", err), http.StatusInternalServerError)
 	}
 
-	var relatedPosts []*models.Post
-	query := r.db.Model(&models.Post{}).
-		Select("id", "title", "summary", "image_url", "read_time", "like_count", "is_active", "created_at", "category", "tags"). // Select necessary fields
-		Where("id <> ?", postID).                                                                                                // Exclude the current post
-		Where("is_active = ?", true)
-
-	// Build ORDER BY clause parts using CASE statement for relevance
-	// var orderClauses []string
-	var args []interface{}
-
-	hasCategoryCondition := currentPost.Category != ""
-	hasTagsCondition := currentPost.Tags != ""
-	tags := splitTags(currentPost.Tags) // Use helper function
-
-	// Build CASE statement for ordering
-	caseStatement := "CASE "
-	priority := 1 // Lower number means higher priority
-
-	// Priority 1: Category AND Tags match (requires robust tag matching)
-	if hasCategoryCondition && hasTagsCondition && len(tags) > 0 {
-		// Build tag matching condition (example using simple LIKE, adjust as needed)
-		tagLikes := make([]string, len(tags))
-		tagArgs := make([]interface{}, len(tags))
-		for i, tag := range tags {
-			// Assuming tags are comma-separated, check if the tag exists within the string
-			// This is basic, consider full-text search or array types in Postgres for better performance/accuracy
-			tagLikes[i] = "tags LIKE ?"
-			tagArgs[i] = "%" + tag + "%"
-		}
-		tagCondition := "(" + strings.Join(tagLikes, " OR ") + ")" // Match any tag
+	// Number of tags each post shares with the current post
+	sharedTags := r.db.Table("post_tags AS pt").
+		Select("pt.post_id, COUNT(*) AS shared").
+		Joins("JOIN post_tags AS cur ON cur.tag_id = pt.tag_id AND cur.post_id = ?", postID).
+		Group("pt.post_id")
 
-		caseStatement += fmt.Sprintf("WHEN category = ? AND %s THEN %d ", tagCondition, priority)
-		args = append(args, currentPost.Category)
-		args = append(args, tagArgs...)
-		priority++
+	var categoryID uint
+	if currentPost.CategoryID != nil {
+		categoryID = *currentPost.CategoryID
 	}
 
-	// Priority 2: Category Only matches
-	if hasCategoryCondition {
-		caseStatement += fmt.Sprintf("WHEN category = ? THEN %d ", priority)
-		args = append(args, currentPost.Category)
-		priority++
-	}
-
-	// Priority 3: Tags Only match (requires robust tag matching)
-	if hasTagsCondition && len(tags) > 0 {
-		// Reuse tag matching condition from Priority 1 if built
-		tagLikes := make([]string, len(tags))
-		tagArgs := make([]interface{}, len(tags))
-		for i, tag := range tags {
-			tagLikes[i] = "tags LIKE ?"
-			tagArgs[i] = "%" + tag + "%"
-		}
-		tagCondition := "(" + strings.Join(tagLikes, " OR ") + ")"
-
-		caseStatement += fmt.Sprintf("WHEN %s THEN %d ", tagCondition, priority)
-		args = append(args, tagArgs...)
-		priority++
-	}
-
-	// Default priority (lower relevance)
-	caseStatement += fmt.Sprintf("ELSE %d END", priority)
-
-	// Final Order Clause: Order by relevance (CASE statement), then by creation date
-	finalOrderClause := gorm.Expr(caseStatement+", created_at DESC", args...)
-
-	// Log the generated clause and arguments for debugging
-	r.logger.WithFields(logrus.Fields{
-		"case_statement": caseStatement,
-		"arguments":      args,
-	}).Debug("FindRelated: Generated ORDER BY CASE statement")
-
-	// Apply conditions and limit
-	if err := query.Order(finalOrderClause).Limit(limit).Find(&relatedPosts).Error; err != nil {
+	var relatedPosts []*models.Post
+	err := r.db.Model(&models.Post{}).
+		Select("posts.id", "posts.title", "posts.slug", "posts.summary", "posts.image_url", "posts.read_time", "posts.like_count", "posts.is_active", "posts.created_at", "posts.category", "posts.tags", "posts.status", "posts.published_at", "posts.category_id", "posts.visibility"). // Select necessary fields
+		Joins("LEFT JOIN (?) AS st ON st.post_id = posts.id", sharedTags).
+		Where("posts.id <> ?", postID). // Exclude the current post
+		Scopes(models.ListedPosts).
+		Order(gorm.Expr(
+			"(posts.category_id = ? AND st.shared IS NOT NULL) DESC, (posts.category_id = ?) DESC, COALESCE(st.shared, 0) DESC, posts.created_at DESC",
+			categoryID, categoryID,
+		)).
+		Limit(limit).
+		Find(&relatedPosts).Error
+	if err != nil {
 		r.logger.WithError(err).Errorf("FindRelated: Error fetching related posts for ID %d", postID)
 		// Wrap the error with a 500 status code
 		return nil, myerr.WithHTTPStatus(fmt.Errorf("error fetching related posts: %w", err), http.StatusInternalServerError)
@@ -272,22 +499,6 @@ func (r *postRepository) FindRelated(postID uint, limit int) ([]*models.Post, er
 	return relatedPosts, nil
 }
 
-// Helper function to split tags (simple comma split and trim)
-func splitTags(tags string) []string {
-	if tags == "" {
-		return []string{}
-	}
-	split := strings.Split(tags, ",")
-	result := make([]string, 0, len(split))
-	for _, tag := range split {
-		trimmed := strings.TrimSpace(tag)
-		if trimmed != "" {
-			result = append(result, trimmed)
-		}
-	}
-	return result
-}
-
 func (r *postRepository) DeletePostPermanently(id uint) error {
 	log := r.logger.WithField("post_id", id)
 	log.Info("Attempting to permanently delete post")
@@ -335,6 +546,34 @@ func (r *postRepository) DeletePostPermanently(id uint) error {
 			return myerr.WithHTTPStatus(fmt.Errorf("error deleting post stats: %w", err), http.StatusInternalServerError)
 		}
 
+		// Delete slug history
+		log.Info("Deleting related slug history")
+		if err := tx.Where("post_id = ?", id).Delete(&models.PostSlugHistory{}).Error; err != nil {
+			log.WithError(err).Error("Error deleting slug history")
+			return myerr.WithHTTPStatus(fmt.Errorf("error deleting slug history: %w", err), http.StatusInternalServerError)
+		}
+
+		// Delete revisions
+		log.Info("Deleting related post revisions")
+		if err := tx.Where("post_id = ?", id).Delete(&models.PostRevision{}).Error; err != nil {
+			log.WithError(err).Error("Error deleting post revisions")
+			return myerr.WithHTTPStatus(fmt.Errorf("error deleting post revisions: %w", err), http.StatusInternalServerError)
+		}
+
+		// Delete tag links
+		log.Info("Deleting related post tags")
+		if err := tx.Where("post_id = ?", id).Delete(&models.PostTag{}).Error; err != nil {
+			log.WithError(err).Error("Error deleting post tags")
+			return myerr.WithHTTPStatus(fmt.Errorf("error deleting post tags: %w", err), http.StatusInternalServerError)
+		}
+
+		// Remove the post from its series
+		log.Info("Deleting related series membership")
+		if err := tx.Where("post_id = ?", id).Delete(&models.SeriesPost{}).Error; err != nil {
+			log.WithError(err).Error("Error deleting series membership")
+			return myerr.WithHTTPStatus(fmt.Errorf("error deleting series membership: %w", err), http.StatusInternalServerError)
+		}
+
 		// Delete related comments
 		log.Info("Deleting related post comments")
 		if err := tx.Unscoped().Where("post_id = ?", id).Delete(&models.Comment{}).Error; err != nil {
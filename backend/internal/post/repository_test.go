@@ -0,0 +1,89 @@
+package post
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/dbtest"
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sirupsen/logrus"
+)
+
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+func TestPublicListingsLeaveOutUnlistedPosts(t *testing.T) {
+	db, recorder := dbtest.DryRun(t)
+	repo := NewPostRepository(db, newTestLogger())
+
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{name: "FindAll", run: func() error { _, err := repo.FindAll(); return err }},
+		{name: "FindPaginated", run: func() error { _, err := repo.FindPaginated(1, 10); return err }},
+		{name: "CountPublished", run: func() error { _, err := repo.CountPublished(); return err }},
+		{name: "FindByCursor", run: func() error { _, _, err := repo.FindByCursor(nil, 10); return err }},
+		{name: "FindForFeed", run: func() error { _, err := repo.FindForFeed(FeedFilter{Limit: 10}); return err }},
+		{name: "FindForFeed by tag", run: func() error { _, err := repo.FindForFeed(FeedFilter{TagSlug: "go", Limit: 10}); return err }},
+		{name: "SearchPosts", run: func() error { _, err := repo.SearchPosts("go", 1, 10); return err }},
+		{name: "CountSearchResults", run: func() error { _, err := repo.CountSearchResults("go"); return err }},
+		{name: "SearchByCursor", run: func() error { _, _, err := repo.SearchByCursor("go", nil, 10); return err }},
+		{name: "FindRelated", run: func() error { _, err := repo.FindRelated(1, 3); return err }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.Reset()
+			if err := tt.run(); err != nil && !dbtest.Unsupported(err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			dbtest.ExpectListedPostsOnly(t, recorder)
+		})
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	tests := []struct {
+		name, headline, want string
+	}{
+		{"plain", "learning " + snippetStart + "Go" + snippetStop + " today", "learning <mark>Go</mark> today"},
+		{"entities decoded then escaped", "Tom &amp; " + snippetStart + "Jerry" + snippetStop, "Tom &amp; <mark>Jerry</mark>"},
+		{"encoded tag", "&lt;script&gt;alert(1)&lt;/script&gt; " + snippetStart + "go" + snippetStop, "&lt;script&gt;alert(1)&lt;/script&gt; <mark>go</mark>"},
+		{"tag left by a broken strip", `<img src=x onerror=alert(1)> ` + snippetStart + "go" + snippetStop, "&lt;img src=x onerror=alert(1)&gt; <mark>go</mark>"},
+		{"fake mark", "&lt;mark&gt;go&lt;/mark&gt;", "&lt;mark&gt;go&lt;/mark&gt;"},
+		{"encoded marker", "&#xE000;go&#xE001;", "go"},
+		{"unclosed marker", snippetStart + "go <b>", "go &lt;b&gt;"},
+		{"stray stop marker", "go" + snippetStop + " & co", "go &amp; co"},
+		{"several fragments", snippetStart + "a" + snippetStop + " … " + snippetStart + "b" + snippetStop, "<mark>a</mark> … <mark>b</mark>"},
+	}
+	for _, tt := range tests {
+		if got := highlightSnippet(tt.headline); got != tt.want {
+			t.Errorf("%s: highlightSnippet(%q) = %q, want %q", tt.name, tt.headline, got, tt.want)
+		}
+	}
+}
+
+func TestSlugConflict(t *testing.T) {
+	violation := func(constraint string) error {
+		return fmt.Errorf("error while updating post: %w", &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: constraint})
+	}
+
+	for _, constraint := range []string{"idx_posts_slug", "idx_post_slug_histories_slug"} {
+		err := slugConflict(violation(constraint))
+		if !errors.Is(err, ErrSlugTaken) || myerr.HTTPStatus(err) != http.StatusConflict {
+			t.Errorf("violation of %s = %v (status %d), want ErrSlugTaken with 409", constraint, err, myerr.HTTPStatus(err))
+		}
+	}
+	for _, err := range []error{violation("post_tags_pkey"), errors.New("connection lost"), nil} {
+		if got := slugConflict(err); got != err {
+			t.Errorf("slugConflict(%v) = %v, want it unchanged", err, got)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package post
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+// ListRevisions godoc
+// @Summary List revisions of a post
+// @Description Lists the saved revisions of a post (without content), newest first
+// @Tags Post Revisions
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {array} dto.PostRevisionResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid post ID"
+// @Failure 404 {object} models.ErrorResponse "Post not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/posts/{id}/revisions [get]
+func (h *PostHandler) ListRevisions(c *gin.Context) {
+	postID, ok := parseIDParam(c, "id", "invalid post ID")
+	if !ok {
+		return
+	}
+
+	revisions, err := h.service.ListRevisions(postID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetRevision godoc
+// @Summary Get a post revision
+// @Description Fetch a single revision of a post including its content
+// @Tags Post Revisions
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param revision_id path int true "Revision ID"
+// @Success 200 {object} dto.PostRevisionResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 404 {object} models.ErrorResponse "Revision not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/posts/{id}/revisions/{revision_id} [get]
+func (h *PostHandler) GetRevision(c *gin.Context) {
+	postID, ok := parseIDParam(c, "id", "invalid post ID")
+	if !ok {
+		return
+	}
+	revisionID, ok := parseIDParam(c, "revision_id", "invalid revision ID")
+	if !ok {
+		return
+	}
+
+	revision, err := h.service.GetRevision(postID, revisionID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, revision)
+}
+
+// DiffRevisions godoc
+// @Summary Diff two post revisions
+// @Description Returns a unified diff between two revisions. "current" (or omitting the parameter) refers to the live post.
+// @Tags Post Revisions
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param from query string true "Revision ID to diff from, or 'current'"
+// @Param to query string false "Revision ID to diff to, or 'current' (default)"
+// @Success 200 {object} dto.PostRevisionDiffResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid parameters"
+// @Failure 404 {object} models.ErrorResponse "Post or revision not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/posts/{id}/revisions/diff [get]
+func (h *PostHandler) DiffRevisions(c *gin.Context) {
+	postID, ok := parseIDParam(c, "id", "invalid post ID")
+	if !ok {
+		return
+	}
+
+	fromID, err := parseRevisionRef(c.Query("from"))
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid 'from' revision"), http.StatusBadRequest))
+		return
+	}
+	toID, err := parseRevisionRef(c.DefaultQuery("to", "current"))
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid 'to' revision"), http.StatusBadRequest))
+		return
+	}
+
+	result, err := h.service.DiffRevisions(postID, fromID, toID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// RestoreRevision godoc
+// @Summary Restore a post revision
+// @Description Replaces the current content of the post with the revision. The replaced content is saved as a new revision.
+// @Tags Post Revisions
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param revision_id path int true "Revision ID"
+// @Success 200 {object} models.Post
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 404 {object} models.ErrorResponse "Post or revision not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/posts/{id}/revisions/{revision_id}/restore [post]
+func (h *PostHandler) RestoreRevision(c *gin.Context) {
+	postID, ok := parseIDParam(c, "id", "invalid post ID")
+	if !ok {
+		return
+	}
+	revisionID, ok := parseIDParam(c, "revision_id", "invalid revision ID")
+	if !ok {
+		return
+	}
+
+	post, err := h.service.RestoreRevision(postID, revisionID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, post)
+}
+
+// parseIDParam parses a numeric path parameter and reports a 400 error when it is invalid.
+func parseIDParam(c *gin.Context, name, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(errors.New(message), http.StatusBadRequest))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parseRevisionRef parses a revision ID; "current" maps to 0, the live version of the post.
+func parseRevisionRef(value string) (uint, error) {
+	if value == "current" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || id == 0 {
+		return 0, errors.New("invalid revision")
+	}
+	return uint(id), nil
+}
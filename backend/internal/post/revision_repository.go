@@ -0,0 +1,86 @@
+package post
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ErrRevisionNotFound is returned when a revision does not exist or belongs to another post
+var ErrRevisionNotFound = errors.New("revision not found")
+
+type RevisionRepository interface {
+	FindByPostID(postID uint) ([]models.PostRevision, error)
+	FindByID(postID, revisionID uint) (*models.PostRevision, error)
+	Prune(postID uint, keepLast int, olderThan time.Time) (int64, error)
+}
+
+type revisionRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewRevisionRepository(db *gorm.DB, logger *logrus.Logger) RevisionRepository {
+	return &revisionRepository{db: db, logger: logger}
+}
+
+// FindByPostID returns the revisions of a post, newest first, without their content.
+func (r *revisionRepository) FindByPostID(postID uint) ([]models.PostRevision, error) {
+	var revisions []models.PostRevision
+	err := r.db.Select("id", "created_at", "post_id", "title", "summary", "image_url", "category", "tags", "content_format").
+		Where("post_id = ?", postID).
+		Order("created_at DESC, id DESC").
+		Find(&revisions).Error
+	if err != nil {
+		r.logger.WithError(err).WithField("post_id", postID).Error("Repository: Failed to fetch post revisions")
+		return nil, fmt.Errorf("failed to fetch post revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+func (r *revisionRepository) FindByID(postID, revisionID uint) (*models.PostRevision, error) {
+	var revision models.PostRevision
+	if err := r.db.Where("post_id = ?", postID).First(&revision, revisionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, myerr.WithHTTPStatus(ErrRevisionNotFound, http.StatusNotFound)
+		}
+		r.logger.WithError(err).WithField("revision_id", revisionID).Error("Repository: Failed to fetch post revision")
+		return nil, myerr.WithHTTPStatus(fmt.Errorf("failed to fetch post revision: %w", err), http.StatusInternalServerError)
+	}
+	return &revision, nil
+}
+
+// Prune deletes revisions of a post beyond the newest keepLast ones and revisions created before olderThan.
+// A keepLast of 0 or a zero olderThan disables the respective rule.
+func (r *revisionRepository) Prune(postID uint, keepLast int, olderThan time.Time) (int64, error) {
+	var deleted int64
+
+	if keepLast > 0 {
+		keep := r.db.Model(&models.PostRevision{}).
+			Select("id").
+			Where("post_id = ?", postID).
+			Order("created_at DESC, id DESC").
+			Limit(keepLast)
+		result := r.db.Where("post_id = ? AND id NOT IN (?)", postID, keep).Delete(&models.PostRevision{})
+		if result.Error != nil {
+			return deleted, fmt.Errorf("failed to prune post revisions: %w", result.Error)
+		}
+		deleted += result.RowsAffected
+	}
+
+	if !olderThan.IsZero() {
+		result := r.db.Where("post_id = ? AND created_at < ?", postID, olderThan).Delete(&models.PostRevision{})
+		if result.Error != nil {
+			return deleted, fmt.Errorf("failed to prune post revisions: %w", result.Error)
+		}
+		deleted += result.RowsAffected
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,168 @@
+package post
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/diff"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// RevisionPolicy controls how many revisions are kept per post. Zero values disable a limit.
+type RevisionPolicy struct {
+	KeepLast int // Keep only the newest N revisions
+	KeepDays int // Drop revisions older than N days
+}
+
+// blockEndRegex matches closing block tags; the diff breaks lines after them so single-line HTML diffs per paragraph.
+var blockEndRegex = regexp.MustCompile(`(?i)(</(p|h[1-6]|li|ul|ol|pre|blockquote|div|table|tr)>|<br\s*/?>)`)
+
+// newRevision returns the revision that keeps the current state of a post before it is replaced by next,
+// nil when the update doesn't touch any versioned field.
+func newRevision(current, next *models.Post) *models.PostRevision {
+	if current.Title == next.Title && current.Summary == next.Summary && current.Content == next.Content &&
+		current.ImageURL == next.ImageURL && current.Category == next.Category && current.Tags == next.Tags &&
+		current.ContentFormat == next.ContentFormat {
+		return nil
+	}
+	return &models.PostRevision{
+		PostID:        current.ID,
+		Title:         current.Title,
+		Summary:       current.Summary,
+		Content:       current.Content,
+		ImageURL:      current.ImageURL,
+		Category:      current.Category,
+		Tags:          current.Tags,
+		ContentFormat: current.ContentFormat,
+	}
+}
+
+// pruneRevisions applies the retention policy after a revision was saved. A failure here must not fail the update.
+func (s *PostService) pruneRevisions(revision *models.PostRevision) {
+	var olderThan time.Time
+	if s.revisionPolicy.KeepDays > 0 {
+		olderThan = time.Now().AddDate(0, 0, -s.revisionPolicy.KeepDays)
+	}
+	pruned, err := s.revisionRepo.Prune(revision.PostID, s.revisionPolicy.KeepLast, olderThan)
+	log := s.logger.WithFields(logrus.Fields{"post_id": revision.PostID, "revision_id": revision.ID})
+	if err != nil {
+		log.WithError(err).Warn("Service: Failed to prune old post revisions")
+	} else if pruned > 0 {
+		log.WithField("pruned", pruned).Info("Service: Pruned old post revisions")
+	}
+}
+
+// ListRevisions returns the revisions of a post without their content, newest first.
+func (s *PostService) ListRevisions(postID uint) ([]dto.PostRevisionResponse, error) {
+	if _, err := s.findPostForAdmin(postID); err != nil {
+		return nil, err
+	}
+	revisions, err := s.revisionRepo.FindByPostID(postID)
+	if err != nil {
+		return nil, myerr.WithHTTPStatus(err, http.StatusInternalServerError)
+	}
+	return dto.ToPostRevisionResponses(revisions), nil
+}
+
+// GetRevision returns a single revision including its content.
+func (s *PostService) GetRevision(postID, revisionID uint) (*dto.PostRevisionResponse, error) {
+	revision, err := s.revisionRepo.FindByID(postID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	response := dto.ToPostRevisionResponse(revision)
+	return &response, nil
+}
+
+// DiffRevisions returns a unified diff between two revisions of a post.
+// A revision ID of 0 stands for the current version of the post.
+func (s *PostService) DiffRevisions(postID, fromID, toID uint) (*dto.PostRevisionDiffResponse, error) {
+	post, err := s.findPostForAdmin(postID)
+	if err != nil {
+		return nil, err
+	}
+
+	fromName, fromText, err := s.revisionText(post, fromID)
+	if err != nil {
+		return nil, err
+	}
+	toName, toText, err := s.revisionText(post, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PostRevisionDiffResponse{
+		PostID: postID,
+		FromID: fromID,
+		ToID:   toID,
+		Diff:   diff.Unified(fromName, toName, fromText, toText, diff.DefaultContext),
+	}, nil
+}
+
+// RestoreRevision makes the given revision the current content of the post.
+// The content being replaced is itself saved as a revision by UpdatePost, so a restore can be undone.
+func (s *PostService) RestoreRevision(postID, revisionID uint) (*models.Post, error) {
+	post, err := s.findPostForAdmin(postID)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := s.revisionRepo.FindByID(postID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	restored := *post
+	restored.Slug = "" // Let UpdatePost keep or regenerate the slug for the restored title
+	restored.Title = revision.Title
+	restored.Summary = revision.Summary
+	restored.Content = revision.Content
+	restored.ImageURL = revision.ImageURL
+	restored.Category = revision.Category
+	restored.Tags = revision.Tags
+	restored.ContentFormat = revision.ContentFormat
+
+	if err := s.UpdatePost(&restored); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{"post_id": postID, "revision_id": revisionID}).Info("Service: Post revision restored")
+	return s.postRepo.FindByIDAdmin(postID)
+}
+
+func (s *PostService) findPostForAdmin(postID uint) (*models.Post, error) {
+	post, err := s.postRepo.FindByIDAdmin(postID)
+	if err != nil {
+		return nil, myerr.WithHTTPStatus(ErrPostNotFound, http.StatusNotFound)
+	}
+	return post, nil
+}
+
+// revisionText renders a revision (or the current post for ID 0) as plain text for diffing.
+func (s *PostService) revisionText(post *models.Post, revisionID uint) (name, text string, err error) {
+	if revisionID == 0 {
+		return "current", formatForDiff(post.Title, post.Summary, post.ImageURL, post.Category, post.Tags, post.Content), nil
+	}
+	revision, err := s.revisionRepo.FindByID(post.ID, revisionID)
+	if err != nil {
+		if errors.Is(err, ErrRevisionNotFound) {
+			return "", "", myerr.WithHTTPStatus(fmt.Errorf("revision %d: %w", revisionID, ErrRevisionNotFound), http.StatusNotFound)
+		}
+		return "", "", err
+	}
+	name = fmt.Sprintf("revision %d (%s)", revision.ID, revision.CreatedAt.Format(time.RFC3339))
+	return name, formatForDiff(revision.Title, revision.Summary, revision.ImageURL, revision.Category, revision.Tags, revision.Content), nil
+}
+
+func formatForDiff(title, summary, imageURL, category, tags, content string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Title: %s\nSummary: %s\nImage: %s\nCategory: %s\nTags: %s\n\n", title, summary, imageURL, category, tags)
+	b.WriteString(blockEndRegex.ReplaceAllString(content, "$1\n"))
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package post
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// updateRepository records the revisions passed along with updates
+type updateRepository struct {
+	PostRepository
+	current   models.Post
+	err       error
+	revisions []*models.PostRevision
+}
+
+func (r *updateRepository) FindByIDAdmin(id uint) (*models.Post, error) {
+	post := r.current
+	return &post, nil
+}
+
+func (r *updateRepository) Update(post *models.Post, revision *models.PostRevision) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.revisions = append(r.revisions, revision)
+	return nil
+}
+
+// pruneCounter counts retention runs
+type pruneCounter struct {
+	RevisionRepository
+	prunes int
+}
+
+func (r *pruneCounter) Prune(postID uint, keepLast int, olderThan time.Time) (int64, error) {
+	r.prunes++
+	return 0, nil
+}
+
+func TestUpdateSavesRevisionWithUpdate(t *testing.T) {
+	published := time.Now().Add(-time.Hour)
+	current := models.Post{
+		ID: 4, Title: "Hello", Slug: "hello", Content: "<p>old</p>", ContentFormat: models.ContentFormatHTML,
+		Status: models.PostStatusPublished, IsActive: true, PublishedAt: &published,
+		Visibility: models.PostVisibilityPublic, CommentMode: models.CommentModeOpen, Language: "en",
+	}
+	update := func(repo *updateRepository, revisions *pruneCounter, content string) error {
+		s := NewPostService(repo, nil, revisions, RevisionPolicy{KeepLast: 10}, FeedSettings{}, ReadingSettings{}, AccessSettings{}, CommentPolicy{}, "", newTestLogger())
+		return s.UpdatePost(&models.Post{ID: 4, Title: "Hello", Content: content, IsActive: true})
+	}
+
+	repo, revisions := &updateRepository{current: current}, &pruneCounter{}
+	if err := update(repo, revisions, "<p>new</p>"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.revisions) != 1 || repo.revisions[0] == nil || repo.revisions[0].Content != "<p>old</p>" {
+		t.Fatalf("update didn't carry a revision of the previous content: %+v", repo.revisions)
+	}
+	if revisions.prunes != 1 {
+		t.Errorf("revisions pruned %d times, want once", revisions.prunes)
+	}
+
+	repo, revisions = &updateRepository{current: current}, &pruneCounter{}
+	if err := update(repo, revisions, "<p>old</p>"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.revisions) != 1 || repo.revisions[0] != nil || revisions.prunes != 0 {
+		t.Errorf("unchanged post got a revision: %+v", repo.revisions)
+	}
+
+	repo, revisions = &updateRepository{current: current, err: errors.New("connection lost")}, &pruneCounter{}
+	if err := update(repo, revisions, "<p>new</p>"); err == nil {
+		t.Fatal("failed update reported success")
+	}
+	if revisions.prunes != 0 {
+		t.Error("revisions pruned after a failed update")
+	}
+}
+
+// Two saves can both find a slug free; the one losing the race on the unique index gets a 409, not a 500
+func TestUpdateReportsSlugRaceAsConflict(t *testing.T) {
+	published := time.Now().Add(-time.Hour)
+	repo := &updateRepository{
+		current: models.Post{ID: 4, Title: "Hello", Slug: "hello", Content: "<p>old</p>", Status: models.PostStatusPublished,
+			IsActive: true, PublishedAt: &published, Visibility: models.PostVisibilityPublic},
+		err: slugConflict(fmt.Errorf("error while updating post: %w", &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "idx_posts_slug"})),
+	}
+	s := NewPostService(repo, nil, &pruneCounter{}, RevisionPolicy{}, FeedSettings{}, ReadingSettings{}, AccessSettings{}, CommentPolicy{}, "", newTestLogger())
+
+	err := s.UpdatePost(&models.Post{ID: 4, Title: "Hello", Content: "<p>new</p>", IsActive: true})
+	if !errors.Is(err, ErrSlugTaken) || myerr.HTTPStatus(err) != http.StatusConflict {
+		t.Errorf("UpdatePost = %v (status %d), want ErrSlugTaken with 409", err, myerr.HTTPStatus(err))
+	}
+}
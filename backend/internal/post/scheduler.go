@@ -0,0 +1,116 @@
+package post
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// PublishListener is called for every post the scheduler publishes.
+type PublishListener func(post *models.Post)
+
+// Scheduler periodically publishes scheduled posts whose publish time has passed.
+type Scheduler struct {
+	repo      PostRepository
+	interval  time.Duration
+	logger    *logrus.Logger
+	listeners []PublishListener
+	mu        sync.Mutex
+	stop      chan struct{}
+	done      chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+func NewScheduler(repo PostRepository, interval time.Duration, logger *logrus.Logger) *Scheduler {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &Scheduler{
+		repo:     repo,
+		interval: interval,
+		logger:   logger,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// OnPublish registers a listener that is notified when a scheduled post goes live.
+func (s *Scheduler) OnPublish(listener PublishListener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+// Start runs the scheduler loop in a goroutine. Due posts are published immediately and then every interval.
+// Only the first call starts it, and a stopped scheduler doesn't start again.
+func (s *Scheduler) Start() {
+	s.startOnce.Do(s.start)
+}
+
+func (s *Scheduler) start() {
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		s.publishDue()
+		for {
+			select {
+			case <-ticker.C:
+				s.publishDue()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+	s.logger.WithField("interval", s.interval.String()).Info("Post scheduler started")
+}
+
+func (s *Scheduler) publishDue() {
+	posts, err := s.repo.PublishDue(time.Now())
+	if err != nil {
+		s.logger.WithError(err).Error("Post scheduler: Failed to publish scheduled posts")
+		return
+	}
+
+	s.mu.Lock()
+	listeners := append([]PublishListener(nil), s.listeners...)
+	s.mu.Unlock()
+
+	for _, post := range posts {
+		s.logger.WithFields(logrus.Fields{
+			"event":        "post_published",
+			"post_id":      post.ID,
+			"slug":         post.Slug,
+			"published_at": post.PublishedAt,
+		}).Info("Post scheduler: Scheduled post published")
+
+		for _, listener := range listeners {
+			listener(post)
+		}
+	}
+}
+
+// Shutdown stops the scheduler loop and waits for a running publish pass to finish. It can be called
+// more than once, and also if the scheduler was never started.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		s.startOnce.Do(func() { close(s.done) })
+	})
+
+	select {
+	case <-s.done:
+		s.logger.Info("Post scheduler shutdown completed")
+		return nil
+	case <-ctx.Done():
+		return myerr.WithHTTPStatus(ctx.Err(), http.StatusInternalServerError)
+	}
+}
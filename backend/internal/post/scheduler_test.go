@@ -0,0 +1,55 @@
+package post
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+)
+
+// dueRepository publishes nothing; any other repository call panics
+type dueRepository struct {
+	PostRepository
+	calls chan struct{}
+}
+
+func (r *dueRepository) PublishDue(time.Time) ([]*models.Post, error) {
+	r.calls <- struct{}{}
+	return nil, nil
+}
+
+func TestSchedulerShutdown(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	t.Run("never started", func(t *testing.T) {
+		s := NewScheduler(&dueRepository{calls: make(chan struct{}, 1)}, time.Hour, newTestLogger())
+		if err := s.Shutdown(ctx); err != nil {
+			t.Fatalf("shutdown of a scheduler that never started: %v", err)
+		}
+		if err := s.Shutdown(ctx); err != nil {
+			t.Fatalf("second shutdown: %v", err)
+		}
+	})
+
+	t.Run("started", func(t *testing.T) {
+		repo := &dueRepository{calls: make(chan struct{}, 1)}
+		s := NewScheduler(repo, time.Hour, newTestLogger())
+		s.Start()
+		s.Start()
+		<-repo.calls // The first pass runs right away
+		if err := s.Shutdown(ctx); err != nil {
+			t.Fatalf("shutdown: %v", err)
+		}
+		if err := s.Shutdown(ctx); err != nil {
+			t.Fatalf("second shutdown: %v", err)
+		}
+		s.Start() // A stopped scheduler stays stopped
+		select {
+		case <-repo.calls:
+			t.Error("scheduler ran again after shutdown")
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
@@ -9,17 +9,73 @@ import (
 	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg" // Import custom error package
 	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
 	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/render"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/slug"
+	"github.com/sirupsen/logrus"
 )
 
+// ErrSlugTaken is returned when an admin chooses a slug that another post already uses
+var ErrSlugTaken = errors.New("slug is already used by another post")
+
+// ChangeListener is called after a post has been created, updated or deleted.
+type ChangeListener func(postID uint)
+
+// ReadingSettings configures how posts are analyzed for their word count and reading time
+type ReadingSettings struct {
+	DefaultLanguage string // Language of posts created without one, selects the words per minute
+}
+
+// CommentPolicy is the site-wide comment policy, reported with each post so the frontend can hide the comment form
+type CommentPolicy struct {
+	CloseAfterDays int // Comments close this many days after publication; 0 keeps them open
+}
+
+// SeriesLookup finds the series a post belongs to with its published parts, see series.SeriesRepository
+type SeriesLookup interface {
+	FindPostSeries(postID uint) (*models.Series, []models.SeriesPart, error)
+}
+
 type PostService struct {
-	postRepo PostRepository
-	baseURL  string
+	postRepo       PostRepository
+	series         SeriesLookup
+	revisionRepo   RevisionRepository
+	revisionPolicy RevisionPolicy
+	feedSettings   FeedSettings
+	reading        ReadingSettings
+	access         AccessSettings
+	comments       CommentPolicy
+	baseURL        string
+	logger         *logrus.Logger
+	listeners      []ChangeListener
+	counts         *countCache
 }
 
-func NewPostService(postRepo PostRepository, baseURL string) *PostService {
+func NewPostService(postRepo PostRepository, series SeriesLookup, revisionRepo RevisionRepository, revisionPolicy RevisionPolicy, feedSettings FeedSettings, reading ReadingSettings, access AccessSettings, comments CommentPolicy, baseURL string, logger *logrus.Logger) *PostService {
 	return &PostService{
-		postRepo: postRepo,
-		baseURL:  baseURL,
+		postRepo:       postRepo,
+		series:         series,
+		revisionRepo:   revisionRepo,
+		revisionPolicy: revisionPolicy,
+		feedSettings:   feedSettings,
+		reading:        reading,
+		access:         access,
+		comments:       comments,
+		baseURL:        baseURL,
+		logger:         logger,
+		counts:         newCountCache(),
+	}
+}
+
+// OnChange registers a listener for post changes, e.g. to invalidate caches.
+// Listeners must be registered before the service starts serving requests.
+func (s *PostService) OnChange(listener ChangeListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+func (s *PostService) notifyChange(postID uint) {
+	s.counts.clear()
+	for _, listener := range s.listeners {
+		listener(postID)
 	}
 }
 
@@ -35,13 +91,58 @@ func (s *PostService) GetAllAdmin() ([]*models.Post, error) {
 	return s.postRepo.FindAllAdmin()
 }
 
-func (s *PostService) GetPostByID(id uint) (*dto.PostDetailResponse, error) {
+// GetPostByID returns a published post. The content of a password-protected post is withheld
+// unless accessToken was issued for it by UnlockPost.
+func (s *PostService) GetPostByID(id uint, accessToken string) (*dto.PostDetailResponse, error) {
 	post, err := s.postRepo.FindByID(id)
 	if err != nil {
 		return nil, err
 	}
-	response := dto.ToPostDetailResponse(post)
-	return &response, nil
+	return s.toDetail(post, accessToken), nil
+}
+
+// GetPostBySlug returns the post currently using the slug. If the slug belongs to the history
+// of a post, the post is not returned; instead redirectSlug holds its current slug.
+func (s *PostService) GetPostBySlug(postSlug, accessToken string) (response *dto.PostDetailResponse, redirectSlug string, err error) {
+	post, err := s.postRepo.FindBySlug(postSlug)
+	if err == nil {
+		return s.toDetail(post, accessToken), "", nil
+	}
+	if !errors.Is(err, ErrPostNotFound) {
+		return nil, "", err
+	}
+
+	// Not a current slug, check whether it is an old one
+	renamed, err := s.postRepo.FindBySlugHistory(postSlug)
+	if err != nil {
+		return nil, "", err
+	}
+	return nil, renamed.Slug, nil
+}
+
+func (s *PostService) toDetail(post *models.Post, accessToken string) *dto.PostDetailResponse {
+	var detail dto.PostDetailResponse
+	if s.canRead(post, accessToken) {
+		detail = dto.ToPostDetailResponse(post)
+	} else {
+		detail = dto.ToLockedPostDetailResponse(post)
+	}
+	detail.CommentsOpen = post.CommentsOpen(s.comments.CloseAfterDays, time.Now())
+	detail.CommentsCloseAt = post.CommentsCloseAt(s.comments.CloseAfterDays)
+	s.attachSeries(&detail)
+	return &detail
+}
+
+// attachSeries adds the series navigation to a post. The post is still served if the lookup fails.
+func (s *PostService) attachSeries(detail *dto.PostDetailResponse) {
+	series, parts, err := s.series.FindPostSeries(detail.ID)
+	if err != nil {
+		s.logger.WithError(err).WithField("post_id", detail.ID).Warn("Service: Failed to load series of post")
+		return
+	}
+	if series != nil {
+		detail.Series = dto.ToPostSeriesInfo(series, parts, detail.ID)
+	}
 }
 
 func (s *PostService) GetPostByIDAdmin(id uint) (*models.Post, error) {
@@ -61,16 +162,42 @@ func (s *PostService) CreatePost(post *models.Post) error {
 	// 	return errors.New("image URL must be set when image path exists")
 	// }
 
+	if err := applyStatus(post, time.Now()); err != nil {
+		return err
+	}
+	if err := applyVisibility(post); err != nil {
+		return err
+	}
+	if err := applyCommentMode(post); err != nil {
+		return err
+	}
+	if post.Language == "" {
+		post.Language = s.reading.DefaultLanguage
+	}
+	if err := renderContent(post); err != nil {
+		return err
+	}
+
+	postSlug, err := s.resolveSlug(post.Slug, post.Title, 0)
+	if err != nil {
+		return err
+	}
+	post.Slug = postSlug
+
 	post.CreatedAt = time.Now()
 	post.UpdatedAt = time.Now()
 
-	return s.postRepo.Create(post)
+	if err := s.postRepo.Create(post); err != nil {
+		return err
+	}
+	s.notifyChange(post.ID)
+	return nil
 }
 
 func (s *PostService) UpdatePost(post *models.Post) error {
 
 	// Check if post exists before updating
-	_, err := s.postRepo.FindByIDAdmin(post.ID)
+	existingPost, err := s.postRepo.FindByIDAdmin(post.ID)
 	if err != nil {
 		// Check if the error is the specific ErrPostNotFound from the repository
 		if errors.Is(err, ErrPostNotFound) {
@@ -84,18 +211,75 @@ func (s *PostService) UpdatePost(post *models.Post) error {
 		return myerr.WithHTTPStatus(errors.New("title and content cannot be empty"), http.StatusBadRequest) // Return 400
 	}
 
+	// Clients that only know about isActive don't send a status; keep the current one unless isActive was toggled
+	if post.Status == "" && post.IsActive == existingPost.IsActive {
+		post.Status = existingPost.Status
+	}
+	if post.PublishedAt == nil {
+		post.PublishedAt = existingPost.PublishedAt
+	}
+	if err := applyStatus(post, time.Now()); err != nil {
+		return err
+	}
+	if post.Visibility == "" {
+		post.Visibility = existingPost.Visibility
+	}
+	if post.Visibility == existingPost.Visibility && post.PasswordHash == "" {
+		post.PasswordHash = existingPost.PasswordHash // Keep the password unless a new one is given
+	}
+	if err := applyVisibility(post); err != nil {
+		return err
+	}
+	if post.CommentMode == "" {
+		post.CommentMode = existingPost.CommentMode
+	}
+	if err := applyCommentMode(post); err != nil {
+		return err
+	}
+	if post.ContentFormat == "" {
+		post.ContentFormat = existingPost.ContentFormat
+	}
+	if post.Language == "" {
+		post.Language = existingPost.Language
+	}
+	if err := renderContent(post); err != nil {
+		return err
+	}
+
+	// Keep the slug unless the admin overrides it or the title changes.
+	// The repository moves the previous slug into the history so it keeps resolving.
+	switch {
+	case post.Slug != "" && post.Slug != existingPost.Slug:
+		post.Slug, err = s.resolveSlug(post.Slug, post.Title, post.ID)
+	case post.Slug == "" && post.Title != existingPost.Title:
+		post.Slug, err = s.resolveSlug("", post.Title, post.ID)
+	default:
+		post.Slug = existingPost.Slug
+	}
+	if err != nil {
+		return err
+	}
+
 	post.UpdatedAt = time.Now()
 
-	// The repository Update method now handles Category and Tags
-	err = s.postRepo.Update(post)
+	// The repository Update method now handles Category and Tags, and snapshots the current version with the update
+	revision := newRevision(existingPost, post)
+	err = s.postRepo.Update(post, revision)
 	if err != nil {
 		// Check if the update error is ErrPostNotFound (e.g., if row affected was 0 and check failed)
 		if errors.Is(err, ErrPostNotFound) {
 			return myerr.WithHTTPStatus(err, http.StatusNotFound)
 		}
+		if errors.Is(err, ErrSlugTaken) {
+			return err // Already carries 409
+		}
 		// Wrap other update errors
 		return myerr.WithHTTPStatus(fmt.Errorf("failed to update post in repository: %w", err), http.StatusInternalServerError)
 	}
+	if revision != nil {
+		s.pruneRevisions(revision)
+	}
+	s.notifyChange(post.ID)
 	return nil
 }
 
@@ -116,12 +300,14 @@ func (s *PostService) DeletePost(id uint) error {
 	}
 
 	existingPost.IsActive = false
+	existingPost.Status = models.PostStatusArchived
 	existingPost.UpdatedAt = time.Now()
 	// Use a specific method if available, otherwise rely on Update to only change IsActive and UpdatedAt
-	err = s.postRepo.Update(existingPost) // Assuming Update can handle this partial update
+	err = s.postRepo.Update(existingPost, nil) // Assuming Update can handle this partial update
 	if err != nil {
 		return myerr.WithHTTPStatus(fmt.Errorf("failed to soft delete post: %w", err), http.StatusInternalServerError)
 	}
+	s.notifyChange(id)
 	return nil
 }
 
@@ -133,6 +319,7 @@ func (s *PostService) DeletePostPermanently(id uint) error {
 		// and logs details. We just pass the error up.
 		return err // Pass the wrapped error (includes status code)
 	}
+	s.notifyChange(id)
 	return nil
 }
 
@@ -144,7 +331,11 @@ func (s *PostService) GetPaginatedPosts(page, pageSize int) (*dto.PaginatedPostR
 		pageSize = 9 // Default page size
 	}
 
-	posts, totalPosts, err := s.postRepo.FindPaginated(page, pageSize)
+	totalPosts, err := s.countPublished()
+	if err != nil {
+		return nil, err
+	}
+	posts, err := s.postRepo.FindPaginated(page, pageSize)
 	if err != nil {
 		return nil, err
 	}
@@ -160,7 +351,7 @@ func (s *PostService) GetPaginatedPosts(page, pageSize int) (*dto.PaginatedPostR
 	}, nil
 }
 
-func (s *PostService) SearchPosts(query string, page, pageSize int) (*models.PaginatedPosts, error) {
+func (s *PostService) SearchPosts(query string, page, pageSize int) (*dto.PostSearchResponse, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -168,15 +359,23 @@ func (s *PostService) SearchPosts(query string, page, pageSize int) (*models.Pag
 		pageSize = 9
 	}
 
-	posts, totalPosts, err := s.postRepo.SearchPosts(query, page, pageSize)
+	totalPosts, err := s.countSearchResults(query)
 	if err != nil {
 		return nil, err
 	}
+	results := []*models.PostSearchResult{}
+	if totalPosts > 0 {
+		results, err = s.postRepo.SearchPosts(query, page, pageSize)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	totalPages := int(((totalPosts - 1) / int64(pageSize)) + 1)
 
-	return &models.PaginatedPosts{
-		Posts:      posts,
+	return &dto.PostSearchResponse{
+		Query:      query,
+		Posts:      dto.ToPostSearchHits(results),
 		TotalPosts: totalPosts,
 		Page:       page,
 		PageSize:   pageSize,
@@ -184,6 +383,96 @@ func (s *PostService) SearchPosts(query string, page, pageSize int) (*models.Pag
 	}, nil
 }
 
+// GetPostsByCursor returns the page of published posts after (or before) the cursor.
+// The total is only counted when withTotal is set, since that is what makes offset pagination slow.
+func (s *PostService) GetPostsByCursor(encodedCursor string, pageSize int, withTotal bool) (*dto.CursorPostResponse, error) {
+	cursor, err := DecodeCursor(encodedCursor)
+	if err != nil {
+		return nil, err
+	}
+	if pageSize < 1 {
+		pageSize = 9
+	}
+
+	posts, hasMore, err := s.postRepo.FindByCursor(cursor, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.CursorPostResponse{
+		Posts:    dto.ToPostListResponses(posts),
+		PageSize: pageSize,
+	}
+	if len(posts) > 0 {
+		first, last := posts[0], posts[len(posts)-1]
+		response.NextCursor, response.PrevCursor = pageCursors(cursor,
+			Cursor{PublishedAt: *first.PublishedAt, ID: first.ID},
+			Cursor{PublishedAt: *last.PublishedAt, ID: last.ID},
+			hasMore)
+	}
+	if withTotal {
+		total, err := s.countPublished()
+		if err != nil {
+			return nil, err
+		}
+		response.TotalPosts = &total
+	}
+	return response, nil
+}
+
+// SearchPostsByCursor is SearchPosts in cursor mode. Results keep their rank order.
+func (s *PostService) SearchPostsByCursor(query, encodedCursor string, pageSize int, withTotal bool) (*dto.CursorSearchResponse, error) {
+	cursor, err := DecodeCursor(encodedCursor)
+	if err != nil {
+		return nil, err
+	}
+	if pageSize < 1 {
+		pageSize = 9
+	}
+
+	results, hasMore, err := s.postRepo.SearchByCursor(query, cursor, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.CursorSearchResponse{
+		Query:    query,
+		Posts:    dto.ToPostSearchHits(results),
+		PageSize: pageSize,
+	}
+	if len(results) > 0 {
+		first, last := results[0], results[len(results)-1]
+		response.NextCursor, response.PrevCursor = pageCursors(cursor,
+			Cursor{Score: first.Score, PublishedAt: *first.PublishedAt, ID: first.ID},
+			Cursor{Score: last.Score, PublishedAt: *last.PublishedAt, ID: last.ID},
+			hasMore)
+	}
+	if withTotal {
+		total, err := s.countSearchResults(query)
+		if err != nil {
+			return nil, err
+		}
+		response.TotalPosts = &total
+	}
+	return response, nil
+}
+
+// InvalidateCounts drops the cached totals of listings and searches, e.g. after posts were published or imported
+// without going through the service
+func (s *PostService) InvalidateCounts() {
+	s.counts.clear()
+}
+
+// countPublished returns the number of listed posts, see countCache
+func (s *PostService) countPublished() (int64, error) {
+	return s.counts.get("posts", s.postRepo.CountPublished)
+}
+
+// countSearchResults returns the number of posts matching a search query, see countCache
+func (s *PostService) countSearchResults(query string) (int64, error) {
+	return s.counts.get("search:"+query, func() (int64, error) { return s.postRepo.CountSearchResults(query) })
+}
+
 // GetRelatedPosts retrieves posts related to the given post ID.
 func (s *PostService) GetRelatedPosts(id uint, limit int) ([]dto.PostListResponse, error) {
 	if limit <= 0 {
@@ -196,3 +485,99 @@ func (s *PostService) GetRelatedPosts(id uint, limit int) ([]dto.PostListRespons
 	}
 	return dto.ToPostListResponses(posts), nil
 }
+
+// resolveSlug returns the slug to store for a post. An explicit slug chosen by the admin must be free,
+// otherwise a slug is generated from the title and suffixed with a counter until it is unique.
+func (s *PostService) resolveSlug(requested, title string, postID uint) (string, error) {
+	if requested != "" {
+		postSlug := slug.Make(requested)
+		if postSlug == "" {
+			return "", myerr.WithHTTPStatus(errors.New("slug must contain at least one letter or digit"), http.StatusBadRequest)
+		}
+		taken, err := s.postRepo.SlugExists(postSlug, postID)
+		if err != nil {
+			return "", myerr.WithHTTPStatus(err, http.StatusInternalServerError)
+		}
+		if taken {
+			return "", myerr.WithHTTPStatus(ErrSlugTaken, http.StatusConflict)
+		}
+		return postSlug, nil
+	}
+
+	base := slug.Make(title)
+	if base == "" {
+		base = "post"
+	}
+	candidate := base
+	for i := 2; ; i++ {
+		taken, err := s.postRepo.SlugExists(candidate, postID)
+		if err != nil {
+			return "", myerr.WithHTTPStatus(err, http.StatusInternalServerError)
+		}
+		if !taken {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// applyCommentMode validates the comment mode of a post, defaulting to open
+func applyCommentMode(post *models.Post) error {
+	if post.CommentMode == "" {
+		post.CommentMode = models.CommentModeOpen
+	}
+	switch post.CommentMode {
+	case models.CommentModeOpen, models.CommentModeClosed, models.CommentModeModerated, models.CommentModeAutoApprove:
+		return nil
+	}
+	return myerr.WithHTTPStatus(fmt.Errorf("invalid comment mode %q, expected %s, %s, %s or %s", post.CommentMode,
+		models.CommentModeOpen, models.CommentModeClosed, models.CommentModeModerated, models.CommentModeAutoApprove), http.StatusBadRequest)
+}
+
+// applyStatus validates the lifecycle status of a post and keeps Status, PublishedAt and IsActive consistent.
+// Posts without an explicit status fall back to the legacy IsActive flag.
+func applyStatus(post *models.Post, now time.Time) error {
+	if post.Status == "" {
+		post.Status = models.PostStatusDraft
+		if post.IsActive {
+			post.Status = models.PostStatusPublished
+		}
+	}
+
+	switch post.Status {
+	case models.PostStatusPublished:
+		if post.PublishedAt == nil {
+			post.PublishedAt = &now
+		} else if post.PublishedAt.After(now) {
+			post.Status = models.PostStatusScheduled // Publishing in the future means scheduling
+		}
+	case models.PostStatusScheduled:
+		if post.PublishedAt == nil {
+			return myerr.WithHTTPStatus(errors.New("publishedAt is required for scheduled posts"), http.StatusBadRequest)
+		}
+		if !post.PublishedAt.After(now) {
+			post.Status = models.PostStatusPublished // Publish time already passed
+		}
+	case models.PostStatusDraft, models.PostStatusArchived:
+		// Nothing to adjust
+	default:
+		return myerr.WithHTTPStatus(fmt.Errorf("invalid post status %q", post.Status), http.StatusBadRequest)
+	}
+
+	post.IsActive = post.Status == models.PostStatusPublished
+	return nil
+}
+
+// renderContent validates the content format of a post, caches its sanitized HTML in RenderedContent
+// and recomputes the table of contents, word count and reading time.
+func renderContent(post *models.Post) error {
+	switch post.ContentFormat {
+	case "":
+		post.ContentFormat = models.ContentFormatHTML
+	case models.ContentFormatHTML, models.ContentFormatMarkdown:
+	default:
+		return myerr.WithHTTPStatus(fmt.Errorf("invalid content format %q, expected %s or %s", post.ContentFormat, models.ContentFormatHTML, models.ContentFormatMarkdown), http.StatusBadRequest)
+	}
+	render.Refresh(post)
+	return nil
+}
@@ -0,0 +1,48 @@
+package post
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+)
+
+// seriesLookup serves one series and counts the lookups
+type seriesLookup struct {
+	series  *models.Series
+	parts   []models.SeriesPart
+	lookups int
+}
+
+func (l *seriesLookup) FindPostSeries(postID uint) (*models.Series, []models.SeriesPart, error) {
+	l.lookups++
+	return l.series, l.parts, nil
+}
+
+func TestDetailIncludesSeries(t *testing.T) {
+	lookup := &seriesLookup{
+		series: &models.Series{ID: 2, Title: "Building a Blog", Slug: "building-a-blog"},
+		parts:  []models.SeriesPart{{ID: 1, Title: "Part 1", Slug: "part-1"}, {ID: 5, Title: "Part 2", Slug: "part-2"}, {ID: 9, Title: "Part 3", Slug: "part-3"}},
+	}
+	s := NewPostService(nil, lookup, nil, RevisionPolicy{}, FeedSettings{}, ReadingSettings{}, AccessSettings{}, CommentPolicy{}, "", newTestLogger())
+
+	published := time.Now().Add(-time.Hour)
+	detail := s.toDetail(&models.Post{ID: 5, Title: "Part 2", Status: models.PostStatusPublished, PublishedAt: &published,
+		Visibility: models.PostVisibilityPublic}, "")
+	info := detail.Series
+	if info == nil {
+		t.Fatal("detail has no series")
+	}
+	if info.Slug != "building-a-blog" || info.Position != 2 || info.Total != 3 ||
+		info.Previous == nil || info.Previous.ID != 1 || info.Next == nil || info.Next.ID != 9 {
+		t.Errorf("series navigation = %+v", info)
+	}
+	if lookup.lookups != 1 {
+		t.Errorf("series looked up %d times, want once", lookup.lookups)
+	}
+
+	lookup.series, lookup.parts = nil, nil
+	if detail := s.toDetail(&models.Post{ID: 5}, ""); detail.Series != nil {
+		t.Errorf("post outside a series has series %+v", detail.Series)
+	}
+}
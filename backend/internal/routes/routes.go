@@ -1,26 +1,58 @@
 package routes
 
 import (
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/archive"
 	"github.com/dervisgenc/dervisgenc-blog/backend/internal/auth"
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/blocklist"
 	"github.com/dervisgenc/dervisgenc-blog/backend/internal/comment" // Import comment
 	"github.com/dervisgenc/dervisgenc-blog/backend/internal/image"
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/importer"
 	"github.com/dervisgenc/dervisgenc-blog/backend/internal/like"
 	"github.com/dervisgenc/dervisgenc-blog/backend/internal/post"
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/series"
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/sitemap"
 	"github.com/dervisgenc/dervisgenc-blog/backend/internal/stat"
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/taxonomy"
 	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/middleware"
 	"github.com/gin-gonic/gin"
 )
 
 type HandlerContainer struct {
-	Post    *post.PostHandler
-	Auth    *auth.LoginHandler
-	Like    *like.LikeHandler
-	Stats   *stat.StatHandler
-	Image   *image.ImageHandler
-	Comment *comment.CommentHandler // Add Comment handler
+	Post      *post.PostHandler
+	Auth      *auth.LoginHandler
+	Like      *like.LikeHandler
+	Stats     *stat.StatHandler
+	Image     *image.ImageHandler
+	Comment   *comment.CommentHandler // Add Comment handler
+	Taxonomy  *taxonomy.TaxonomyHandler
+	Sitemap   *sitemap.SitemapHandler
+	Series    *series.SeriesHandler
+	Preview   *post.PreviewHandler
+	Archive   *archive.ArchiveHandler
+	Importer  *importer.ImportHandler
+	Blocklist *blocklist.BlocklistHandler
+	Limits    RateLimiters
+}
+
+// RateLimiters are the rate limiting middleware of the public write endpoints
+type RateLimiters struct {
+	Comments  gin.HandlerFunc
+	Likes     gin.HandlerFunc
+	Shares    gin.HandlerFunc
+	Unlock    gin.HandlerFunc
+	Reactions gin.HandlerFunc
+	Flags     gin.HandlerFunc
 }
 
 func SetupRoutes(r *gin.Engine, h *HandlerContainer, jwtSecret []byte) {
+	// Syndication feeds are served from the site root so feed readers find them at the usual paths
+	setupFeedRoutes(r, h)
+
+	// Sitemap and robots.txt, also served from the site root
+	r.GET("/sitemap.xml", h.Sitemap.GetSitemap)
+	r.GET("/sitemaps/:file", h.Sitemap.GetSitemapFile)
+	r.GET("/robots.txt", h.Sitemap.GetRobots)
+
 	// Tüm rotaları /api altına al
 	api := r.Group("/api")
 	{
@@ -34,18 +66,39 @@ func SetupRoutes(r *gin.Engine, h *HandlerContainer, jwtSecret []byte) {
 
 		posts := api.Group("/posts") // -> /api/posts grubu
 		{
-			posts.GET("", h.Post.GetAllPosts)                 // -> /api/posts
-			posts.GET("/paginated", h.Post.GetPaginatedPosts) // -> /api/posts/paginated (ISTENEN)
-			posts.GET("/search", h.Post.SearchPosts)          // -> /api/posts/search
-			posts.GET("/:id", h.Post.GetPostByID)             // -> /api/posts/:id
-			posts.POST("/:id/like", h.Like.ToggleLike)        // -> /api/posts/:id/like
-			posts.GET("/:id/like", h.Like.GetLikeStatus)      // -> /api/posts/:id/like
-			posts.POST("/:id/share", h.Stats.IncrementShare)  // -> /api/posts/:id/share
-			posts.GET("/:id/related", h.Post.GetRelatedPosts) // -> /api/posts/:id/related (YENİ EKLENDİ)
+			posts.GET("", h.Post.GetAllPosts)                                 // -> /api/posts
+			posts.GET("/paginated", h.Post.GetPaginatedPosts)                 // -> /api/posts/paginated (ISTENEN)
+			posts.GET("/search", h.Post.SearchPosts)                          // -> /api/posts/search
+			posts.GET("/by-slug/:slug", h.Post.GetPostBySlug)                 // -> /api/posts/by-slug/:slug
+			posts.GET("/:id", h.Post.GetPostByID)                             // -> /api/posts/:id
+			posts.POST("/:id/unlock", h.Limits.Unlock, h.Post.UnlockPost)     // -> /api/posts/:id/unlock
+			posts.POST("/:id/like", h.Limits.Likes, h.Like.ToggleLike)        // -> /api/posts/:id/like
+			posts.GET("/:id/like", h.Like.GetLikeStatus)                      // -> /api/posts/:id/like
+			posts.POST("/:id/share", h.Limits.Shares, h.Stats.IncrementShare) // -> /api/posts/:id/share
+			posts.GET("/:id/related", h.Post.GetRelatedPosts)                 // -> /api/posts/:id/related (YENİ EKLENDİ)
 
 			// Add Comment Routes (Public)
-			posts.POST("/:id/comments", h.Comment.HandleCreateComment)    // Create comment for post :id
-			posts.GET("/:id/comments", h.Comment.HandleGetCommentsByPost) // Get approved comments for post :id
+			posts.POST("/:id/comments", h.Limits.Comments, h.Comment.HandleCreateComment) // Create comment for post :id
+			posts.GET("/:id/comments", h.Comment.HandleGetCommentsByPost)                 // Get approved comments for post :id
+			posts.GET("/:id/comments/form-token", h.Comment.HandleGetFormToken)
+		}
+
+		api.GET("/categories", h.Taxonomy.GetCategories)                  // -> /api/categories
+		api.GET("/categories/:slug/posts", h.Taxonomy.GetPostsByCategory) // -> /api/categories/:slug/posts
+		api.GET("/tags", h.Taxonomy.GetTags)                              // -> /api/tags
+		api.GET("/tags/:slug/posts", h.Taxonomy.GetPostsByTag)            // -> /api/tags/:slug/posts
+		api.GET("/series/:slug", h.Series.GetSeriesBySlug)                // -> /api/series/:slug
+		api.GET("/preview/:token", h.Preview.GetPreview)                  // -> /api/preview/:token (draft previews, no view counting)
+
+		comments := api.Group("/comments") // -> /api/comments grubu
+		{
+			comments.GET("/unsubscribe", h.Comment.HandleUnsubscribe)                           // Link in reply notification emails
+			comments.POST("/unsubscribe", h.Comment.HandleUnsubscribe)                          // One-click unsubscribe from mail clients
+			comments.GET("/verify", h.Comment.HandleVerifyEmail)                                // Link in verification emails
+			comments.PUT("/:comment_id", h.Limits.Comments, h.Comment.HandleEditComment)        // Edit own comment with its edit token
+			comments.DELETE("/:comment_id", h.Limits.Comments, h.Comment.HandleWithdrawComment) // Withdraw own comment with its edit token
+			comments.POST("/:comment_id/reactions", h.Limits.Reactions, h.Comment.HandleToggleReaction)
+			comments.POST("/:comment_id/flags", h.Limits.Flags, h.Comment.HandleFlagComment) // Report a comment to the moderators
 		}
 
 		// Admin routes within /api
@@ -57,6 +110,20 @@ func SetupRoutes(r *gin.Engine, h *HandlerContainer, jwtSecret []byte) {
 	}
 }
 
+func setupFeedRoutes(r *gin.Engine, h *HandlerContainer) {
+	r.GET("/feed.xml", h.Post.GetRSSFeed)
+	r.GET("/atom.xml", h.Post.GetAtomFeed)
+	r.GET("/feed.json", h.Post.GetJSONFeed)
+
+	r.GET("/categories/:category/feed.xml", h.Post.GetRSSFeed)
+	r.GET("/categories/:category/atom.xml", h.Post.GetAtomFeed)
+	r.GET("/categories/:category/feed.json", h.Post.GetJSONFeed)
+
+	r.GET("/tags/:tag/feed.xml", h.Post.GetRSSFeed)
+	r.GET("/tags/:tag/atom.xml", h.Post.GetAtomFeed)
+	r.GET("/tags/:tag/feed.json", h.Post.GetJSONFeed)
+}
+
 func setupAdminRoutes(rg *gin.RouterGroup, h *HandlerContainer) {
 	posts := rg.Group("/posts")
 	{
@@ -67,11 +134,35 @@ func setupAdminRoutes(rg *gin.RouterGroup, h *HandlerContainer) {
 		posts.GET("/:id", h.Post.GetPostByIDAdmin)
 		posts.DELETE("/:id", h.Post.DeletePost)
 		posts.DELETE("/:id/permanent", h.Post.DeletePostPermanently)
+		posts.GET("/:id/revisions", h.Post.ListRevisions)
+		posts.GET("/:id/revisions/diff", h.Post.DiffRevisions) // ?from=<revision_id>&to=<revision_id|current>
+		posts.GET("/:id/revisions/:revision_id", h.Post.GetRevision)
+		posts.POST("/:id/revisions/:revision_id/restore", h.Post.RestoreRevision)
+		posts.GET("/:id/preview-links", h.Preview.ListPreviewLinks)
+		posts.POST("/:id/preview-links", h.Preview.CreatePreviewLink)
+		posts.DELETE("/:id/preview-links/:link_id", h.Preview.RevokePreviewLink)
 		// posts.GET("/stats", h.Stats.GetAllPostsStats) // Can be removed if detailed-stats is preferred
 		posts.GET("/stats/:id", h.Stats.GetPostStats)
 		posts.GET("/count", h.Stats.CountPosts)
 	}
 
+	seriesAdmin := rg.Group("/series")
+	{
+		seriesAdmin.GET("", h.Series.ListSeries)
+		seriesAdmin.POST("", h.Series.CreateSeries)
+		seriesAdmin.GET("/:id", h.Series.GetSeries)
+		seriesAdmin.PUT("/:id", h.Series.UpdateSeries)
+		seriesAdmin.DELETE("/:id", h.Series.DeleteSeries)
+	}
+
+	archiveAdmin := rg.Group("/archive")
+	{
+		archiveAdmin.GET("/export", h.Archive.ExportArchive)  // -> /api/admin/archive/export
+		archiveAdmin.POST("/import", h.Archive.ImportArchive) // -> /api/admin/archive/import
+	}
+
+	rg.POST("/import/:source", h.Importer.ImportExport) // -> /api/admin/import/wordpress or /api/admin/import/ghost
+
 	stats := rg.Group("/stats")
 	{
 		stats.GET("/overall", h.Stats.GetOverallStats)
@@ -89,7 +180,25 @@ func setupAdminRoutes(rg *gin.RouterGroup, h *HandlerContainer) {
 	commentsAdmin := rg.Group("/comments")
 	{
 		commentsAdmin.GET("", h.Comment.HandleGetAllCommentsAdmin)                  // Get all comments (paginated, filtered)
+		commentsAdmin.GET("/moderation-log", h.Comment.HandleGetModerationLog)      // Who moderated which comment and when
+		commentsAdmin.POST("/bulk/:action", h.Comment.HandleBulkModerate)           // Approve, unapprove, (not) spam or delete many comments
 		commentsAdmin.PATCH("/:comment_id/approve", h.Comment.HandleApproveComment) // Approve a comment
+		commentsAdmin.PATCH("/:comment_id/spam", h.Comment.HandleMarkSpam)          // Move a comment to the spam bucket
+		commentsAdmin.PATCH("/:comment_id/not-spam", h.Comment.HandleMarkNotSpam)   // Return a false positive to the pending queue
 		commentsAdmin.DELETE("/:comment_id", h.Comment.HandleDeleteComment)         // Delete a comment
+		commentsAdmin.GET("/:comment_id/flags", h.Comment.HandleGetFlags)           // Reports of readers about a comment
+		commentsAdmin.POST("/:comment_id/reply", h.Comment.HandleAdminReply)        // Reply as the blog author
+	}
+
+	rg.GET("/profile", h.Auth.GetProfile)    // Name and avatar shown on the admin's comment replies
+	rg.PUT("/profile", h.Auth.UpdateProfile) // -> /api/admin/profile
+
+	blocklistAdmin := rg.Group("/blocklist")
+	{
+		blocklistAdmin.GET("", h.Blocklist.ListRules)
+		blocklistAdmin.POST("", h.Blocklist.CreateRule)
+		blocklistAdmin.POST("/from-comment/:comment_id", h.Blocklist.CreateRuleFromComment) // Block the IP, email or domain of a comment
+		blocklistAdmin.PUT("/:id", h.Blocklist.UpdateRule)
+		blocklistAdmin.DELETE("/:id", h.Blocklist.DeleteRule)
 	}
 }
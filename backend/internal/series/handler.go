@@ -0,0 +1,171 @@
+package series
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+type SeriesHandler struct {
+	service *SeriesService
+}
+
+func NewSeriesHandler(service *SeriesService) *SeriesHandler {
+	return &SeriesHandler{service: service}
+}
+
+// GetSeriesBySlug godoc
+// @Summary Get a series
+// @Description Fetch a series and its published posts in reading order
+// @Tags Series
+// @Produce json
+// @Param slug path string true "Series slug"
+// @Success 200 {object} dto.SeriesResponse
+// @Failure 404 {object} models.ErrorResponse "Series not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /series/{slug} [get]
+func (h *SeriesHandler) GetSeriesBySlug(c *gin.Context) {
+	result, err := h.service.GetSeriesBySlug(c.Param("slug"))
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ListSeries godoc
+// @Summary List series
+// @Description Lists all series with the number of posts in them, drafts included
+// @Tags Series
+// @Produce json
+// @Success 200 {array} models.SeriesSummary
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/series [get]
+func (h *SeriesHandler) ListSeries(c *gin.Context) {
+	series, err := h.service.ListSeries()
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+	c.JSON(http.StatusOK, series)
+}
+
+// GetSeries godoc
+// @Summary Get a series for editing
+// @Description Fetch a series and all of its posts in reading order, drafts included
+// @Tags Series
+// @Produce json
+// @Param id path int true "Series ID"
+// @Success 200 {object} dto.SeriesResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid series ID"
+// @Failure 404 {object} models.ErrorResponse "Series not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/series/{id} [get]
+func (h *SeriesHandler) GetSeries(c *gin.Context) {
+	id, ok := parseSeriesID(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.GetSeries(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateSeries godoc
+// @Summary Create a series
+// @Description Create a series from an ordered list of posts. A post can only belong to one series.
+// @Tags Series
+// @Accept json
+// @Produce json
+// @Param series body dto.SeriesRequest true "Series data"
+// @Success 201 {object} dto.SeriesResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 409 {object} models.ErrorResponse "Slug taken or post already in another series"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/series [post]
+func (h *SeriesHandler) CreateSeries(c *gin.Context) {
+	var req dto.SeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(myerr.WithHTTPStatus(fmt.Errorf("invalid request body: %w", err), http.StatusBadRequest))
+		return
+	}
+	result, err := h.service.CreateSeries(&req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// UpdateSeries godoc
+// @Summary Update a series
+// @Description Update a series; postIds replaces the posts of the series and their order
+// @Tags Series
+// @Accept json
+// @Produce json
+// @Param id path int true "Series ID"
+// @Param series body dto.SeriesRequest true "Series data"
+// @Success 200 {object} dto.SeriesResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 404 {object} models.ErrorResponse "Series not found"
+// @Failure 409 {object} models.ErrorResponse "Slug taken or post already in another series"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/series/{id} [put]
+func (h *SeriesHandler) UpdateSeries(c *gin.Context) {
+	id, ok := parseSeriesID(c)
+	if !ok {
+		return
+	}
+	var req dto.SeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(myerr.WithHTTPStatus(fmt.Errorf("invalid request body: %w", err), http.StatusBadRequest))
+		return
+	}
+	result, err := h.service.UpdateSeries(id, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// DeleteSeries godoc
+// @Summary Delete a series
+// @Description Delete a series. Its posts are kept.
+// @Tags Series
+// @Produce json
+// @Param id path int true "Series ID"
+// @Success 200 {object} models.SuccessResponse "Series deleted"
+// @Failure 400 {object} models.ErrorResponse "Invalid series ID"
+// @Failure 404 {object} models.ErrorResponse "Series not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/series/{id} [delete]
+func (h *SeriesHandler) DeleteSeries(c *gin.Context) {
+	id, ok := parseSeriesID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteSeries(id); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{Message: "Series deleted"})
+}
+
+func parseSeriesID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid series ID"), http.StatusBadRequest))
+		return 0, false
+	}
+	return uint(id), true
+}
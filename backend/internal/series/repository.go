@@ -0,0 +1,226 @@
+package series
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Sentinel errors of the series repository
+var (
+	ErrSeriesNotFound = errors.New("series not found")
+	ErrUnknownPost    = errors.New("series contains a post that does not exist")
+)
+
+type SeriesRepository interface {
+	FindAll() ([]models.SeriesSummary, error)
+	FindByID(id uint) (*models.Series, error)
+	FindBySlug(slug string) (*models.Series, error)
+	FindPosts(seriesID uint, publishedOnly bool) ([]*models.Post, error)
+	FindPostSeries(postID uint) (*models.Series, []models.SeriesPart, error)
+	SlugExists(slug string, excludeSeriesID uint) (bool, error)
+	Create(series *models.Series, postIDs []uint) error
+	Update(series *models.Series, postIDs []uint) error
+	Delete(id uint) error
+}
+
+type seriesRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewSeriesRepository(db *gorm.DB, logger *logrus.Logger) SeriesRepository {
+	return &seriesRepository{db: db, logger: logger}
+}
+
+// FindAll lists every series with the number of posts in it, drafts included.
+func (r *seriesRepository) FindAll() ([]models.SeriesSummary, error) {
+	var summaries []models.SeriesSummary
+	err := r.db.Model(&models.Series{}).
+		Select("series.*, COUNT(series_posts.post_id) AS post_count").
+		Joins("LEFT JOIN series_posts ON series_posts.series_id = series.id").
+		Group("series.id").
+		Order("series.title ASC").
+		Scan(&summaries).Error
+	if err != nil {
+		r.logger.WithError(err).Error("Repository: Failed to fetch series")
+		return nil, fmt.Errorf("failed to fetch series: %w", err)
+	}
+	return summaries, nil
+}
+
+func (r *seriesRepository) FindByID(id uint) (*models.Series, error) {
+	var series models.Series
+	if err := r.db.First(&series, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &series, nil
+}
+
+func (r *seriesRepository) FindBySlug(slug string) (*models.Series, error) {
+	var series models.Series
+	if err := r.db.Where("slug = ?", slug).First(&series).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &series, nil
+}
+
+// FindPosts returns the posts of a series in reading order.
+func (r *seriesRepository) FindPosts(seriesID uint, publishedOnly bool) ([]*models.Post, error) {
+	query := r.db.Model(&models.Post{}).
+		Joins("JOIN series_posts ON series_posts.post_id = posts.id").
+		Where("series_posts.series_id = ?", seriesID)
+	if publishedOnly {
+		query = query.Scopes(models.ListedPosts)
+	}
+
+	var posts []*models.Post
+	if err := query.Order("series_posts.position ASC").Find(&posts).Error; err != nil {
+		return nil, fmt.Errorf("error fetching series posts: %w", err)
+	}
+	return posts, nil
+}
+
+func (r *seriesRepository) SlugExists(slug string, excludeSeriesID uint) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.Series{}).Where("slug = ? AND id <> ?", slug, excludeSeriesID).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("error checking series slug: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *seriesRepository) Create(series *models.Series, postIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(series).Error; err != nil {
+			r.logger.WithError(err).Error("Error creating series")
+			return fmt.Errorf("failed to create series: %w", err)
+		}
+		return linkPosts(tx, series.ID, postIDs)
+	})
+}
+
+func (r *seriesRepository) Update(series *models.Series, postIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(series).Select("title", "slug", "description", "updated_at").Updates(series)
+		if result.Error != nil {
+			r.logger.WithError(result.Error).WithField("series_id", series.ID).Error("Error updating series")
+			return fmt.Errorf("failed to update series: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return myerr.WithHTTPStatus(ErrSeriesNotFound, http.StatusNotFound)
+		}
+		return linkPosts(tx, series.ID, postIDs)
+	})
+}
+
+// Delete removes a series; its posts are kept and simply no longer belong to a series.
+func (r *seriesRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("series_id = ?", id).Delete(&models.SeriesPost{}).Error; err != nil {
+			return fmt.Errorf("error deleting series posts: %w", err)
+		}
+		result := tx.Delete(&models.Series{}, id)
+		if result.Error != nil {
+			r.logger.WithError(result.Error).WithField("series_id", id).Error("Error deleting series")
+			return fmt.Errorf("failed to delete series: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return myerr.WithHTTPStatus(ErrSeriesNotFound, http.StatusNotFound)
+		}
+		return nil
+	})
+}
+
+// linkPosts replaces the posts of a series; their position follows the order of postIDs.
+// A post can only be in one series, so it must be removed from another series before it is added here.
+func linkPosts(tx *gorm.DB, seriesID uint, postIDs []uint) error {
+	if len(postIDs) > 0 {
+		var existing int64
+		if err := tx.Model(&models.Post{}).Where("id IN ?", postIDs).Count(&existing).Error; err != nil {
+			return fmt.Errorf("error checking series posts: %w", err)
+		}
+		if existing != int64(len(postIDs)) {
+			return myerr.WithHTTPStatus(ErrUnknownPost, http.StatusBadRequest)
+		}
+
+		var taken models.SeriesPost
+		err := tx.Preload("Series").Where("post_id IN ? AND series_id <> ?", postIDs, seriesID).First(&taken).Error
+		if err == nil {
+			return myerr.WithHTTPStatus(fmt.Errorf("post %d already belongs to the series %q", taken.PostID, taken.Series.Title), http.StatusConflict)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("error checking series posts: %w", err)
+		}
+	}
+
+	if err := tx.Where("series_id = ?", seriesID).Delete(&models.SeriesPost{}).Error; err != nil {
+		return fmt.Errorf("failed to clear series posts: %w", err)
+	}
+	if len(postIDs) == 0 {
+		return nil
+	}
+	links := make([]models.SeriesPost, len(postIDs))
+	for i, postID := range postIDs {
+		links[i] = models.SeriesPost{SeriesID: seriesID, PostID: postID, Position: i + 1}
+	}
+	if err := tx.Create(&links).Error; err != nil {
+		return fmt.Errorf("failed to link series posts: %w", err)
+	}
+	return nil
+}
+
+// postSeriesRow is a published part of the series a post belongs to, together with the series
+type postSeriesRow struct {
+	SeriesID          uint
+	SeriesTitle       string
+	SeriesSlug        string
+	SeriesDescription string
+	models.SeriesPart `gorm:"embedded"`
+}
+
+// FindPostSeries returns the series a post belongs to together with its published parts in reading order,
+// loaded in a single query. Both are nil if the post is not part of a series or none of its parts is published.
+func (r *seriesRepository) FindPostSeries(postID uint) (*models.Series, []models.SeriesPart, error) {
+	var rows []postSeriesRow
+	err := r.db.Table("series_posts AS own").
+		Select("series.id AS series_id, series.title AS series_title, series.slug AS series_slug, "+
+			"series.description AS series_description, posts.id, posts.title, posts.slug").
+		Joins("JOIN series ON series.id = own.series_id").
+		Joins("JOIN series_posts ON series_posts.series_id = own.series_id").
+		Joins("JOIN posts ON posts.id = series_posts.post_id").
+		Where("own.post_id = ?", postID).
+		Scopes(models.ListedPosts).
+		Order("series_posts.position ASC").
+		Scan(&rows).Error
+	if err != nil {
+		r.logger.WithError(err).WithField("post_id", postID).Error("Repository: Failed to fetch series of post")
+		return nil, nil, fmt.Errorf("error fetching series of post: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil, nil
+	}
+
+	series := &models.Series{
+		ID:          rows[0].SeriesID,
+		Title:       rows[0].SeriesTitle,
+		Slug:        rows[0].SeriesSlug,
+		Description: rows[0].SeriesDescription,
+	}
+	parts := make([]models.SeriesPart, len(rows))
+	for i, row := range rows {
+		parts[i] = row.SeriesPart
+	}
+	return series, parts, nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return myerr.WithHTTPStatus(ErrSeriesNotFound, http.StatusNotFound)
+	}
+	return myerr.WithHTTPStatus(fmt.Errorf("failed to fetch series: %w", err), http.StatusInternalServerError)
+}
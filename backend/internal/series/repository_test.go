@@ -0,0 +1,34 @@
+package series
+
+import (
+	"io"
+	"testing"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/dbtest"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSeriesListingsLeaveOutUnlistedPosts(t *testing.T) {
+	db, recorder := dbtest.DryRun(t)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	repo := NewSeriesRepository(db, logger)
+
+	t.Run("FindPosts", func(t *testing.T) {
+		recorder.Reset()
+		if _, err := repo.FindPosts(1, true); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		dbtest.ExpectListedPostsOnly(t, recorder)
+	})
+	t.Run("FindPostSeries", func(t *testing.T) {
+		recorder.Reset()
+		if _, _, err := repo.FindPostSeries(1); err != nil && !dbtest.Unsupported(err) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		dbtest.ExpectListedPostsOnly(t, recorder)
+		if queries := recorder.Queries(); len(queries) != 1 {
+			t.Errorf("series of a post loaded with %d queries, want 1: %q", len(queries), queries)
+		}
+	})
+}
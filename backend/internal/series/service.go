@@ -0,0 +1,161 @@
+package series
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/slug"
+)
+
+// ErrSlugTaken is returned when an admin chooses a slug that another series already uses
+var ErrSlugTaken = errors.New("slug is already used by another series")
+
+type SeriesService struct {
+	repo SeriesRepository
+}
+
+func NewSeriesService(repo SeriesRepository) *SeriesService {
+	return &SeriesService{repo: repo}
+}
+
+// GetSeriesBySlug returns a series with its published posts. Series without published posts are not found.
+func (s *SeriesService) GetSeriesBySlug(seriesSlug string) (*dto.SeriesResponse, error) {
+	series, err := s.repo.FindBySlug(seriesSlug)
+	if err != nil {
+		return nil, err
+	}
+	posts, err := s.repo.FindPosts(series.ID, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(posts) == 0 {
+		return nil, myerr.WithHTTPStatus(ErrSeriesNotFound, http.StatusNotFound)
+	}
+	response := dto.ToSeriesResponse(series, posts)
+	return &response, nil
+}
+
+func (s *SeriesService) ListSeries() ([]models.SeriesSummary, error) {
+	return s.repo.FindAll()
+}
+
+// GetSeries returns a series with all of its posts, drafts included.
+func (s *SeriesService) GetSeries(id uint) (*dto.SeriesResponse, error) {
+	series, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.withAllPosts(series)
+}
+
+func (s *SeriesService) CreateSeries(req *dto.SeriesRequest) (*dto.SeriesResponse, error) {
+	series := &models.Series{
+		Title:       strings.TrimSpace(req.Title),
+		Description: req.Description,
+	}
+	if err := s.prepare(series, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(series, req.PostIDs); err != nil {
+		return nil, err
+	}
+	return s.withAllPosts(series)
+}
+
+func (s *SeriesService) UpdateSeries(id uint, req *dto.SeriesRequest) (*dto.SeriesResponse, error) {
+	series, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	previousTitle := series.Title
+	series.Title = strings.TrimSpace(req.Title)
+	series.Description = req.Description
+
+	// Keep the slug unless the admin overrides it or the title changes, like posts do
+	if req.Slug == "" && series.Title == previousTitle {
+		req.Slug = series.Slug
+	}
+	if err := s.prepare(series, req); err != nil {
+		return nil, err
+	}
+	series.UpdatedAt = time.Now()
+	if err := s.repo.Update(series, req.PostIDs); err != nil {
+		return nil, err
+	}
+	return s.withAllPosts(series)
+}
+
+func (s *SeriesService) DeleteSeries(id uint) error {
+	return s.repo.Delete(id)
+}
+
+// prepare validates the request and resolves the slug of the series
+func (s *SeriesService) prepare(series *models.Series, req *dto.SeriesRequest) error {
+	if series.Title == "" {
+		return myerr.WithHTTPStatus(errors.New("title cannot be empty"), http.StatusBadRequest)
+	}
+	seen := make(map[uint]bool, len(req.PostIDs))
+	for _, postID := range req.PostIDs {
+		if seen[postID] {
+			return myerr.WithHTTPStatus(fmt.Errorf("post %d is listed more than once", postID), http.StatusBadRequest)
+		}
+		seen[postID] = true
+	}
+
+	seriesSlug, err := s.resolveSlug(req.Slug, series.Title, series.ID)
+	if err != nil {
+		return err
+	}
+	series.Slug = seriesSlug
+	return nil
+}
+
+// resolveSlug returns the slug to store for a series. An explicit slug must be free,
+// otherwise a slug is generated from the title and suffixed with a counter until it is unique.
+func (s *SeriesService) resolveSlug(requested, title string, seriesID uint) (string, error) {
+	if requested != "" {
+		seriesSlug := slug.Make(requested)
+		if seriesSlug == "" {
+			return "", myerr.WithHTTPStatus(errors.New("slug must contain at least one letter or digit"), http.StatusBadRequest)
+		}
+		taken, err := s.repo.SlugExists(seriesSlug, seriesID)
+		if err != nil {
+			return "", myerr.WithHTTPStatus(err, http.StatusInternalServerError)
+		}
+		if taken {
+			return "", myerr.WithHTTPStatus(ErrSlugTaken, http.StatusConflict)
+		}
+		return seriesSlug, nil
+	}
+
+	base := slug.Make(title)
+	if base == "" {
+		base = "series"
+	}
+	candidate := base
+	for i := 2; ; i++ {
+		taken, err := s.repo.SlugExists(candidate, seriesID)
+		if err != nil {
+			return "", myerr.WithHTTPStatus(err, http.StatusInternalServerError)
+		}
+		if !taken {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+func (s *SeriesService) withAllPosts(series *models.Series) (*dto.SeriesResponse, error) {
+	posts, err := s.repo.FindPosts(series.ID, false)
+	if err != nil {
+		return nil, err
+	}
+	response := dto.ToSeriesResponse(series, posts)
+	return &response, nil
+}
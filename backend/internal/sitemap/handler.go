@@ -0,0 +1,67 @@
+package sitemap
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const xmlContentType = "application/xml; charset=utf-8"
+
+type SitemapHandler struct {
+	service *SitemapService
+}
+
+func NewSitemapHandler(service *SitemapService) *SitemapHandler {
+	return &SitemapHandler{service: service}
+}
+
+// GetSitemap godoc
+// @Summary XML sitemap
+// @Description Sitemap of the home page and the published posts. Becomes a sitemap index when there are more than 50,000 URLs
+// @Tags SEO
+// @Produce xml
+// @Success 200 {string} string "Sitemap or sitemap index"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /sitemap.xml [get]
+func (h *SitemapHandler) GetSitemap(c *gin.Context) {
+	body, err := h.service.Sitemap()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Data(http.StatusOK, xmlContentType, body)
+}
+
+// GetSitemapFile godoc
+// @Summary Sitemap file of a sitemap index
+// @Description One of the sitemaps listed by the sitemap index (posts-N.xml)
+// @Tags SEO
+// @Produce xml
+// @Param file path string true "Sitemap file name"
+// @Success 200 {string} string "Sitemap"
+// @Failure 404 {object} models.ErrorResponse "Sitemap not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /sitemaps/{file} [get]
+func (h *SitemapHandler) GetSitemapFile(c *gin.Context) {
+	body, err := h.service.File(c.Param("file"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Data(http.StatusOK, xmlContentType, body)
+}
+
+// GetRobots godoc
+// @Summary robots.txt
+// @Description Crawler rules, configured with ROBOTS_DISALLOW, pointing to the sitemap
+// @Tags SEO
+// @Produce plain
+// @Success 200 {string} string "robots.txt"
+// @Router /robots.txt [get]
+func (h *SitemapHandler) GetRobots(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", h.service.Robots())
+}
@@ -0,0 +1,56 @@
+package sitemap
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Entry is the minimal data needed to list a post in the sitemap
+type Entry struct {
+	ID        uint
+	Slug      string
+	UpdatedAt time.Time
+}
+
+type SitemapRepository interface {
+	CountPosts() (int64, error)
+	FindPosts(offset, limit int) ([]Entry, error)
+}
+
+type sitemapRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewSitemapRepository(db *gorm.DB, logger *logrus.Logger) SitemapRepository {
+	return &sitemapRepository{db: db, logger: logger}
+}
+
+func (r *sitemapRepository) CountPosts() (int64, error) {
+	var count int64
+	if err := r.db.Model(&models.Post{}).Scopes(models.ListedPosts).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("error counting posts: %w", err)
+	}
+	return count, nil
+}
+
+// FindPosts returns published posts ordered by ID so sitemap pages stay stable.
+func (r *sitemapRepository) FindPosts(offset, limit int) ([]Entry, error) {
+	var entries []Entry
+	err := r.db.Model(&models.Post{}).
+		Select("posts.id, posts.slug, posts.updated_at").
+		Scopes(models.ListedPosts).
+		Order("posts.id").
+		Offset(offset).
+		Limit(limit).
+		Scan(&entries).Error
+	if err != nil {
+		r.logger.WithError(err).Error("Sitemap: Failed to fetch posts")
+		return nil, fmt.Errorf("error fetching sitemap posts: %w", err)
+	}
+	return entries, nil
+}
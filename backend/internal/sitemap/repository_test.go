@@ -0,0 +1,33 @@
+package sitemap
+
+import (
+	"io"
+	"testing"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/dbtest"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSitemapLeavesOutUnlistedPosts(t *testing.T) {
+	db, recorder := dbtest.DryRun(t)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	repo := NewSitemapRepository(db, logger)
+
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{name: "CountPosts", run: func() error { _, err := repo.CountPosts(); return err }},
+		{name: "FindPosts", run: func() error { _, err := repo.FindPosts(0, 100); return err }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.Reset()
+			if err := tt.run(); err != nil && !dbtest.Unsupported(err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			dbtest.ExpectListedPostsOnly(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package sitemap
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/sitemap"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrSitemapNotFound is returned for sitemap files that don't exist
+var ErrSitemapNotFound = errors.New("sitemap not found")
+
+// Settings configures the generated sitemap and robots.txt.
+type Settings struct {
+	SiteURL        string        // Public URL of the blog, used to build absolute links
+	CacheTTL       time.Duration // Generated documents are rebuilt after this long even without changes, 0 disables expiry
+	RobotsDisallow []string      // Paths crawlers are asked to skip
+}
+
+type cachedDocument struct {
+	body    []byte
+	builtAt time.Time
+}
+
+// SitemapService builds the sitemap documents and caches them until a post changes.
+type SitemapService struct {
+	repo     SitemapRepository
+	settings Settings
+	logger   *logrus.Logger
+	mu       sync.Mutex
+	cache    map[string]cachedDocument
+}
+
+func NewSitemapService(repo SitemapRepository, settings Settings, logger *logrus.Logger) *SitemapService {
+	settings.SiteURL = strings.TrimSuffix(settings.SiteURL, "/")
+	return &SitemapService{
+		repo:     repo,
+		settings: settings,
+		logger:   logger,
+		cache:    make(map[string]cachedDocument),
+	}
+}
+
+// Invalidate drops all cached documents. It is registered as a post change listener.
+func (s *SitemapService) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache = make(map[string]cachedDocument)
+	s.logger.Debug("Sitemap cache invalidated")
+}
+
+// Sitemap returns /sitemap.xml: the home page and the published posts. Every URL is counted first; when they
+// don't fit into one file the sitemap becomes an index pointing to /sitemaps/posts-N.xml.
+func (s *SitemapService) Sitemap() ([]byte, error) {
+	return s.cached("sitemap.xml", func() ([]byte, error) {
+		total, err := s.repo.CountPosts()
+		if err != nil {
+			return nil, err
+		}
+
+		files := int((total + 1 + sitemap.MaxURLs - 1) / sitemap.MaxURLs)
+		if files <= 1 {
+			return s.page(1)
+		}
+		index := make([]sitemap.URL, 0, files)
+		for page := 1; page <= files; page++ {
+			index = append(index, sitemap.URL{Loc: fmt.Sprintf("%s/sitemaps/posts-%d.xml", s.settings.SiteURL, page)})
+		}
+		return sitemap.Index(index)
+	})
+}
+
+// File returns one of the sitemaps listed in the index, "posts-N.xml".
+func (s *SitemapService) File(name string) ([]byte, error) {
+	page, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "posts-"), ".xml"))
+	if err != nil || page < 1 || name != fmt.Sprintf("posts-%d.xml", page) {
+		return nil, myerr.WithHTTPStatus(ErrSitemapNotFound, http.StatusNotFound)
+	}
+	return s.cached(name, func() ([]byte, error) {
+		return s.page(page)
+	})
+}
+
+// Robots returns the robots.txt content.
+func (s *SitemapService) Robots() []byte {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	if len(s.settings.RobotsDisallow) == 0 {
+		b.WriteString("Disallow:\n")
+	}
+	for _, path := range s.settings.RobotsDisallow {
+		fmt.Fprintf(&b, "Disallow: %s\n", path)
+	}
+	fmt.Fprintf(&b, "\nSitemap: %s/sitemap.xml\n", s.settings.SiteURL)
+	return []byte(b.String())
+}
+
+func (s *SitemapService) cached(name string, build func() ([]byte, error)) ([]byte, error) {
+	// The lock is held while building so concurrent requests don't all hit the database
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if doc, ok := s.cache[name]; ok && (s.settings.CacheTTL <= 0 || time.Since(doc.builtAt) < s.settings.CacheTTL) {
+		return doc.body, nil
+	}
+
+	body, err := build()
+	if err != nil {
+		return nil, myerr.WithHTTPStatus(err, http.StatusInternalServerError)
+	}
+	s.cache[name] = cachedDocument{body: body, builtAt: time.Now()}
+	return body, nil
+}
+
+// page builds the Nth file of MaxURLs URLs out of the home page followed by the posts
+func (s *SitemapService) page(page int) ([]byte, error) {
+	var urls []sitemap.URL
+	offset, limit := (page-1)*sitemap.MaxURLs-1, sitemap.MaxURLs
+	if page == 1 {
+		urls = append(urls, sitemap.URL{Loc: s.settings.SiteURL + "/"})
+		offset, limit = 0, limit-1
+	}
+
+	posts, err := s.repo.FindPosts(offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, post := range posts {
+		urls = append(urls, sitemap.URL{Loc: fmt.Sprintf("%s/post/%d", s.settings.SiteURL, post.ID), LastMod: post.UpdatedAt})
+	}
+	if page > 1 && len(urls) == 0 {
+		return nil, myerr.WithHTTPStatus(ErrSitemapNotFound, http.StatusNotFound)
+	}
+	return sitemap.URLSet(urls)
+}
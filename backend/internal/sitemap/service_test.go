@@ -0,0 +1,89 @@
+package sitemap
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/sitemap"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeRepository has posts with IDs 1 to posts
+type fakeRepository struct{ posts int }
+
+func (r fakeRepository) CountPosts() (int64, error) { return int64(r.posts), nil }
+
+func (r fakeRepository) FindPosts(offset, limit int) ([]Entry, error) {
+	var entries []Entry
+	for id := offset + 1; id <= r.posts && len(entries) < limit; id++ {
+		entries = append(entries, Entry{ID: uint(id)})
+	}
+	return entries, nil
+}
+
+func newTestService(posts int) *SitemapService {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewSitemapService(fakeRepository{posts: posts}, Settings{SiteURL: "https://blog.example"}, logger)
+}
+
+func TestSitemapCountsEveryURL(t *testing.T) {
+	// The home page and the posts just fit into one file
+	body, err := newTestService(sitemap.MaxURLs - 1).Sitemap()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Contains(body, []byte("<urlset")) || bytes.Count(body, []byte("<loc>")) != sitemap.MaxURLs {
+		t.Fatalf("sitemap of %d posts is not a single file with every URL", sitemap.MaxURLs-1)
+	}
+
+	// One more post needs an index of two files that split the URLs between them
+	s := newTestService(sitemap.MaxURLs)
+	body, err = s.Sitemap()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Contains(body, []byte("<sitemapindex")) || bytes.Count(body, []byte("<loc>")) != 2 {
+		t.Fatalf("sitemap of %d posts is not an index of two files:\n%s", sitemap.MaxURLs, body)
+	}
+	first, err := s.File("posts-1.xml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Contains(first, []byte("<loc>https://blog.example/</loc>")) || bytes.Count(first, []byte("<loc>")) != sitemap.MaxURLs {
+		t.Error("first file doesn't start with the home page or isn't full")
+	}
+	second, err := s.File("posts-2.xml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	last := fmt.Sprintf("<loc>https://blog.example/post/%d</loc>", sitemap.MaxURLs)
+	if bytes.Count(second, []byte("<loc>")) != 1 || !bytes.Contains(second, []byte(last)) {
+		t.Errorf("second file doesn't hold only the last post:\n%s", second)
+	}
+
+	for _, name := range []string{"posts-3.xml", "taxonomy.xml", "posts-01.xml"} {
+		if _, err := s.File(name); myerr.HTTPStatus(err) != http.StatusNotFound {
+			t.Errorf("File(%q): err = %v, want not found", name, err)
+		}
+	}
+}
+
+func TestSitemapListsOnlyFrontendRoutes(t *testing.T) {
+	body, err := newTestService(2).Sitemap()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, loc := range []string{"https://blog.example/", "https://blog.example/post/1", "https://blog.example/post/2"} {
+		if !bytes.Contains(body, []byte("<loc>"+loc+"</loc>")) {
+			t.Errorf("sitemap lacks %s", loc)
+		}
+	}
+	if n := bytes.Count(body, []byte("<loc>")); n != 3 {
+		t.Errorf("sitemap has %d URLs, want 3:\n%s", n, body)
+	}
+}
@@ -0,0 +1,119 @@
+package taxonomy
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+type TaxonomyHandler struct {
+	service *TaxonomyService
+}
+
+func NewTaxonomyHandler(service *TaxonomyService) *TaxonomyHandler {
+	return &TaxonomyHandler{service: service}
+}
+
+// GetCategories godoc
+// @Summary List categories
+// @Description Lists categories that contain published posts, with their post counts
+// @Tags Taxonomy
+// @Produce json
+// @Success 200 {array} models.TaxonomyCount
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /categories [get]
+func (h *TaxonomyHandler) GetCategories(c *gin.Context) {
+	categories, err := h.service.GetCategories()
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+	c.JSON(http.StatusOK, categories)
+}
+
+// GetTags godoc
+// @Summary List tags
+// @Description Lists tags used by published posts, with their post counts
+// @Tags Taxonomy
+// @Produce json
+// @Success 200 {array} models.TaxonomyCount
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /tags [get]
+func (h *TaxonomyHandler) GetTags(c *gin.Context) {
+	tags, err := h.service.GetTags()
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+	c.JSON(http.StatusOK, tags)
+}
+
+// GetPostsByCategory godoc
+// @Summary Get posts in a category
+// @Description Fetch published posts of a category with pagination
+// @Tags Taxonomy
+// @Produce json
+// @Param slug path string true "Category slug"
+// @Param page query int false "Page number (default: 1)"
+// @Param size query int false "Page size (default: 9)"
+// @Success 200 {object} dto.TaxonomyPostsResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid parameters"
+// @Failure 404 {object} models.ErrorResponse "Category not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /categories/{slug}/posts [get]
+func (h *TaxonomyHandler) GetPostsByCategory(c *gin.Context) {
+	page, pageSize, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.GetPostsByCategory(c.Param("slug"), page, pageSize)
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetPostsByTag godoc
+// @Summary Get posts with a tag
+// @Description Fetch published posts with a tag with pagination
+// @Tags Taxonomy
+// @Produce json
+// @Param slug path string true "Tag slug"
+// @Param page query int false "Page number (default: 1)"
+// @Param size query int false "Page size (default: 9)"
+// @Success 200 {object} dto.TaxonomyPostsResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid parameters"
+// @Failure 404 {object} models.ErrorResponse "Tag not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /tags/{slug}/posts [get]
+func (h *TaxonomyHandler) GetPostsByTag(c *gin.Context) {
+	page, pageSize, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+	result, err := h.service.GetPostsByTag(c.Param("slug"), page, pageSize)
+	if err != nil {
+		c.Error(myerr.WithHTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func parsePagination(c *gin.Context) (int, int, bool) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid page number"), http.StatusBadRequest))
+		return 0, 0, false
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("size", "9"))
+	if err != nil || pageSize < 1 {
+		c.Error(myerr.WithHTTPStatus(errors.New("invalid page size"), http.StatusBadRequest))
+		return 0, 0, false
+	}
+	return page, pageSize, true
+}
@@ -0,0 +1,125 @@
+package taxonomy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Sentinel errors for unknown taxonomy slugs
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrTagNotFound      = errors.New("tag not found")
+)
+
+type TaxonomyRepository interface {
+	FindCategoriesWithCounts() ([]models.TaxonomyCount, error)
+	FindTagsWithCounts() ([]models.TaxonomyCount, error)
+	FindCategoryBySlug(slug string) (*models.Category, error)
+	FindTagBySlug(slug string) (*models.Tag, error)
+	FindPostsByCategory(categoryID uint, page, pageSize int) ([]*models.Post, int64, error)
+	FindPostsByTag(tagID uint, page, pageSize int) ([]*models.Post, int64, error)
+}
+
+type taxonomyRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewTaxonomyRepository(db *gorm.DB, logger *logrus.Logger) TaxonomyRepository {
+	return &taxonomyRepository{db: db, logger: logger}
+}
+
+// FindCategoriesWithCounts lists categories that have at least one published post.
+func (r *taxonomyRepository) FindCategoriesWithCounts() ([]models.TaxonomyCount, error) {
+	var counts []models.TaxonomyCount
+	err := r.db.Model(&models.Category{}).
+		Select("categories.id, categories.name, categories.slug, COUNT(posts.id) AS post_count").
+		Joins("JOIN posts ON posts.category_id = categories.id").
+		Scopes(models.ListedPosts).
+		Group("categories.id").
+		Order("post_count DESC, categories.name ASC").
+		Scan(&counts).Error
+	if err != nil {
+		r.logger.WithError(err).Error("Repository: Failed to fetch categories")
+		return nil, fmt.Errorf("failed to fetch categories: %w", err)
+	}
+	return counts, nil
+}
+
+// FindTagsWithCounts lists tags that are used by at least one published post.
+func (r *taxonomyRepository) FindTagsWithCounts() ([]models.TaxonomyCount, error) {
+	var counts []models.TaxonomyCount
+	err := r.db.Model(&models.Tag{}).
+		Select("tags.id, tags.name, tags.slug, COUNT(posts.id) AS post_count").
+		Joins("JOIN post_tags ON post_tags.tag_id = tags.id").
+		Joins("JOIN posts ON posts.id = post_tags.post_id").
+		Scopes(models.ListedPosts).
+		Group("tags.id").
+		Order("post_count DESC, tags.name ASC").
+		Scan(&counts).Error
+	if err != nil {
+		r.logger.WithError(err).Error("Repository: Failed to fetch tags")
+		return nil, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+	return counts, nil
+}
+
+func (r *taxonomyRepository) FindCategoryBySlug(slug string) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.Where("slug = ?", slug).First(&category).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, myerr.WithHTTPStatus(ErrCategoryNotFound, http.StatusNotFound)
+		}
+		return nil, myerr.WithHTTPStatus(fmt.Errorf("failed to fetch category: %w", err), http.StatusInternalServerError)
+	}
+	return &category, nil
+}
+
+func (r *taxonomyRepository) FindTagBySlug(slug string) (*models.Tag, error) {
+	var tag models.Tag
+	if err := r.db.Where("slug = ?", slug).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, myerr.WithHTTPStatus(ErrTagNotFound, http.StatusNotFound)
+		}
+		return nil, myerr.WithHTTPStatus(fmt.Errorf("failed to fetch tag: %w", err), http.StatusInternalServerError)
+	}
+	return &tag, nil
+}
+
+func (r *taxonomyRepository) FindPostsByCategory(categoryID uint, page, pageSize int) ([]*models.Post, int64, error) {
+	query := func() *gorm.DB {
+		return r.db.Model(&models.Post{}).Scopes(models.ListedPosts).Where("posts.category_id = ?", categoryID)
+	}
+	return r.paginate(query, page, pageSize)
+}
+
+func (r *taxonomyRepository) FindPostsByTag(tagID uint, page, pageSize int) ([]*models.Post, int64, error) {
+	query := func() *gorm.DB {
+		return r.db.Model(&models.Post{}).
+			Joins("JOIN post_tags ON post_tags.post_id = posts.id").
+			Scopes(models.ListedPosts).
+			Where("post_tags.tag_id = ?", tagID)
+	}
+	return r.paginate(query, page, pageSize)
+}
+
+func (r *taxonomyRepository) paginate(query func() *gorm.DB, page, pageSize int) ([]*models.Post, int64, error) {
+	var posts []*models.Post
+	var totalPosts int64
+
+	if err := query().Count(&totalPosts).Error; err != nil {
+		return nil, 0, fmt.Errorf("error counting posts: %w", err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query().Order("posts.created_at desc").Limit(pageSize).Offset(offset).Find(&posts).Error; err != nil {
+		return nil, 0, fmt.Errorf("error fetching posts: %w", err)
+	}
+	return posts, totalPosts, nil
+}
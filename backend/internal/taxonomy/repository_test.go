@@ -0,0 +1,35 @@
+package taxonomy
+
+import (
+	"io"
+	"testing"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/internal/dbtest"
+	"github.com/sirupsen/logrus"
+)
+
+func TestTaxonomyListingsLeaveOutUnlistedPosts(t *testing.T) {
+	db, recorder := dbtest.DryRun(t)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	repo := NewTaxonomyRepository(db, logger)
+
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{name: "FindCategoriesWithCounts", run: func() error { _, err := repo.FindCategoriesWithCounts(); return err }},
+		{name: "FindTagsWithCounts", run: func() error { _, err := repo.FindTagsWithCounts(); return err }},
+		{name: "FindPostsByCategory", run: func() error { _, _, err := repo.FindPostsByCategory(1, 1, 10); return err }},
+		{name: "FindPostsByTag", run: func() error { _, _, err := repo.FindPostsByTag(1, 1, 10); return err }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.Reset()
+			if err := tt.run(); err != nil && !dbtest.Unsupported(err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			dbtest.ExpectListedPostsOnly(t, recorder)
+		})
+	}
+}
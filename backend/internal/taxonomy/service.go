@@ -0,0 +1,60 @@
+package taxonomy
+
+import (
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+)
+
+type TaxonomyService struct {
+	repo TaxonomyRepository
+}
+
+func NewTaxonomyService(repo TaxonomyRepository) *TaxonomyService {
+	return &TaxonomyService{repo: repo}
+}
+
+func (s *TaxonomyService) GetCategories() ([]models.TaxonomyCount, error) {
+	return s.repo.FindCategoriesWithCounts()
+}
+
+func (s *TaxonomyService) GetTags() ([]models.TaxonomyCount, error) {
+	return s.repo.FindTagsWithCounts()
+}
+
+// GetPostsByCategory returns a page of published posts in the category with the given slug.
+func (s *TaxonomyService) GetPostsByCategory(categorySlug string, page, pageSize int) (*dto.TaxonomyPostsResponse, error) {
+	category, err := s.repo.FindCategoryBySlug(categorySlug)
+	if err != nil {
+		return nil, err
+	}
+	posts, total, err := s.repo.FindPostsByCategory(category.ID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return toTaxonomyPostsResponse(category.Name, category.Slug, posts, total, page, pageSize), nil
+}
+
+// GetPostsByTag returns a page of published posts tagged with the given slug.
+func (s *TaxonomyService) GetPostsByTag(tagSlug string, page, pageSize int) (*dto.TaxonomyPostsResponse, error) {
+	tag, err := s.repo.FindTagBySlug(tagSlug)
+	if err != nil {
+		return nil, err
+	}
+	posts, total, err := s.repo.FindPostsByTag(tag.ID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return toTaxonomyPostsResponse(tag.Name, tag.Slug, posts, total, page, pageSize), nil
+}
+
+func toTaxonomyPostsResponse(name, slug string, posts []*models.Post, total int64, page, pageSize int) *dto.TaxonomyPostsResponse {
+	return &dto.TaxonomyPostsResponse{
+		Name:       name,
+		Slug:       slug,
+		Posts:      dto.ToPostListResponses(posts),
+		TotalPosts: total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int(((total - 1) / int64(pageSize)) + 1),
+	}
+}
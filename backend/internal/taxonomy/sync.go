@@ -0,0 +1,107 @@
+package taxonomy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/dto"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/slug"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ResolvePostTaxonomy finds or creates the category and tags named in post.Category and post.Tags.
+// It sets post.CategoryID and rewrites post.Category/post.Tags with the canonical names so the
+// legacy columns stay in sync. The returned tags must be linked with LinkPostTags once the post has an ID.
+// Must be called inside the transaction that saves the post.
+func ResolvePostTaxonomy(tx *gorm.DB, post *models.Post) ([]models.Tag, error) {
+	post.CategoryID = nil
+	categoryName := strings.TrimSpace(post.Category)
+	post.Category = ""
+	if categoryName != "" {
+		category, err := findOrCreateCategory(tx, categoryName)
+		if err != nil {
+			return nil, err
+		}
+		if category != nil {
+			post.CategoryID = &category.ID
+			post.Category = category.Name
+		}
+	}
+
+	var tags []models.Tag
+	seen := make(map[uint]bool)
+	names := make([]string, 0)
+	for _, name := range dto.SplitTags(post.Tags) {
+		tag, err := findOrCreateTag(tx, name)
+		if err != nil {
+			return nil, err
+		}
+		if tag == nil || seen[tag.ID] {
+			continue
+		}
+		seen[tag.ID] = true
+		tags = append(tags, *tag)
+		names = append(names, tag.Name)
+	}
+	post.Tags = strings.Join(names, ",")
+
+	return tags, nil
+}
+
+// LinkPostTags replaces the tags linked to a post.
+func LinkPostTags(tx *gorm.DB, postID uint, tags []models.Tag) error {
+	if err := tx.Where("post_id = ?", postID).Delete(&models.PostTag{}).Error; err != nil {
+		return fmt.Errorf("failed to clear post tags: %w", err)
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	links := make([]models.PostTag, len(tags))
+	for i, tag := range tags {
+		links[i] = models.PostTag{PostID: postID, TagID: tag.ID}
+	}
+	if err := tx.Omit(clause.Associations).Create(&links).Error; err != nil {
+		return fmt.Errorf("failed to link post tags: %w", err)
+	}
+	return nil
+}
+
+// findOrCreateCategory returns the category with the slug of name, creating it if needed.
+// Names without any letter or digit are ignored (nil, nil).
+func findOrCreateCategory(tx *gorm.DB, name string) (*models.Category, error) {
+	categorySlug := slug.Make(name)
+	if categorySlug == "" {
+		return nil, nil
+	}
+
+	// Insert first and ignore conflicts so concurrent saves don't fail on the unique slug
+	category := models.Category{Name: name, Slug: categorySlug}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&category).Error; err != nil {
+		return nil, fmt.Errorf("failed to create category %q: %w", name, err)
+	}
+	if err := tx.Where("slug = ?", categorySlug).First(&category).Error; err != nil {
+		return nil, fmt.Errorf("failed to load category %q: %w", name, err)
+	}
+	return &category, nil
+}
+
+// findOrCreateTag returns the tag with the slug of name, creating it if needed.
+// Names without any letter or digit are ignored (nil, nil).
+func findOrCreateTag(tx *gorm.DB, name string) (*models.Tag, error) {
+	tagSlug := slug.Make(name)
+	if tagSlug == "" {
+		return nil, nil
+	}
+
+	tag := models.Tag{Name: name, Slug: tagSlug}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tag).Error; err != nil {
+		return nil, fmt.Errorf("failed to create tag %q: %w", name, err)
+	}
+	if err := tx.Where("slug = ?", tagSlug).First(&tag).Error; err != nil {
+		return nil, fmt.Errorf("failed to load tag %q: %w", name, err)
+	}
+	return &tag, nil
+}
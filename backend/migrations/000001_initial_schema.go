@@ -21,6 +21,24 @@ func MigrateSchema(db *gorm.DB) error {
 		&models.PostView{},
 		&models.DailyStat{},
 		&models.Comment{}, // Ensure Comment is also migrated if added in a later migration file originally
+		&models.PostSlugHistory{},
+		&models.PostRevision{},
+		&models.Category{},
+		&models.Tag{},
+		&models.PostTag{},
+		&models.Series{},
+		&models.SeriesPost{},
+		&models.PreviewLink{},
+		&models.ImportMapping{},
+		&models.SpamToken{},
+		&models.SpamClass{},
+		&models.RateLimitBucket{},
+		&models.EmailUnsubscribe{},
+		&models.VerifiedEmail{},
+		&models.ModerationLogEntry{},
+		&models.BlockRule{},
+		&models.CommentReaction{},
+		&models.CommentFlag{},
 	)
 }
 
@@ -47,6 +65,11 @@ func CreateIndices(db *gorm.DB) error {
 	// Post indices (Add category and tags)
 	db.Exec(`CREATE INDEX IF NOT EXISTS idx_posts_category ON posts(category)`)
 	db.Exec(`CREATE INDEX IF NOT EXISTS idx_posts_tags ON posts(tags)`) // Index for tags if needed for searching
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_posts_status_published_at ON posts(status, published_at)`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON posts USING GIN(search_vector)`) // Full-text search
+
+	// Post revisions are listed and pruned per post, newest first
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_post_revisions_post_id_created_at ON post_revisions(post_id, created_at DESC)`)
 
 	return nil
 }
@@ -92,6 +115,36 @@ func Initialize(db *gorm.DB) error {
 	// 	return err
 	// }
 
+	// Generate slugs for posts created before slugs existed
+	if err := Up_000004(db); err != nil {
+		return err
+	}
+
+	// Backfill status/published_at for posts created before the publishing lifecycle existed
+	if err := Up_000005(db); err != nil {
+		return err
+	}
+
+	// Add the generated tsvector column used by full-text search
+	if err := Up_000006(db); err != nil {
+		return err
+	}
+
+	// Move the free-text category/tags of existing posts into the categories, tags and post_tags tables
+	if err := Up_000007(db); err != nil {
+		return err
+	}
+
+	// Render and sanitize the content of posts saved before rendered_content existed
+	if err := Up_000008(db); err != nil {
+		return err
+	}
+
+	// Compute word counts, reading times and tables of contents of posts saved before they were derived from the content
+	if err := Up_000009(db); err != nil {
+		return err
+	}
+
 	// Then create indices
 	if err := CreateIndices(db); err != nil {
 		return err
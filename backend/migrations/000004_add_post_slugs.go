@@ -0,0 +1,56 @@
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/slug"
+	"gorm.io/gorm"
+)
+
+// Up_000004 backfills slugs for posts that were created before the slug column existed.
+// The column itself and the post_slug_histories table are created by AutoMigrate in MigrateSchema.
+func Up_000004(db *gorm.DB) error {
+	var posts []models.Post
+	if err := db.Select("id", "title").Where("slug IS NULL OR slug = ''").Order("id").Find(&posts).Error; err != nil {
+		return fmt.Errorf("failed to load posts without slug: %w", err)
+	}
+	if len(posts) == 0 {
+		return nil
+	}
+
+	// Collect slugs already in use so generated ones do not collide
+	var used []string
+	if err := db.Model(&models.Post{}).Where("slug IS NOT NULL AND slug <> ''").Pluck("slug", &used).Error; err != nil {
+		return fmt.Errorf("failed to load existing slugs: %w", err)
+	}
+	taken := make(map[string]bool, len(used))
+	for _, s := range used {
+		taken[s] = true
+	}
+
+	for _, post := range posts {
+		base := slug.Make(post.Title)
+		if base == "" {
+			base = "post"
+		}
+		candidate := base
+		for i := 2; taken[candidate]; i++ {
+			candidate = fmt.Sprintf("%s-%d", base, i)
+		}
+		taken[candidate] = true
+
+		if err := db.Model(&models.Post{}).Where("id = ?", post.ID).Update("slug", candidate).Error; err != nil {
+			return fmt.Errorf("failed to set slug for post %d: %w", post.ID, err)
+		}
+	}
+	return nil
+}
+
+// Down_000004 removes the slug column and the slug history table
+func Down_000004(db *gorm.DB) error {
+	if err := db.Migrator().DropTable(&models.PostSlugHistory{}); err != nil {
+		return err
+	}
+	return db.Migrator().DropColumn(&models.Post{}, "Slug")
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// Up_000005 backfills the lifecycle columns added to posts (status, published_at).
+// AutoMigrate adds the columns with status defaulting to 'published'; posts created before that
+// are published at their creation time if active, otherwise they become drafts.
+// Only rows left in that intermediate state (published without published_at) are touched, so it is safe to re-run.
+func Up_000005(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Post{}).
+			Where("status = ? AND published_at IS NULL AND is_active = ?", models.PostStatusPublished, true).
+			Update("published_at", gorm.Expr("created_at")).Error; err != nil {
+			return fmt.Errorf("failed to backfill published_at: %w", err)
+		}
+		if err := tx.Model(&models.Post{}).
+			Where("status = ? AND published_at IS NULL AND is_active = ?", models.PostStatusPublished, false).
+			Update("status", models.PostStatusDraft).Error; err != nil {
+			return fmt.Errorf("failed to backfill draft status: %w", err)
+		}
+		return nil
+	})
+}
+
+// Down_000005 removes the lifecycle columns from the posts table
+func Down_000005(db *gorm.DB) error {
+	if err := db.Migrator().DropColumn(&models.Post{}, "Status"); err != nil {
+		return err
+	}
+	return db.Migrator().DropColumn(&models.Post{}, "PublishedAt")
+}
@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// Up_000006 adds the generated full-text search column to posts. Title is weighted highest,
+// then summary together with category and tags, then the content with HTML tags stripped.
+// The GIN index on the column is created in CreateIndices.
+func Up_000006(db *gorm.DB) error {
+	if db.Migrator().HasColumn(&models.Post{}, "search_vector") {
+		return nil
+	}
+
+	cfg := models.PostSearchConfig
+	err := db.Exec(fmt.Sprintf(`
+		ALTER TABLE posts ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector('%[1]s', coalesce(title, '')), 'A') ||
+			setweight(to_tsvector('%[1]s', coalesce(summary, '')), 'B') ||
+			setweight(to_tsvector('%[1]s', coalesce(category, '') || ' ' || replace(coalesce(tags, ''), ',', ' ')), 'B') ||
+			setweight(to_tsvector('%[1]s', regexp_replace(coalesce(content, ''), '<[^>]+>', ' ', 'g')), 'C')
+		) STORED
+	`, cfg)).Error
+	if err != nil {
+		return fmt.Errorf("failed to add posts.search_vector: %w", err)
+	}
+	return nil
+}
+
+// Down_000006 removes the full-text search column and its index
+func Down_000006(db *gorm.DB) error {
+	if err := db.Exec(`DROP INDEX IF EXISTS idx_posts_search_vector`).Error; err != nil {
+		return err
+	}
+	return db.Exec(`ALTER TABLE posts DROP COLUMN IF EXISTS search_vector`).Error
+}
@@ -0,0 +1,107 @@
+package migrations
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/slug"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Up_000007 moves the free-text category and tags of existing posts into the categories, tags and
+// post_tags tables (created by AutoMigrate in MigrateSchema). Only posts that have a category without
+// category_id, or tags without any post_tags rows, are touched, so it is safe to re-run.
+func Up_000007(db *gorm.DB) error {
+	var posts []models.Post
+	err := db.Unscoped().Select("id", "category", "tags").
+		Where("(category <> '' AND category_id IS NULL) OR " +
+			"(tags <> '' AND NOT EXISTS (SELECT 1 FROM post_tags WHERE post_tags.post_id = posts.id))").
+		Find(&posts).Error
+	if err != nil {
+		return fmt.Errorf("failed to load posts for taxonomy backfill: %w", err)
+	}
+
+	for i := range posts {
+		post := &posts[i]
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return normalizePostTaxonomy(tx, post)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to backfill taxonomy for post %d: %w", post.ID, err)
+		}
+	}
+	return nil
+}
+
+// normalizePostTaxonomy links a post to the category and tags named in its legacy columns, creating them as needed,
+// and rewrites those columns with the canonical names
+func normalizePostTaxonomy(tx *gorm.DB, post *models.Post) error {
+	var categoryID *uint
+	category := strings.TrimSpace(post.Category)
+	if categorySlug := slug.Make(category); categorySlug != "" {
+		found := models.Category{Name: category, Slug: categorySlug}
+		if err := findOrCreateBySlug(tx, &found, categorySlug); err != nil {
+			return fmt.Errorf("category %q: %w", category, err)
+		}
+		categoryID, category = &found.ID, found.Name
+	} else {
+		category = ""
+	}
+
+	var links []models.PostTag
+	var names []string
+	seen := make(map[uint]bool)
+	for _, name := range strings.Split(post.Tags, ",") {
+		name = strings.TrimSpace(name)
+		tagSlug := slug.Make(name)
+		if tagSlug == "" {
+			continue
+		}
+		tag := models.Tag{Name: name, Slug: tagSlug}
+		if err := findOrCreateBySlug(tx, &tag, tagSlug); err != nil {
+			return fmt.Errorf("tag %q: %w", name, err)
+		}
+		if seen[tag.ID] {
+			continue
+		}
+		seen[tag.ID] = true
+		links = append(links, models.PostTag{PostID: post.ID, TagID: tag.ID})
+		names = append(names, tag.Name)
+	}
+
+	if err := tx.Model(&models.Post{}).Unscoped().Where("id = ?", post.ID).UpdateColumns(map[string]interface{}{
+		"category_id": categoryID,
+		"category":    category,
+		"tags":        strings.Join(names, ","),
+	}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("post_id = ?", post.ID).Delete(&models.PostTag{}).Error; err != nil {
+		return fmt.Errorf("failed to clear post tags: %w", err)
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	return tx.Omit(clause.Associations).Create(&links).Error
+}
+
+// findOrCreateBySlug inserts row unless a row with its slug exists, then loads the stored row into it
+func findOrCreateBySlug(tx *gorm.DB, row interface{}, slug string) error {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(row).Error; err != nil {
+		return fmt.Errorf("failed to create: %w", err)
+	}
+	if err := tx.Where("slug = ?", slug).First(row).Error; err != nil {
+		return fmt.Errorf("failed to load: %w", err)
+	}
+	return nil
+}
+
+// Down_000007 drops the taxonomy tables and the category_id column. The legacy category and tags columns are kept.
+func Down_000007(db *gorm.DB) error {
+	if err := db.Migrator().DropTable(&models.PostTag{}, &models.Tag{}, &models.Category{}); err != nil {
+		return err
+	}
+	return db.Migrator().DropColumn(&models.Post{}, "CategoryID")
+}
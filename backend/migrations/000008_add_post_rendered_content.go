@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/render"
+	"gorm.io/gorm"
+)
+
+// Up_000008 renders the sanitized HTML of posts created before content_format and rendered_content
+// existed (both columns are added by AutoMigrate). Only posts without rendered content are touched.
+func Up_000008(db *gorm.DB) error {
+	var posts []models.Post
+	return db.Select("id", "content", "content_format").
+		Where("rendered_content IS NULL OR rendered_content = ''").
+		FindInBatches(&posts, 100, func(tx *gorm.DB, batch int) error {
+			for _, post := range posts {
+				rendered, _ := render.Content(post.ContentFormat, post.Content)
+				if err := tx.Model(&models.Post{}).Where("id = ?", post.ID).UpdateColumn("rendered_content", rendered).Error; err != nil {
+					return fmt.Errorf("failed to render content of post %d: %w", post.ID, err)
+				}
+			}
+			return nil
+		}).Error
+}
+
+// Down_000008 removes the content format columns from posts and revisions
+func Down_000008(db *gorm.DB) error {
+	if err := db.Migrator().DropColumn(&models.Post{}, "RenderedContent"); err != nil {
+		return err
+	}
+	if err := db.Migrator().DropColumn(&models.Post{}, "ContentFormat"); err != nil {
+		return err
+	}
+	return db.Migrator().DropColumn(&models.PostRevision{}, "ContentFormat")
+}
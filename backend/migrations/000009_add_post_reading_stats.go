@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/render"
+	"gorm.io/gorm"
+)
+
+// Up_000009 fills word_count and toc of posts that predate them (the columns are added by AutoMigrate).
+// Their hand-typed read_time is kept as read_time_override, the estimate is only used where it was 0.
+// Rendering again also adds anchor ids to headings.
+// Every saved post gets a toc, an empty list if it has no headings, so a NULL toc marks the posts that
+// were not backfilled yet and each post is done once.
+func Up_000009(db *gorm.DB) error {
+	var posts []models.Post
+	return db.Unscoped().Select("id", "content", "content_format", "language", "read_time").
+		Where("toc IS NULL").
+		FindInBatches(&posts, 100, func(tx *gorm.DB, batch int) error {
+			for _, post := range posts {
+				post.ReadTimeOverride = post.ReadTime
+				render.Refresh(&post)
+				if err := tx.Model(&models.Post{}).Unscoped().Where("id = ?", post.ID).UpdateColumns(map[string]interface{}{
+					"rendered_content":   post.RenderedContent,
+					"toc":                post.TableOfContents,
+					"word_count":         post.WordCount,
+					"read_time_override": post.ReadTimeOverride,
+					"read_time":          post.ReadTime,
+				}).Error; err != nil {
+					return fmt.Errorf("failed to compute reading stats of post %d: %w", post.ID, err)
+				}
+			}
+			return nil
+		}).Error
+}
+
+// Down_000009 removes the reading stats columns from posts
+func Down_000009(db *gorm.DB) error {
+	for _, field := range []string{"TableOfContents", "ReadTimeOverride", "WordCount", "Language"} {
+		if err := db.Migrator().DropColumn(&models.Post{}, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package migrations
+
+import (
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// The series and series_posts tables are created by AutoMigrate in MigrateSchema; there is nothing to backfill.
+
+// Down_000010 drops the series tables. Posts are kept.
+func Down_000010(db *gorm.DB) error {
+	if err := db.Migrator().DropTable(&models.SeriesPost{}); err != nil {
+		return err
+	}
+	return db.Migrator().DropTable(&models.Series{})
+}
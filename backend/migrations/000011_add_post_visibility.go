@@ -0,0 +1,18 @@
+package migrations
+
+import (
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// The visibility and password_hash columns are added by AutoMigrate; existing posts default to public.
+
+// Down_000011 removes the visibility columns from posts, making every post public again
+func Down_000011(db *gorm.DB) error {
+	for _, field := range []string{"PasswordHash", "Visibility"} {
+		if err := db.Migrator().DropColumn(&models.Post{}, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package migrations
+
+import (
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// The preview_links table is created by AutoMigrate in MigrateSchema; there is nothing to backfill.
+
+// Down_000012 drops the preview links. Tokens already handed out stop working.
+func Down_000012(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.PreviewLink{})
+}
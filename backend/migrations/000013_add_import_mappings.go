@@ -0,0 +1,13 @@
+package migrations
+
+import (
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// The import_mappings table is created by AutoMigrate in MigrateSchema; there is nothing to backfill.
+
+// Down_000013 drops the import mappings. Imported posts and comments are kept, but importing again duplicates them.
+func Down_000013(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.ImportMapping{})
+}
@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// The spam columns of comments and the spam_tokens and spam_classes tables are added by AutoMigrate.
+// Existing comments keep a score of 0 and stay in the queue they are in.
+
+// Down_000014 removes spam scoring and forgets everything the classifier learned
+func Down_000014(db *gorm.DB) error {
+	for _, field := range []string{"IsSpam", "SpamScore", "SpamReasons", "ContentHash", "SpamTrainedAs"} {
+		if err := db.Migrator().DropColumn(&models.Comment{}, field); err != nil {
+			return err
+		}
+	}
+	return db.Migrator().DropTable(&models.SpamToken{}, &models.SpamClass{})
+}
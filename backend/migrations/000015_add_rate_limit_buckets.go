@@ -0,0 +1,13 @@
+package migrations
+
+import (
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// The rate_limit_buckets table is created by AutoMigrate; it is only used with RATE_LIMIT_BACKEND=postgres.
+
+// Down_000015 drops the rate limit buckets, every client starts with a full bucket again
+func Down_000015(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.RateLimitBucket{})
+}
@@ -0,0 +1,13 @@
+package migrations
+
+import (
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// The email_unsubscribes table is created by AutoMigrate in MigrateSchema.
+
+// Down_000016 drops the unsubscribes; every commenter gets reply notifications again
+func Down_000016(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.EmailUnsubscribe{})
+}
@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// The edit token and verification columns of comments and the verified_emails table are added by AutoMigrate.
+// Existing comments have no edit token, so only admins can change them.
+
+// Down_000017 removes self-service editing and forgets which emails were verified
+func Down_000017(db *gorm.DB) error {
+	for _, field := range []string{"EditTokenHash", "EditedAt", "AwaitingVerification"} {
+		if err := db.Migrator().DropColumn(&models.Comment{}, field); err != nil {
+			return err
+		}
+	}
+	return db.Migrator().DropTable(&models.VerifiedEmail{})
+}
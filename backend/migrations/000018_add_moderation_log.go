@@ -0,0 +1,13 @@
+package migrations
+
+import (
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// The moderation_log_entries table is created by AutoMigrate in MigrateSchema. Moderation before it is not logged.
+
+// Down_000018 drops the moderation log
+func Down_000018(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.ModerationLogEntry{})
+}
@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// The block_rules table and the submitter columns of comments are added by AutoMigrate. Existing comments have no
+// submitter IP, so only their email can be used to build rules.
+
+// Down_000019 drops the blocklist and the recorded submitter IPs and user agents
+func Down_000019(db *gorm.DB) error {
+	for _, field := range []string{"IPAddress", "UserAgent"} {
+		if err := db.Migrator().DropColumn(&models.Comment{}, field); err != nil {
+			return err
+		}
+	}
+	return db.Migrator().DropTable(&models.BlockRule{})
+}
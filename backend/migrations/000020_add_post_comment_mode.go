@@ -0,0 +1,14 @@
+package migrations
+
+import (
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// The comment_mode column is added by AutoMigrate; existing posts default to open and follow the site-wide
+// COMMENT_CLOSE_AFTER_DAYS policy.
+
+// Down_000020 removes the comment mode from posts, opening comments on every post again
+func Down_000020(db *gorm.DB) error {
+	return db.Migrator().DropColumn(&models.Post{}, "CommentMode")
+}
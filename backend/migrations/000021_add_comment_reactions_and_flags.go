@@ -0,0 +1,16 @@
+package migrations
+
+import (
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// The comment_reactions and comment_flags tables and the flag_count column of comments are added by AutoMigrate.
+
+// Down_000021 drops the reactions and flags of comments. Comments put back into moderation by flags stay there.
+func Down_000021(db *gorm.DB) error {
+	if err := db.Migrator().DropTable(&models.CommentFlag{}, &models.CommentReaction{}); err != nil {
+		return err
+	}
+	return db.Migrator().DropColumn(&models.Comment{}, "FlagCount")
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// The user_id column of comments and the display_name and avatar_url columns of users are added by AutoMigrate.
+// Comments from before have no user and are shown as written by readers.
+
+// Down_000022 removes the author link of comments and the profile of users. Replies of admins stay, without badge.
+func Down_000022(db *gorm.DB) error {
+	if err := db.Migrator().DropColumn(&models.Comment{}, "UserID"); err != nil {
+		return err
+	}
+	for _, field := range []string{"DisplayName", "AvatarURL"} {
+		if err := db.Migrator().DropColumn(&models.User{}, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
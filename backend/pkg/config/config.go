@@ -1,10 +1,14 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
@@ -22,7 +26,16 @@ type Config struct {
 	DBPort      string
 	JWTSecret   []byte
 	AppURL      string // Add AppURL field
+	Environment string // development or production; production refuses settings that are only safe locally
 	Image       ImageConfig
+	Post        PostConfig
+	Feed        FeedConfig
+	Sitemap     SitemapConfig
+	Archive     ArchiveConfig
+	Import      ImportConfig
+	Comment     CommentConfig
+	RateLimit   RateLimitConfig
+	Mail        MailConfig
 }
 
 type ImageConfig struct {
@@ -36,6 +49,90 @@ type ImageConfig struct {
 	StorageType  string // "local" or potentially "s3", "gcs" etc.
 }
 
+type PostConfig struct {
+	SchedulerInterval time.Duration // How often scheduled posts are checked for publishing
+	RevisionKeepLast  int           // Number of revisions kept per post, 0 keeps all
+	RevisionKeepDays  int           // Revisions older than this many days are removed, 0 keeps all
+	DefaultLanguage   string        // Language of posts created without one, used for the reading time estimate
+	AccessTokenTTL    time.Duration // Lifetime of the tokens that unlock password-protected posts
+	PreviewLinkTTL    time.Duration // Default lifetime of draft preview links
+	PreviewLinkMaxTTL time.Duration // Longest lifetime an admin may give a preview link
+}
+
+type FeedConfig struct {
+	Title       string
+	Description string
+	Language    string
+	Limit       int  // Number of posts included in a feed
+	FullContent bool // Include the full post content by default instead of only the summary
+}
+
+type SitemapConfig struct {
+	CacheTTL       time.Duration // Cached sitemaps are rebuilt after this long even if no post changed
+	RobotsDisallow []string      // Paths listed as Disallow in robots.txt
+}
+
+type ArchiveConfig struct {
+	MaxImportSizeMB int // Limit on the uncompressed size of imported archives
+}
+
+type ImportConfig struct {
+	MaxFileSizeMB int           // Limit on the size of WordPress and Ghost exports
+	ImageTimeout  time.Duration // Timeout of a featured image download
+}
+
+type CommentConfig struct {
+	MaxDepth      int           // Levels of nested replies shown before replies are listed flat
+	SpamThreshold float64       // Comments scoring at least this much (0 to 1) go to the spam bucket
+	MaxLinks      int           // Links a comment may contain before the spam filter grows suspicious
+	BlockedWords  []string      // Words and phrases that mark a comment as spam
+	MinSubmitTime time.Duration // Comments submitted sooner after loading the form are likely spam
+	FormTokenTTL  time.Duration // Lifetime of comment form tokens
+	EditWindow    time.Duration // How long authors can edit and withdraw their comments; 0 disables it
+	// Publish comments once their author confirms the email, instead of moderating them
+	VerifyEmails    bool
+	VerificationTTL time.Duration // Lifetime of email verification links
+	CloseAfterDays  int           // Comments on a post close this many days after its publication; 0 keeps them open
+	Reactions       []string      // Emojis readers can react to comments with
+	FlagThreshold   int           // Flags from distinct networks that put an approved comment back into moderation; 0 only records them
+	FlagsPerDay     int           // Comments a client IP may flag per day; 0 doesn't limit them
+}
+
+type RateLimitConfig struct {
+	Backend        string        // memory, or postgres to share the limits between instances
+	TrustedProxies []string      // Proxies allowed to set the client IP through X-Forwarded-For, see DefaultTrustedProxies
+	PerRoute       []string      // Names of the limits below that keep separate buckets for each route they guard
+	Comments       RateLimitRule // POST /api/posts/:id/comments
+	Likes          RateLimitRule // POST /api/posts/:id/like
+	Shares         RateLimitRule // POST /api/posts/:id/share
+	Unlock         RateLimitRule // POST /api/posts/:id/unlock, limits password guessing
+	Reactions      RateLimitRule // POST /api/comments/:comment_id/reactions
+	Flags          RateLimitRule // POST /api/comments/:comment_id/flags
+}
+
+type MailConfig struct {
+	Driver       string // smtp, file to write .eml files into Dir, log (links are redacted) or disabled; required in production
+	From         string // Sender address, e.g. "Blog <blog@example.com>"
+	SMTPHost     string
+	SMTPPort     int // 465 uses implicit TLS, other ports STARTTLS
+	SMTPUsername string
+	SMTPPassword string
+	Dir          string        // Directory of the file driver
+	AdminEmails  []string      // Notified of comments awaiting moderation
+	MaxAttempts  int           // Attempts per email before it is given up
+	RetryDelay   time.Duration // Delay before the first retry, doubled for every further one
+}
+
+// DefaultTrustedProxies are trusted when TRUSTED_PROXIES is unset: loopback and private networks, where the Next.js
+// server and the reverse proxy in front of the backend run. An empty TRUSTED_PROXIES trusts no proxy.
+var DefaultTrustedProxies = []string{"127.0.0.1", "::1", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"}
+
+// RateLimitRule allows Requests requests per Period and client; a rule without requests disables the limit
+type RateLimitRule struct {
+	Requests int
+	Period   time.Duration
+}
+
 // Loads the configuration from environment variables end returns a Config struct
 func LoadConfig() *Config {
 	err := godotenv.Load()
@@ -54,6 +151,7 @@ func LoadConfig() *Config {
 		DBPort:      getEnv("DB_PORT", "5433"),
 		JWTSecret:   []byte(getEnv("JWT_SECRET", "secret-key")),
 		AppURL:      getEnv("APP_URL", "https://blog.dervisgenc.com"), // Provide a default for local dev
+		Environment: getEnv("APP_ENV", "development"),
 		Image: ImageConfig{
 			StoragePath:  "uploads/images",
 			MaxSizeMB:    5,
@@ -64,16 +162,100 @@ func LoadConfig() *Config {
 			MaxHeight:    1080,
 			StorageType:  "local", // Default storage type
 		},
+		Post: PostConfig{
+			SchedulerInterval: getEnvDuration("POST_SCHEDULER_INTERVAL", time.Minute),
+			RevisionKeepLast:  getEnvInt("POST_REVISION_KEEP_LAST", 50),
+			RevisionKeepDays:  getEnvInt("POST_REVISION_KEEP_DAYS", 0),
+			DefaultLanguage:   getEnv("POST_DEFAULT_LANGUAGE", "en"),
+			AccessTokenTTL:    getEnvDuration("POST_ACCESS_TOKEN_TTL", 30*time.Minute),
+			PreviewLinkTTL:    getEnvDuration("POST_PREVIEW_LINK_TTL", 7*24*time.Hour),
+			PreviewLinkMaxTTL: getEnvDuration("POST_PREVIEW_LINK_MAX_TTL", 30*24*time.Hour),
+		},
+		Feed: FeedConfig{
+			Title:       getEnv("FEED_TITLE", "CyberTron - Tech & Cybersecurity Blog"),
+			Description: getEnv("FEED_DESCRIPTION", "Latest posts from the CyberTron blog"),
+			Language:    getEnv("FEED_LANGUAGE", "en"),
+			Limit:       getEnvInt("FEED_LIMIT", 20),
+			FullContent: getEnv("FEED_FULL_CONTENT", "true") == "true",
+		},
+		Sitemap: SitemapConfig{
+			CacheTTL:       getEnvDuration("SITEMAP_CACHE_TTL", time.Hour),
+			RobotsDisallow: getEnvList("ROBOTS_DISALLOW", []string{"/admin", "/api/admin"}),
+		},
+		Archive: ArchiveConfig{
+			MaxImportSizeMB: getEnvInt("ARCHIVE_MAX_IMPORT_SIZE_MB", 512),
+		},
+		Import: ImportConfig{
+			MaxFileSizeMB: getEnvInt("IMPORT_MAX_FILE_SIZE_MB", 256),
+			ImageTimeout:  getEnvDuration("IMPORT_IMAGE_TIMEOUT", 30*time.Second),
+		},
+		RateLimit: RateLimitConfig{
+			Backend:        getEnv("RATE_LIMIT_BACKEND", "memory"),
+			TrustedProxies: getEnvList("TRUSTED_PROXIES", DefaultTrustedProxies),
+			PerRoute:       getEnvList("RATE_LIMIT_PER_ROUTE", nil),
+			Comments:       getEnvRate("RATE_LIMIT_COMMENTS", RateLimitRule{5, 10 * time.Minute}),
+			Likes:          getEnvRate("RATE_LIMIT_LIKES", RateLimitRule{30, time.Minute}),
+			Shares:         getEnvRate("RATE_LIMIT_SHARES", RateLimitRule{10, time.Minute}),
+			Unlock:         getEnvRate("RATE_LIMIT_UNLOCK", RateLimitRule{10, 15 * time.Minute}),
+			Reactions:      getEnvRate("RATE_LIMIT_REACTIONS", RateLimitRule{30, time.Minute}),
+			Flags:          getEnvRate("RATE_LIMIT_FLAGS", RateLimitRule{5, 10 * time.Minute}),
+		},
+		Comment: CommentConfig{
+			MaxDepth:      getEnvInt("COMMENT_MAX_DEPTH", 4),
+			SpamThreshold: getEnvFloat("COMMENT_SPAM_THRESHOLD", 0.9),
+			MaxLinks:      getEnvInt("COMMENT_MAX_LINKS", 2),
+			BlockedWords:  getEnvList("COMMENT_BLOCKED_WORDS", nil),
+			MinSubmitTime: getEnvDuration("COMMENT_MIN_SUBMIT_TIME", 3*time.Second),
+			FormTokenTTL:  getEnvDuration("COMMENT_FORM_TOKEN_TTL", 24*time.Hour),
+			EditWindow:    getEnvDuration("COMMENT_EDIT_WINDOW", 15*time.Minute),
+
+			VerifyEmails:    getEnv("COMMENT_VERIFY_EMAILS", "false") == "true",
+			VerificationTTL: getEnvDuration("COMMENT_VERIFICATION_TTL", 72*time.Hour),
+			CloseAfterDays:  getEnvInt("COMMENT_CLOSE_AFTER_DAYS", 0),
+			Reactions:       getEnvList("COMMENT_REACTIONS", []string{"👍", "❤️", "😂", "😮", "😢"}),
+			FlagThreshold:   getEnvInt("COMMENT_FLAG_THRESHOLD", 3),
+			FlagsPerDay:     getEnvInt("COMMENT_FLAGS_PER_DAY", 10),
+		},
+		Mail: MailConfig{
+			Driver:       getEnv("MAIL_DRIVER", ""),
+			From:         getEnv("MAIL_FROM", ""),
+			SMTPHost:     getEnv("SMTP_HOST", ""),
+			SMTPPort:     getEnvInt("SMTP_PORT", 587),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			Dir:          getEnv("MAIL_DIR", "./mail"),
+			AdminEmails:  getEnvList("MAIL_ADMIN_EMAILS", nil),
+			MaxAttempts:  getEnvInt("MAIL_MAX_ATTEMPTS", 5),
+			RetryDelay:   getEnvDuration("MAIL_RETRY_DELAY", 30*time.Second),
+		},
 	}
 
 	// Add checks for required fields
 	if cfg.DBHost == "" || cfg.DBPort == "" || cfg.DBUser == "" || cfg.DBPassword == "" || cfg.DBName == "" || len(cfg.JWTSecret) == 0 || cfg.AppURL == "" {
 		log.Fatal("Missing required environment variables (DB_HOST, DB_PORT, DB_USER, DB_PASSWORD, DB_NAME, JWT_SECRET, APP_URL)")
 	}
+	if cfg.Mail.Driver, err = resolveMailDriver(cfg.Mail.Driver, cfg.Environment); err != nil {
+		log.Fatal(err)
+	}
 
 	return &cfg
 }
 
+// resolveMailDriver returns the mail driver to use. Without MAIL_DRIVER no email is sent, except in production,
+// which has to choose a driver explicitly.
+func resolveMailDriver(driver, environment string) (string, error) {
+	switch driver {
+	case "":
+		if environment == "production" {
+			return "", fmt.Errorf("MAIL_DRIVER is required in production (smtp, file, log or disabled)")
+		}
+		return "disabled", nil
+	case "smtp", "file", "log", "disabled":
+		return driver, nil
+	}
+	return "", fmt.Errorf("unknown MAIL_DRIVER %q (smtp, file, log or disabled)", driver)
+}
+
 // return value from env or default value
 func getEnv(key, fallback string) string {
 	if value, exists := os.LookupEnv(key); exists {
@@ -82,6 +264,49 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// return int from env or default value
+func getEnvInt(key string, fallback int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid integer for %s (%q), using default %d", key, value, fallback)
+		return fallback
+	}
+	return i
+}
+
+// return comma separated list from env or default value; an empty variable gives an empty list
+func getEnvList(key string, fallback []string) []string {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// return duration from env (e.g. "30s", "5m") or default value
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid duration for %s (%q), using default %s", key, value, fallback)
+		return fallback
+	}
+	return d
+}
+
 // Set up logger based on config
 func SetupLogger(cfg *Config) *logrus.Logger {
 	log := logrus.New()
@@ -129,3 +354,36 @@ func getDefaultLogPath() string {
 
 	return logPath
 }
+
+// return float from env or default value
+func getEnvFloat(key string, fallback float64) float64 {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid number for %s (%q), using default %g", key, value, fallback)
+		return fallback
+	}
+	return f
+}
+
+// return rate limit rule from env in the form "requests/period" (e.g. "5/10m"), "off" to disable, or default value
+func getEnvRate(key string, fallback RateLimitRule) RateLimitRule {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	if value == "off" {
+		return RateLimitRule{}
+	}
+	requests, period, ok := strings.Cut(value, "/")
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	d, perr := time.ParseDuration(strings.TrimSpace(period))
+	if !ok || err != nil || perr != nil || n < 1 || d <= 0 {
+		log.Printf("Invalid rate limit for %s (%q), using default %d/%s", key, value, fallback.Requests, fallback.Period)
+		return fallback
+	}
+	return RateLimitRule{Requests: n, Period: d}
+}
@@ -0,0 +1,24 @@
+package config
+
+import "testing"
+
+func TestResolveMailDriver(t *testing.T) {
+	tests := []struct {
+		driver, environment string
+		want                string
+		wantErr             bool
+	}{
+		{"", "development", "disabled", false},
+		{"", "production", "", true},
+		{"smtp", "production", "smtp", false},
+		{"disabled", "production", "disabled", false},
+		{"log", "development", "log", false},
+		{"sendmail", "development", "", true},
+	}
+	for _, tt := range tests {
+		got, err := resolveMailDriver(tt.driver, tt.environment)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("resolveMailDriver(%q, %q) = %q, %v", tt.driver, tt.environment, got, err)
+		}
+	}
+}
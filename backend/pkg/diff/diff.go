@@ -0,0 +1,238 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around each change, like `diff -u`.
+const DefaultContext = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	text string
+}
+
+// Unified returns a unified diff between a and b. fromName and toName are used in the
+// ---/+++ header lines. An empty string is returned when both texts are equal.
+func Unified(fromName, toName, a, b string, context int) string {
+	if a == b {
+		return ""
+	}
+	if context < 0 {
+		context = DefaultContext
+	}
+
+	ops := lineOps(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range hunks(ops, context) {
+		out.WriteString(h)
+	}
+	return out.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// maxEdits caps the number of inserted and deleted lines lineOps searches for. Windows that differ in more lines
+// are shown as the old lines replaced by the new ones, which keeps a diff O((n+m)·maxEdits) in time and
+// O(maxEdits²) in memory however large the posts are.
+const maxEdits = 1000
+
+// lineOps computes the edit script between a and b with the Myers algorithm.
+// Common prefix and suffix are trimmed first so typical edits only search a small window.
+func lineOps(a, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, op{opEqual, line})
+	}
+
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if middle := shortestEdit(ma, mb); middle != nil {
+		ops = append(ops, middle...)
+	} else {
+		for _, line := range ma {
+			ops = append(ops, op{opDelete, line})
+		}
+		for _, line := range mb {
+			ops = append(ops, op{opInsert, line})
+		}
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, op{opEqual, line})
+	}
+	return ops
+}
+
+// shortestEdit returns a shortest edit script turning a into b, or nil if it needs more than maxEdits edits.
+// v[k] is the furthest line of a reached on diagonal k = x - y; trace keeps v[-d..d] of every step d for the
+// walk back from the end.
+func shortestEdit(a, b []string) []op {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxEdits)
+	offset := limit + 1
+	v := make([]int32, 2*offset+1)
+	var trace [][]int32
+
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = int(v[offset+k+1]) // Insert a line of b
+			} else {
+				x = int(v[offset+k-1]) + 1 // Delete a line of a
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = int32(x)
+			if x >= n && y >= m {
+				trace = append(trace, append([]int32(nil), v[offset-d:offset+d+1]...))
+				return backtrack(a, b, trace)
+			}
+		}
+		trace = append(trace, append([]int32(nil), v[offset-d:offset+d+1]...))
+	}
+	return nil
+}
+
+// backtrack follows the steps recorded by shortestEdit from the end of both texts back to their start
+func backtrack(a, b []string, trace [][]int32) []op {
+	x, y := len(a), len(b)
+	ops := make([]op, 0, len(a)+len(b))
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1] // prev[k+d-1] is the furthest x on diagonal k after step d-1
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+			prevK = k + 1
+		}
+		prevX := int(prev[prevK+d-1])
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, op{opEqual, a[x-1]})
+			x--
+			y--
+		}
+		if prevK == k+1 {
+			ops = append(ops, op{opInsert, b[y-1]})
+			y--
+		} else {
+			ops = append(ops, op{opDelete, a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		ops = append(ops, op{opEqual, a[x-1]})
+		x--
+		y--
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// hunks groups the edit script into @@ hunks with the given amount of context.
+func hunks(ops []op, context int) []string {
+	var result []string
+
+	for start := 0; start < len(ops); {
+		// Find the next change
+		for start < len(ops) && ops[start].kind == opEqual {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// Extend the hunk while changes are closer than 2*context lines apart
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == opEqual {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				break
+			}
+			end = run
+		}
+
+		from := max(start-context, 0)
+		to := min(end+context, len(ops))
+
+		// Line numbers (1-based) of the hunk in the old and new text
+		oldLine, newLine := 1, 1
+		for _, o := range ops[:from] {
+			if o.kind != opInsert {
+				oldLine++
+			}
+			if o.kind != opDelete {
+				newLine++
+			}
+		}
+
+		var body strings.Builder
+		oldCount, newCount := 0, 0
+		for _, o := range ops[from:to] {
+			switch o.kind {
+			case opEqual:
+				body.WriteString(" " + o.text + "\n")
+				oldCount++
+				newCount++
+			case opDelete:
+				body.WriteString("-" + o.text + "\n")
+				oldCount++
+			case opInsert:
+				body.WriteString("+" + o.text + "\n")
+				newCount++
+			}
+		}
+
+		result = append(result, fmt.Sprintf("@@ -%s +%s @@\n%s", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount), body.String()))
+		start = to
+	}
+	return result
+}
+
+func hunkRange(line, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", line-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
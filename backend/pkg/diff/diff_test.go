@@ -0,0 +1,107 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnified(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive\n"
+	b := "one\ntwo\n3\nfour\nfive\nsix\n"
+	want := "--- a\n+++ b\n@@ -1,5 +1,6 @@\n one\n two\n-three\n+3\n four\n five\n+six\n"
+	if got := Unified("a", "b", a, b, DefaultContext); got != want {
+		t.Errorf("Unified\n got: %q\nwant: %q", got, want)
+	}
+	if got := Unified("a", "b", a, a, DefaultContext); got != "" {
+		t.Errorf("Unified of equal texts = %q, want empty", got)
+	}
+}
+
+// apply returns the old and new texts described by an edit script
+func apply(ops []op) (a, b []string) {
+	for _, o := range ops {
+		if o.kind != opInsert {
+			a = append(a, o.text)
+		}
+		if o.kind != opDelete {
+			b = append(b, o.text)
+		}
+	}
+	return a, b
+}
+
+func edits(ops []op) int {
+	n := 0
+	for _, o := range ops {
+		if o.kind != opEqual {
+			n++
+		}
+	}
+	return n
+}
+
+// lcsLength is the textbook dynamic program, the reference for the shortest edit script
+func lcsLength(a, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	return lcs[0][0]
+}
+
+func TestLineOpsIsShortest(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	text := func() []string {
+		lines := make([]string, rng.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.Intn(4)))
+		}
+		return lines
+	}
+	for i := 0; i < 2000; i++ {
+		a, b := text(), text()
+		ops := lineOps(a, b)
+		gotA, gotB := apply(ops)
+		if strings.Join(gotA, "\n") != strings.Join(a, "\n") || strings.Join(gotB, "\n") != strings.Join(b, "\n") {
+			t.Fatalf("edit script of %q -> %q doesn't reproduce the texts", a, b)
+		}
+		if want := len(a) + len(b) - 2*lcsLength(a, b); edits(ops) != want {
+			t.Fatalf("edit script of %q -> %q has %d edits, want %d", a, b, edits(ops), want)
+		}
+	}
+}
+
+func TestLineOpsIsBounded(t *testing.T) {
+	const lines = 50000
+	a, b := make([]string, lines), make([]string, lines)
+	for i := range a {
+		a[i] = fmt.Sprintf("old %d", i)
+		b[i] = fmt.Sprintf("new %d", i)
+	}
+	b[lines/2] = a[lines/2]
+
+	start := time.Now()
+	ops := lineOps(a, b)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("diff of two %d line texts took %v", lines, elapsed)
+	}
+	gotA, gotB := apply(ops)
+	if len(gotA) != lines || len(gotB) != lines || gotA[lines-1] != a[lines-1] || gotB[lines-1] != b[lines-1] {
+		t.Fatal("edit script doesn't reproduce the texts")
+	}
+	if edits(ops) != 2*lines {
+		t.Errorf("texts beyond maxEdits have %d edits, want every line replaced (%d)", edits(ops), 2*lines)
+	}
+}
@@ -0,0 +1,18 @@
+package dto
+
+// ArchiveImportResult summarizes an archive import
+type ArchiveImportResult struct {
+	Created int                   `json:"created"`
+	Updated int                   `json:"updated"`
+	Images  int                   `json:"images"` // Images stored from the archive, including ones that were already present
+	Posts   []ArchiveImportedPost `json:"posts"`
+}
+
+// ArchiveImportedPost is a post written by an archive import
+type ArchiveImportedPost struct {
+	ID      uint   `json:"id"`
+	Slug    string `json:"slug"`
+	Title   string `json:"title"`
+	File    string `json:"file"` // Path of the post inside the archive
+	Created bool   `json:"created"`
+}
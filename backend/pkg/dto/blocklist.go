@@ -0,0 +1,16 @@
+package dto
+
+// BlockRuleRequest defines a block rule
+type BlockRuleRequest struct {
+	Kind    string `json:"kind" binding:"required,oneof=ip cidr email domain phrase"`
+	Pattern string `json:"pattern" binding:"required,max=255"` // IP, CIDR range, email, domain or regular expression
+	Action  string `json:"action" binding:"required,oneof=reject hold discard"`
+	Note    string `json:"note,omitempty" binding:"max=255"`
+}
+
+// BlockRuleFromCommentRequest blocks the IP, email or email domain of an existing comment
+type BlockRuleFromCommentRequest struct {
+	Kind   string `json:"kind" binding:"required,oneof=ip email domain"`
+	Action string `json:"action" binding:"required,oneof=reject hold discard"`
+	Note   string `json:"note,omitempty" binding:"max=255"` // Defaults to the comment it was built from
+}
@@ -8,16 +8,83 @@ type CommentCreateRequest struct {
 	AuthorEmail     string `json:"author_email" binding:"required,email"`
 	Content         string `json:"content" binding:"required,min=1,max=2000"`
 	ParentCommentID *uint  `json:"parent_comment_id,omitempty"` // Optional for replies
+	Website         string `json:"website,omitempty"`           // Honeypot: a field hidden from people, only bots fill it in
+	FormToken       string `json:"form_token,omitempty"`        // From GET /posts/{id}/comments/form-token when the form was loaded
+}
+
+// CommentCreateResponse is returned for a new comment. The edit token is only ever shown here.
+type CommentCreateResponse struct {
+	Message       string     `json:"message"`
+	ID            uint       `json:"id"`
+	Status        string     `json:"status" enums:"pending,approved,awaiting_verification"`
+	EditToken     string     `json:"edit_token,omitempty"`     // Send as X-Comment-Edit-Token to edit or withdraw the comment; unset when authors can't edit
+	EditableUntil *time.Time `json:"editable_until,omitempty"` // End of the edit window, unset when authors can't edit
+}
+
+// CommentUpdateRequest is the new content of a comment edited by its author
+type CommentUpdateRequest struct {
+	Content string `json:"content" binding:"required,min=1,max=2000"`
+}
+
+// CommentFormTokenResponse holds the token the comment form sends with a comment
+type CommentFormTokenResponse struct {
+	Token string `json:"token"`
 }
 
 // CommentResponse defines the structure for a comment returned to the public.
 type CommentResponse struct {
-	ID              uint      `json:"id"`
-	CreatedAt       time.Time `json:"created_at"`
-	AuthorName      string    `json:"author_name"`
-	Content         string    `json:"content"`
-	ParentCommentID *uint     `json:"parent_comment_id,omitempty"`
-	// Replies    []CommentResponse `json:"replies,omitempty"` // Include if supporting nested replies directly
+	ID              uint              `json:"id"`
+	CreatedAt       time.Time         `json:"created_at"`
+	AuthorName      string            `json:"author_name"`
+	Content         string            `json:"content"`
+	ParentCommentID *uint             `json:"parent_comment_id,omitempty"`
+	EditedAt        *time.Time        `json:"edited_at,omitempty"`
+	Deleted         bool              `json:"deleted,omitempty"`   // Tombstone of a deleted comment kept for its replies, without author and content
+	ReplyCount      int               `json:"reply_count"`         // Visible replies at any depth below this comment
+	Reactions       map[string]int    `json:"reactions,omitempty"` // Number of readers who gave each reaction
+	IsAuthor        bool              `json:"is_author"`           // Written by the blog author from the dashboard
+	Author          *CommentAuthor    `json:"author,omitempty"`    // Profile of the blog author, set with is_author
+	Replies         []CommentResponse `json:"replies,omitempty"`
+}
+
+// CommentAuthor is the public profile of the blog author on their comments
+type CommentAuthor struct {
+	DisplayName string `json:"display_name"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+}
+
+// CommentReplyRequest is the reply of the blog author to a comment
+type CommentReplyRequest struct {
+	Content string `json:"content" binding:"required,min=1,max=2000"`
+}
+
+// CommentThreadsResponse is a page of the comment threads of a post, oldest thread first.
+type CommentThreadsResponse struct {
+	Threads       []CommentResponse `json:"threads"`
+	TotalThreads  int               `json:"total_threads"`
+	TotalComments int               `json:"total_comments"` // Visible comments of the post, replies included
+	Page          int               `json:"current_page"`
+	PageSize      int               `json:"page_size"`
+	TotalPages    int               `json:"total_pages"`
+	Reactions     []string          `json:"reactions"` // Emojis readers can react to comments with
+}
+
+// CommentReactionRequest names the reaction a reader gives to or takes back from a comment
+type CommentReactionRequest struct {
+	Emoji string `json:"emoji" binding:"required"`
+}
+
+// CommentReactionResponse tells whether the reader gives the reaction now, with the new reaction counts of the comment
+type CommentReactionResponse struct {
+	Emoji     string         `json:"emoji"`
+	Reacted   bool           `json:"reacted"`
+	Reactions map[string]int `json:"reactions"`
+}
+
+// CommentFlagRequest reports a comment to the moderators
+type CommentFlagRequest struct {
+	Reason  string `json:"reason" binding:"required" enums:"spam,abuse,off_topic,other"`
+	Details string `json:"details,omitempty" binding:"max=500"`
 }
 
 // AdminCommentResponse defines the structure for a comment returned to the admin panel.
@@ -33,4 +100,40 @@ type AdminCommentResponse struct {
 	ApprovedAt      *time.Time `json:"approved_at,omitempty"`
 	ParentCommentID *uint      `json:"parent_comment_id,omitempty"`
 	PostTitle       string     `json:"post_title,omitempty"` // Include post title for context
+	IsSpam          bool       `json:"is_spam"`
+	SpamScore       float64    `json:"spam_score"`
+	SpamReasons     string     `json:"spam_reasons,omitempty"`
+	EditedAt        *time.Time `json:"edited_at,omitempty"`
+	// Held until the author verifies the email; approving it publishes it without verification
+	AwaitingVerification bool   `json:"awaiting_verification"`
+	IPAddress            string `json:"ip_address,omitempty"` // Not recorded for imported and older comments
+	UserAgent            string `json:"user_agent,omitempty"`
+	FlagCount            int    `json:"flag_count"` // Flags by readers since the comment was last approved
+	IsAuthor             bool   `json:"is_author"`  // Reply of an admin from the dashboard
+}
+
+// CommentBulkRequest selects the comments of a bulk moderation action: the listed IDs, or every comment matching the filter
+type CommentBulkRequest struct {
+	IDs    []uint             `json:"ids,omitempty"`
+	Filter *CommentBulkFilter `json:"filter,omitempty"`
+}
+
+// CommentBulkFilter matches comments by every field that is set; at least one must be
+type CommentBulkFilter struct {
+	PostID      uint       `json:"post_id,omitempty"`
+	AuthorEmail string     `json:"author_email,omitempty"`                             // Matched case-insensitively
+	From        *time.Time `json:"from,omitempty"`                                     // Created at or after
+	To          *time.Time `json:"to,omitempty"`                                       // Created before
+	Status      string     `json:"status,omitempty" enums:"pending,approved,spam,all"` // Default: all
+}
+
+// CommentBulkResponse reports the comments a bulk moderation action changed
+type CommentBulkResponse struct {
+	Action   string `json:"action"`
+	Affected int    `json:"affected"`
+	IDs      []uint `json:"ids"`
+	BatchID  string `json:"batch_id,omitempty"` // Finds the action in the moderation log
+	// The filter selected as many comments as one action handles, more may match it. Narrow down the filter, or send
+	// it again if the action takes the comments out of it.
+	Capped bool `json:"capped,omitempty"`
 }
@@ -0,0 +1,20 @@
+package dto
+
+// ImportSummary reports what an import of another blog's export did, or would do for a dry run
+type ImportSummary struct {
+	Source        string      `json:"source"` // wordpress or ghost
+	DryRun        bool        `json:"dry_run"`
+	Posts         ImportCount `json:"posts"`
+	Comments      ImportCount `json:"comments"`
+	Images        ImportCount `json:"images"` // Featured images; a dry run counts the downloads it would make as created
+	NewCategories int         `json:"new_categories"`
+	NewTags       int         `json:"new_tags"`
+	Warnings      []string    `json:"warnings"`
+}
+
+// ImportCount counts the items of one kind. Skipped items were imported before and are left untouched.
+type ImportCount struct {
+	Created int `json:"created"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
@@ -4,12 +4,15 @@ import (
 	"time"
 
 	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/render"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/toc"
 )
 
 // PostResponse represents the API response for a post
 type PostResponse struct {
 	ID        uint      `json:"id"`
 	Title     string    `json:"title"`
+	Slug      string    `json:"slug"`
 	Summary   string    `json:"summary"`
 	Content   string    `json:"content,omitempty"`
 	ImageURL  string    `json:"image_url"`
@@ -23,54 +26,124 @@ type PostResponse struct {
 
 // PostCreateRequest represents the request to create a post
 type PostCreateRequest struct {
-	Title       string `json:"title" binding:"required"`
-	Content     string `json:"content" binding:"required"`
-	Description string `json:"description"` // Frontend sends 'description', maps to 'Summary'
-	ReadTime    int    `json:"readTime"`    // Frontend sends 'readTime', maps to 'ReadTime'
-	IsActive    bool   `json:"isActive"`    // Frontend sends 'isActive', maps to 'IsActive'
-	ImageURL    string `json:"imageUrl"`    // Frontend sends 'imageUrl', maps to 'ImageURL'
-	Category    string `json:"category"`    // Added Category
-	Tags        string `json:"tags"`        // Added Tags (comma-separated string)
+	Title            string     `json:"title" binding:"required"`
+	Slug             string     `json:"slug"` // Optional, generated from the title when empty
+	Content          string     `json:"content" binding:"required"`
+	Description      string     `json:"description"`      // Frontend sends 'description', maps to 'Summary'
+	ReadTime         int        `json:"readTime"`         // Read time in minutes, only used with overrideReadTime
+	OverrideReadTime bool       `json:"overrideReadTime"` // Use readTime instead of the estimate computed from the content
+	IsActive         bool       `json:"isActive"`         // Frontend sends 'isActive', maps to 'IsActive'
+	ImageURL         string     `json:"imageUrl"`         // Frontend sends 'imageUrl', maps to 'ImageURL'
+	Category         string     `json:"category"`         // Added Category
+	Tags             TagList    `json:"tags"`             // Array of tags; a comma-separated string is still accepted
+	Status           string     `json:"status"`           // draft, scheduled, published or archived; derived from isActive when empty
+	PublishedAt      *time.Time `json:"publishedAt"`      // Required for scheduled posts, defaults to now when publishing
+	ContentFormat    string     `json:"contentFormat"`    // html (default) or markdown
+	Language         string     `json:"language"`         // e.g. en or tr, defaults to POST_DEFAULT_LANGUAGE
+	Visibility       string     `json:"visibility"`       // public (default), unlisted or password
+	Password         string     `json:"password"`         // Required for password visibility
+	CommentMode      string     `json:"commentMode"`      // open (default), closed, moderated or auto_approve
 }
 
 // PostUpdateRequest represents the request to update a post
 type PostUpdateRequest struct {
-	Title       string `json:"title" binding:"required"`
-	Content     string `json:"content" binding:"required"`
-	Description string `json:"description"` // Frontend sends 'description', maps to 'Summary'
-	ReadTime    int    `json:"readTime"`    // Frontend sends 'readTime', maps to 'ReadTime'
-	IsActive    bool   `json:"isActive"`    // Frontend sends 'isActive', maps to 'IsActive'
-	ImageURL    string `json:"imageUrl"`    // Frontend sends 'imageUrl', maps to 'ImageURL'
-	Category    string `json:"category"`    // Added Category
-	Tags        string `json:"tags"`        // Added Tags (comma-separated string)
+	Title            string     `json:"title" binding:"required"`
+	Slug             string     `json:"slug"` // Optional, generated from the title when empty
+	Content          string     `json:"content" binding:"required"`
+	Description      string     `json:"description"`      // Frontend sends 'description', maps to 'Summary'
+	ReadTime         int        `json:"readTime"`         // Read time in minutes, only used with overrideReadTime
+	OverrideReadTime bool       `json:"overrideReadTime"` // Use readTime instead of the estimate; false clears a previous override
+	IsActive         bool       `json:"isActive"`         // Frontend sends 'isActive', maps to 'IsActive'
+	ImageURL         string     `json:"imageUrl"`         // Frontend sends 'imageUrl', maps to 'ImageURL'
+	Category         string     `json:"category"`         // Added Category
+	Tags             TagList    `json:"tags"`             // Array of tags; a comma-separated string is still accepted
+	Status           string     `json:"status"`           // draft, scheduled, published or archived; derived from isActive when empty
+	PublishedAt      *time.Time `json:"publishedAt"`      // Required for scheduled posts, defaults to now when publishing
+	ContentFormat    string     `json:"contentFormat"`    // html or markdown; keeps the current format when empty
+	Language         string     `json:"language"`         // Keeps the current language when empty
+	Visibility       string     `json:"visibility"`       // public, unlisted or password; keeps the current visibility when empty
+	Password         string     `json:"password"`         // New password for password visibility, empty keeps the current one
+	CommentMode      string     `json:"commentMode"`      // open, closed, moderated or auto_approve; keeps the current mode when empty
 }
 
 type PostListResponse struct {
-	ID        uint      `json:"id"`
-	Title     string    `json:"title"`
-	Summary   string    `json:"summary"`
-	ImageURL  string    `json:"image_url"`
-	ReadTime  int       `json:"read_time"`
-	LikeCount int       `json:"like_count"`
-	IsActive  bool      `json:"is_active"` // Added IsActive
-	CreatedAt time.Time `json:"created_at"`
-	Category  string    `json:"category,omitempty"` // Added Category
-	Tags      string    `json:"tags,omitempty"`     // Added Tags
+	ID          uint       `json:"id"`
+	Title       string     `json:"title"`
+	Slug        string     `json:"slug"`
+	Summary     string     `json:"summary"`
+	ImageURL    string     `json:"image_url"`
+	ReadTime    int        `json:"read_time"`
+	LikeCount   int        `json:"like_count"`
+	IsActive    bool       `json:"is_active"` // Added IsActive
+	CreatedAt   time.Time  `json:"created_at"`
+	Status      string     `json:"status"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	Category    string     `json:"category,omitempty"` // Added Category
+	Tags        string     `json:"tags,omitempty"`     // Added Tags
+	Visibility  string     `json:"visibility"`
 }
 
 type PostDetailResponse struct {
-	ID        uint      `json:"id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	Summary   string    `json:"summary"`
-	ImageURL  string    `json:"image_url"`
-	ReadTime  int       `json:"read_time"`
-	LikeCount int       `json:"like_count"`
-	IsActive  bool      `json:"is_active"` // Added IsActive
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`         // Added UpdatedAt
-	Category  string    `json:"category,omitempty"` // Added Category
-	Tags      string    `json:"tags,omitempty"`     // Added Tags
+	ID          uint            `json:"id"`
+	Title       string          `json:"title"`
+	Slug        string          `json:"slug"`
+	Content     string          `json:"content"`
+	Summary     string          `json:"summary"`
+	ImageURL    string          `json:"image_url"`
+	ReadTime    int             `json:"read_time"`
+	WordCount   int             `json:"word_count"`
+	TOC         []toc.Entry     `json:"toc"` // Headings of Content; their ids are the anchors in Content
+	Language    string          `json:"language"`
+	LikeCount   int             `json:"like_count"`
+	IsActive    bool            `json:"is_active"` // Added IsActive
+	CreatedAt   time.Time       `json:"created_at"`
+	Status      string          `json:"status"`
+	PublishedAt *time.Time      `json:"published_at,omitempty"`
+	UpdatedAt   time.Time       `json:"updated_at"`         // Added UpdatedAt
+	Category    string          `json:"category,omitempty"` // Added Category
+	Tags        string          `json:"tags,omitempty"`     // Added Tags
+	Series      *PostSeriesInfo `json:"series,omitempty"`   // Set when the post is a published part of a series
+	Visibility  string          `json:"visibility"`
+	Locked      bool            `json:"locked"` // Content and toc are withheld until the post is unlocked with its password
+	// The comment form is shown while comments are open; they may close at CommentsCloseAt
+	CommentMode     string     `json:"comment_mode"`
+	CommentsOpen    bool       `json:"comments_open"`
+	CommentsCloseAt *time.Time `json:"comments_close_at,omitempty"`
+}
+
+// PostUnlockRequest carries the password of a password-protected post
+type PostUnlockRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// PostAccessResponse holds the token that unlocks a password-protected post.
+// It is sent back in the X-Post-Access-Token header when fetching the post.
+type PostAccessResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PreviewLinkCreateRequest chooses the lifetime of a new preview link
+type PreviewLinkCreateRequest struct {
+	ExpiresInHours int `json:"expiresInHours"` // Optional, POST_PREVIEW_LINK_TTL when 0; capped at POST_PREVIEW_LINK_MAX_TTL
+}
+
+// PreviewLinkResponse is a preview link of a post. URL opens the post through the public preview endpoint.
+type PreviewLinkResponse struct {
+	ID        uint       `json:"id"`
+	PostID    uint       `json:"post_id"`
+	Token     string     `json:"token"`
+	URL       string     `json:"url"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	Active    bool       `json:"active"` // Neither expired nor revoked
+}
+
+// SlugRedirectResponse is returned with a 301 when a post is requested by one of its old slugs
+type SlugRedirectResponse struct {
+	Slug     string `json:"slug"`     // Current slug of the post
+	Location string `json:"location"` // Same value as the Location header
 }
 
 type PaginatedPostResponse struct {
@@ -81,39 +154,113 @@ type PaginatedPostResponse struct {
 	TotalPages int                `json:"total_pages"`
 }
 
+// CursorPostResponse is a page of posts in cursor mode. The cursors are opaque; an empty cursor means there is no such page.
+type CursorPostResponse struct {
+	Posts      []PostListResponse `json:"posts"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+	PrevCursor string             `json:"prev_cursor,omitempty"`
+	PageSize   int                `json:"page_size"`
+	TotalPosts *int64             `json:"total_posts,omitempty"` // Only counted when requested
+}
+
+// PostSearchHit is a post in the search results with its rank and a highlighted snippet.
+// Snippet is escaped HTML text in which only the matched words are wrapped in <mark> tags.
+type PostSearchHit struct {
+	PostListResponse
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet"`
+}
+
+// PostSearchResponse is the paginated response of the full-text search endpoint
+type PostSearchResponse struct {
+	Query      string          `json:"query"`
+	Posts      []PostSearchHit `json:"posts"`
+	TotalPosts int64           `json:"total_posts"`
+	Page       int             `json:"current_page"`
+	PageSize   int             `json:"page_size"`
+	TotalPages int             `json:"total_pages"`
+}
+
+// CursorSearchResponse is a page of search results in cursor mode
+type CursorSearchResponse struct {
+	Query      string          `json:"query"`
+	Posts      []PostSearchHit `json:"posts"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	PrevCursor string          `json:"prev_cursor,omitempty"`
+	PageSize   int             `json:"page_size"`
+	TotalPosts *int64          `json:"total_posts,omitempty"` // Only counted when requested
+}
+
 // Converter functions
 func ToPostListResponse(post *models.Post) PostListResponse {
 	return PostListResponse{
-		ID:        post.ID,
-		Title:     post.Title,
-		Summary:   post.Summary,
-		ImageURL:  post.ImageURL,
-		ReadTime:  post.ReadTime,
-		LikeCount: post.LikeCount,
-		IsActive:  post.IsActive, // Added IsActive
-		CreatedAt: post.CreatedAt,
-		Category:  post.Category, // Added Category
-		Tags:      post.Tags,     // Added Tags
+		ID:          post.ID,
+		Title:       post.Title,
+		Slug:        post.Slug,
+		Summary:     post.Summary,
+		ImageURL:    post.ImageURL,
+		ReadTime:    post.ReadTime,
+		LikeCount:   post.LikeCount,
+		IsActive:    post.IsActive, // Added IsActive
+		CreatedAt:   post.CreatedAt,
+		Status:      post.Status,
+		PublishedAt: post.PublishedAt,
+		Category:    post.Category, // Added Category
+		Tags:        post.Tags,     // Added Tags
+		Visibility:  post.Visibility,
 	}
 }
 
 func ToPostDetailResponse(post *models.Post) PostDetailResponse {
+	entries := post.TableOfContents
+	if entries == nil {
+		entries = models.TableOfContents{} // Serialize as an empty list
+	}
 	return PostDetailResponse{
-		ID:        post.ID,
-		Title:     post.Title,
-		Content:   post.Content,
-		Summary:   post.Summary,
-		ImageURL:  post.ImageURL,
-		ReadTime:  post.ReadTime,
-		LikeCount: post.LikeCount,
-		IsActive:  post.IsActive, // Added IsActive
-		CreatedAt: post.CreatedAt,
-		UpdatedAt: post.UpdatedAt, // Added UpdatedAt
-		Category:  post.Category,  // Added Category
-		Tags:      post.Tags,      // Added Tags
+		ID:          post.ID,
+		Title:       post.Title,
+		Slug:        post.Slug,
+		Content:     render.HTML(post), // Always sanitized HTML, whatever the source format
+		Summary:     post.Summary,
+		ImageURL:    post.ImageURL,
+		ReadTime:    post.ReadTime,
+		WordCount:   post.WordCount,
+		TOC:         entries,
+		Language:    post.Language,
+		LikeCount:   post.LikeCount,
+		IsActive:    post.IsActive, // Added IsActive
+		CreatedAt:   post.CreatedAt,
+		Status:      post.Status,
+		PublishedAt: post.PublishedAt,
+		UpdatedAt:   post.UpdatedAt, // Added UpdatedAt
+		Category:    post.Category,  // Added Category
+		Tags:        post.Tags,      // Added Tags
+		Visibility:  post.Visibility,
+		CommentMode: post.CommentMode,
 	}
 }
 
+// ToLockedPostDetailResponse is ToPostDetailResponse for readers who have not unlocked a password-protected post
+func ToLockedPostDetailResponse(post *models.Post) PostDetailResponse {
+	response := ToPostDetailResponse(post)
+	response.Content = ""
+	response.TOC = []toc.Entry{}
+	response.Locked = true
+	return response
+}
+
+func ToPostSearchHits(results []*models.PostSearchResult) []PostSearchHit {
+	hits := make([]PostSearchHit, len(results))
+	for i, result := range results {
+		hits[i] = PostSearchHit{
+			PostListResponse: ToPostListResponse(&result.Post),
+			Score:            result.Score,
+			Snippet:          result.Snippet,
+		}
+	}
+	return hits
+}
+
 func ToPostListResponses(posts []*models.Post) []PostListResponse {
 	responses := make([]PostListResponse, len(posts))
 	for i, post := range posts {
@@ -121,3 +268,48 @@ func ToPostListResponses(posts []*models.Post) []PostListResponse {
 	}
 	return responses
 }
+
+// PostRevisionResponse represents a stored revision of a post
+type PostRevisionResponse struct {
+	ID            uint      `json:"id"`
+	PostID        uint      `json:"post_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	Title         string    `json:"title"`
+	Summary       string    `json:"summary"`
+	Content       string    `json:"content,omitempty"` // Omitted in revision lists
+	ImageURL      string    `json:"image_url"`
+	Category      string    `json:"category,omitempty"`
+	Tags          string    `json:"tags,omitempty"`
+	ContentFormat string    `json:"content_format"`
+}
+
+// PostRevisionDiffResponse holds a unified diff between two versions of a post (ID 0 is the current version)
+type PostRevisionDiffResponse struct {
+	PostID uint   `json:"post_id"`
+	FromID uint   `json:"from_revision_id"`
+	ToID   uint   `json:"to_revision_id"`
+	Diff   string `json:"diff"`
+}
+
+func ToPostRevisionResponse(revision *models.PostRevision) PostRevisionResponse {
+	return PostRevisionResponse{
+		ID:            revision.ID,
+		PostID:        revision.PostID,
+		CreatedAt:     revision.CreatedAt,
+		Title:         revision.Title,
+		Summary:       revision.Summary,
+		Content:       revision.Content,
+		ImageURL:      revision.ImageURL,
+		Category:      revision.Category,
+		Tags:          revision.Tags,
+		ContentFormat: revision.ContentFormat,
+	}
+}
+
+func ToPostRevisionResponses(revisions []models.PostRevision) []PostRevisionResponse {
+	responses := make([]PostRevisionResponse, len(revisions))
+	for i := range revisions {
+		responses[i] = ToPostRevisionResponse(&revisions[i])
+	}
+	return responses
+}
@@ -0,0 +1,74 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+)
+
+// SeriesRequest creates or updates a series
+type SeriesRequest struct {
+	Title       string `json:"title" binding:"required"`
+	Slug        string `json:"slug"` // Optional, generated from the title when empty
+	Description string `json:"description"`
+	PostIDs     []uint `json:"postIds"` // Posts of the series in reading order; replaces the current list
+}
+
+// SeriesResponse is a series with its posts in reading order
+type SeriesResponse struct {
+	ID          uint               `json:"id"`
+	Title       string             `json:"title"`
+	Slug        string             `json:"slug"`
+	Description string             `json:"description"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	Posts       []PostListResponse `json:"posts"`
+}
+
+// PostSeriesInfo places a post within its series so readers can navigate between the parts
+type PostSeriesInfo struct {
+	ID       uint               `json:"id"`
+	Title    string             `json:"title"`
+	Slug     string             `json:"slug"`
+	Position int                `json:"position"` // 1-based position among the published parts ("Part 3 of 5")
+	Total    int                `json:"total"`    // Number of published parts
+	Previous *models.SeriesPart `json:"previous,omitempty"`
+	Next     *models.SeriesPart `json:"next,omitempty"`
+}
+
+func ToSeriesResponse(series *models.Series, posts []*models.Post) SeriesResponse {
+	return SeriesResponse{
+		ID:          series.ID,
+		Title:       series.Title,
+		Slug:        series.Slug,
+		Description: series.Description,
+		CreatedAt:   series.CreatedAt,
+		UpdatedAt:   series.UpdatedAt,
+		Posts:       ToPostListResponses(posts),
+	}
+}
+
+// ToPostSeriesInfo returns the position of a post among the published parts of its series,
+// or nil if the post is not one of them.
+func ToPostSeriesInfo(series *models.Series, parts []models.SeriesPart, postID uint) *PostSeriesInfo {
+	for i := range parts {
+		if parts[i].ID != postID {
+			continue
+		}
+		info := &PostSeriesInfo{
+			ID:       series.ID,
+			Title:    series.Title,
+			Slug:     series.Slug,
+			Position: i + 1,
+			Total:    len(parts),
+		}
+		if i > 0 {
+			info.Previous = &parts[i-1]
+		}
+		if i < len(parts)-1 {
+			info.Next = &parts[i+1]
+		}
+		return info
+	}
+	return nil
+}
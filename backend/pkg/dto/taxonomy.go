@@ -0,0 +1,56 @@
+package dto
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// TagList accepts tags either as a JSON array (["go", "api"]) or as the legacy comma-separated string ("go,api").
+type TagList []string
+
+func (t *TagList) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*t = TagList(SplitTags(strings.Join(list, ",")))
+		return nil
+	}
+
+	var legacy string
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return err
+	}
+	*t = TagList(SplitTags(legacy))
+	return nil
+}
+
+// String returns the tags in the legacy comma-separated form
+func (t TagList) String() string {
+	return strings.Join(t, ",")
+}
+
+// SplitTags splits a comma-separated tag string, trimming whitespace and dropping empty entries.
+func SplitTags(tags string) []string {
+	if tags == "" {
+		return []string{}
+	}
+	split := strings.Split(tags, ",")
+	result := make([]string, 0, len(split))
+	for _, tag := range split {
+		trimmed := strings.TrimSpace(tag)
+		if trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
+
+// TaxonomyPostsResponse is a page of posts belonging to a category or tag
+type TaxonomyPostsResponse struct {
+	Name       string             `json:"name"`
+	Slug       string             `json:"slug"`
+	Posts      []PostListResponse `json:"posts"`
+	TotalPosts int64              `json:"total_posts"`
+	Page       int                `json:"current_page"`
+	PageSize   int                `json:"page_size"`
+	TotalPages int                `json:"total_pages"`
+}
@@ -0,0 +1,297 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"path"
+	"strings"
+	"time"
+)
+
+// Content types of the supported feed formats
+const (
+	RSSContentType  = "application/rss+xml; charset=utf-8"
+	AtomContentType = "application/atom+xml; charset=utf-8"
+	JSONContentType = "application/feed+json; charset=utf-8"
+)
+
+// Feed is the format independent description of a feed. URLs must be absolute.
+type Feed struct {
+	Title       string
+	Description string
+	Link        string // Home page of the site
+	FeedURL     string // URL the feed itself is served from
+	Language    string
+	Updated     time.Time
+	Items       []Item
+}
+
+type Item struct {
+	ID         string // Stable, unique identifier (usually the permalink)
+	Title      string
+	Link       string
+	Summary    string // Plain text
+	Content    string // HTML, empty in summary mode
+	ImageURL   string
+	Categories []string
+	Published  time.Time
+	Updated    time.Time
+}
+
+// RSS renders f as an RSS 2.0 document.
+func RSS(f *Feed) ([]byte, error) {
+	channel := rssChannel{
+		Title:         f.Title,
+		Link:          f.Link,
+		Description:   f.Description,
+		Language:      f.Language,
+		LastBuildDate: rssTime(f.Updated),
+		AtomLink:      rssAtomLink{Href: f.FeedURL, Rel: "self", Type: strings.Split(RSSContentType, ";")[0]},
+	}
+	for _, item := range f.Items {
+		ri := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        rssGUID{Value: item.ID, IsPermaLink: item.ID == item.Link},
+			Description: item.Summary,
+			PubDate:     rssTime(item.Published),
+			Categories:  item.Categories,
+		}
+		if item.Content != "" {
+			ri.Content = &rssCDATA{Value: item.Content}
+		}
+		if item.ImageURL != "" {
+			ri.Enclosure = &rssEnclosure{URL: item.ImageURL, Length: 0, Type: imageType(item.ImageURL)}
+		}
+		channel.Items = append(channel.Items, ri)
+	}
+
+	return marshalXML(rssDocument{
+		Version:   "2.0",
+		AtomNS:    "http://www.w3.org/2005/Atom",
+		ContentNS: "http://purl.org/rss/1.0/modules/content/",
+		Channel:   channel,
+	})
+}
+
+// Atom renders f as an Atom 1.0 document.
+func Atom(f *Feed) ([]byte, error) {
+	doc := atomFeed{
+		NS:       "http://www.w3.org/2005/Atom",
+		Lang:     f.Language,
+		ID:       f.FeedURL,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  atomTime(f.Updated),
+		Links: []atomLink{
+			{Href: f.Link, Rel: "alternate", Type: "text/html"},
+			{Href: f.FeedURL, Rel: "self", Type: strings.Split(AtomContentType, ";")[0]},
+		},
+	}
+	for _, item := range f.Items {
+		entry := atomEntry{
+			ID:        item.ID,
+			Title:     item.Title,
+			Published: atomTime(item.Published),
+			Updated:   atomTime(item.Updated),
+			Links:     []atomLink{{Href: item.Link, Rel: "alternate", Type: "text/html"}},
+			Summary:   &atomText{Type: "text", Value: item.Summary},
+		}
+		if item.Content != "" {
+			entry.Content = &atomText{Type: "html", Value: item.Content}
+		}
+		if item.ImageURL != "" {
+			entry.Links = append(entry.Links, atomLink{Href: item.ImageURL, Rel: "enclosure", Type: imageType(item.ImageURL)})
+		}
+		for _, category := range item.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return marshalXML(doc)
+}
+
+// JSON renders f as a JSON Feed 1.1 document.
+func JSON(f *Feed) ([]byte, error) {
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		FeedURL:     f.FeedURL,
+		Description: f.Description,
+		Language:    f.Language,
+		Items:       make([]jsonItem, 0, len(f.Items)),
+	}
+	for _, item := range f.Items {
+		ji := jsonItem{
+			ID:          item.ID,
+			URL:         item.Link,
+			Title:       item.Title,
+			Summary:     item.Summary,
+			ContentHTML: item.Content,
+			Image:       item.ImageURL,
+			Tags:        item.Categories,
+			Published:   atomTime(item.Published),
+			Modified:    atomTime(item.Updated),
+		}
+		// content_html or content_text is required, fall back to the summary in summary mode
+		if ji.ContentHTML == "" {
+			ji.ContentText = item.Summary
+		}
+		doc.Items = append(doc.Items, ji)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // Keep content_html readable
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+func rssTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC1123Z)
+}
+
+func atomTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// imageType guesses the MIME type of an image from its URL, defaulting to JPEG.
+func imageType(url string) string {
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		url = url[:i]
+	}
+	if t := mime.TypeByExtension(path.Ext(url)); strings.HasPrefix(t, "image/") {
+		return t
+	}
+	return "image/jpeg"
+}
+
+type rssDocument struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	AtomNS    string     `xml:"xmlns:atom,attr"`
+	ContentNS string     `xml:"xmlns:content,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string      `xml:"title"`
+	Link          string      `xml:"link"`
+	Description   string      `xml:"description"`
+	Language      string      `xml:"language,omitempty"`
+	LastBuildDate string      `xml:"lastBuildDate,omitempty"`
+	AtomLink      rssAtomLink `xml:"atom:link"`
+	Items         []rssItem   `xml:"item"`
+}
+
+type rssAtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        rssGUID       `xml:"guid"`
+	Description string        `xml:"description"`
+	Content     *rssCDATA     `xml:"content:encoded"`
+	PubDate     string        `xml:"pubDate,omitempty"`
+	Categories  []string      `xml:"category"`
+	Enclosure   *rssEnclosure `xml:"enclosure"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+type rssCDATA struct {
+	Value string `xml:",cdata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int    `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"feed"`
+	NS       string      `xml:"xmlns,attr"`
+	Lang     string      `xml:"xml:lang,attr,omitempty"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Published  string         `xml:"published,omitempty"`
+	Updated    string         `xml:"updated"`
+	Links      []atomLink     `xml:"link"`
+	Summary    *atomText      `xml:"summary"`
+	Content    *atomText      `xml:"content"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type jsonFeed struct {
+	Version     string     `json:"version"`
+	Title       string     `json:"title"`
+	HomePageURL string     `json:"home_page_url,omitempty"`
+	FeedURL     string     `json:"feed_url,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Language    string     `json:"language,omitempty"`
+	Items       []jsonItem `json:"items"`
+}
+
+type jsonItem struct {
+	ID          string   `json:"id"`
+	URL         string   `json:"url,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	ContentHTML string   `json:"content_html,omitempty"`
+	ContentText string   `json:"content_text,omitempty"`
+	Summary     string   `json:"summary,omitempty"`
+	Image       string   `json:"image,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Published   string   `json:"date_published,omitempty"`
+	Modified    string   `json:"date_modified,omitempty"`
+}
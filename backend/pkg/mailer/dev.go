@@ -0,0 +1,64 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// tokenParam matches the token of a link, e.g. the one in unsubscribe and verification links
+var tokenParam = regexp.MustCompile(`([?&]token=)[^&\s"'<>]+`)
+
+// DevMailer is used during development instead of sending mail. It writes every message to an .eml file in Dir,
+// which mail clients can open, or to the log when Dir is empty. Logged messages have the tokens of their links
+// redacted, as anyone reading the log could otherwise act as the recipient.
+type DevMailer struct {
+	dir    string
+	from   string
+	logger *logrus.Logger
+}
+
+func NewDevMailer(dir, from string, logger *logrus.Logger) *DevMailer {
+	return &DevMailer{dir: dir, from: from, logger: logger}
+}
+
+func (m *DevMailer) Send(msg *Message) error {
+	now := time.Now()
+	if m.dir == "" {
+		m.logger.WithFields(logrus.Fields{"to": strings.Join(msg.To, ", "), "subject": msg.Subject}).Info("Mailer: " + tokenParam.ReplaceAllString(msg.Text, "${1}[redacted]"))
+		return nil
+	}
+
+	data, err := build(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	path := filepath.Join(m.dir, fmt.Sprintf("%s-%d.eml", now.Format("20060102-150405"), now.UnixNano()%1e9))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	m.logger.WithFields(logrus.Fields{"to": strings.Join(msg.To, ", "), "subject": msg.Subject, "file": path}).Info("Mailer: Message written")
+	return nil
+}
+
+// DisabledMailer drops every message, for deployments that send no email
+type DisabledMailer struct {
+	logger *logrus.Logger
+}
+
+func NewDisabledMailer(logger *logrus.Logger) *DisabledMailer {
+	return &DisabledMailer{logger: logger}
+}
+
+func (m *DisabledMailer) Send(msg *Message) error {
+	m.logger.WithField("subject", msg.Subject).Debug("Mailer: Mail is disabled, message dropped")
+	return nil
+}
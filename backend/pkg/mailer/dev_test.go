@@ -0,0 +1,34 @@
+package mailer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestDevMailerRedactsLinkTokensInTheLog(t *testing.T) {
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&out)
+
+	msg := &Message{
+		To:      []string{"reader@example.com"},
+		Subject: "Verify your email",
+		Text: "Verify: https://blog.example.com/api/comments/verify?token=c2VjcmV0LXRva2Vu\n" +
+			"Unsubscribe: https://blog.example.com/api/comments/unsubscribe?lang=en&token=b3RoZXI.c2ln",
+	}
+	if err := NewDevMailer("", "", logger).Send(msg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logged := out.String()
+	for _, token := range []string{"c2VjcmV0LXRva2Vu", "b3RoZXI.c2ln"} {
+		if strings.Contains(logged, token) {
+			t.Errorf("log contains the token %q: %s", token, logged)
+		}
+	}
+	if !strings.Contains(logged, "verify?token=[redacted]") || !strings.Contains(logged, "lang=en&token=[redacted]") {
+		t.Errorf("links are not kept with a redacted token: %s", logged)
+	}
+}
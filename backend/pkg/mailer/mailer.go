@@ -0,0 +1,109 @@
+// Package mailer sends emails through SMTP, or writes them to files or the log during development.
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Message is an email with a plain text body and an optional HTML alternative
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+	Headers map[string]string // Extra headers, e.g. List-Unsubscribe
+}
+
+// Mailer delivers messages
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// build renders a message as RFC 5322 bytes, with a multipart/alternative body when it has HTML
+func build(from string, msg *Message, now time.Time) ([]byte, error) {
+	if len(msg.To) == 0 {
+		return nil, fmt.Errorf("message has no recipients")
+	}
+	var b bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&b, "%s: %s\r\n", name, value)
+	}
+	header("From", from)
+	header("To", strings.Join(msg.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", messageID(from))
+	header("MIME-Version", "1.0")
+	names := make([]string, 0, len(msg.Headers))
+	for name := range msg.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		header(textproto.CanonicalMIMEHeaderKey(name), msg.Headers[name])
+	}
+
+	if msg.HTML == "" {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		b.WriteString("\r\n")
+		return b.Bytes(), writeQuotedPrintable(&b, msg.Text)
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	b.WriteString("\r\n")
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	b.Write(body.Bytes())
+	return b.Bytes(), nil
+}
+
+func writeQuotedPrintable(w interface{ Write([]byte) (int, error) }, content string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(content, "\n", "\r\n"))); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// messageID returns a unique Message-ID in the domain of the sender
+func messageID(from string) string {
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(addr.Address, "@"); at >= 0 {
+			domain = addr.Address[at+1:]
+		}
+	}
+	id := make([]byte, 16)
+	rand.Read(id)
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(id), domain)
+}
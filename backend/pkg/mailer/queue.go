@@ -0,0 +1,155 @@
+package mailer
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// QueueSettings configures a Queue
+type QueueSettings struct {
+	Size        int           // Messages waiting to be sent; further messages are dropped
+	MaxAttempts int           // Attempts per message before it is given up
+	RetryDelay  time.Duration // Delay before the first retry, doubled for every further one
+}
+
+type queuedMessage struct {
+	msg      *Message
+	attempts int
+}
+
+// Queue sends messages in the background, retrying failed attempts, so that callers never wait for a mail server.
+// Messages only live in memory; those still waiting for a retry when the application stops are lost.
+type Queue struct {
+	mailer    Mailer
+	settings  QueueSettings
+	logger    *logrus.Logger
+	messages  chan queuedMessage
+	stop      chan struct{}
+	done      chan struct{}
+	retries   sync.WaitGroup
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+func NewQueue(mailer Mailer, settings QueueSettings, logger *logrus.Logger) *Queue {
+	if settings.Size <= 0 {
+		settings.Size = 100
+	}
+	if settings.MaxAttempts <= 0 {
+		settings.MaxAttempts = 1
+	}
+	return &Queue{
+		mailer:   mailer,
+		settings: settings,
+		logger:   logger,
+		messages: make(chan queuedMessage, settings.Size),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Enqueue queues a message without blocking and reports whether there was room for it
+func (q *Queue) Enqueue(msg *Message) bool {
+	select {
+	case q.messages <- queuedMessage{msg: msg}:
+		return true
+	default:
+		q.logger.WithField("subject", msg.Subject).Error("Mailer: Queue full, message dropped")
+		return false
+	}
+}
+
+// Start sends queued messages in a goroutine until Shutdown. Only the first call starts it, and a stopped
+// queue doesn't start again.
+func (q *Queue) Start() {
+	q.startOnce.Do(q.start)
+}
+
+func (q *Queue) start() {
+	go func() {
+		defer close(q.done)
+		for {
+			select {
+			case m := <-q.messages:
+				q.send(m)
+			case <-q.stop:
+				// Send what is already queued, without waiting for retries
+				for {
+					select {
+					case m := <-q.messages:
+						m.attempts = q.settings.MaxAttempts - 1
+						q.send(m)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+	q.logger.Info("Mail queue started")
+}
+
+func (q *Queue) send(m queuedMessage) {
+	m.attempts++
+	log := q.logger.WithFields(logrus.Fields{"to": strings.Join(m.msg.To, ", "), "subject": m.msg.Subject, "attempt": m.attempts})
+	err := q.mailer.Send(m.msg)
+	if err == nil {
+		log.Info("Mailer: Message sent")
+		return
+	}
+	if m.attempts >= q.settings.MaxAttempts {
+		log.WithError(err).Error("Mailer: Failed to send message, giving up")
+		return
+	}
+
+	delay := q.settings.RetryDelay << (m.attempts - 1)
+	log.WithError(err).WithField("retry_in", delay.String()).Warn("Mailer: Failed to send message, retrying")
+	q.retries.Add(1)
+	go func() {
+		defer q.retries.Done()
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			select {
+			case q.messages <- m:
+			default:
+				log.Error("Mailer: Queue full, retry dropped")
+			}
+		case <-q.stop:
+			log.Warn("Mailer: Shutting down, retry dropped")
+		}
+	}()
+}
+
+// Shutdown sends the queued messages and stops the queue. It can be called more than once; if the queue was
+// never started, the queued messages are dropped.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.stopOnce.Do(func() {
+		close(q.stop)
+		q.startOnce.Do(func() {
+			if n := len(q.messages); n > 0 {
+				q.logger.WithField("count", n).Warn("Mailer: Queue was never started, messages dropped")
+			}
+			close(q.done)
+		})
+	})
+	finished := make(chan struct{})
+	go func() {
+		q.retries.Wait()
+		<-q.done
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		q.logger.Info("Mail queue shutdown completed")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,70 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// countingMailer counts the messages it is asked to send
+type countingMailer struct {
+	mu   sync.Mutex
+	sent int
+}
+
+func (m *countingMailer) Send(*Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent++
+	return nil
+}
+
+func newTestQueue(mailer Mailer) *Queue {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewQueue(mailer, QueueSettings{Size: 10, MaxAttempts: 1}, logger)
+}
+
+func TestQueueShutdown(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	t.Run("never started", func(t *testing.T) {
+		mailer := &countingMailer{}
+		q := newTestQueue(mailer)
+		q.Enqueue(&Message{To: []string{"a@example.com"}, Subject: "s"})
+		if err := q.Shutdown(ctx); err != nil {
+			t.Fatalf("shutdown of a queue that never started: %v", err)
+		}
+		if err := q.Shutdown(ctx); err != nil {
+			t.Fatalf("second shutdown: %v", err)
+		}
+		q.Start()
+		if mailer.sent != 0 {
+			t.Errorf("a queue that never started sent %d messages", mailer.sent)
+		}
+	})
+
+	t.Run("started", func(t *testing.T) {
+		mailer := &countingMailer{}
+		q := newTestQueue(mailer)
+		q.Enqueue(&Message{To: []string{"a@example.com"}, Subject: "s"})
+		q.Start()
+		q.Start()
+		if err := q.Shutdown(ctx); err != nil {
+			t.Fatalf("shutdown: %v", err)
+		}
+		if err := q.Shutdown(ctx); err != nil {
+			t.Fatalf("second shutdown: %v", err)
+		}
+		mailer.mu.Lock()
+		defer mailer.mu.Unlock()
+		if mailer.sent != 1 {
+			t.Errorf("sent %d messages, want the queued one", mailer.sent)
+		}
+	})
+}
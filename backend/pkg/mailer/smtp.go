@@ -0,0 +1,97 @@
+package mailer
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPSettings configures an SMTP server
+type SMTPSettings struct {
+	Host     string
+	Port     int    // 465 uses implicit TLS, other ports STARTTLS when the server offers it
+	Username string // Empty for servers without authentication
+	Password string
+	From     string // Sender, e.g. "Blog <blog@example.com>"
+}
+
+// SMTPMailer sends messages through an SMTP server
+type SMTPMailer struct {
+	settings SMTPSettings
+}
+
+func NewSMTPMailer(settings SMTPSettings) *SMTPMailer {
+	return &SMTPMailer{settings: settings}
+}
+
+// smtpTimeout bounds the whole conversation with the server
+const smtpTimeout = 30 * time.Second
+
+func (m *SMTPMailer) Send(msg *Message) error {
+	data, err := build(m.settings.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(m.settings.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	addr := net.JoinHostPort(m.settings.Host, strconv.Itoa(m.settings.Port))
+	tlsConfig := &tls.Config{ServerName: m.settings.Host}
+	var conn net.Conn
+	if m.settings.Port == 465 {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: smtpTimeout}, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, smtpTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	client, err := smtp.NewClient(conn, m.settings.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && m.settings.Port != 465 {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+	if m.settings.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.settings.Username, m.settings.Password, m.settings.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("SMTP server rejected sender: %w", err)
+	}
+	for _, to := range msg.To {
+		addr, err := mail.ParseAddress(to)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", to, err)
+		}
+		if err := client.Rcpt(addr.Address); err != nil {
+			return fmt.Errorf("SMTP server rejected recipient %q: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA failed: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return client.Quit()
+}
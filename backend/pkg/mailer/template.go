@@ -0,0 +1,69 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Template renders the subject and bodies of an email. A template file defines the blocks "subject", "text"
+// and optionally "html"; the HTML block is escaped for HTML, the others are not escaped.
+type Template struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// ParseTemplate parses a template file of fsys
+func ParseTemplate(fsys fs.FS, name string) (*Template, error) {
+	text, err := texttemplate.ParseFS(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse email template %s: %w", name, err)
+	}
+	if text.Lookup("subject") == nil || text.Lookup("text") == nil {
+		return nil, fmt.Errorf("email template %s must define subject and text", name)
+	}
+	t := &Template{text: text}
+	if text.Lookup("html") != nil {
+		if t.html, err = htmltemplate.ParseFS(fsys, name); err != nil {
+			return nil, fmt.Errorf("failed to parse email template %s: %w", name, err)
+		}
+	}
+	return t, nil
+}
+
+// MustParseTemplate is like ParseTemplate but panics on errors, for templates embedded in the binary
+func MustParseTemplate(fsys fs.FS, name string) *Template {
+	t, err := ParseTemplate(fsys, name)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Render executes the template with data into a message for the given recipients
+func (t *Template) Render(to []string, data interface{}) (*Message, error) {
+	msg := &Message{To: to}
+	var b bytes.Buffer
+	if err := t.text.ExecuteTemplate(&b, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render email subject: %w", err)
+	}
+	msg.Subject = strings.Join(strings.Fields(b.String()), " ")
+
+	b.Reset()
+	if err := t.text.ExecuteTemplate(&b, "text", data); err != nil {
+		return nil, fmt.Errorf("failed to render email text: %w", err)
+	}
+	msg.Text = strings.TrimSpace(b.String()) + "\n"
+
+	if t.html != nil {
+		b.Reset()
+		if err := t.html.ExecuteTemplate(&b, "html", data); err != nil {
+			return nil, fmt.Errorf("failed to render email HTML: %w", err)
+		}
+		msg.HTML = b.String()
+	}
+	return msg, nil
+}
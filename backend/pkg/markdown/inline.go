@@ -0,0 +1,646 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	entityRegex       = regexp.MustCompile(`^&(?:#[xX][0-9a-fA-F]{1,6}|#[0-9]{1,7}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+	autolinkRegex     = regexp.MustCompile(`^<([a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^\s<>]*)>`)
+	emailAutolinkExpr = regexp.MustCompile(`^<([a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*)>`)
+	inlineHTMLRegex   = regexp.MustCompile(`^(?:<[a-zA-Z][a-zA-Z0-9-]*(?:\s+[a-zA-Z_:][a-zA-Z0-9_.:-]*(?:\s*=\s*(?:[^\s"'=<>` + "`" + `]+|'[^']*'|"[^"]*"))?)*\s*/?>|</[a-zA-Z][a-zA-Z0-9-]*\s*>|<!--[\s\S]*?-->)`)
+	bareURLRegex      = regexp.MustCompile(`^(?:https?://|www\.)[^\s<]*`)
+)
+
+// node is a piece of rendered inline output. Delimiter runs (*, _, ~) are kept as separate nodes
+// until emphasis has been resolved.
+type node struct {
+	html      string
+	delim     byte
+	count     int // Delimiters left unmatched
+	origCount int
+	canOpen   bool
+	canClose  bool
+	openTags  []string
+	closeTags []string
+}
+
+// Limits that keep rendering linear on hostile input
+const (
+	maxLabelLength   = 999 // Longer labels don't match reference definitions, as in CommonMark
+	maxLabelNesting  = 32  // Brackets nested deeper are literal, each level renders its label again
+	maxParenNesting  = 32  // Parentheses nested deeper end a link destination, as in cmark
+	maxCodeSpanTicks = 64  // Longer backtick runs are literal
+)
+
+type inlineParser struct {
+	r       *renderer
+	src     string
+	pos     int
+	nodes   []node
+	text    strings.Builder
+	closers map[int]int // Position of the ] matching each [ scanned so far, -1 if it has none
+	noTicks map[int]int // Length of backtick runs -> position after which no run of that length closes a code span
+}
+
+// renderInline renders the inline content of a block.
+func (r *renderer) renderInline(s string) string {
+	p := &inlineParser{r: r, src: s, closers: make(map[int]int), noTicks: make(map[int]int)}
+	p.parse()
+	p.processEmphasis()
+	return p.render()
+}
+
+func (p *inlineParser) parse() {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch c {
+		case '\\':
+			p.parseBackslash()
+		case '`':
+			p.parseCodeSpan()
+		case '*', '_', '~':
+			p.parseDelimiterRun()
+		case '!':
+			if p.pos+1 < len(p.src) && p.src[p.pos+1] == '[' && p.parseLink(true) {
+				continue
+			}
+			p.text.WriteByte('!')
+			p.pos++
+		case '[':
+			if p.parseFootnoteRef() || p.parseLink(false) {
+				continue
+			}
+			p.text.WriteByte('[')
+			p.pos++
+		case '<':
+			p.parseAngle()
+		case '&':
+			if m := entityRegex.FindString(p.src[p.pos:]); m != "" {
+				p.text.WriteString(m)
+				p.pos += len(m)
+			} else {
+				p.text.WriteString("&amp;")
+				p.pos++
+			}
+		case '\n':
+			p.parseNewline()
+		case 'h', 'w':
+			if !p.parseBareURL() {
+				p.text.WriteByte(c)
+				p.pos++
+			}
+		case '>':
+			p.text.WriteString("&gt;")
+			p.pos++
+		case '"':
+			p.text.WriteString("&quot;")
+			p.pos++
+		default:
+			p.text.WriteByte(c)
+			p.pos++
+		}
+	}
+	p.flush()
+}
+
+func (p *inlineParser) flush() {
+	if p.text.Len() > 0 {
+		p.nodes = append(p.nodes, node{html: p.text.String()})
+		p.text.Reset()
+	}
+}
+
+func (p *inlineParser) emit(html string) {
+	p.flush()
+	p.nodes = append(p.nodes, node{html: html})
+}
+
+func isASCIIPunct(c byte) bool {
+	return c < utf8.RuneSelf && unicode.IsPunct(rune(c)) || strings.IndexByte("$+<=>^`|~", c) >= 0
+}
+
+func (p *inlineParser) parseBackslash() {
+	if p.pos+1 < len(p.src) {
+		next := p.src[p.pos+1]
+		if next == '\n' {
+			p.emit("<br />\n")
+			p.pos += 2
+			return
+		}
+		if isASCIIPunct(next) {
+			p.text.WriteString(escapeHTML(string(next)))
+			p.pos += 2
+			return
+		}
+	}
+	p.text.WriteByte('\\')
+	p.pos++
+}
+
+func (p *inlineParser) parseCodeSpan() {
+	n := runLength(p.src, p.pos, '`')
+	if j := p.codeSpanEnd(p.pos, n); j >= 0 {
+		code := strings.ReplaceAll(p.src[p.pos+n:j], "\n", " ")
+		if len(code) >= 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+			code = code[1 : len(code)-1]
+		}
+		p.emit("<code>" + escapeHTML(code) + "</code>")
+		p.pos = j + n
+		return
+	}
+	// No closing run, the backticks are literal
+	p.text.WriteString(p.src[p.pos : p.pos+n])
+	p.pos += n
+}
+
+// codeSpanEnd returns the position of the run of n backticks closing the code span opened at pos, or -1.
+// A run without a closing run has none at any later position either, which is remembered so that
+// the source is searched only once past it for each length.
+func (p *inlineParser) codeSpanEnd(pos, n int) int {
+	if n > maxCodeSpanTicks {
+		return -1
+	}
+	// The run that found no closing run can still close this one
+	limit, known := p.noTicks[n]
+	if !known || limit >= len(p.src) {
+		limit = len(p.src)
+	} else {
+		limit++
+	}
+	for i := pos + n; i < limit; {
+		j := strings.IndexByte(p.src[i:limit], '`')
+		if j < 0 {
+			break
+		}
+		j += i
+		m := runLength(p.src, j, '`')
+		if m == n {
+			return j
+		}
+		i = j + m
+	}
+	if failed, ok := p.noTicks[n]; !ok || pos < failed {
+		p.noTicks[n] = pos
+	}
+	return -1
+}
+
+func runLength(s string, pos int, c byte) int {
+	n := 0
+	for pos+n < len(s) && s[pos+n] == c {
+		n++
+	}
+	return n
+}
+
+func (p *inlineParser) parseDelimiterRun() {
+	c := p.src[p.pos]
+	n := runLength(p.src, p.pos, c)
+
+	before, after := ' ', ' '
+	if p.pos > 0 {
+		before, _ = utf8.DecodeLastRuneInString(p.src[:p.pos])
+	}
+	if p.pos+n < len(p.src) {
+		after, _ = utf8.DecodeRuneInString(p.src[p.pos+n:])
+	}
+	isSpace := func(r rune) bool { return unicode.IsSpace(r) }
+	isPunct := func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSymbol(r) }
+
+	leftFlanking := !isSpace(after) && (!isPunct(after) || isSpace(before) || isPunct(before))
+	rightFlanking := !isSpace(before) && (!isPunct(before) || isSpace(after) || isPunct(after))
+
+	d := node{html: strings.Repeat(string(c), n), delim: c, count: n, origCount: n}
+	switch c {
+	case '_':
+		d.canOpen = leftFlanking && (!rightFlanking || isPunct(before))
+		d.canClose = rightFlanking && (!leftFlanking || isPunct(after))
+	case '~':
+		// Strikethrough uses runs of one or two tildes
+		if n <= 2 {
+			d.canOpen, d.canClose = leftFlanking, rightFlanking
+		}
+	default:
+		d.canOpen, d.canClose = leftFlanking, rightFlanking
+	}
+
+	p.flush()
+	p.nodes = append(p.nodes, d)
+	p.pos += n
+}
+
+// processEmphasis matches delimiter runs into <em>, <strong> and <del> following the CommonMark rules.
+// Like cmark, it keeps the delimiter runs in a linked list that drops the runs which can no longer be matched,
+// and remembers for each kind of closer how far back an opener was searched for in vain, so that runs are
+// not visited again and again.
+func (p *inlineParser) processEmphasis() {
+	var delims []int // Indexes of the delimiter nodes
+	for i := range p.nodes {
+		if p.nodes[i].delim != 0 {
+			delims = append(delims, i)
+		}
+	}
+	prev := make([]int, len(delims))
+	next := make([]int, len(delims))
+	for i := range delims {
+		prev[i], next[i] = i-1, i+1
+	}
+	remove := func(i int) {
+		if prev[i] >= 0 {
+			next[prev[i]] = next[i]
+		}
+		if next[i] < len(delims) {
+			prev[next[i]] = prev[i]
+		}
+	}
+
+	// Openers at or before the bottom of a kind of closer were searched already and can't match it
+	type closerKind struct {
+		delim   byte
+		canOpen bool
+		length  int // Length modulo 3 for the rule of 3, the exact length for strikethrough
+	}
+	bottom := make(map[closerKind]int)
+
+	for ci := 0; ci < len(delims); {
+		closer := &p.nodes[delims[ci]]
+		if !closer.canClose || closer.count == 0 {
+			ci = next[ci]
+			continue
+		}
+		kind := closerKind{delim: closer.delim, canOpen: closer.canOpen, length: closer.origCount % 3}
+		if closer.delim == '~' {
+			kind.length = closer.origCount
+		}
+		floor, ok := bottom[kind]
+		if !ok {
+			floor = -1
+		}
+
+		oi := prev[ci]
+		for ; oi > floor; oi = prev[oi] {
+			opener := &p.nodes[delims[oi]]
+			if opener.delim != closer.delim || !opener.canOpen || opener.count == 0 {
+				continue
+			}
+			if closer.delim == '~' {
+				if opener.count == closer.count {
+					break
+				}
+				continue
+			}
+			// "Rule of 3" for runs that can both open and close
+			if (opener.canClose || closer.canOpen) && (opener.origCount+closer.origCount)%3 == 0 &&
+				!(opener.origCount%3 == 0 && closer.origCount%3 == 0) {
+				continue
+			}
+			break
+		}
+		if oi <= floor {
+			bottom[kind] = prev[ci]
+			if !closer.canOpen {
+				remove(ci)
+			}
+			ci = next[ci]
+			continue
+		}
+
+		opener := &p.nodes[delims[oi]]
+		use, tag := 1, "em"
+		switch {
+		case closer.delim == '~':
+			use, tag = closer.count, "del"
+		case opener.count >= 2 && closer.count >= 2:
+			use, tag = 2, "strong"
+		}
+		opener.openTags = append(opener.openTags, "<"+tag+">")
+		closer.closeTags = append(closer.closeTags, "</"+tag+">")
+		opener.count -= use
+		closer.count -= use
+
+		// Delimiters between the pair can no longer be matched
+		next[oi], prev[ci] = ci, oi
+		if opener.count == 0 {
+			remove(oi)
+		}
+		if closer.count == 0 {
+			remove(ci)
+			ci = next[ci]
+		}
+	}
+}
+
+func (p *inlineParser) render() string {
+	var b strings.Builder
+	for _, n := range p.nodes {
+		if n.delim == 0 {
+			b.WriteString(n.html)
+			continue
+		}
+		for _, tag := range n.closeTags {
+			b.WriteString(tag)
+		}
+		b.WriteString(strings.Repeat(string(n.delim), n.count))
+		for i := len(n.openTags) - 1; i >= 0; i-- {
+			b.WriteString(n.openTags[i])
+		}
+	}
+	return b.String()
+}
+
+func (p *inlineParser) parseNewline() {
+	// Two or more trailing spaces make a hard line break
+	text := p.text.String()
+	trimmed := strings.TrimRight(text, " ")
+	hard := len(text)-len(trimmed) >= 2
+	p.text.Reset()
+	p.text.WriteString(trimmed)
+	if hard {
+		p.emit("<br />\n")
+	} else {
+		p.text.WriteByte('\n')
+	}
+	p.pos++
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *inlineParser) parseAngle() {
+	rest := p.src[p.pos:]
+	if m := autolinkRegex.FindStringSubmatch(rest); m != nil {
+		p.emit(`<a href="` + escapeURL(m[1]) + `">` + escapeHTML(m[1]) + "</a>")
+		p.pos += len(m[0])
+		return
+	}
+	if m := emailAutolinkExpr.FindStringSubmatch(rest); m != nil {
+		p.emit(`<a href="mailto:` + escapeURL(m[1]) + `">` + escapeHTML(m[1]) + "</a>")
+		p.pos += len(m[0])
+		return
+	}
+	if m := inlineHTMLRegex.FindString(rest); m != "" {
+		p.emit(m)
+		p.pos += len(m)
+		return
+	}
+	p.text.WriteString("&lt;")
+	p.pos++
+}
+
+// parseBareURL links www. and http(s):// URLs that are not written as links (GFM autolink extension).
+func (p *inlineParser) parseBareURL() bool {
+	if p.pos > 0 && !strings.ContainsRune(" \t\n*_~(", rune(p.src[p.pos-1])) {
+		return false
+	}
+	url := bareURLRegex.FindString(p.src[p.pos:])
+	if url == "" {
+		return false
+	}
+
+	// Trailing punctuation is not part of the URL, nor is an unbalanced closing parenthesis
+	for len(url) > 0 {
+		last := url[len(url)-1]
+		if strings.IndexByte("?!.,:*_~'\"", last) >= 0 {
+			url = url[:len(url)-1]
+			continue
+		}
+		if last == ')' && strings.Count(url, ")") > strings.Count(url, "(") {
+			url = url[:len(url)-1]
+			continue
+		}
+		break
+	}
+	if url == "www." || strings.HasSuffix(url, "://") {
+		return false
+	}
+
+	href := url
+	if strings.HasPrefix(url, "www.") {
+		href = "http://" + url
+	}
+	p.emit(`<a href="` + escapeURL(href) + `">` + escapeHTML(url) + "</a>")
+	p.pos += len(url)
+	return true
+}
+
+func (p *inlineParser) parseFootnoteRef() bool {
+	rest := p.src[p.pos:]
+	if !strings.HasPrefix(rest, "[^") {
+		return false
+	}
+	// The label ends at the first ], it has no whitespace or brackets
+	end := strings.IndexAny(rest[2:], "[] \t\n")
+	if end <= 0 || rest[2+end] != ']' {
+		return false
+	}
+	end += 2
+	label := rest[2:end]
+	n, first, ok := p.r.footnoteNumber(label)
+	if !ok {
+		return false
+	}
+
+	id := ""
+	if first {
+		id = fmt.Sprintf(` id="fnref-%d"`, n)
+	}
+	p.emit(fmt.Sprintf(`<sup class="footnote-ref"><a href="#fn-%d"%s>%d</a></sup>`, n, id, n))
+	p.pos += end + 1
+	return true
+}
+
+// parseLink parses an inline, full reference, collapsed or shortcut link (or image) starting at p.pos.
+func (p *inlineParser) parseLink(image bool) bool {
+	start := p.pos
+	if image {
+		start++
+	}
+	closeBracket := p.closingBracket(start)
+	if closeBracket < 0 || p.r.labelDepth >= maxLabelNesting {
+		return false
+	}
+	label := p.src[start+1 : closeBracket]
+	after := closeBracket + 1
+
+	var dest, title string
+	found := false
+
+	if after < len(p.src) && p.src[after] == '(' {
+		if d, t, end, ok := parseInlineDestination(p.src, after); ok {
+			dest, title, after, found = d, t, end, true
+		}
+	}
+	if !found && after < len(p.src) && p.src[after] == '[' {
+		if end := strings.IndexAny(p.src[after+1:], "[]"); end >= 0 && p.src[after+1+end] == ']' {
+			end++
+			refLabel := p.src[after+1 : after+end]
+			if refLabel == "" {
+				refLabel = label
+			}
+			if ref, ok := p.r.refs[normalizeLabel(refLabel)]; ok {
+				dest, title, after, found = ref.url, ref.title, after+end+1, true
+			}
+		}
+	}
+	if !found && len(label) <= maxLabelLength {
+		if ref, ok := p.r.refs[normalizeLabel(label)]; ok {
+			dest, title, found = ref.url, ref.title, true
+		}
+	}
+	if !found {
+		return false
+	}
+
+	titleAttr := ""
+	if title != "" {
+		titleAttr = ` title="` + escapeHTML(title) + `"`
+	}
+	p.r.labelDepth++
+	content := p.r.renderInline(label)
+	p.r.labelDepth--
+	if image {
+		alt := stripTags(content)
+		p.emit(`<img src="` + escapeURL(dest) + `" alt="` + strings.ReplaceAll(alt, `"`, "&quot;") + `"` + titleAttr + " />")
+	} else {
+		p.emit(`<a href="` + escapeURL(dest) + `"` + titleAttr + ">" + content + "</a>")
+	}
+	p.pos = after
+	return true
+}
+
+// closingBracket returns the index of the ] matching the [ at start, or -1. Escaped brackets and brackets
+// inside code spans don't count. The brackets met on the way are paired too, and the ranges between brackets
+// paired before are skipped, so the source is scanned about once however many brackets it has.
+func (p *inlineParser) closingBracket(start int) int {
+	if end, ok := p.closers[start]; ok {
+		return end
+	}
+	s := p.src
+	open := []int{start}
+	for i := start + 1; i < len(s) && len(open) > 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '`':
+			n := runLength(s, i, '`')
+			if end := p.codeSpanEnd(i, n); end >= 0 {
+				i = end + n - 1
+			} else {
+				i += n - 1
+			}
+		case '[':
+			end, ok := p.closers[i]
+			switch {
+			case !ok:
+				open = append(open, i)
+			case end < 0:
+				// The brackets opened before this one can't be closed either
+				i = len(s)
+			default:
+				i = end
+			}
+		case ']':
+			p.closers[open[len(open)-1]] = i
+			open = open[:len(open)-1]
+		}
+	}
+	for _, i := range open {
+		p.closers[i] = -1
+	}
+	return p.closers[start]
+}
+
+// parseInlineDestination parses `(url "title")` starting at the opening parenthesis.
+func parseInlineDestination(s string, open int) (dest, title string, end int, ok bool) {
+	i := skipSpaces(s, open+1)
+
+	if i < len(s) && s[i] == '<' {
+		close := strings.IndexAny(s[i+1:], "<>\n") + 1
+		if close <= 0 || s[i+close] != '>' {
+			return "", "", 0, false
+		}
+		dest = s[i+1 : i+close]
+		i += close + 1
+	} else {
+		depth := 0
+		startDest := i
+	loop:
+		for ; i < len(s); i++ {
+			switch c := s[i]; {
+			case c == '\\' && i+1 < len(s):
+				i++
+			case c == '(':
+				depth++
+				if depth > maxParenNesting {
+					return "", "", 0, false
+				}
+			case c == ')':
+				if depth == 0 {
+					break loop
+				}
+				depth--
+			case c == ' ' || c == '\n' || c == '\t':
+				break loop
+			}
+		}
+		dest = s[startDest:i]
+	}
+
+	j := skipSpaces(s, i)
+	if j < len(s) && j > i && (s[j] == '"' || s[j] == '\'' || s[j] == '(') {
+		closer := s[j]
+		if closer == '(' {
+			closer = ')'
+		}
+		// A title in parentheses can't contain an unescaped (
+		k := j + 1
+		for ; k < len(s) && s[k] != closer && !(closer == ')' && s[k] == '('); k++ {
+			if s[k] == '\\' {
+				k++
+			}
+		}
+		if k < len(s) && s[k] != closer {
+			return "", "", 0, false
+		}
+		if k >= len(s) {
+			return "", "", 0, false
+		}
+		title = s[j+1 : k]
+		j = skipSpaces(s, k+1)
+	}
+	if j >= len(s) || s[j] != ')' {
+		return "", "", 0, false
+	}
+	return unescapeBackslashes(dest), unescapeBackslashes(title), j + 1, true
+}
+
+func skipSpaces(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+func unescapeBackslashes(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// escapeURL makes a link destination safe to put into an attribute. Spaces are percent-encoded.
+func escapeURL(u string) string {
+	return escapeHTML(strings.ReplaceAll(u, " ", "%20"))
+}
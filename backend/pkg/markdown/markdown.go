@@ -0,0 +1,670 @@
+// Package markdown renders CommonMark with the GitHub extensions used by the blog:
+// tables, footnotes, task lists, strikethrough and bare URL autolinks.
+//
+// The output is not sanitized; raw HTML in the source is passed through, so the result
+// must go through sanitize.HTML before it is served.
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/slug"
+)
+
+type linkRef struct {
+	url   string
+	title string
+}
+
+type renderer struct {
+	refs          map[string]linkRef
+	footnotes     map[string][]string // Footnote label -> source lines
+	footnoteOrder []string            // Labels in order of their first reference
+	footnoteIndex map[string]int
+	headingIDs    map[string]bool
+	labelDepth    int // Link labels being rendered, see maxLabelNesting
+}
+
+// Render converts Markdown source into HTML.
+func Render(src string) string {
+	r := &renderer{
+		refs:          make(map[string]linkRef),
+		footnotes:     make(map[string][]string),
+		footnoteIndex: make(map[string]int),
+		headingIDs:    make(map[string]bool),
+	}
+
+	src = strings.ReplaceAll(strings.ReplaceAll(src, "\r\n", "\n"), "\r", "\n")
+	lines := strings.Split(src, "\n")
+	for i, line := range lines {
+		lines[i] = expandTabs(line)
+	}
+
+	lines = r.collectDefinitions(lines)
+
+	var b strings.Builder
+	r.renderBlocks(&b, lines, false)
+	r.renderFootnotes(&b)
+	return b.String()
+}
+
+var (
+	atxHeadingRegex    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	thematicBreakRegex = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	setextRegex        = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	fenceRegex         = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^`]*?)[ \t]*$")
+	bulletRegex        = regexp.MustCompile(`^( {0,3})([-+*])( +|$)`)
+	orderedRegex       = regexp.MustCompile(`^( {0,3})([0-9]{1,9})([.)])( +|$)`)
+	htmlBlockRegex     = regexp.MustCompile(`(?i)^ {0,3}<(?:!--|/?(?:address|article|aside|blockquote|details|div|dl|dd|dt|fieldset|figcaption|figure|footer|form|h[1-6]|header|hr|iframe|li|main|nav|ol|p|pre|script|section|style|summary|table|tbody|td|tfoot|th|thead|tr|ul)(?:[\s/>]|$))`)
+	refDefRegex        = regexp.MustCompile(`^ {0,3}\[([^\]^][^\]]*)\]:[ \t]*(<[^>]*>|\S+)(?:[ \t]+("[^"]*"|'[^']*'|\([^)]*\)))?[ \t]*$`)
+	footnoteDefRegex   = regexp.MustCompile(`^ {0,3}\[\^([^\]\s]+)\]:[ \t]?(.*)$`)
+	delimiterCellRegex = regexp.MustCompile(`^:?-+:?$`)
+	taskRegex          = regexp.MustCompile(`^\[([ xX])\](?:[ \t]+|$)`)
+)
+
+// collectDefinitions removes link reference and footnote definitions from the top level of the document.
+func (r *renderer) collectDefinitions(lines []string) []string {
+	out := make([]string, 0, len(lines))
+	inFence := ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := fenceRegex.FindStringSubmatch(line); m != nil {
+			switch {
+			case inFence == "":
+				inFence = m[2]
+			case m[3] == "" && m[2][0] == inFence[0] && len(m[2]) >= len(inFence):
+				inFence = ""
+			}
+		}
+		if inFence != "" {
+			out = append(out, line)
+			continue
+		}
+
+		// Definitions can't interrupt a paragraph
+		afterParagraph := len(out) > 0 && !isBlank(out[len(out)-1])
+		if !afterParagraph {
+			if m := footnoteDefRegex.FindStringSubmatch(line); m != nil {
+				body := []string{m[2]}
+			collect:
+				for i+1 < len(lines) {
+					next := lines[i+1]
+					switch {
+					case indentOf(next) >= 4:
+						body = append(body, next[4:])
+					case isBlank(next) && i+2 < len(lines) && indentOf(lines[i+2]) >= 4:
+						body = append(body, "")
+					case !isBlank(next) && !isBlank(body[len(body)-1]) && !startsBlock(next) && !footnoteDefRegex.MatchString(next):
+						body = append(body, strings.TrimLeft(next, " ")) // Lazy continuation
+					default:
+						break collect
+					}
+					i++
+				}
+				label := normalizeLabel(m[1])
+				if _, exists := r.footnotes[label]; !exists {
+					r.footnotes[label] = body
+				}
+				continue
+			}
+			if m := refDefRegex.FindStringSubmatch(line); m != nil {
+				label := normalizeLabel(m[1])
+				if _, exists := r.refs[label]; !exists {
+					url := strings.TrimSuffix(strings.TrimPrefix(m[2], "<"), ">")
+					title := ""
+					if len(m[3]) >= 2 {
+						title = m[3][1 : len(m[3])-1]
+					}
+					r.refs[label] = linkRef{url: unescapeBackslashes(url), title: unescapeBackslashes(title)}
+				}
+				continue
+			}
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+// renderBlocks renders a sequence of block level lines. In tight mode (items of a tight list)
+// paragraphs are written without <p> tags.
+func (r *renderer) renderBlocks(b *strings.Builder, lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+
+		switch {
+		case isBlank(line):
+			i++
+
+		case fenceRegex.MatchString(line):
+			i = r.renderFencedCode(b, lines, i)
+
+		case indentOf(line) >= 4:
+			i = r.renderIndentedCode(b, lines, i)
+
+		case atxHeadingRegex.MatchString(line):
+			m := atxHeadingRegex.FindStringSubmatch(line)
+			r.renderHeading(b, len(m[1]), m[2])
+			i++
+
+		case thematicBreakRegex.MatchString(line):
+			b.WriteString("<hr />\n")
+			i++
+
+		case isQuote(line):
+			i = r.renderBlockquote(b, lines, i)
+
+		case isListItem(line):
+			i = r.renderList(b, lines, i)
+
+		case htmlBlockRegex.MatchString(line):
+			i = r.renderHTMLBlock(b, lines, i)
+
+		case i+1 < len(lines) && isTableStart(line, lines[i+1]):
+			i = r.renderTable(b, lines, i)
+
+		default:
+			i = r.renderParagraph(b, lines, i, tight)
+		}
+	}
+}
+
+func (r *renderer) renderFencedCode(b *strings.Builder, lines []string, start int) int {
+	m := fenceRegex.FindStringSubmatch(lines[start])
+	indent, fence, info := len(m[1]), m[2], m[3]
+
+	var code strings.Builder
+	i := start + 1
+	for ; i < len(lines); i++ {
+		if c := fenceRegex.FindStringSubmatch(lines[i]); c != nil && c[3] == "" && c[2][0] == fence[0] && len(c[2]) >= len(fence) {
+			i++
+			break
+		}
+		line := lines[i]
+		// Remove up to the indentation of the opening fence
+		for n := 0; n < indent && strings.HasPrefix(line, " "); n++ {
+			line = line[1:]
+		}
+		code.WriteString(line + "\n")
+	}
+
+	b.WriteString("<pre><code")
+	if lang := strings.Fields(unescapeBackslashes(info)); len(lang) > 0 {
+		b.WriteString(` class="language-` + escapeHTML(lang[0]) + `"`)
+	}
+	b.WriteString(">" + escapeHTML(code.String()) + "</code></pre>\n")
+	return i
+}
+
+func (r *renderer) renderIndentedCode(b *strings.Builder, lines []string, start int) int {
+	var code []string
+	i := start
+	for ; i < len(lines) && (indentOf(lines[i]) >= 4 || isBlank(lines[i])); i++ {
+		if isBlank(lines[i]) {
+			code = append(code, strings.TrimPrefix(lines[i], "    "))
+		} else {
+			code = append(code, lines[i][4:])
+		}
+	}
+	// Trailing blank lines belong to the surrounding document
+	for len(code) > 0 && isBlank(code[len(code)-1]) {
+		code = code[:len(code)-1]
+		i--
+	}
+	b.WriteString("<pre><code>" + escapeHTML(strings.Join(code, "\n")+"\n") + "</code></pre>\n")
+	return i
+}
+
+func (r *renderer) renderHeading(b *strings.Builder, level int, text string) {
+	content := r.renderInline(strings.TrimSpace(text))
+	fmt.Fprintf(b, "<h%d id=\"%s\">%s</h%d>\n", level, r.headingID(content), content, level)
+}
+
+// headingID returns a unique anchor for a heading based on its text.
+func (r *renderer) headingID(content string) string {
+	return slug.Anchor(html.UnescapeString(stripTags(content)), r.headingIDs)
+}
+
+func isQuote(line string) bool {
+	return indentOf(line) <= 3 && strings.HasPrefix(strings.TrimLeft(line, " "), ">")
+}
+
+func stripQuote(line string) string {
+	line = strings.TrimLeft(line, " ")[1:]
+	return strings.TrimPrefix(line, " ")
+}
+
+func (r *renderer) renderBlockquote(b *strings.Builder, lines []string, start int) int {
+	var inner []string
+	i := start
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if isQuote(line) {
+			inner = append(inner, stripQuote(line))
+			continue
+		}
+		// Lazy continuation of a paragraph inside the quote
+		if !isBlank(line) && len(inner) > 0 && !isBlank(inner[len(inner)-1]) && !startsBlock(line) {
+			inner = append(inner, line)
+			continue
+		}
+		break
+	}
+
+	b.WriteString("<blockquote>\n")
+	r.renderBlocks(b, inner, false)
+	b.WriteString("</blockquote>\n")
+	return i
+}
+
+type listMarker struct {
+	ordered       bool
+	char          byte // Bullet character or ordered delimiter
+	start         int
+	contentIndent int
+	rest          string
+}
+
+func parseListMarker(line string) (listMarker, bool) {
+	if thematicBreakRegex.MatchString(line) {
+		return listMarker{}, false
+	}
+
+	var m listMarker
+	var indent, markerLen, spaces int
+	if g := bulletRegex.FindStringSubmatch(line); g != nil {
+		indent, markerLen, spaces = len(g[1]), 1, len(g[3])
+		m.char = g[2][0]
+	} else if g := orderedRegex.FindStringSubmatch(line); g != nil {
+		indent, markerLen, spaces = len(g[1]), len(g[2])+1, len(g[4])
+		m.ordered = true
+		m.char = g[3][0]
+		m.start, _ = strconv.Atoi(g[2])
+	} else {
+		return listMarker{}, false
+	}
+
+	// Content starts after the spaces following the marker, unless it is an indented code block
+	if spaces == 0 || spaces > 4 {
+		spaces = 1
+	}
+	m.contentIndent = indent + markerLen + spaces
+	if len(line) > m.contentIndent {
+		m.rest = line[m.contentIndent:]
+	} else {
+		m.rest = ""
+	}
+	return m, true
+}
+
+func isListItem(line string) bool {
+	_, ok := parseListMarker(line)
+	return ok
+}
+
+// canInterruptParagraph reports whether a list item may start right after paragraph text:
+// it must not be empty and ordered lists must start at 1.
+func canInterruptParagraph(line string) bool {
+	m, ok := parseListMarker(line)
+	return ok && !isBlank(m.rest) && (!m.ordered || m.start == 1)
+}
+
+func (r *renderer) renderList(b *strings.Builder, lines []string, start int) int {
+	first, _ := parseListMarker(lines[start])
+
+	var items [][]string
+	current := []string{first.rest}
+	contentIndent := first.contentIndent
+	loose := false
+	sawBlank := false
+
+	i := start + 1
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if isBlank(line) {
+			current = append(current, "")
+			sawBlank = true
+			continue
+		}
+		if indentOf(line) >= contentIndent {
+			current = append(current, line[contentIndent:])
+			sawBlank = false
+			continue
+		}
+		if m, ok := parseListMarker(line); ok && m.ordered == first.ordered && m.char == first.char {
+			if sawBlank {
+				loose = true
+			}
+			items = append(items, current)
+			current = []string{m.rest}
+			contentIndent = m.contentIndent
+			sawBlank = false
+			continue
+		}
+		// Lazy continuation line of the item's paragraph
+		if !sawBlank && !isBlank(current[len(current)-1]) && !startsBlock(line) {
+			current = append(current, strings.TrimLeft(line, " "))
+			continue
+		}
+		break
+	}
+	items = append(items, current)
+
+	for k, item := range items {
+		for len(item) > 0 && isBlank(item[len(item)-1]) {
+			item = item[:len(item)-1]
+		}
+		items[k] = item
+		// A blank line between two direct children of an item makes the list loose
+		for j := 1; j < len(item); j++ {
+			if isBlank(item[j-1]) && !isBlank(item[j]) && indentOf(item[j]) == 0 {
+				loose = true
+			}
+		}
+	}
+
+	tag := "ul"
+	if first.ordered {
+		tag = "ol"
+	}
+	isTaskList := false
+	for _, item := range items {
+		if len(item) > 0 && taskRegex.MatchString(item[0]) {
+			isTaskList = true
+		}
+	}
+
+	b.WriteString("<" + tag)
+	if first.ordered && first.start != 1 {
+		fmt.Fprintf(b, ` start="%d"`, first.start)
+	}
+	if isTaskList {
+		b.WriteString(` class="contains-task-list"`)
+	}
+	b.WriteString(">\n")
+
+	for _, item := range items {
+		checkbox := ""
+		if len(item) > 0 {
+			if m := taskRegex.FindStringSubmatch(item[0]); m != nil {
+				checkbox = `<input type="checkbox" disabled=""`
+				if m[1] != " " {
+					checkbox += ` checked=""`
+				}
+				checkbox += " /> "
+				item = append([]string{item[0][len(m[0]):]}, item[1:]...)
+			}
+		}
+
+		if checkbox != "" {
+			b.WriteString(`<li class="task-list-item">`)
+		} else {
+			b.WriteString("<li>")
+		}
+
+		var inner strings.Builder
+		r.renderBlocks(&inner, item, !loose)
+		content := inner.String()
+		if loose && content != "" {
+			b.WriteString("\n")
+		}
+		if checkbox != "" {
+			// Put the checkbox inside the first paragraph of loose items
+			if strings.HasPrefix(content, "<p>") {
+				content = "<p>" + checkbox + content[3:]
+			} else {
+				content = checkbox + content
+			}
+		}
+		if !loose {
+			content = strings.TrimSuffix(content, "\n")
+		}
+		b.WriteString(content + "</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+func (r *renderer) renderHTMLBlock(b *strings.Builder, lines []string, start int) int {
+	first := strings.ToLower(strings.TrimLeft(lines[start], " "))
+
+	// Comments and raw text elements may contain blank lines; they end at their closing marker
+	end := ""
+	switch {
+	case strings.HasPrefix(first, "<!--"):
+		end = "-->"
+	case strings.HasPrefix(first, "<pre"), strings.HasPrefix(first, "<script"), strings.HasPrefix(first, "<style"):
+		end = "</" + strings.FieldsFunc(first[1:], func(c rune) bool { return c == ' ' || c == '>' || c == '\t' })[0] + ">"
+	}
+
+	i := start
+	for ; i < len(lines); i++ {
+		if end == "" && isBlank(lines[i]) {
+			break
+		}
+		b.WriteString(lines[i] + "\n")
+		if end != "" && strings.Contains(strings.ToLower(lines[i]), end) {
+			i++
+			break
+		}
+	}
+	return i
+}
+
+// splitTableRow splits a table row into its cells, honoring escaped pipes.
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// parseDelimiterRow parses the |---|:---:| row below a table header into column alignments.
+func parseDelimiterRow(line string) ([]string, bool) {
+	if !strings.Contains(line, "-") {
+		return nil, false
+	}
+	cells := splitTableRow(line)
+	aligns := make([]string, len(cells))
+	for i, cell := range cells {
+		if !delimiterCellRegex.MatchString(cell) {
+			return nil, false
+		}
+		left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+		switch {
+		case left && right:
+			aligns[i] = "center"
+		case right:
+			aligns[i] = "right"
+		case left:
+			aligns[i] = "left"
+		}
+	}
+	return aligns, true
+}
+
+func isTableStart(header, delimiter string) bool {
+	if !strings.Contains(header, "|") || indentOf(header) >= 4 {
+		return false
+	}
+	aligns, ok := parseDelimiterRow(delimiter)
+	return ok && len(aligns) == len(splitTableRow(header))
+}
+
+func (r *renderer) renderTable(b *strings.Builder, lines []string, start int) int {
+	header := splitTableRow(lines[start])
+	aligns, _ := parseDelimiterRow(lines[start+1])
+
+	writeRow := func(cells []string, tag string) {
+		b.WriteString("<tr>\n")
+		for col := range aligns {
+			cell := ""
+			if col < len(cells) {
+				cell = cells[col]
+			}
+			b.WriteString("<" + tag)
+			if aligns[col] != "" {
+				b.WriteString(` align="` + aligns[col] + `"`)
+			}
+			b.WriteString(">" + r.renderInline(cell) + "</" + tag + ">\n")
+		}
+		b.WriteString("</tr>\n")
+	}
+
+	b.WriteString("<table>\n<thead>\n")
+	writeRow(header, "th")
+	b.WriteString("</thead>\n")
+
+	i := start + 2
+	if i < len(lines) && !isBlank(lines[i]) && !startsBlock(lines[i]) {
+		b.WriteString("<tbody>\n")
+		for ; i < len(lines) && !isBlank(lines[i]) && !startsBlock(lines[i]); i++ {
+			writeRow(splitTableRow(lines[i]), "td")
+		}
+		b.WriteString("</tbody>\n")
+	}
+	b.WriteString("</table>\n")
+	return i
+}
+
+func (r *renderer) renderParagraph(b *strings.Builder, lines []string, start int, tight bool) int {
+	para := []string{strings.TrimLeft(lines[start], " ")}
+	i := start + 1
+	for ; i < len(lines) && !isBlank(lines[i]); i++ {
+		if m := setextRegex.FindStringSubmatch(lines[i]); m != nil && indentOf(lines[i]) < 4 {
+			level := 2
+			if m[1][0] == '=' {
+				level = 1
+			}
+			r.renderHeading(b, level, strings.Join(para, "\n"))
+			return i + 1
+		}
+		if startsBlock(lines[i]) {
+			break
+		}
+		para = append(para, strings.TrimLeft(lines[i], " "))
+	}
+
+	text := strings.TrimRight(strings.Join(para, "\n"), " \t")
+	if tight {
+		b.WriteString(r.renderInline(text) + "\n")
+	} else {
+		b.WriteString("<p>" + r.renderInline(text) + "</p>\n")
+	}
+	return i
+}
+
+// startsBlock reports whether line starts a block that interrupts a paragraph.
+func startsBlock(line string) bool {
+	return atxHeadingRegex.MatchString(line) ||
+		fenceRegex.MatchString(line) ||
+		thematicBreakRegex.MatchString(line) ||
+		isQuote(line) ||
+		htmlBlockRegex.MatchString(line) ||
+		canInterruptParagraph(line)
+}
+
+func (r *renderer) renderFootnotes(b *strings.Builder) {
+	if len(r.footnoteOrder) == 0 {
+		return
+	}
+
+	b.WriteString("<section class=\"footnotes\">\n<ol>\n")
+	// Footnotes may reference further footnotes, so the order can grow while rendering
+	for n := 0; n < len(r.footnoteOrder); n++ {
+		label := r.footnoteOrder[n]
+		var content strings.Builder
+		r.renderBlocks(&content, r.footnotes[label], false)
+
+		backref := fmt.Sprintf(` <a href="#fnref-%d" class="footnote-backref">&#8617;</a>`, n+1)
+		body := content.String()
+		if strings.HasSuffix(body, "</p>\n") {
+			body = strings.TrimSuffix(body, "</p>\n") + backref + "</p>\n"
+		} else {
+			body += "<p>" + strings.TrimSpace(backref) + "</p>\n"
+		}
+		fmt.Fprintf(b, "<li id=\"fn-%d\">\n%s</li>\n", n+1, body)
+	}
+	b.WriteString("</ol>\n</section>\n")
+}
+
+// footnoteNumber returns the number of a referenced footnote, assigning one on first use.
+// ok is false if the footnote is not defined.
+func (r *renderer) footnoteNumber(label string) (n int, first bool, ok bool) {
+	label = normalizeLabel(label)
+	if _, defined := r.footnotes[label]; !defined {
+		return 0, false, false
+	}
+	if n, seen := r.footnoteIndex[label]; seen {
+		return n, false, true
+	}
+	r.footnoteOrder = append(r.footnoteOrder, label)
+	r.footnoteIndex[label] = len(r.footnoteOrder)
+	return len(r.footnoteOrder), true, true
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// expandTabs replaces tabs in the leading whitespace of a line with spaces (tab stop 4).
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var b strings.Builder
+	col := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\t':
+			n := 4 - col%4
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+		case ' ', '>':
+			b.WriteByte(line[i])
+			col++
+		default:
+			return b.String() + line[i:]
+		}
+	}
+	return b.String()
+}
+
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+var tagRegex = regexp.MustCompile(`<[^>]*>`)
+
+func stripTags(s string) string {
+	return tagRegex.ReplaceAllString(s, "")
+}
+
+func escapeHTML(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
@@ -0,0 +1,117 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/sanitize"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"headings get unique ids", "# Hello *world*\n\n## Hello world",
+			"<h1 id=\"hello-world\">Hello <em>world</em></h1>\n<h2 id=\"hello-world-1\">Hello world</h2>\n"},
+		{"heading ids don't collide with suffixed ones", "# A\n# A-1\n# A",
+			"<h1 id=\"a\">A</h1>\n<h1 id=\"a-1\">A-1</h1>\n<h1 id=\"a-2\">A</h1>\n"},
+		{"emphasis", "*em* **strong** ***both*** ~~del~~ _em_ snake_case_word",
+			"<p><em>em</em> <strong>strong</strong> <em><strong>both</strong></em> <del>del</del> <em>em</em> snake_case_word</p>\n"},
+		{"code spans", "`code <b>` and ``a ` b``",
+			"<p><code>code &lt;b&gt;</code> and <code>a ` b</code></p>\n"},
+		{"code span needs a closing run of the same length", "[`]()``",
+			"<p><a href=\"\">`</a>``</p>\n"},
+		{"inline link and image", "[link](https://example.com \"Title\") ![alt *x*](/a.png)",
+			"<p><a href=\"https://example.com\" title=\"Title\">link</a> <img src=\"/a.png\" alt=\"alt x\" /></p>\n"},
+		{"reference links", "[ref][r] [r] [R][]\n\n[r]: /target 'T'",
+			"<p><a href=\"/target\" title=\"T\">ref</a> <a href=\"/target\" title=\"T\">r</a> <a href=\"/target\" title=\"T\">R</a></p>\n"},
+		{"title in parentheses can't contain (", "[a](b (c)", "<p>[a](b (c)</p>\n"},
+		{"escaped brackets", `\[a](b) [a\](b) [a\]](b)`, "<p>[a](b) [a](b) <a href=\"b\">a]</a></p>\n"},
+		{"brackets in code spans", "[a `]` b](c)", "<p><a href=\"c\">a <code>]</code> b</a></p>\n"},
+		{"task list", "- [x] done\n- [ ] todo",
+			"<ul class=\"contains-task-list\">\n<li class=\"task-list-item\"><input type=\"checkbox\" disabled=\"\" checked=\"\" /> done</li>\n<li class=\"task-list-item\"><input type=\"checkbox\" disabled=\"\" /> todo</li>\n</ul>\n"},
+		{"lists", "1. one\n2. two\n\n- a\n\n  b",
+			"<ol>\n<li>one</li>\n<li>two</li>\n</ol>\n<ul>\n<li>\n<p>a</p>\n<p>b</p>\n</li>\n</ul>\n"},
+		{"table", "| a | b |\n|:--|--:|\n| 1 | 2 |",
+			"<table>\n<thead>\n<tr>\n<th align=\"left\">a</th>\n<th align=\"right\">b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td align=\"left\">1</td>\n<td align=\"right\">2</td>\n</tr>\n</tbody>\n</table>\n"},
+		{"footnote", "Text[^n].\n\n[^n]: The note.",
+			"<p>Text<sup class=\"footnote-ref\"><a href=\"#fn-1\" id=\"fnref-1\">1</a></sup>.</p>\n<section class=\"footnotes\">\n<ol>\n<li id=\"fn-1\">\n<p>The note. <a href=\"#fnref-1\" class=\"footnote-backref\">&#8617;</a></p>\n</li>\n</ol>\n</section>\n"},
+		{"bare URLs", "see https://example.com/a_(b). and www.example.com",
+			"<p>see <a href=\"https://example.com/a_(b)\">https://example.com/a_(b)</a>. and <a href=\"http://www.example.com\">www.example.com</a></p>\n"},
+		{"blockquote", "> quote\n> more", "<blockquote>\n<p>quote\nmore</p>\n</blockquote>\n"},
+		{"fenced code", "```go\nx := 1 < 2\n```", "<pre><code class=\"language-go\">x := 1 &lt; 2\n</code></pre>\n"},
+		{"hard breaks", "a  \nb\\\nc", "<p>a<br />\nb<br />\nc</p>\n"},
+		{"escapes and entities", `\*not em\* &copy; & <`, "<p>*not em* &copy; &amp; &lt;</p>\n"},
+	}
+	for _, tt := range tests {
+		if got := Render(tt.src); got != tt.want {
+			t.Errorf("%s: Render(%q)\n got %q\nwant %q", tt.name, tt.src, got, tt.want)
+		}
+	}
+}
+
+// Render passes raw HTML and any link destination through; sanitize.HTML removes what is unsafe
+func TestRenderedHTMLIsSanitized(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"[x](javascript:alert(1))", "<p><a>x</a></p>\n"},
+		{"[x](JaVaScRiPt&#58;alert(1))", "<p><a href=\"JaVaScRiPt&amp;#58;alert(1)\">x</a></p>\n"}, // Entities stay literal, a relative URL
+		{"![x](javascript:alert(1))", "<p><img alt=\"x\" /></p>\n"},
+		{"<javascript:alert(1)>", "<p><a>javascript:alert(1)</a></p>\n"},
+		{"[x][r]\n\n[r]: javascript:alert(1)", "<p><a>x</a></p>\n"},
+		{"<div onclick=\"alert(1)\">raw</div>", "<div>raw</div>\n"},
+		{"a <img src=x onerror=alert(1)> b", "<p>a <img src=\"x\" /> b</p>\n"},
+		{"<script>alert(1)</script>", "\n"},
+	}
+	for _, tt := range tests {
+		if got := sanitize.HTML(Render(tt.src)); got != tt.want {
+			t.Errorf("sanitize.HTML(Render(%q))\n got %q\nwant %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+// Every construct that searches ahead for its end must not do so again for each of many openers
+func TestRenderIsLinear(t *testing.T) {
+	tests := map[string]string{
+		"open brackets":    strings.Repeat("[", 200000),
+		"open images":      strings.Repeat("![", 100000),
+		"nested brackets":  strings.Repeat("[", 100000) + strings.Repeat("]", 100000),
+		"nested links":     strings.Repeat("[", 20000) + "a" + strings.Repeat("](b)", 20000),
+		"footnote refs":    strings.Repeat("[^", 100000),
+		"destinations":     strings.Repeat("[a](", 50000),
+		"titles":           strings.Repeat("[a](b (", 50000),
+		"reference labels": strings.Repeat("[a][", 50000),
+		"backticks":        strings.Repeat("` [", 50000),
+		"emphasis":         strings.Repeat("*a_ ", 50000),
+	}
+	for name, src := range tests {
+		start := time.Now()
+		Render(src)
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("%s: rendering %d bytes took %v", name, len(src), elapsed)
+		}
+	}
+}
+
+func FuzzRender(f *testing.F) {
+	for _, seed := range []string{
+		"# Title\n\nSome *text* with [a link](https://example.com) and `code`.",
+		"- [x] task\n- item\n\n1. one\n2. two",
+		"| a | b |\n|---|---|\n| 1 | 2 |",
+		"Note[^1]\n\n[^1]: Footnote",
+		"[ref]\n\n[ref]: /url \"title\"",
+		"[a `]` b](c) ![i](j (t)) <https://x.y> www.example.com",
+		"***a** b* ~~c~~ _d_",
+		"> quote\n> ```\n> code\n> ```",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		out := sanitize.HTML(Render(src))
+		if again := sanitize.HTML(out); again != out {
+			t.Fatalf("rendered HTML of %q is not stable under sanitizing:\nfirst  %q\nsecond %q", src, out, again)
+		}
+	})
+}
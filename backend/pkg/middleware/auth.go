@@ -10,6 +10,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// UserKey is the context key of the claims of the admin authenticated by AuthMiddleware
+const UserKey = auth.ClaimsKey
+
 func AuthMiddleware(jwtSecret []byte) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -34,7 +37,12 @@ func AuthMiddleware(jwtSecret []byte) gin.HandlerFunc {
 			return
 		}
 
-		c.Set("user", claims)
+		c.Set(UserKey, claims)
 		c.Next()
 	}
 }
+
+// AdminUsername returns the username of the admin authenticated by AuthMiddleware, empty outside admin routes
+func AdminUsername(c *gin.Context) string {
+	return auth.UsernameFromContext(c)
+}
@@ -10,10 +10,17 @@ import (
 	"github.com/sirupsen/logrus" // Import logrus
 )
 
+// PostViewIDKey is the context key handlers set when the post ID is not part of the path (e.g. slug lookups).
+const PostViewIDKey = "post_view_id"
+
 // PostViewMiddleware captures post views.
 func PostViewMiddleware(worker StatsCollector) gin.HandlerFunc {
 	// Regex to match post detail paths like /api/posts/123
 	postPathRegex := regexp.MustCompile(`^/api/posts/(\d+)$`)
+	// Regex to match slug detail paths like /api/posts/by-slug/my-first-post
+	postSlugPathRegex := regexp.MustCompile(`^/api/posts/by-slug/[^/]+$`)
+	// Regex to match draft preview paths like /api/preview/<token>, which never count as views
+	previewPathRegex := regexp.MustCompile(`^/api/preview(/|$)`)
 
 	return func(c *gin.Context) {
 		// --- Add Logging ---
@@ -36,6 +43,11 @@ func PostViewMiddleware(worker StatsCollector) gin.HandlerFunc {
 		// Let the request proceed first
 		c.Next()
 
+		if previewPathRegex.MatchString(c.Request.URL.Path) {
+			log.WithField("path", c.Request.URL.Path).Trace("PostViewMiddleware: Preview request, skipping view count.")
+			return
+		}
+
 		// Check status code - only count views for successful requests (e.g., 2xx)
 		if c.Writer.Status() < 200 || c.Writer.Status() >= 300 {
 			log.WithFields(logrus.Fields{
@@ -54,15 +66,28 @@ func PostViewMiddleware(worker StatsCollector) gin.HandlerFunc {
 			"matches": len(matches),
 		}).Debug("PostViewMiddleware: Checking path for post view")
 
-		if len(matches) == 2 && c.Request.Method == "GET" { // Ensure it's a GET request
-			postIDStr := matches[1]
-			postID, err := strconv.ParseUint(postIDStr, 10, 32)
-			if err != nil {
-				log.WithFields(logrus.Fields{
-					"path":  c.Request.URL.Path,
-					"error": err.Error(),
-				}).Warn("PostViewMiddleware: Failed to parse post ID")
-				return // Don't queue if ID is invalid
+		var postID uint64
+		isSlugPath := len(matches) != 2 && postSlugPathRegex.MatchString(c.Request.URL.Path)
+		if isSlugPath {
+			// The slug handler stores the resolved post ID in the context
+			if id, ok := c.Get(PostViewIDKey); ok {
+				if v, ok := id.(uint); ok {
+					postID = uint64(v)
+				}
+			}
+		}
+
+		if (len(matches) == 2 || postID != 0) && c.Request.Method == "GET" { // Ensure it's a GET request
+			if !isSlugPath {
+				var err error
+				postID, err = strconv.ParseUint(matches[1], 10, 32)
+				if err != nil {
+					log.WithFields(logrus.Fields{
+						"path":  c.Request.URL.Path,
+						"error": err.Error(),
+					}).Warn("PostViewMiddleware: Failed to parse post ID")
+					return // Don't queue if ID is invalid
+				}
 			}
 
 			ipAddress := c.ClientIP()
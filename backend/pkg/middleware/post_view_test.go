@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// viewRecorder is a StatsCollector that keeps the queued views
+type viewRecorder struct {
+	views []*models.PostView
+}
+
+func (r *viewRecorder) QueueVisit(*models.Visitor)      {}
+func (r *viewRecorder) QueueView(view *models.PostView) { r.views = append(r.views, view) }
+
+func TestPostViewMiddlewareSkipsPreviews(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	recorder := &viewRecorder{}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("logger", logger) }, PostViewMiddleware(recorder))
+	ok := func(c *gin.Context) {
+		c.Set(PostViewIDKey, uint(7))
+		c.Status(http.StatusOK)
+	}
+	r.GET("/api/posts/:id", ok)
+	r.GET("/api/preview/:token", ok)
+
+	for _, path := range []string{"/api/preview/7", "/api/preview/abc"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if len(recorder.views) != 0 {
+		t.Fatalf("preview requests queued %d views, want none", len(recorder.views))
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/posts/3", nil))
+	if len(recorder.views) != 1 || recorder.views[0].PostID != 3 {
+		t.Errorf("post detail queued %+v, want one view of post 3", recorder.views)
+	}
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	myerr "github.com/dervisgenc/dervisgenc-blog/backend/pkg"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RateLimit is a token bucket: a client may make Requests requests at once, and regains the right
+// to one more request every Period/Requests.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+	PerRoute bool // Separate buckets for each route using the limit, instead of one per client shared by all of them
+}
+
+// rate is the number of tokens added to a bucket per second
+func (l RateLimit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// RateLimitResult is the state of a bucket after a request took, or failed to take, a token from it
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int           // Tokens left in the bucket
+	RetryAfter time.Duration // Time until the next token, when the request was not allowed
+	Reset      time.Duration // Time until the bucket is full again
+}
+
+// RateLimitStore keeps the token buckets of a rate limit
+type RateLimitStore interface {
+	// Take takes a token from the bucket under key, creating a full bucket for new keys
+	Take(key string, limit RateLimit, now time.Time) (RateLimitResult, error)
+}
+
+// tokenBucket is the state stored for a key
+type tokenBucket struct {
+	tokens     float64
+	refilledAt time.Time
+}
+
+// take refills the bucket for the time passed since the last request and takes a token if one is left
+func (b *tokenBucket) take(limit RateLimit, now time.Time) RateLimitResult {
+	capacity := float64(limit.Requests)
+	if elapsed := now.Sub(b.refilledAt).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed*limit.rate())
+		b.refilledAt = now
+	}
+
+	var result RateLimitResult
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - b.tokens) / limit.rate())
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = secondsToDuration((capacity - b.tokens) / limit.rate())
+	return result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// MemoryRateLimitStore keeps the buckets in memory. Limits are per process, so with several instances
+// behind a load balancer each of them allows the full limit; use PostgresRateLimitStore there.
+type MemoryRateLimitStore struct {
+	mu       sync.Mutex
+	buckets  map[string]*memoryBucket
+	lastScan time.Time
+}
+
+type memoryBucket struct {
+	tokenBucket
+	period time.Duration // Period of the limit the bucket belongs to
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*memoryBucket)}
+}
+
+// memoryScanInterval is how often buckets that are full again are dropped
+const memoryScanInterval = time.Minute
+
+func (s *MemoryRateLimitStore) Take(key string, limit RateLimit, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastScan) > memoryScanInterval {
+		s.lastScan = now
+		for k, b := range s.buckets {
+			// A bucket refilled for longer than the period is full, forgetting it changes nothing
+			if now.Sub(b.refilledAt) > b.period {
+				delete(s.buckets, k)
+			}
+		}
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokenBucket: tokenBucket{tokens: float64(limit.Requests), refilledAt: now}, period: limit.Period}
+		s.buckets[key] = b
+	}
+	return b.take(limit, now), nil
+}
+
+// RateLimitMiddleware limits the requests of each client IP with a token bucket. Routes using the same name share
+// their buckets unless the limit is PerRoute. Every response carries RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers; requests over the limit are answered with 429 and Retry-After.
+// If the store fails, requests are let through.
+func RateLimitMiddleware(store RateLimitStore, name string, limit RateLimit, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limit.Requests <= 0 || limit.Period <= 0 {
+			c.Next()
+			return
+		}
+
+		key := name + ":" + c.ClientIP()
+		if limit.PerRoute {
+			key += ":" + c.Request.Method + " " + c.FullPath()
+		}
+		result, err := store.Take(key, limit, time.Now())
+		if err != nil {
+			logger.WithError(err).WithField("limit", name).Error("Middleware: Rate limit store failed, allowing request")
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(limit.Requests))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			logger.WithFields(logrus.Fields{"limit": name, "client_ip": c.ClientIP()}).Warn("Middleware: Rate limit exceeded")
+			c.Error(myerr.WithHTTPStatus(fmt.Errorf("too many requests, retry in %d seconds", ceilSeconds(result.RetryAfter)), http.StatusTooManyRequests))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
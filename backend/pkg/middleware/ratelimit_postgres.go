@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// postgresCleanupEvery is how many requests pass between deletions of stale buckets
+const postgresCleanupEvery = 1000
+
+// postgresStaleAfter is how long a bucket is kept after its last request; longer than any configured period
+const postgresStaleAfter = 24 * time.Hour
+
+// PostgresRateLimitStore keeps the buckets in the rate_limit_buckets table, so that every instance of the
+// application shares them. Each request locks the row of its bucket for the duration of a short transaction.
+type PostgresRateLimitStore struct {
+	db       *gorm.DB
+	requests atomic.Uint64
+}
+
+func NewPostgresRateLimitStore(db *gorm.DB) *PostgresRateLimitStore {
+	return &PostgresRateLimitStore{db: db}
+}
+
+func (s *PostgresRateLimitStore) Take(key string, limit RateLimit, now time.Time) (RateLimitResult, error) {
+	var result RateLimitResult
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var row models.RateLimitBucket
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&row).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Concurrent first requests of a key both insert; the loser waits for the winner's lock
+			row = models.RateLimitBucket{Key: key, Tokens: float64(limit.Requests), RefilledAt: now}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+				return err
+			}
+			err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&row).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		bucket := tokenBucket{tokens: row.Tokens, refilledAt: row.RefilledAt}
+		result = bucket.take(limit, now)
+		return tx.Model(&models.RateLimitBucket{}).Where("key = ?", key).
+			Updates(map[string]interface{}{"tokens": bucket.tokens, "refilled_at": bucket.refilledAt}).Error
+	})
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+
+	if s.requests.Add(1)%postgresCleanupEvery == 0 {
+		s.db.Where("refilled_at < ?", now.Add(-postgresStaleAfter)).Delete(&models.RateLimitBucket{})
+	}
+	return result, nil
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func TestTokenBucket(t *testing.T) {
+	limit := RateLimit{Requests: 2, Period: 10 * time.Second}
+	store := NewMemoryRateLimitStore()
+	start := time.Now()
+
+	for i, want := range []bool{true, true, false} {
+		result, _ := store.Take("k", limit, start)
+		if result.Allowed != want {
+			t.Fatalf("request %d: allowed = %v, want %v", i+1, result.Allowed, want)
+		}
+	}
+	if result, _ := store.Take("k", limit, start); result.RetryAfter != 5*time.Second {
+		t.Errorf("retry after = %v, want 5s", result.RetryAfter)
+	}
+	// One token comes back every Period/Requests
+	if result, _ := store.Take("k", limit, start.Add(5*time.Second)); !result.Allowed {
+		t.Error("request after the refill was not allowed")
+	}
+	if result, _ := store.Take("other", limit, start); !result.Allowed {
+		t.Error("keys don't share buckets")
+	}
+}
+
+// newLimitedRouter serves GET / behind a limit of one request, trusting the given proxies like the server does
+func newLimitedRouter(t *testing.T, trustedProxies []string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	r := gin.New()
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		t.Fatal(err)
+	}
+	r.Use(ErrorMiddleware())
+	limit := RateLimit{Requests: 1, Period: time.Hour}
+	r.GET("/", RateLimitMiddleware(NewMemoryRateLimitStore(), "test", limit, logger), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return r
+}
+
+func get(r http.Handler, remoteAddr, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRateLimitIgnoresForwardedForWithoutTrustedProxies(t *testing.T) {
+	r := newLimitedRouter(t, nil)
+
+	if code := get(r, "203.0.113.7:1234", "198.51.100.1"); code != http.StatusNoContent {
+		t.Fatalf("first request: status %d", code)
+	}
+	// A client making up a new X-Forwarded-For for every request must not get a new bucket
+	if code := get(r, "203.0.113.7:1234", "198.51.100.2"); code != http.StatusTooManyRequests {
+		t.Errorf("request with a forged X-Forwarded-For: status %d, want 429", code)
+	}
+}
+
+func TestRateLimitUsesForwardedForFromTrustedProxies(t *testing.T) {
+	r := newLimitedRouter(t, []string{"10.0.0.0/8"})
+
+	if code := get(r, "10.0.0.2:1234", "198.51.100.1"); code != http.StatusNoContent {
+		t.Fatalf("first client: status %d", code)
+	}
+	if code := get(r, "10.0.0.2:1234", "198.51.100.2"); code != http.StatusNoContent {
+		t.Errorf("second client behind the proxy: status %d, want 204", code)
+	}
+	if code := get(r, "10.0.0.2:1234", "198.51.100.1"); code != http.StatusTooManyRequests {
+		t.Errorf("first client again: status %d, want 429", code)
+	}
+}
+
+func TestRateLimitPerRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	store := NewMemoryRateLimitStore()
+
+	r := gin.New()
+	r.Use(ErrorMiddleware())
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	shared := RateLimitMiddleware(store, "shared", RateLimit{Requests: 1, Period: time.Hour}, logger)
+	perRoute := RateLimitMiddleware(store, "per-route", RateLimit{Requests: 1, Period: time.Hour, PerRoute: true}, logger)
+	r.POST("/shared/a", shared, ok)
+	r.POST("/shared/b", shared, ok)
+	r.POST("/per-route/a/:id", perRoute, ok)
+	r.POST("/per-route/b/:id", perRoute, ok)
+
+	post := func(path string) int {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	for _, step := range []struct {
+		path string
+		want int
+	}{
+		{"/shared/a", http.StatusNoContent},
+		{"/shared/b", http.StatusTooManyRequests}, // Shares the bucket of /shared/a
+		{"/per-route/a/1", http.StatusNoContent},
+		{"/per-route/b/1", http.StatusNoContent},
+		{"/per-route/a/2", http.StatusTooManyRequests}, // Same route as /per-route/a/1, whatever the parameters
+	} {
+		if code := post(step.path); code != step.want {
+			t.Errorf("POST %s: status %d, want %d", step.path, code, step.want)
+		}
+	}
+}
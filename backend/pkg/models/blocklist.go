@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Kinds of block rules, by what they match
+const (
+	BlockKindIP     = "ip"     // Exact submitter IP
+	BlockKindCIDR   = "cidr"   // Submitter IP within a range
+	BlockKindEmail  = "email"  // Exact author email, case-insensitively
+	BlockKindDomain = "domain" // Domain of the author email and its subdomains
+	BlockKindPhrase = "phrase" // Regular expression matched case-insensitively against author name and content
+)
+
+// Actions of block rules on the comments they match
+const (
+	BlockActionReject  = "reject"  // Refuse the comment with an error
+	BlockActionHold    = "hold"    // Keep the comment in the moderation queue, even if it would be approved
+	BlockActionDiscard = "discard" // Pretend to accept the comment but put it in the spam bucket
+)
+
+// BlockRule is an admin-managed rule that stops comments before they are stored
+type BlockRule struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Kind      string     `json:"kind" gorm:"type:varchar(10);not null;uniqueIndex:idx_block_rules_kind_pattern"`
+	Pattern   string     `json:"pattern" gorm:"type:varchar(255);not null;uniqueIndex:idx_block_rules_kind_pattern"` // Normalized when saved
+	Action    string     `json:"action" gorm:"type:varchar(10);not null"`
+	Note      string     `json:"note,omitempty" gorm:"type:varchar(255)"` // Why the rule exists
+	Hits      int64      `json:"hits" gorm:"not null;default:0"`          // Comments the rule stopped
+	LastHitAt *time.Time `json:"last_hit_at,omitempty"`
+}
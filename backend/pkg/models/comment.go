@@ -19,9 +19,23 @@ type Comment struct {
 	IsApproved      bool           `json:"is_approved" gorm:"default:false;index"`
 	ApprovedAt      *time.Time     `json:"approved_at,omitempty"`
 	ParentCommentID *uint          `json:"parent_comment_id,omitempty" gorm:"index"` // For nested comments
+	IsSpam          bool           `json:"is_spam" gorm:"default:false;index"`       // Put in the spam bucket instead of the pending queue
+	SpamScore       float64        `json:"spam_score" gorm:"default:0"`              // 0 to 1, computed by the spam filter when the comment was submitted
+	SpamReasons     string         `json:"spam_reasons,omitempty" gorm:"type:varchar(255)"`
+	ContentHash     string         `json:"-" gorm:"type:varchar(64);index"` // Hash of the normalized content, finds repeated submissions
+	SpamTrainedAs   string         `json:"-" gorm:"type:varchar(10)"`       // Label the spam classifier learned this comment as, empty if none
+	EditTokenHash   string         `json:"-" gorm:"type:varchar(64)"`       // SHA-256 of the token the author edits and withdraws the comment with
+	EditedAt        *time.Time     `json:"edited_at,omitempty"`
+	// Held back until the author follows the link in the verification email, see VerifiedEmail
+	AwaitingVerification bool   `json:"-" gorm:"default:false;index"`
+	IPAddress            string `json:"-" gorm:"type:varchar(45);index"` // Of the submitter; empty for comments from before it was recorded
+	UserAgent            string `json:"-" gorm:"type:varchar(255)"`
+	FlagCount            int    `json:"flag_count" gorm:"default:0;index"` // Flags by readers since the comment was last approved
+	UserID               *uint  `json:"-" gorm:"index"`                    // Admin account that replied from the dashboard, nil for readers
 
 	// Relations (optional but recommended)
-	Post Post `json:"-" gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"` // Ensures comment deletion if post is deleted
+	Post Post  `json:"-" gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"` // Ensures comment deletion if post is deleted
+	User *User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:SET NULL"`
 	// ParentComment *Comment `json:"-" gorm:"foreignKey:ParentCommentID"`
 	// Replies     []Comment `json:"replies,omitempty" gorm:"foreignKey:ParentCommentID"` // For eager loading replies
 }
@@ -31,3 +45,54 @@ type AdminComment struct {
 	Comment
 	AuthorEmail string `json:"author_email"` // Expose email for admin
 }
+
+// VerifiedEmail is an email address whose owner followed a comment verification link. Comments from it are approved
+// without moderation.
+type VerifiedEmail struct {
+	Email      string    `gorm:"primaryKey;type:varchar(100)"` // Lowercase
+	VerifiedAt time.Time `gorm:"not null"`
+}
+
+// ModerationLogEntry records a moderation action of an admin on a comment. Entries outlive the comments they refer to.
+type ModerationLogEntry struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+	Actor     string    `json:"actor" gorm:"type:varchar(50);not null;index"`  // Username of the admin, or "flags" for comments flagged by readers
+	Action    string    `json:"action" gorm:"type:varchar(20);not null;index"` // approve, unapprove, spam, not_spam or delete
+	CommentID uint      `json:"comment_id" gorm:"not null;index"`
+	PostID    uint      `json:"post_id" gorm:"not null;index"`
+	BatchID   string    `json:"batch_id,omitempty" gorm:"type:varchar(36);index"` // Shared by the entries of one bulk action
+}
+
+// Reasons readers flag a comment for
+const (
+	FlagReasonSpam     = "spam"
+	FlagReasonAbuse    = "abuse"
+	FlagReasonOffTopic = "off_topic"
+	FlagReasonOther    = "other"
+)
+
+// CommentReaction is an emoji reaction of a reader, identified by IP address, to a comment. A reader can give each
+// reaction once per comment.
+type CommentReaction struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	CommentID uint      `json:"comment_id" gorm:"not null;uniqueIndex:idx_comment_reactions_reader"`
+	Emoji     string    `json:"emoji" gorm:"type:varchar(32);not null;uniqueIndex:idx_comment_reactions_reader"`
+	IPAddress string    `json:"-" gorm:"type:varchar(45);not null;uniqueIndex:idx_comment_reactions_reader"`
+	Comment   Comment   `json:"-" gorm:"foreignKey:CommentID;constraint:OnDelete:CASCADE"`
+}
+
+// CommentFlag is a report of a reader, identified by IP address, that a comment breaks the rules. A reader can flag
+// a comment once.
+type CommentFlag struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+	CommentID uint      `json:"comment_id" gorm:"not null;uniqueIndex:idx_comment_flags_reader"`
+	Reason    string    `json:"reason" gorm:"type:varchar(20);not null"` // spam, abuse, off_topic or other
+	Details   string    `json:"details,omitempty" gorm:"type:varchar(500)"`
+	IPAddress string    `json:"ip_address" gorm:"type:varchar(45);not null;uniqueIndex:idx_comment_flags_reader;index"`
+	// Network of the IP address, a /24 for IPv4 and a /64 for IPv6. Flags from one network count once.
+	Network string  `json:"network" gorm:"type:varchar(49);not null;default:''"`
+	Comment Comment `json:"-" gorm:"foreignKey:CommentID;constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Kinds of imported items
+const (
+	ImportKindPost    = "post"
+	ImportKindComment = "comment"
+	ImportKindImage   = "image"
+)
+
+// ImportMapping remembers which local row an item of another blog was imported into,
+// so importing the same export again doesn't duplicate anything.
+type ImportMapping struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	CreatedAt  time.Time `json:"created_at"`
+	Source     string    `json:"source" gorm:"type:varchar(20);not null;uniqueIndex:idx_import_mappings_item"`       // wordpress or ghost
+	Site       string    `json:"site" gorm:"type:varchar(255);not null;uniqueIndex:idx_import_mappings_item"`        // Identifies the exporting blog, IDs are only unique per site
+	Kind       string    `json:"kind" gorm:"type:varchar(20);not null;uniqueIndex:idx_import_mappings_item"`         // post, comment or image
+	ExternalID string    `json:"external_id" gorm:"type:varchar(500);not null;uniqueIndex:idx_import_mappings_item"` // ID (or URL for images) in the source blog
+	LocalID    uint      `json:"local_id"`                                                                           // Post or comment ID
+	LocalFile  string    `json:"local_file,omitempty" gorm:"type:varchar(255)"`                                      // Stored filename of an image
+}
@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+// EmailUnsubscribe is an email address that no longer gets notified of replies to its comments
+type EmailUnsubscribe struct {
+	Email     string `gorm:"primaryKey;type:varchar(100)"` // Lowercase
+	CreatedAt time.Time
+}
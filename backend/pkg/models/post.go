@@ -1,23 +1,154 @@
 package models
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/toc"
+	"gorm.io/gorm"
+)
+
+// Post lifecycle statuses
+const (
+	PostStatusDraft     = "draft"
+	PostStatusScheduled = "scheduled"
+	PostStatusPublished = "published"
+	PostStatusArchived  = "archived"
+)
+
+// Post visibility modes, independent of the lifecycle status
+const (
+	PostVisibilityPublic   = "public"   // Listed everywhere
+	PostVisibilityUnlisted = "unlisted" // Reachable by its URL but left out of listings, search, feeds and related posts
+	PostVisibilityPassword = "password" // Listed, but the content is only served to readers who know the password
+)
+
+// Comment modes of a post
+const (
+	CommentModeOpen        = "open"         // Comments are moderated as configured and close with the site-wide policy
+	CommentModeClosed      = "closed"       // No new comments
+	CommentModeModerated   = "moderated"    // Every comment is moderated, even from verified emails
+	CommentModeAutoApprove = "auto_approve" // Comments that pass the spam filter and the blocklist are published right away
+)
+
+// Source formats of Post.Content
+const (
+	ContentFormatHTML     = "html"
+	ContentFormatMarkdown = "markdown"
+)
+
+// PostSearchConfig is the PostgreSQL text search configuration used for posts.search_vector.
+// The generated column is created by the migrations, so changing it requires re-running them.
+const PostSearchConfig = "english"
 
 // Post struct
 type Post struct {
-	ID        uint       `json:"id" example:"1"`
-	CreatedAt time.Time  `json:"created_at" example:"2024-10-01T00:00:00Z"`
-	UpdatedAt time.Time  `json:"updated_at" example:"2024-10-01T01:00:00Z"`
-	DeletedAt *time.Time `gorm:"index" json:"deleted_at,omitempty"` // Added gorm index tag
-	Title     string     `gorm:"type:varchar(200);not null;index" json:"title" example:"Sample Post"`
-	Content   string     `gorm:"type:text;not null" json:"content" example:"This is the content of the post"`
-	Summary   string     `gorm:"type:text" json:"summary" example:"This is a summary"`
-	ImagePath string     `gorm:"type:varchar(255)" json:"image_path"`
-	ImageURL  string     `gorm:"type:varchar(255)" json:"image_url"`
-	ReadTime  int        `gorm:"type:integer" json:"read_time" example:"5"`
-	IsActive  bool       `gorm:"type:boolean;index" json:"is_active" example:"false"`
-	LikeCount int        `gorm:"type:integer;default:0;index" json:"like_count"`
-	Category  string     `gorm:"type:varchar(100);index" json:"category" example:"Technology"` // Added Category
-	Tags      string     `gorm:"type:varchar(255);index" json:"tags" example:"go,backend,api"` // Added Tags (comma-separated)
+	ID               uint            `json:"id" example:"1"`
+	CreatedAt        time.Time       `json:"created_at" example:"2024-10-01T00:00:00Z"`
+	UpdatedAt        time.Time       `json:"updated_at" example:"2024-10-01T01:00:00Z"`
+	DeletedAt        *time.Time      `gorm:"index" json:"deleted_at,omitempty"` // Added gorm index tag
+	Title            string          `gorm:"type:varchar(200);not null;index" json:"title" example:"Sample Post"`
+	Slug             string          `gorm:"type:varchar(255);uniqueIndex" json:"slug" example:"sample-post"`
+	Content          string          `gorm:"type:text;not null" json:"content" example:"This is the content of the post"`
+	Summary          string          `gorm:"type:text" json:"summary" example:"This is a summary"`
+	ImagePath        string          `gorm:"type:varchar(255)" json:"image_path"`
+	ImageURL         string          `gorm:"type:varchar(255)" json:"image_url"`
+	ReadTime         int             `gorm:"type:integer" json:"read_time" example:"5"` // Estimated from the content unless ReadTimeOverride is set
+	IsActive         bool            `gorm:"type:boolean;index" json:"is_active" example:"false"`
+	LikeCount        int             `gorm:"type:integer;default:0;index" json:"like_count"`
+	Category         string          `gorm:"type:varchar(100);index" json:"category" example:"Technology"` // Display name of the category, kept in sync with CategoryID
+	Tags             string          `gorm:"type:varchar(255);index" json:"tags" example:"go,backend,api"` // Comma-separated copy of the post_tags rows, used by search
+	CategoryID       *uint           `gorm:"index" json:"category_id,omitempty"`
+	Status           string          `gorm:"type:varchar(20);not null;default:'published';index" json:"status" example:"published"` // draft, scheduled, published or archived
+	PublishedAt      *time.Time      `gorm:"index" json:"published_at,omitempty" example:"2024-10-01T09:00:00Z"`                    // Public listings only include posts published before now
+	ContentFormat    string          `gorm:"type:varchar(20);not null;default:'html'" json:"content_format" example:"markdown"`     // html or markdown, describes Content
+	RenderedContent  string          `gorm:"type:text" json:"-"`                                                                    // Sanitized HTML rendered from Content, see render.Content
+	Language         string          `gorm:"type:varchar(10);not null;default:'en'" json:"language" example:"en"`                   // Language of the content, selects the reading speed
+	WordCount        int             `gorm:"type:integer;not null;default:0" json:"word_count" example:"1200"`
+	ReadTimeOverride int             `gorm:"type:integer;not null;default:0" json:"read_time_override" example:"0"` // Read time in minutes entered by the admin, 0 uses the estimate
+	TableOfContents  TableOfContents `gorm:"column:toc;type:jsonb" json:"toc"`
+	Visibility       string          `gorm:"type:varchar(20);not null;default:'public';index" json:"visibility" example:"public"` // public, unlisted or password
+	PasswordHash     string          `gorm:"type:varchar(255)" json:"-"`                                                          // bcrypt hash, only set for password visibility
+	Password         string          `gorm:"-" json:"-"`                                                                          // Plain password chosen by the admin, hashed into PasswordHash by the service
+	CommentMode      string          `gorm:"type:varchar(20);not null;default:'open'" json:"comment_mode" example:"open"`         // open, closed, moderated or auto_approve
+}
+
+// CommentsCloseAt returns when the post stops accepting comments under a policy closing them closeAfterDays after
+// publication, or nil if it doesn't close. Posts that are closed already or not published have no closing time.
+func (p *Post) CommentsCloseAt(closeAfterDays int) *time.Time {
+	if closeAfterDays <= 0 || p.PublishedAt == nil || p.CommentMode == CommentModeClosed {
+		return nil
+	}
+	closeAt := p.PublishedAt.AddDate(0, 0, closeAfterDays)
+	return &closeAt
+}
+
+// CommentsOpen reports whether the post accepts new comments at the given time
+func (p *Post) CommentsOpen(closeAfterDays int, now time.Time) bool {
+	if p.CommentMode == CommentModeClosed {
+		return false
+	}
+	closeAt := p.CommentsCloseAt(closeAfterDays)
+	return closeAt == nil || now.Before(*closeAt)
+}
+
+// PublishedPosts limits a query on posts to the ones readers can open by their URL: active, published and with a
+// publish time in the past. Unlisted posts are included; listings use ListedPosts instead.
+func PublishedPosts(db *gorm.DB) *gorm.DB {
+	return db.Where("posts.is_active = ? AND posts.status = ? AND posts.published_at <= ?", true, PostStatusPublished, time.Now())
+}
+
+// ListedPosts limits a query on posts to the published ones that may appear in listings, leaving unlisted posts out
+func ListedPosts(db *gorm.DB) *gorm.DB {
+	return PublishedPosts(db).Where("posts.visibility <> ?", PostVisibilityUnlisted)
+}
+
+// TableOfContents lists the headings of a post. It is stored as JSON.
+type TableOfContents []toc.Entry
+
+// Value implements driver.Valuer. A post without headings stores an empty list, NULL is left to posts
+// saved before the column existed (see migrations.Up_000009).
+func (t TableOfContents) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner
+func (t *TableOfContents) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	}
+	return fmt.Errorf("cannot scan %T into TableOfContents", value)
+}
+
+// PostSlugHistory keeps the previous slugs of a post so old URLs keep resolving after a rename
+type PostSlugHistory struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	PostID    uint      `json:"post_id" gorm:"not null;index"`
+	Slug      string    `json:"slug" gorm:"type:varchar(255);not null;uniqueIndex"`
+	Post      Post      `json:"-" gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+}
+
+// PostSearchResult is a post matched by full-text search together with its rank and highlighted snippet
+type PostSearchResult struct {
+	Post    `gorm:"embedded"`
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet"`
 }
 
 // PaginatedPosts represents a paginated response for posts
@@ -28,3 +159,29 @@ type PaginatedPosts struct {
 	PageSize   int     `json:"page_size"`
 	TotalPages int     `json:"total_pages"`
 }
+
+// PostRevision is a snapshot of a post taken right before it was updated
+type PostRevision struct {
+	ID            uint      `json:"id" gorm:"primarykey"`
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+	PostID        uint      `json:"post_id" gorm:"not null;index"`
+	Title         string    `json:"title" gorm:"type:varchar(200);not null"`
+	Summary       string    `json:"summary" gorm:"type:text"`
+	Content       string    `json:"content" gorm:"type:text;not null"`
+	ImageURL      string    `json:"image_url" gorm:"type:varchar(255)"`
+	Category      string    `json:"category" gorm:"type:varchar(100)"`
+	Tags          string    `json:"tags" gorm:"type:varchar(255)"`
+	ContentFormat string    `json:"content_format" gorm:"type:varchar(20);not null;default:'html'"`
+	Post          Post      `json:"-" gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+}
+
+// PreviewLink lets someone without an admin account read a post before it is published.
+// The link itself is a signed token; the row allows it to be listed and revoked.
+type PreviewLink struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time  `json:"created_at"`
+	PostID    uint       `json:"post_id" gorm:"not null;index"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	Post      Post       `json:"-" gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// RateLimitBucket is the token bucket of a rate-limited client, stored when rate limits are shared between instances
+type RateLimitBucket struct {
+	Key        string    `gorm:"primaryKey;type:varchar(255)"` // Limit name, client IP and optionally the route
+	Tokens     float64   `gorm:"not null"`
+	RefilledAt time.Time `gorm:"not null;index"`
+}
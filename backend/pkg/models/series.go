@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Series is an ordered collection of posts, e.g. the parts of a multi-part tutorial
+type Series struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Title       string    `json:"title" gorm:"type:varchar(200);not null" example:"Building a Blog in Go"`
+	Slug        string    `json:"slug" gorm:"type:varchar(255);not null;uniqueIndex" example:"building-a-blog-in-go"`
+	Description string    `json:"description" gorm:"type:text"`
+}
+
+// SeriesPost places a post in a series. A post belongs to at most one series.
+type SeriesPost struct {
+	PostID   uint   `json:"post_id" gorm:"primaryKey;autoIncrement:false"`
+	SeriesID uint   `json:"series_id" gorm:"not null;uniqueIndex:idx_series_posts_position"`
+	Position int    `json:"position" gorm:"not null;uniqueIndex:idx_series_posts_position"` // 1-based order within the series
+	Post     Post   `json:"-" gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+	Series   Series `json:"-" gorm:"foreignKey:SeriesID;constraint:OnDelete:CASCADE"`
+}
+
+// SeriesSummary is a series together with the number of posts in it
+type SeriesSummary struct {
+	Series    `gorm:"embedded"`
+	PostCount int64 `json:"post_count"`
+}
+
+// SeriesPart identifies a post of a series, enough to link to it
+type SeriesPart struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+	Slug  string `json:"slug"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// Labels the spam classifier learns comments as
+const (
+	SpamLabelSpam = "spam"
+	SpamLabelHam  = "ham"
+)
+
+// SpamToken counts the learned comments of each label that contained a token
+type SpamToken struct {
+	Token string `gorm:"primaryKey;type:varchar(64)"`
+	Spam  int    `gorm:"not null;default:0"`
+	Ham   int    `gorm:"not null;default:0"`
+}
+
+// SpamClass counts the comments the spam classifier learned with a label
+type SpamClass struct {
+	Label     string `gorm:"primaryKey;type:varchar(10)"`
+	Documents int    `gorm:"not null;default:0"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Category groups posts; every post belongs to at most one category
+type Category struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name" gorm:"type:varchar(100);not null" example:"Technology"`
+	Slug      string    `json:"slug" gorm:"type:varchar(120);not null;uniqueIndex" example:"technology"`
+}
+
+// Tag labels posts; posts and tags are linked through PostTag
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name" gorm:"type:varchar(100);not null" example:"go"`
+	Slug      string    `json:"slug" gorm:"type:varchar(120);not null;uniqueIndex" example:"go"`
+}
+
+// PostTag is the join table between posts and tags
+type PostTag struct {
+	PostID uint `json:"post_id" gorm:"primaryKey"`
+	TagID  uint `json:"tag_id" gorm:"primaryKey;index"`
+	Post   Post `json:"-" gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+	Tag    Tag  `json:"-" gorm:"foreignKey:TagID;constraint:OnDelete:CASCADE"`
+}
+
+// TaxonomyCount is a category or tag together with the number of published posts using it
+type TaxonomyCount struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	PostCount int64  `json:"post_count"`
+}
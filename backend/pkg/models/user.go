@@ -11,4 +11,6 @@ type User struct {
 	Username     string     `gorm:"type:varchar(50)" json:"username" example:"john_doe"`
 	Email        string     `gorm:"type:varchar(100)" json:"email" example:"john@example.com"`
 	PasswordHash string     `gorm:"type:varchar(255);not null" json:"password_hash"`
+	DisplayName  string     `gorm:"type:varchar(100)" json:"display_name" example:"John Doe"` // Signs the comments of the user; unset signs them "Author"
+	AvatarURL    string     `gorm:"type:varchar(255)" json:"avatar_url"`
 }
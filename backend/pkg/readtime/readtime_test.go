@@ -0,0 +1,71 @@
+package readtime
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWordsPerMinute(t *testing.T) {
+	tests := map[string]int{
+		"en":    238,
+		"EN":    238,
+		"pt-BR": 181,
+		"zh_TW": 255,
+		"xx":    DefaultWordsPerMinute,
+		"":      DefaultWordsPerMinute,
+	}
+	for language, want := range tests {
+		if got := WordsPerMinute(language); got != want {
+			t.Errorf("WordsPerMinute(%q) = %d, want %d", language, got, want)
+		}
+	}
+}
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"Hello, world 42", 3},
+		{"don't stop", 2},
+		{"  spaced\tout\nwords  ", 3},
+		{"日本語です", 5},
+		{"Go言語", 3},
+		{"İstanbul'da güzel", 2},
+	}
+	for _, tt := range tests {
+		if got := countWords(tt.text); got != tt.want {
+			t.Errorf("countWords(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	words := func(n int) string { return "<p>" + strings.Repeat("word ", n) + "</p>" }
+	images := func(n int) string { return strings.Repeat(`<img src="a.png" alt="an image">`, n) }
+
+	tests := []struct {
+		name, doc, language string
+		want                Stats
+	}{
+		{"empty", "", "en", Stats{}},
+		{"markup only", "<p></p>", "en", Stats{}},
+		{"one minute of English", words(238), "en", Stats{Words: 238, Minutes: 1}},
+		{"a word more", words(239), "en", Stats{Words: 239, Minutes: 2}},
+		{"short text takes a minute", words(3), "en", Stats{Words: 3, Minutes: 1}},
+		{"slower language", words(180), "de", Stats{Words: 180, Minutes: 2}},
+		{"attributes are not words", images(1), "en", Stats{Images: 1, Minutes: 1}},
+		// 12+11+...+3 seconds for the first ten images, 3 seconds for each after that
+		{"images get quicker", images(12), "en", Stats{Images: 12, Minutes: 2}},
+		{"images add to the text", words(238) + images(1), "en", Stats{Words: 238, Images: 1, Minutes: 2}},
+		{"inline markup doesn't split words", "<p>a <b>b</b> c</p>", "en", Stats{Words: 3, Minutes: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Estimate(tt.doc, tt.language); got != tt.want {
+				t.Errorf("Estimate = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
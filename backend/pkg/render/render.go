@@ -0,0 +1,47 @@
+// Package render turns the stored content of a post into the sanitized HTML served to readers,
+// together with everything derived from it.
+package render
+
+import (
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/markdown"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/readtime"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/sanitize"
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/toc"
+)
+
+// Content converts post content in the given format into sanitized HTML with an anchor id on every heading,
+// and returns the table of contents built from those headings.
+// Both formats go through the allow-list sanitizer; raw HTML inside Markdown is sanitized too.
+func Content(format, content string) (string, models.TableOfContents) {
+	var rendered string
+	if format == models.ContentFormatMarkdown {
+		rendered = sanitize.HTML(markdown.Render(content))
+	} else {
+		rendered = sanitize.HTML(content)
+	}
+	rendered, entries := toc.Build(rendered)
+	return rendered, entries
+}
+
+// Refresh renders the Content of a post and recomputes everything derived from it:
+// RenderedContent, TableOfContents, WordCount and ReadTime. ReadTimeOverride wins over the estimate.
+func Refresh(p *models.Post) {
+	p.RenderedContent, p.TableOfContents = Content(p.ContentFormat, p.Content)
+
+	stats := readtime.Estimate(p.RenderedContent, p.Language)
+	p.WordCount = stats.Words
+	p.ReadTime = stats.Minutes
+	if p.ReadTimeOverride > 0 {
+		p.ReadTime = p.ReadTimeOverride
+	}
+}
+
+// HTML returns the sanitized HTML of a post, rendering it if the cached copy is missing.
+func HTML(p *models.Post) string {
+	if p.RenderedContent != "" {
+		return p.RenderedContent
+	}
+	rendered, _ := Content(p.ContentFormat, p.Content)
+	return rendered
+}
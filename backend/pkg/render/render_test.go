@@ -0,0 +1,43 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/models"
+)
+
+func TestRefresh(t *testing.T) {
+	post := &models.Post{ContentFormat: models.ContentFormatMarkdown, Content: "## Setup\n\nHello <script>alert(1)</script> world"}
+	Refresh(post)
+
+	if strings.Contains(post.RenderedContent, "<script") {
+		t.Errorf("rendered content is not sanitized: %q", post.RenderedContent)
+	}
+	if !strings.Contains(post.RenderedContent, `id="setup"`) {
+		t.Errorf("heading has no anchor: %q", post.RenderedContent)
+	}
+	if len(post.TableOfContents) != 1 || post.TableOfContents[0].ID != "setup" {
+		t.Errorf("table of contents = %+v", post.TableOfContents)
+	}
+	if post.WordCount == 0 || post.ReadTime != 1 {
+		t.Errorf("word count = %d, read time = %d", post.WordCount, post.ReadTime)
+	}
+
+	post.ReadTimeOverride = 12
+	Refresh(post)
+	if post.ReadTime != 12 {
+		t.Errorf("read time = %d, want the override", post.ReadTime)
+	}
+}
+
+func TestHTMLRendersMissingCache(t *testing.T) {
+	post := &models.Post{ContentFormat: models.ContentFormatHTML, Content: "<p>hi</p><img src=x onerror=alert(1)>"}
+	if got := HTML(post); strings.Contains(got, "onerror") || !strings.Contains(got, "<p>hi</p>") {
+		t.Errorf("HTML = %q", got)
+	}
+	post.RenderedContent = "<p>cached</p>"
+	if got := HTML(post); got != post.RenderedContent {
+		t.Errorf("HTML = %q, want the cached copy", got)
+	}
+}
@@ -0,0 +1,279 @@
+package sanitize
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// allowedTags maps every allowed element to the attributes it may carry in addition to globalAttrs.
+// Elements that are not listed are dropped but their text content is kept.
+var allowedTags = map[string][]string{
+	"a":          {"href", "title", "target"},
+	"abbr":       {"title"},
+	"b":          nil,
+	"blockquote": {"cite"},
+	"br":         nil,
+	"caption":    nil,
+	"code":       nil,
+	"dd":         nil,
+	"del":        nil,
+	"details":    {"open"},
+	"div":        nil,
+	"dl":         nil,
+	"dt":         nil,
+	"em":         nil,
+	"figcaption": nil,
+	"figure":     nil,
+	"h1":         nil,
+	"h2":         nil,
+	"h3":         nil,
+	"h4":         nil,
+	"h5":         nil,
+	"h6":         nil,
+	"hr":         nil,
+	"i":          nil,
+	"img":        {"src", "alt", "title", "width", "height"},
+	"input":      {"type", "checked", "disabled"},
+	"ins":        nil,
+	"kbd":        nil,
+	"li":         {"value"},
+	"mark":       nil,
+	"ol":         {"start", "reversed"},
+	"p":          nil,
+	"pre":        nil,
+	"q":          {"cite"},
+	"s":          nil,
+	"section":    nil,
+	"small":      nil,
+	"span":       nil,
+	"strike":     nil,
+	"strong":     nil,
+	"sub":        nil,
+	"summary":    nil,
+	"sup":        nil,
+	"table":      nil,
+	"tbody":      nil,
+	"td":         {"align", "colspan", "rowspan"},
+	"tfoot":      nil,
+	"th":         {"align", "colspan", "rowspan", "scope"},
+	"thead":      nil,
+	"tr":         nil,
+	"u":          nil,
+	"ul":         nil,
+}
+
+// globalAttrs may appear on any allowed element
+var globalAttrs = []string{"id", "class", "dir", "lang"}
+
+// voidTags never have content or a closing tag
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true, "input": true}
+
+// droppedTags are removed together with everything inside them
+var droppedTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true, "noscript": true,
+	"template": true, "textarea": true, "title": true, "head": true, "svg": true, "math": true, "select": true,
+}
+
+var (
+	tokenRegex    = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	numberRegex   = regexp.MustCompile(`^[0-9]{1,5}%?$`)
+	dataImageURL  = regexp.MustCompile(`^data:image/(png|gif|jpe?g|webp);base64,[A-Za-z0-9+/=\s]+$`)
+	urlSchemeExpr = regexp.MustCompile(`^([a-z][a-z0-9+.-]*):`)
+	controlChars  = regexp.MustCompile(`[\x00-\x20\x7f]+`)
+)
+
+// HTML returns s with every element and attribute that is not on the allow-list removed.
+// Links and image sources are restricted to safe URL schemes and the output is always well-formed.
+func HTML(s string) string {
+	var b strings.Builder
+	var open []string // Allowed elements that are currently open
+	skip := 0         // Depth inside a dropped element
+	var skipTag string
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break // io.EOF or malformed input, either way nothing more to read
+		}
+		token := z.Token()
+		name := token.Data
+
+		if skip > 0 {
+			switch {
+			case tt == html.StartTagToken && name == skipTag:
+				skip++
+			case tt == html.EndTagToken && name == skipTag:
+				skip--
+			}
+			continue
+		}
+
+		switch tt {
+		case html.TextToken:
+			b.WriteString(html.EscapeString(token.Data))
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedTags[name] {
+				if tt == html.StartTagToken && !voidTags[name] {
+					skip, skipTag = 1, name
+				}
+				continue
+			}
+			attrs, ok := allowedTags[name]
+			if !ok || (name == "input" && !isCheckbox(token.Attr)) {
+				continue
+			}
+			writeStartTag(&b, name, token.Attr, attrs)
+			if !voidTags[name] {
+				if tt == html.SelfClosingTagToken {
+					b.WriteString("</" + name + ">")
+				} else {
+					open = append(open, name)
+				}
+			}
+
+		case html.EndTagToken:
+			if _, ok := allowedTags[name]; !ok || voidTags[name] {
+				continue
+			}
+			// Close everything that was opened after the matching element; ignore stray end tags
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == name {
+					for j := len(open) - 1; j >= i; j-- {
+						b.WriteString("</" + open[j] + ">")
+					}
+					open = open[:i]
+					break
+				}
+			}
+		}
+		// Comments and doctypes are dropped
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String()
+}
+
+func writeStartTag(b *strings.Builder, name string, attrs []html.Attribute, allowed []string) {
+	b.WriteString("<" + name)
+
+	blankTarget := false
+	seen := make(map[string]bool)
+	for _, attr := range attrs {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace != "" || seen[key] || !(contains(allowed, key) || contains(globalAttrs, key)) {
+			continue
+		}
+		value, ok := cleanAttr(name, key, attr.Val)
+		if !ok {
+			continue
+		}
+		seen[key] = true
+		if key == "target" {
+			blankTarget = true
+		}
+		b.WriteString(" " + key + `="` + html.EscapeString(value) + `"`)
+	}
+
+	switch name {
+	case "a":
+		if blankTarget {
+			b.WriteString(` rel="noopener noreferrer"`)
+		}
+	case "input":
+		// Task list checkboxes are display only
+		if !seen["disabled"] {
+			b.WriteString(` disabled=""`)
+		}
+	}
+
+	if voidTags[name] {
+		b.WriteString(" />")
+	} else {
+		b.WriteString(">")
+	}
+}
+
+// cleanAttr validates an attribute value, returning false if the attribute must be dropped.
+func cleanAttr(tag, key, value string) (string, bool) {
+	switch key {
+	case "href", "cite":
+		return value, safeURL(value, tag == "a")
+	case "src":
+		if dataImageURL.MatchString(strings.TrimSpace(value)) {
+			return strings.TrimSpace(value), true
+		}
+		return value, safeURL(value, false)
+	case "target":
+		return "_blank", value == "_blank"
+	case "type":
+		return "checkbox", tag == "input" && strings.EqualFold(value, "checkbox")
+	case "id":
+		return value, tokenRegex.MatchString(value)
+	case "class":
+		var classes []string
+		for _, class := range strings.Fields(value) {
+			if tokenRegex.MatchString(class) {
+				classes = append(classes, class)
+			}
+		}
+		return strings.Join(classes, " "), len(classes) > 0
+	case "width", "height", "colspan", "rowspan", "start", "value":
+		return value, numberRegex.MatchString(value)
+	case "align":
+		switch strings.ToLower(value) {
+		case "left", "right", "center", "justify":
+			return strings.ToLower(value), true
+		}
+		return "", false
+	case "dir":
+		switch strings.ToLower(value) {
+		case "ltr", "rtl", "auto":
+			return strings.ToLower(value), true
+		}
+		return "", false
+	case "checked", "disabled", "open", "reversed":
+		return "", true
+	}
+	return value, true
+}
+
+// safeURL reports whether u is relative or uses an allowed scheme.
+// Whitespace and control characters are removed first since browsers ignore them ("java\tscript:").
+func safeURL(u string, allowContactSchemes bool) bool {
+	normalized := strings.ToLower(controlChars.ReplaceAllString(u, ""))
+	m := urlSchemeExpr.FindStringSubmatch(normalized)
+	if m == nil {
+		return true // Relative URL
+	}
+	switch m[1] {
+	case "http", "https":
+		return true
+	case "mailto", "tel":
+		return allowContactSchemes
+	}
+	return false
+}
+
+func isCheckbox(attrs []html.Attribute) bool {
+	for _, attr := range attrs {
+		if strings.EqualFold(attr.Key, "type") {
+			return strings.EqualFold(attr.Val, "checkbox")
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
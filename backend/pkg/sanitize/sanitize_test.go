@@ -0,0 +1,147 @@
+package sanitize
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestHTMLRemovesScript(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"script element", `<p>a<script>alert(1)</script>b</p>`, `<p>ab</p>`},
+		{"script in script", `<script><script>alert(1)</script>alert(2)</script>ok`, `alert(2)ok`},
+		{"uppercase script", `<SCRIPT>alert(1)</SCRIPT>`, ``},
+		{"event attribute", `<img src="x.png" onerror="alert(1)">`, `<img src="x.png" />`},
+		{"uppercase event attribute", `<p OnClick="alert(1)">a</p>`, `<p>a</p>`},
+		{"event attribute on unknown element", `<body onload="alert(1)">a`, `a`},
+		{"style attribute", `<p style="background:url(javascript:alert(1))">a</p>`, `<p>a</p>`},
+		{"javascript href", `<a href="javascript:alert(1)">a</a>`, `<a>a</a>`},
+		{"uppercase scheme", `<a href="JaVaScRiPt:alert(1)">a</a>`, `<a>a</a>`},
+		{"scheme with tab", "<a href=\"java\tscript:alert(1)\">a</a>", `<a>a</a>`},
+		{"scheme with newline", "<a href=\"java\nscript:alert(1)\">a</a>", `<a>a</a>`},
+		{"scheme with null", "<a href=\"java\x00script:alert(1)\">a</a>", `<a>a</a>`},
+		{"leading space", `<a href="  javascript:alert(1)">a</a>`, `<a>a</a>`},
+		{"decimal entities", `<a href="&#106;&#97;&#118;&#97;&#115;&#99;&#114;&#105;&#112;&#116;&#58;alert(1)">a</a>`, `<a>a</a>`},
+		{"hex entities without semicolons", `<a href="&#x6A&#x61&#x76&#x61&#x73&#x63&#x72&#x69&#x70&#x74&#x3A;alert(1)">a</a>`, `<a>a</a>`},
+		{"padded entities", `<a href="&#0000106;avascript:alert(1)">a</a>`, `<a>a</a>`},
+		{"named colon entity", `<a href="javascript&colon;alert(1)">a</a>`, `<a>a</a>`},
+		{"encoded tab", `<a href="jav&#x09;ascript:alert(1)">a</a>`, `<a>a</a>`},
+		{"vbscript", `<a href="vbscript:msgbox(1)">a</a>`, `<a>a</a>`},
+		{"data href", `<a href="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">a</a>`, `<a>a</a>`},
+		{"svg data image", `<img src="data:image/svg+xml;base64,PHN2Zz48L3N2Zz4=">`, `<img />`},
+		{"javascript image", `<img src="javascript:alert(1)">`, `<img />`},
+		{"javascript cite", `<blockquote cite="javascript:alert(1)">q</blockquote>`, `<blockquote>q</blockquote>`},
+		{"mailto on image", `<img src="mailto:a@example.com">`, `<img />`},
+		{"iframe", `<iframe src="https://example.com"></iframe>after`, `after`},
+		{"svg", `<svg><script>alert(1)</script></svg>after`, `after`},
+		{"math", `<math><mi xlink:href="javascript:alert(1)">x</mi></math>after`, `after`},
+		{"style element", `<style>body{display:none}</style>after`, `after`},
+		{"form elements", `<form action="https://evil.example"><button>go</button></form>`, `go`},
+		{"comment", `a<!-- <script>alert(1)</script> -->b`, `ab`},
+		{"raw text of unknown element", `<xmp><script>alert(1)</script></xmp>`, `&lt;script&gt;alert(1)&lt;/script&gt;`},
+		{"attribute breaking out", `<a title='"><script>alert(1)</script>'>a</a>`, `<a title="&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;">a</a>`},
+		{"text inside a tag name", `<<script>alert(1)</script>`, `&lt;`},
+		{"unclosed tags", `<p><strong>a`, `<p><strong>a</strong></p>`},
+		{"stray end tags", `a</div></p>b`, `ab`},
+		{"non checkbox input", `<input type="text" value="x">`, ``},
+		{"target blank", `<a href="https://example.com" target="_blank">a</a>`, `<a href="https://example.com" target="_blank" rel="noopener noreferrer">a</a>`},
+		{"target other", `<a href="/a" target="_top">a</a>`, `<a href="/a">a</a>`},
+		{"id with quotes", `<h2 id="a b">t</h2>`, `<h2>t</h2>`},
+	}
+	for _, tt := range tests {
+		if got := HTML(tt.in); got != tt.want {
+			t.Errorf("%s: HTML(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHTMLKeepsSafeMarkup(t *testing.T) {
+	tests := []string{
+		`<p>Some <strong>bold</strong>, <em>italic</em> and <code>code</code></p>`,
+		`<a href="https://example.com/a?b=c&amp;d=e" title="t">link</a>`,
+		`<a href="/posts/1">relative</a>`,
+		`<a href="#fn-1">anchor</a>`,
+		`<a href="mailto:a@example.com">mail</a>`,
+		`<img src="https://example.com/a.png" alt="a" width="100" height="50%" />`,
+		`<img src="data:image/png;base64,iVBORw0KGgo=" />`,
+		`<ul><li><input type="checkbox" checked="" disabled="" /> done</li></ul>`,
+		`<table><thead><tr><th align="left">a</th></tr></thead><tbody><tr><td colspan="2">b</td></tr></tbody></table>`,
+		`<h2 id="intro" class="title big">Intro</h2>`,
+	}
+	for _, in := range tests {
+		if got := HTML(in); got != in {
+			t.Errorf("HTML(%q) = %q, want it unchanged", in, got)
+		}
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	tests := []struct {
+		url                 string
+		allowContactSchemes bool
+		safe                bool
+	}{
+		{"https://example.com", false, true},
+		{"HTTP://example.com", false, true},
+		{"/relative/path", false, true},
+		{"relative:colon/not-a-scheme?", false, false},
+		{"./a:b", false, true},
+		{"mailto:a@example.com", true, true},
+		{"mailto:a@example.com", false, false},
+		{"tel:+123", true, true},
+		{"javascript:alert(1)", true, false},
+		{"\x01javascript:alert(1)", true, false},
+		{"java script:alert(1)", true, false},
+		{"file:///etc/passwd", false, false},
+	}
+	for _, tt := range tests {
+		if got := safeURL(tt.url, tt.allowContactSchemes); got != tt.safe {
+			t.Errorf("safeURL(%q, %v) = %v, want %v", tt.url, tt.allowContactSchemes, got, tt.safe)
+		}
+	}
+}
+
+// FuzzHTML checks that the output only holds allowed elements with allowed attributes and safe URLs,
+// and that sanitizing it again changes nothing.
+func FuzzHTML(f *testing.F) {
+	for _, seed := range []string{
+		`<a href="javascript:alert(1)">a</a>`,
+		`<img src=x onerror=alert(1)>`,
+		`<p><script>alert(1)</script></p>`,
+		`<a href="&#106;avascript:alert(1)">a</a>`,
+		`<svg><a xlink:href="javascript:alert(1)">a</a></svg>`,
+		`<table><tr><td>a<p>b</table>`,
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, in string) {
+		out := HTML(in)
+		if again := HTML(out); again != out {
+			t.Fatalf("HTML is not idempotent on %q:\nfirst  %q\nsecond %q", in, out, again)
+		}
+		z := html.NewTokenizer(strings.NewReader(out))
+		for tt := z.Next(); tt != html.ErrorToken; tt = z.Next() {
+			token := z.Token()
+			switch tt {
+			case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+				allowed, ok := allowedTags[token.Data]
+				if !ok {
+					t.Fatalf("HTML(%q) = %q keeps the element %q", in, out, token.Data)
+				}
+				for _, attr := range token.Attr {
+					if attr.Key != "rel" && !contains(allowed, attr.Key) && !contains(globalAttrs, attr.Key) {
+						t.Fatalf("HTML(%q) = %q keeps the attribute %q", in, out, attr.Key)
+					}
+					if (attr.Key == "href" || attr.Key == "cite" || attr.Key == "src") && !safeURL(attr.Val, true) {
+						t.Fatalf("HTML(%q) = %q keeps the URL %q", in, out, attr.Val)
+					}
+				}
+			case html.CommentToken, html.DoctypeToken:
+				t.Fatalf("HTML(%q) = %q keeps a comment or doctype", in, out)
+			}
+		}
+	})
+}
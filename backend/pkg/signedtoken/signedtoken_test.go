@@ -0,0 +1,69 @@
+package signedtoken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"strings"
+	"testing"
+)
+
+func TestPair(t *testing.T) {
+	s := New([]byte("secret"), "post-preview")
+	token := s.EncodePair(42, 1700000000)
+	if first, second, ok := s.DecodePair(token); !ok || first != 42 || second != 1700000000 {
+		t.Fatalf("DecodePair = %d, %d, %v", first, second, ok)
+	}
+	if token != s.EncodePair(42, 1700000000) {
+		t.Error("the same numbers yield different tokens")
+	}
+
+	tampered := []byte(token)
+	tampered[3] ^= 1
+	for name, token := range map[string]string{
+		"tampered":      string(tampered),
+		"truncated":     token[:len(token)-2],
+		"other purpose": New([]byte("secret"), "comment-verify").EncodePair(42, 1700000000),
+		"other secret":  New([]byte("other"), "post-preview").EncodePair(42, 1700000000),
+		"not base64":    "!!",
+	} {
+		if _, _, ok := s.DecodePair(token); ok {
+			t.Errorf("%s token accepted", name)
+		}
+	}
+}
+
+func TestSeal(t *testing.T) {
+	s := New([]byte("secret"), "comment-unsubscribe")
+	token := s.Seal([]byte("reader@example.com"))
+	if payload, ok := s.Open(token); !ok || string(payload) != "reader@example.com" {
+		t.Fatalf("Open = %q, %v", payload, ok)
+	}
+
+	encodedPayload, encodedSignature, _ := strings.Cut(token, ".")
+	forged := New([]byte("secret"), "comment-unsubscribe").Seal([]byte("other@example.com"))
+	_, forgedSignature, _ := strings.Cut(forged, ".")
+	for name, token := range map[string]string{
+		"swapped signature": encodedPayload + "." + forgedSignature,
+		"no signature":      encodedPayload,
+		"other purpose":     New([]byte("secret"), "comment-edit").Seal([]byte("reader@example.com")),
+		"empty":             "",
+		"not base64":        "!!." + encodedSignature,
+	} {
+		if _, ok := s.Open(token); ok {
+			t.Errorf("%s token accepted", name)
+		}
+	}
+}
+
+// Tokens issued before the helper existed must keep working, so the key derivation may not change
+func TestKeyDerivation(t *testing.T) {
+	key := sha256.Sum256([]byte("post-preview:secret"))
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write([]byte("payload"))
+	if !hmac.Equal(New([]byte("secret"), "post-preview").Sign([]byte("payload")), mac.Sum(nil)) {
+		t.Error("signature doesn't match the HMAC with the derived key")
+	}
+	if !hmac.Equal(Key([]byte("secret"), "post-preview"), key[:]) {
+		t.Error("Key doesn't match the derived key")
+	}
+}
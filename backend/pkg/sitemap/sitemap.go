@@ -0,0 +1,68 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// MaxURLs is the maximum number of URLs a single sitemap file may contain.
+const MaxURLs = 50000
+
+const namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// URL is an entry of a sitemap (or of a sitemap index). Loc must be absolute.
+type URL struct {
+	Loc     string
+	LastMod time.Time // Optional
+}
+
+// URLSet renders a <urlset> sitemap document.
+func URLSet(urls []URL) ([]byte, error) {
+	doc := urlSet{NS: namespace, URLs: make([]entry, len(urls))}
+	for i, u := range urls {
+		doc.URLs[i] = toEntry(u)
+	}
+	return marshal(doc)
+}
+
+// Index renders a <sitemapindex> document listing other sitemaps.
+func Index(sitemaps []URL) ([]byte, error) {
+	doc := sitemapIndex{NS: namespace, Sitemaps: make([]entry, len(sitemaps))}
+	for i, u := range sitemaps {
+		doc.Sitemaps[i] = toEntry(u)
+	}
+	return marshal(doc)
+}
+
+func toEntry(u URL) entry {
+	e := entry{Loc: u.Loc}
+	if !u.LastMod.IsZero() {
+		e.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+	}
+	return e
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+type urlSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	NS      string   `xml:"xmlns,attr"`
+	URLs    []entry  `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name `xml:"sitemapindex"`
+	NS       string   `xml:"xmlns,attr"`
+	Sitemaps []entry  `xml:"sitemap"`
+}
+
+type entry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
@@ -0,0 +1,79 @@
+package slug
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MaxLength is the maximum number of characters kept in a generated slug.
+const MaxLength = 200
+
+// transliterations maps common non-ASCII letters (mostly Turkish) to ASCII.
+var transliterations = map[rune]string{
+	'ç': "c", 'Ç': "c",
+	'ğ': "g", 'Ğ': "g",
+	'ı': "i", 'İ': "i",
+	'ö': "o", 'Ö': "o",
+	'ş': "s", 'Ş': "s",
+	'ü': "u", 'Ü': "u",
+	'â': "a", 'Â': "a",
+	'î': "i", 'Î': "i",
+	'û': "u", 'Û': "u",
+	'ä': "a", 'Ä': "a",
+	'é': "e", 'É': "e",
+	'è': "e", 'È': "e",
+	'ß': "ss",
+}
+
+// Make converts an arbitrary string (usually a post title) into a URL friendly slug.
+// Letters are lowercased and transliterated to ASCII, every other run of characters
+// becomes a single dash. Returns an empty string if nothing usable is left.
+func Make(s string) string {
+	var b strings.Builder
+	lastDash := true // Avoid a leading dash
+
+	for _, r := range s {
+		if t, ok := transliterations[r]; ok {
+			b.WriteString(t)
+			lastDash = false
+			continue
+		}
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(unicode.ToLower(r))
+			lastDash = false
+		case !lastDash:
+			b.WriteByte('-')
+			lastDash = true
+		}
+	}
+
+	result := strings.TrimSuffix(b.String(), "-")
+	if len(result) > MaxLength {
+		result = strings.TrimSuffix(result[:MaxLength], "-")
+	}
+	return result
+}
+
+// IsValid reports whether s is already a well formed slug
+// (lowercase ASCII letters, digits and single dashes).
+func IsValid(s string) bool {
+	return s != "" && Make(s) == s
+}
+
+// Anchor returns a slug of a heading text to use as its anchor id. The id is not in used yet and is
+// marked as used: duplicates get a counter suffix ("setup", "setup-1") and text without usable
+// characters becomes "section".
+func Anchor(text string, used map[string]bool) string {
+	base := Make(text)
+	if base == "" {
+		base = "section"
+	}
+	id := base
+	for i := 1; used[id]; i++ {
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+	used[id] = true
+	return id
+}
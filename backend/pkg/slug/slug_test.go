@@ -0,0 +1,23 @@
+package slug
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMake(t *testing.T) {
+	tests := map[string]string{
+		"":                         "",
+		"Hello, World!":            "hello-world",
+		"  Çok güzel İstanbul  ":   "cok-guzel-istanbul",
+		"Straße":                   "strasse",
+		"C++ & Go":                 "c-go",
+		"日本語 text":                 "text",
+		strings.Repeat("ab ", 100): strings.Repeat("ab-", 66) + "ab",
+	}
+	for in, want := range tests {
+		if got := Make(in); got != want {
+			t.Errorf("Make(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
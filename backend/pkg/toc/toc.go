@@ -0,0 +1,104 @@
+package toc
+
+import (
+	"strings"
+
+	"github.com/dervisgenc/dervisgenc-blog/backend/pkg/slug"
+	"golang.org/x/net/html"
+)
+
+// Entry is a heading of a document
+type Entry struct {
+	Level int    `json:"level" example:"2"`
+	ID    string `json:"id" example:"getting-started"`
+	Text  string `json:"text" example:"Getting started"`
+}
+
+// headingLevels maps heading elements to their level
+var headingLevels = map[string]int{"h1": 1, "h2": 2, "h3": 3, "h4": 4, "h5": 5, "h6": 6}
+
+// Build returns doc with an id on every heading, together with the headings in document order.
+// Existing ids are kept. Missing ones are derived from the heading text and made unique within
+// the document, so the anchor of a heading only changes when its text does.
+func Build(doc string) (string, []Entry) {
+	used := existingIDs(doc)
+
+	var b strings.Builder
+	var entries []Entry
+	var heading *html.Token // Start tag of the heading being read
+	var inner, text strings.Builder
+
+	z := html.NewTokenizer(strings.NewReader(doc))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		raw := string(z.Raw())
+		token := z.Token()
+
+		if heading == nil {
+			if _, ok := headingLevels[token.Data]; ok && tt == html.StartTagToken {
+				heading = &token
+				inner.Reset()
+				text.Reset()
+				continue
+			}
+			b.WriteString(raw)
+			continue
+		}
+
+		if tt == html.EndTagToken && token.Data == heading.Data {
+			entry := Entry{
+				Level: headingLevels[heading.Data],
+				ID:    attr(heading, "id"),
+				Text:  strings.Join(strings.Fields(text.String()), " "),
+			}
+			if entry.ID == "" {
+				entry.ID = slug.Anchor(entry.Text, used)
+				heading.Attr = append(heading.Attr, html.Attribute{Key: "id", Val: entry.ID})
+			}
+			entries = append(entries, entry)
+			b.WriteString(heading.String() + inner.String() + raw)
+			heading = nil
+			continue
+		}
+		if tt == html.TextToken {
+			text.WriteString(token.Data)
+		}
+		inner.WriteString(raw)
+	}
+
+	if heading != nil {
+		// Unclosed heading at the end of the document, keep it untouched
+		b.WriteString(heading.String() + inner.String())
+	}
+	return b.String(), entries
+}
+
+// existingIDs collects the ids already used in doc, e.g. footnote anchors
+func existingIDs(doc string) map[string]bool {
+	used := make(map[string]bool)
+	z := html.NewTokenizer(strings.NewReader(doc))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return used
+		}
+		if tt == html.StartTagToken || tt == html.SelfClosingTagToken {
+			token := z.Token()
+			if id := attr(&token, "id"); id != "" {
+				used[id] = true
+			}
+		}
+	}
+}
+
+func attr(token *html.Token, key string) string {
+	for _, a := range token.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package toc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name, doc, want string
+		entries         []Entry
+	}{
+		{
+			"ids from the heading text",
+			`<h2>Getting started</h2><p>Text</p><h3>Install <code>go</code></h3>`,
+			`<h2 id="getting-started">Getting started</h2><p>Text</p><h3 id="install-go">Install <code>go</code></h3>`,
+			[]Entry{{2, "getting-started", "Getting started"}, {3, "install-go", "Install go"}},
+		},
+		{
+			"existing ids are kept",
+			`<h2 id="custom">Setup</h2>`,
+			`<h2 id="custom">Setup</h2>`,
+			[]Entry{{2, "custom", "Setup"}},
+		},
+		{
+			"duplicates get a suffix",
+			`<h2>Setup</h2><h2>Setup</h2><h2>Setup-1</h2>`,
+			`<h2 id="setup">Setup</h2><h2 id="setup-1">Setup</h2><h2 id="setup-1-1">Setup-1</h2>`,
+			[]Entry{{2, "setup", "Setup"}, {2, "setup-1", "Setup"}, {2, "setup-1-1", "Setup-1"}},
+		},
+		{
+			"ids used elsewhere in the document are avoided",
+			`<h2>Notes</h2><p id="notes">x</p>`,
+			`<h2 id="notes-1">Notes</h2><p id="notes">x</p>`,
+			[]Entry{{2, "notes-1", "Notes"}},
+		},
+		{
+			"whitespace is collapsed",
+			"<h1>\n  Many \t spaces </h1>",
+			"<h1 id=\"many-spaces\">\n  Many \t spaces </h1>",
+			[]Entry{{1, "many-spaces", "Many spaces"}},
+		},
+		{
+			"headings without usable text",
+			`<h2>!!</h2><h2></h2>`,
+			`<h2 id="section">!!</h2><h2 id="section-1"></h2>`,
+			[]Entry{{2, "section", "!!"}, {2, "section-1", ""}},
+		},
+		{
+			"unclosed heading is left alone",
+			`<p>a</p><h2>Open`,
+			`<p>a</p><h2>Open`,
+			nil,
+		},
+		{"no headings", `<p>a</p>`, `<p>a</p>`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, entries := Build(tt.doc)
+			if got != tt.want {
+				t.Errorf("Build HTML\n got: %s\nwant: %s", got, tt.want)
+			}
+			if !reflect.DeepEqual(entries, tt.entries) {
+				t.Errorf("Build entries\n got: %+v\nwant: %+v", entries, tt.entries)
+			}
+		})
+	}
+}
+
+func TestBuildIDsAreStable(t *testing.T) {
+	// Adding a heading doesn't move the anchors of headings with other text
+	_, before := Build(`<h2>Intro</h2><h2>Usage</h2>`)
+	_, after := Build(`<h2>Intro</h2><h2>New section</h2><h2>Usage</h2>`)
+	if before[1].ID != after[2].ID {
+		t.Errorf("anchor of Usage changed from %q to %q", before[1].ID, after[2].ID)
+	}
+}
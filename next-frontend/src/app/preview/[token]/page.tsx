@@ -0,0 +1,89 @@
+import Image from "next/image"
+import type { Metadata } from "next"
+import { notFound } from "next/navigation"
+import { Clock, Tag } from "lucide-react"
+import { Badge } from "@/components/ui/badge"
+import { PostDetail } from "@/types"
+
+const API_URL = process.env.NEXT_PUBLIC_API_URL || "https://blog.dervisgenc.com/api"
+
+export const dynamic = 'force-dynamic' // preview links can be revoked at any time
+export const revalidate = 0
+
+// Previews are shared privately and must never end up in search results
+export const metadata: Metadata = {
+  robots: { index: false, follow: false },
+}
+
+// Fetch the previewed post; the backend checks the token and never counts it as a view
+async function getPreview(token: string): Promise<PostDetail | null> {
+  try {
+    const response = await fetch(`${API_URL}/preview/${encodeURIComponent(token)}`, {
+      cache: 'no-store',
+    })
+
+    if (!response.ok) {
+      if (response.status !== 404) {
+        console.error(`API Error (Preview): ${response.status} ${response.statusText}`)
+      }
+      return null
+    }
+
+    const data: PostDetail = await response.json()
+    return data
+  } catch (error) {
+    console.error("Failed to fetch preview:", error)
+    return null
+  }
+}
+
+export default async function PreviewPage(
+  { params }: { params: Promise<{ token: string }> }
+) {
+  const { token } = await params
+
+  const post = await getPreview(token)
+  if (!post) {
+    notFound()
+  }
+
+  const tagsArray = post.tags ? post.tags.split(',').map(tag => tag.trim()).filter(Boolean) : [];
+
+  return (
+    <div className="container py-6">
+      <div className="mx-auto mb-6 max-w-3xl rounded-md border border-amber-500 px-4 py-2 text-sm text-amber-500">
+        This is a preview. The post may not be published yet and can still change.
+      </div>
+
+      <article className="mx-auto max-w-3xl">
+        <div className="mb-6 space-y-4">
+          <h1 className="text-3xl font-bold leading-tight tracking-tight md:text-4xl">{post.title}</h1>
+          <div className="flex items-center gap-1 text-sm text-muted-foreground">
+            <Clock className="h-4 w-4" />
+            <span>{post.read_time} min read</span>
+          </div>
+        </div>
+
+        <div className="relative mb-6 aspect-video overflow-hidden rounded-lg">
+          <Image src={post.image_url || "/placeholder.svg"} alt={post.title} fill className="object-cover" priority />
+        </div>
+
+        <div
+          className="prose prose-lg dark:prose-invert max-w-none mb-6"
+          dangerouslySetInnerHTML={{ __html: post.content }}
+        />
+
+        {tagsArray.length > 0 && (
+          <div className="mb-6 flex flex-wrap items-center gap-2 border-t border-border pt-4">
+            <Tag className="h-4 w-4 text-muted-foreground" />
+            {tagsArray.map((tag) => (
+              <Badge key={tag} variant="secondary">
+                {tag}
+              </Badge>
+            ))}
+          </div>
+        )}
+      </article>
+    </div>
+  )
+}